@@ -0,0 +1,225 @@
+package candiedyaml
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A MarshalFunc returns the value to encode in place of v, which has the
+// type the adapter was registered for.
+type MarshalFunc func(v interface{}) (interface{}, error)
+
+// An UnmarshalFunc returns a value of the type the adapter was registered
+// for.  It may call unmarshal once to decode the YAML value into a Go value
+// of its choosing, typically a string, and convert that.
+type UnmarshalFunc func(unmarshal func(interface{}) error) (interface{}, error)
+
+type adapter struct {
+	marshal   MarshalFunc
+	unmarshal UnmarshalFunc
+
+	// nodes is set if unmarshal may decode into a Node, which keeps the
+	// comments of the value.
+	nodes bool
+}
+
+var adapters struct {
+	sync.RWMutex
+	m map[reflect.Type]adapter
+}
+
+// RegisterAdapter teaches Encoders and Decoders to handle values of typ,
+// usually a type from another package that cannot be given methods, with
+// the given functions.  Either function may be nil to adapt only one
+// direction.  Adapters are consulted before anything else, including the
+// built-in adapters for time.Duration, net.IP, net.IPNet and url.URL, which
+// registering one of those types replaces.
+//
+// RegisterAdapter is meant to be called from init functions, but it is safe
+// to call while other goroutines encode and decode: each value is handled
+// with the adapter registered when it is reached.
+func RegisterAdapter(typ reflect.Type, marshal MarshalFunc, unmarshal UnmarshalFunc) {
+	registerAdapter(typ, adapter{marshal: marshal, unmarshal: unmarshal, nodes: unmarshal != nil})
+}
+
+func registerAdapter(t reflect.Type, a adapter) {
+	adapters.Lock()
+	if adapters.m == nil {
+		adapters.m = map[reflect.Type]adapter{}
+	}
+	adapters.m[t] = a
+	adapters.Unlock()
+	nodeTypes.Range(func(t, _ interface{}) bool {
+		nodeTypes.Delete(t)
+		return true
+	})
+}
+
+func adapterFor(t reflect.Type) (adapter, bool) {
+	adapters.RLock()
+	a, ok := adapters.m[t]
+	adapters.RUnlock()
+	return a, ok
+}
+
+func init() {
+	registerAdapter(reflect.TypeOf(time.Duration(0)), adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			return v.(time.Duration).String(), nil
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var s string
+			if err := unmarshal(&s); err != nil {
+				return nil, err
+			}
+			// Plain integers are nanoseconds, as they were before
+			// durations were written as strings.
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return time.Duration(n), nil
+			}
+			return time.ParseDuration(s)
+		},
+	})
+
+	registerAdapter(reflect.TypeOf(net.IP{}), textAdapter(reflect.TypeOf(net.IP{})))
+
+	registerAdapter(reflect.TypeOf(net.IPNet{}), adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			n := v.(net.IPNet)
+			return n.String(), nil
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var s string
+			if err := unmarshal(&s); err != nil {
+				return nil, err
+			}
+			ip, n, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, err
+			}
+			// Keep the address as written rather than the network.
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			return net.IPNet{IP: ip, Mask: n.Mask}, nil
+		},
+	})
+
+	registerAdapter(reflect.TypeOf(url.URL{}), adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			u := v.(url.URL)
+			return u.String(), nil
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var s string
+			if err := unmarshal(&s); err != nil {
+				return nil, err
+			}
+			u, err := url.Parse(s)
+			if err != nil {
+				return nil, err
+			}
+			return *u, nil
+		},
+	})
+}
+
+// textAdapter converts values of t through their encoding.TextMarshaler and
+// encoding.TextUnmarshaler methods.
+func textAdapter(t reflect.Type) adapter {
+	return adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			text, err := v.(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			return string(text), nil
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var s string
+			if err := unmarshal(&s); err != nil {
+				return nil, err
+			}
+			v := reflect.New(t)
+			if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return nil, err
+			}
+			return v.Elem().Interface(), nil
+		},
+	}
+}
+
+// adapt decodes the current node into rv with the adapter for its type, if
+// there is one.  Nulls and aliases are left to the usual rules.
+func (d *Decoder) adapt(rv reflect.Value) bool {
+	t := rv.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	a, ok := adapterFor(t)
+	if !ok || a.unmarshal == nil || d.event.event_type == yaml_ALIAS_EVENT {
+		return false
+	}
+	if d.event.event_type == yaml_SCALAR_EVENT && d.event.implicit && null_values[string(d.event.value)] {
+		return false
+	}
+
+	mark := d.event.start_mark
+	anchor := string(d.event.anchor)
+	called := false
+	out, err := a.unmarshal(func(v interface{}) error {
+		if called {
+			return errors.New("yaml: a value can only be unmarshalled once")
+		}
+		called = true
+		d.parse(reflect.ValueOf(v))
+		return nil
+	})
+	if !called {
+		d.parse(reflect.Value{})
+	}
+	if err == nil && (out == nil || reflect.TypeOf(out) != t) {
+		err = fmt.Errorf("adapter for %s returned %T", t, out)
+	}
+	if err != nil {
+		d.error(fmt.Errorf("yaml: line %d, column %d: %v", mark.line+1, mark.column+1, err))
+	}
+
+	v := d.indirect(rv)
+	v.Set(reflect.ValueOf(out))
+	d.anchor(anchor, rv)
+	return true
+}
+
+// adapt encodes v with the adapter for its type, if there is one.
+func (e *Encoder) adapt(tag string, v reflect.Value) bool {
+	if !v.IsValid() || !v.CanInterface() {
+		return false
+	}
+	a, ok := adapterFor(v.Type())
+	if !ok || a.marshal == nil {
+		return false
+	}
+
+	var out interface{}
+	var err error
+	e.call(func() {
+		out, err = a.marshal(v.Interface())
+	})
+	if err != nil {
+		panic(err)
+	}
+	if out == nil {
+		e.emitNil()
+	} else {
+		e.marshal(tag, reflect.ValueOf(out))
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// money stands in for a type from another package.
+type money struct {
+	cents int64
+}
+
+func init() {
+	RegisterAdapter(reflect.TypeOf(money{}),
+		func(v interface{}) (interface{}, error) {
+			m := v.(money)
+			return fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100), nil
+		},
+		func(unmarshal func(interface{}) error) (interface{}, error) {
+			var s string
+			if err := unmarshal(&s); err != nil {
+				return nil, err
+			}
+			var units, cents int64
+			if _, err := fmt.Sscanf(s, "%d.%d", &units, &cents); err != nil {
+				return nil, fmt.Errorf("invalid amount %q", s)
+			}
+			return money{units*100 + cents}, nil
+		})
+
+	RegisterAdapter(reflect.TypeOf(shout("")), func(v interface{}) (interface{}, error) {
+		return strings.ToUpper(string(v.(shout))), nil
+	}, nil)
+}
+
+type shout string
+
+var _ = Describe("Adapters", func() {
+	type config struct {
+		Timeout  time.Duration `yaml:"timeout"`
+		Retry    time.Duration `yaml:"retry"`
+		Addr     net.IP        `yaml:"addr"`
+		Network  *net.IPNet    `yaml:"network"`
+		Endpoint url.URL       `yaml:"endpoint"`
+		Proxy    *url.URL      `yaml:"proxy"`
+	}
+
+	It("decodes standard library types", func() {
+		var c config
+		Ω(Unmarshal([]byte(`timeout: 1m30s
+retry: 500
+addr: 192.168.0.1
+network: 10.1.2.3/8
+endpoint: https://example.com/api?v=1
+proxy: ~
+`), &c)).Should(Succeed())
+
+		Ω(c.Timeout).Should(Equal(90 * time.Second))
+		Ω(c.Retry).Should(Equal(500 * time.Nanosecond))
+		Ω(c.Addr.Equal(net.IPv4(192, 168, 0, 1))).Should(BeTrue())
+		Ω(c.Network.String()).Should(Equal("10.1.2.3/8"))
+		Ω(c.Endpoint.Host).Should(Equal("example.com"))
+		Ω(c.Endpoint.RawQuery).Should(Equal("v=1"))
+	})
+
+	It("encodes standard library types", func() {
+		_, network, _ := net.ParseCIDR("fd00::/64")
+		proxy, _ := url.Parse("http://proxy:3128")
+
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf)
+		enc.SetSchema(CoreSchema)
+		Ω(enc.Encode(config{
+			Timeout: 2 * time.Hour,
+			Addr:    net.ParseIP("::1"),
+			Network: network,
+			Proxy:   proxy,
+		})).Should(Succeed())
+		Ω(buf.String()).Should(Equal(`timeout: 2h0m0s
+retry: 0s
+addr: ::1
+network: fd00::/64
+endpoint: ""
+proxy: http://proxy:3128
+`))
+
+		var c config
+		Ω(Unmarshal(buf.Bytes(), &c)).Should(Succeed())
+		Ω(c.Timeout).Should(Equal(2 * time.Hour))
+		Ω(c.Network).Should(Equal(network))
+		Ω(c.Proxy).Should(Equal(proxy))
+	})
+
+	It("reports invalid values with their position", func() {
+		var c config
+		Ω(Unmarshal([]byte("addr: 1.2.3\n"), &c)).Should(MatchError(
+			"yaml: line 1, column 7: invalid IP address: 1.2.3"))
+		Ω(Unmarshal([]byte("timeout: soon\n"), &c)).Should(MatchError(
+			`yaml: line 1, column 10: time: invalid duration "soon"`))
+	})
+
+	It("uses registered adapters", func() {
+		var v struct {
+			Price  money
+			Prices map[string]*money
+		}
+		Ω(Unmarshal([]byte("price: 12.50\nprices: {a: 0.99}\n"), &v)).Should(Succeed())
+		Ω(v.Price).Should(Equal(money{1250}))
+		Ω(*v.Prices["a"]).Should(Equal(money{99}))
+
+		data, err := Marshal([]interface{}{v.Price, v.Prices["a"]})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(data)).Should(Equal("- \"12.50\"\n- \"0.99\"\n"))
+
+		Ω(Unmarshal([]byte("price: free\n"), &v)).Should(MatchError(
+			`yaml: line 1, column 8: invalid amount "free"`))
+	})
+
+	It("adapts one direction when the other function is nil", func() {
+		data, err := Marshal(map[string]shout{"a": "hello"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(data)).Should(Equal("\"a\": \"HELLO\"\n"))
+
+		var v map[string]shout
+		Ω(Unmarshal(data, &v)).Should(Succeed())
+		Ω(v).Should(Equal(map[string]shout{"a": "HELLO"}))
+	})
+})
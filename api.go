@@ -220,6 +220,19 @@ func yaml_emitter_set_unicode(emitter *yaml_emitter_t, unicode bool) {
 	emitter.unicode = unicode
 }
 
+/*
+ * Add characters that are always written as escapes.
+ */
+
+func yaml_emitter_set_escape(emitter *yaml_emitter_t, runes []rune) {
+	if emitter.escape == nil {
+		emitter.escape = make(map[rune]bool)
+	}
+	for _, r := range runes {
+		emitter.escape[r] = true
+	}
+}
+
 /*
  * Set the preferred line break character.
  */
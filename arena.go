@@ -0,0 +1,33 @@
+package candiedyaml
+
+// arena_chunk is the size of the chunks the scanner carves the buffers for
+// token values out of.
+const arena_chunk = 4096
+
+// arena_alloc returns an empty buffer with room for n bytes, carved out of
+// the parser's current chunk so that the many short values of a document
+// share a few allocations rather than taking one each.  The buffer's
+// capacity is capped at n, so appending past it moves it to the heap
+// instead of writing over its neighbour.
+//
+// Values handed out keep their chunk alive, and the events and nodes built
+// from them may outlive the parser, so chunks are never reused: the parser
+// lets go of its chunk at the end of each document and starts a new one,
+// leaving the old to the collector once the document's values are gone.
+func arena_alloc(parser *yaml_parser_t, n int) []byte {
+	if n > arena_chunk/4 {
+		return make([]byte, 0, n)
+	}
+	l := len(parser.arena)
+	if cap(parser.arena)-l < n {
+		parser.arena = make([]byte, 0, arena_chunk)
+		l = 0
+	}
+	parser.arena = parser.arena[:l+n]
+	return parser.arena[l : l : l+n]
+}
+
+// arena_release lets go of the parser's chunk at the end of a document.
+func arena_release(parser *yaml_parser_t) {
+	parser.arena = nil
+}
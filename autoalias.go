@@ -0,0 +1,142 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+)
+
+// An AnchorNamer names the anchor of a value that AutoAlias found more than
+// once in a document.  It is given the value and the number of anchors
+// named in the document so far, counting this one.  Should a value be given
+// a name already taken, by another value or by Alias, it gets a suffix, as
+// in "a1-2".
+type AnchorNamer func(v interface{}, n int) string
+
+// SequentialAnchors names anchors a1, a2 and so on, in the order they are
+// written.
+func SequentialAnchors(v interface{}, n int) string {
+	return "a" + strconv.Itoa(n)
+}
+
+// HashAnchors names anchors after a hash of the YAML their value is written
+// as, e.g. "h4f1c09d2", so that regenerating a file from changed data only
+// renames the anchors of the values that changed.
+func HashAnchors(v interface{}, n int) string {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.AutoAlias(SequentialAnchors)
+	if err := e.Encode(v); err != nil {
+		return SequentialAnchors(v, n)
+	}
+	h := fnv.New32a()
+	h.Write(buf.Bytes())
+	return fmt.Sprintf("h%08x", h.Sum32())
+}
+
+// AutoAlias makes each document write the pointers, maps and slices that it
+// refers to more than once only once, as Alias does, with anchors named by
+// naming, e.g. SequentialAnchors or HashAnchors.  Values that refer to
+// themselves can then be encoded too.  Values registered with Alias keep
+// their names.  A nil naming turns AutoAlias off, as it is by default.
+func (e *Encoder) AutoAlias(naming AnchorNamer) {
+	e.naming = naming
+}
+
+// findAutoAliases finds the values that v refers to more than once, for
+// AutoAlias.
+func (e *Encoder) findAutoAliases(v reflect.Value) {
+	e.auto, e.named = nil, nil
+	if e.naming == nil {
+		return
+	}
+
+	counts := make(map[aliasKey]int)
+	countRefs(v, counts)
+	e.named = make(map[string]bool)
+	e.autoNamed = 0
+	for _, a := range e.aliases {
+		e.named[a.name] = true
+	}
+	for key, n := range counts {
+		if n > 1 && e.aliases[key] == nil {
+			if e.auto == nil {
+				e.auto = make(map[aliasKey]*alias)
+			}
+			e.auto[key] = &alias{}
+		}
+	}
+}
+
+// countRefs counts how many times v and the values under it refer to each
+// pointer, map and slice, without going into those seen before.
+func countRefs(v reflect.Value, counts map[aliasKey]int) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			countRefs(v.Elem(), counts)
+		}
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		key, ok := aliasKeyOf(v)
+		if !ok {
+			return
+		}
+		if counts[key]++; counts[key] > 1 {
+			return
+		}
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.Elem().Type() != nodeType {
+				countRefs(v.Elem(), counts)
+			}
+		case reflect.Map:
+			iter := v.MapRange()
+			for iter.Next() {
+				countRefs(iter.Key(), counts)
+				countRefs(iter.Value(), counts)
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				countRefs(v.Index(i), counts)
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			countRefs(v.Index(i), counts)
+		}
+	case reflect.Struct:
+		if v.Type() == nodeType {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				countRefs(v.Field(i), counts)
+			}
+		}
+	}
+}
+
+// anchorName returns the name AutoAlias gives the anchor of v, which is
+// neither used in the document yet nor registered with Alias.
+func (e *Encoder) anchorName(v reflect.Value) string {
+	var i interface{}
+	if v.CanInterface() {
+		i = v.Interface()
+	}
+	var name string
+	e.autoNamed++
+	e.call(func() {
+		name = e.naming(i, e.autoNamed)
+	})
+
+	var emitter yaml_emitter_t
+	if !yaml_emitter_analyze_anchor(&emitter, []byte(name), false) {
+		panic(fmt.Errorf("yaml: AnchorNamer returned %q: %s", name, emitter.problem))
+	}
+	for n, base := 2, name; e.named[name]; n++ {
+		name = base + "-" + strconv.Itoa(n)
+	}
+	return name
+}
@@ -0,0 +1,135 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strconv"
+)
+
+type autoServer struct {
+	Name   string      `yaml:"name"`
+	Limits *autoLimits `yaml:"limits"`
+}
+
+type autoLimits struct {
+	CPU int `yaml:"cpu"`
+}
+
+var _ = Describe("AutoAlias", func() {
+	encode := func(naming AnchorNamer, v interface{}) (string, error) {
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(YAML11Schema)
+		e.AutoAlias(naming)
+		err := e.Encode(v)
+		return buf.String(), err
+	}
+
+	limits := &autoLimits{CPU: 2}
+	servers := []autoServer{{"a", limits}, {"b", limits}, {"c", &autoLimits{CPU: 4}}}
+
+	It("writes values referred to more than once as aliases", func() {
+		out, err := encode(SequentialAnchors, servers)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal(`- name: a
+  limits: &a1
+    cpu: 2
+- name: b
+  limits: *a1
+- name: c
+  limits:
+    cpu: 4
+`))
+
+		var back []autoServer
+		Ω(Unmarshal([]byte(out), &back)).Should(Succeed())
+		Ω(back).Should(Equal(servers))
+	})
+
+	It("names anchors after the hash of their values", func() {
+		out, err := encode(HashAnchors, servers)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(ContainSubstring("limits: &h"))
+
+		// Changing another value leaves the name as it was.
+		changed := append([]autoServer{{"z", &autoLimits{CPU: 8}}}, servers...)
+		again, err := encode(HashAnchors, changed)
+		Ω(err).ShouldNot(HaveOccurred())
+		name := out[len("- name: a\n  limits: &"):]
+		name = name[:len("h00000000")]
+		Ω(again).Should(ContainSubstring("&" + name + "\n"))
+	})
+
+	It("names anchors with a callback", func() {
+		out, err := encode(func(v interface{}, n int) string {
+			return "cpu" + strconv.Itoa(v.(*autoLimits).CPU)
+		}, servers)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(ContainSubstring("limits: &cpu2\n"))
+		Ω(out).Should(ContainSubstring("limits: *cpu2\n"))
+	})
+
+	It("makes names given twice unique", func() {
+		a, b := []int{1}, []int{2}
+		out, err := encode(func(interface{}, int) string { return "same" }, [][]int{a, a, b, b})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("- &same\n  - 1\n- *same\n- &same-2\n  - 2\n- *same-2\n"))
+	})
+
+	It("encodes values that refer to themselves", func() {
+		cycle := []interface{}{1, nil}
+		cycle[1] = cycle
+		out, err := encode(SequentialAnchors, cycle)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("&a1\n- 1\n- *a1\n"))
+	})
+
+	It("encodes values that refer to themselves in compact mode", func() {
+		cycle := []interface{}{1, nil}
+		cycle[1] = cycle
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(YAML11Schema)
+		e.SetCompact(80)
+		e.AutoAlias(SequentialAnchors)
+		Ω(e.Encode(map[string]interface{}{"loop": cycle, "other": []int{1}})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("loop: &a1 [1, *a1]\nother: [1]\n"))
+	})
+
+	It("keeps the names of values registered with Alias", func() {
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(YAML11Schema)
+		e.AutoAlias(SequentialAnchors)
+		Ω(e.Alias("limits", limits)).Should(Succeed())
+		Ω(e.Encode(servers)).Should(Succeed())
+		Ω(buf.String()).Should(ContainSubstring("limits: &limits\n"))
+	})
+
+	It("does not reuse the names of values registered with Alias", func() {
+		shared, user := []string{"s"}, []string{"u"}
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(YAML11Schema)
+		e.AutoAlias(SequentialAnchors)
+		Ω(e.Alias("a1", user)).Should(Succeed())
+		Ω(e.Encode(MapSlice{{"a", shared}, {"b", user}, {"c", shared}})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("a: &a1-2\n- s\nb: &a1\n- u\nc: *a1-2\n"))
+
+		var back map[string][]string
+		Ω(Unmarshal(buf.Bytes(), &back)).Should(Succeed())
+		Ω(back["c"]).Should(Equal([]string{"s"}))
+	})
+
+	It("rejects names that are not valid anchors", func() {
+		_, err := encode(func(interface{}, int) string { return "a b" }, servers)
+		Ω(err).Should(MatchError(ContainSubstring(`yaml: AnchorNamer returned "a b"`)))
+	})
+
+	It("leaves values alone when off", func() {
+		out, err := encode(nil, servers[:2])
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).ShouldNot(ContainSubstring("&"))
+	})
+})
@@ -0,0 +1,134 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func benchmarkDocument() []byte {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("- name: item\n  id: 12345\n  tags: [a, b, c]\n  desc: \"a quoted string value\"\n  nested: {x: 1, y: 2}\n")
+	}
+	return []byte(b.String())
+}
+
+// benchmarkIndented returns a document deeply indented and commented, which
+// the scanner spends most of its time skipping over.
+func benchmarkIndented() []byte {
+	var b strings.Builder
+	for i := 0; i < 50; i++ {
+		indent := ""
+		for depth := 0; depth < 10; depth++ {
+			b.WriteString(indent + "# the settings of this level, kept as they were\n")
+			b.WriteString(indent + "level:\n")
+			indent += "    "
+		}
+		b.WriteString(indent + "value: |\n" + indent + "  some text\n" + indent + "  more text\n")
+		b.WriteString("---\n")
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkParse(b *testing.B) {
+	benchmarkParse(b, benchmarkDocument())
+}
+
+func BenchmarkParseIndented(b *testing.B) {
+	benchmarkParse(b, benchmarkIndented())
+}
+
+func benchmarkParse(b *testing.B, data []byte) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var parser yaml_parser_t
+		yaml_parser_initialize(&parser)
+		yaml_parser_set_input_reader(&parser, bytes.NewReader(data))
+		var event yaml_event_t
+		for yaml_parser_parse(&parser, &event) && event.event_type != yaml_STREAM_END_EVENT {
+		}
+		if parser.error != yaml_NO_ERROR {
+			b.Fatal(parser.problem)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := benchmarkDocument()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		if err := NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeCompiled(b *testing.B) {
+	data := benchmarkDocument()
+	c, err := Compile(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		if err := c.Decode(&v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkAliases returns a document that refers to one anchored mapping
+// many times.
+func benchmarkAliases() []byte {
+	var b strings.Builder
+	b.WriteString("defaults: &defaults\n")
+	for i := 0; i < 50; i++ {
+		b.WriteString("  key" + strconv.Itoa(i) + ": [a, b, c]\n")
+	}
+	b.WriteString("services:\n")
+	for i := 0; i < 200; i++ {
+		b.WriteString("- *defaults\n")
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkDecodeAliases(b *testing.B) {
+	benchmarkDecodeAliases(b, false)
+}
+
+func BenchmarkDecodeCopiedAliases(b *testing.B) {
+	benchmarkDecodeAliases(b, true)
+}
+
+func benchmarkDecodeAliases(b *testing.B, copy bool) {
+	data := benchmarkAliases()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		d := NewDecoder(bytes.NewReader(data))
+		d.CopyAliases(copy)
+		if err := d.Decode(&v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	var v interface{}
+	if err := Unmarshal(benchmarkDocument(), &v); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// A ScalarClass is what a schema makes of an untagged plain scalar.
+type ScalarClass struct {
+	Schema *Schema
+
+	// Tag is the standard tag of the value the scalar resolves to: StrTag,
+	// NullTag, BoolTag, IntTag, FloatTag or TimestampTag, or "" for the
+	// values of other resolvers.
+	Tag string
+
+	// Canonical is the value written as an Encoder writes it, and Lossless
+	// reports whether that is the scalar itself, so that the value reads
+	// and writes back unchanged.  yes resolving to true, 0x1F to 31 or 1.50
+	// to 1.5 are not lossless.
+	Canonical string
+	Lossless  bool
+}
+
+// Classify returns what s makes of the untagged plain scalar value, with
+// the resolvers Decoders use.  It never fails: scalars no resolver
+// recognises are strings.
+func (s *Schema) Classify(value string) ScalarClass {
+	c := ScalarClass{Schema: s}
+	switch v := s.Resolve(value).(type) {
+	case nil:
+		c.Tag, c.Canonical = NullTag, "null"
+	case bool:
+		c.Tag, c.Canonical = BoolTag, strconv.FormatBool(v)
+	case int64:
+		c.Tag, c.Canonical = IntTag, strconv.FormatInt(v, 10)
+	case uint64:
+		c.Tag, c.Canonical = IntTag, strconv.FormatUint(v, 10)
+	case float64:
+		c.Tag = FloatTag
+		switch {
+		case math.IsNaN(v):
+			c.Canonical = ".nan"
+		case math.IsInf(v, 1):
+			c.Canonical = "+.inf"
+		case math.IsInf(v, -1):
+			c.Canonical = "-.inf"
+		default:
+			c.Canonical = formatFloat(v, 64, DefaultFloatFormat)
+		}
+	case time.Time:
+		c.Tag, c.Canonical = TimestampTag, v.Format(time.RFC3339)
+	case string:
+		c.Tag, c.Canonical = StrTag, v
+	default:
+		c.Canonical = fmt.Sprint(v)
+	}
+	c.Lossless = c.Canonical == value
+	return c
+}
+
+// ClassifyScalar returns what FailsafeSchema, JSONSchema, CoreSchema and
+// YAML11Schema each make of the untagged plain scalar value, so that
+// linters can warn about scalars whose type depends on the schema, such as
+// the country code no, which YAML 1.1 reads as false:
+//
+//	for _, c := range candiedyaml.ClassifyScalar(value) {
+//		if c.Tag != candiedyaml.StrTag && !c.Lossless {
+//			warn("%s reads as %s under %s", value, c.Canonical, c.Schema)
+//		}
+//	}
+func ClassifyScalar(value string) []ScalarClass {
+	schemas := []*Schema{FailsafeSchema, JSONSchema, CoreSchema, YAML11Schema}
+	classes := make([]ScalarClass, len(schemas))
+	for i, s := range schemas {
+		classes[i] = s.Classify(value)
+	}
+	return classes
+}
@@ -0,0 +1,55 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClassifyScalar", func() {
+	tags := func(value string) []string {
+		var s []string
+		for _, c := range ClassifyScalar(value) {
+			s = append(s, ShortTag(c.Tag))
+		}
+		return s
+	}
+
+	It("classifies a scalar under each schema", func() {
+		Ω(tags("no")).Should(Equal([]string{"!!str", "!!str", "!!str", "!!bool"}))
+		Ω(tags("0o17")).Should(Equal([]string{"!!str", "!!str", "!!int", "!!str"}))
+		Ω(tags("~")).Should(Equal([]string{"!!str", "!!str", "!!null", "!!null"}))
+		Ω(tags("1.5")).Should(Equal([]string{"!!str", "!!float", "!!float", "!!float"}))
+		Ω(tags("2001-12-14")).Should(Equal([]string{"!!str", "!!str", "!!str", "!!timestamp"}))
+
+		classes := ClassifyScalar("no")
+		Ω(classes[0].Schema).Should(Equal(FailsafeSchema))
+		Ω(classes[3].Schema).Should(Equal(YAML11Schema))
+	})
+
+	It("gives the canonical form and whether it is the scalar", func() {
+		for value, want := range map[string]ScalarClass{
+			"yes":                  {Tag: BoolTag, Canonical: "true"},
+			"true":                 {Tag: BoolTag, Canonical: "true", Lossless: true},
+			"0x1F":                 {Tag: IntTag, Canonical: "31"},
+			"0755":                 {Tag: IntTag, Canonical: "493"},
+			"42":                   {Tag: IntTag, Canonical: "42", Lossless: true},
+			"1.50":                 {Tag: FloatTag, Canonical: "1.5"},
+			".Inf":                 {Tag: FloatTag, Canonical: "+.inf"},
+			".nan":                 {Tag: FloatTag, Canonical: ".nan", Lossless: true},
+			"Null":                 {Tag: NullTag, Canonical: "null"},
+			"hello":                {Tag: StrTag, Canonical: "hello", Lossless: true},
+			"2001-12-14T21:59:43Z": {Tag: TimestampTag, Canonical: "2001-12-14T21:59:43Z", Lossless: true},
+		} {
+			want.Schema = YAML11Schema
+			Ω(YAML11Schema.Classify(value)).Should(Equal(want), value)
+		}
+	})
+
+	It("uses the resolvers of custom schemas", func() {
+		s := FailsafeSchema.Extend("enabled", func(value string) (interface{}, bool) {
+			return true, value == "enabled"
+		})
+		Ω(s.Classify("enabled")).Should(Equal(ScalarClass{Schema: s, Tag: BoolTag, Canonical: "true"}))
+		Ω(s.Classify("yes").Tag).Should(Equal(StrTag))
+	})
+})
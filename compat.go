@@ -0,0 +1,288 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Compatibility selects a preset of Decoder options that reproduces how
+// another YAML package decodes, to ease migrating from it.
+type Compatibility int
+
+const (
+	// DefaultCompatibility decodes the way this package always has.
+	DefaultCompatibility Compatibility = iota
+
+	// GoYAMLv2 decodes like gopkg.in/yaml.v2:
+	//
+	//   - mappings decoded into interface{} are map[interface{}]interface{}
+	//   - untagged scalars resolve by YAML 1.1, so yes and on are booleans
+	//   - timestamps decoded into interface{} are left as strings
+	//   - '<<' keys merge mappings into the mapping they are in
+	//   - scalars that cannot be decoded into the value given are collected
+	//     into a TypeError and decoding carries on
+	GoYAMLv2
+
+	// GoYAMLv3 decodes like gopkg.in/yaml.v3.  It differs from GoYAMLv2 in
+	// that mappings decoded into interface{} are map[string]interface{} if
+	// all their keys are strings, and untagged scalars decoded into
+	// interface{} resolve by the YAML 1.2 core schema.  Fields of type bool
+	// still accept the YAML 1.1 booleans.
+	GoYAMLv3
+)
+
+// SetCompatibility sets the Decoder's options to the preset c.  It sets the
+// schema and ResolveTimestamps, so call it before changing either of them.
+func (d *Decoder) SetCompatibility(c Compatibility) {
+	compat := c == GoYAMLv2 || c == GoYAMLv3
+	d.mergeKeys = compat
+	d.typeErrors = compat
+	d.noTimestamps = compat
+	d.stringMaps = c == GoYAMLv3
+	d.schema = YAML11Schema
+	if c == GoYAMLv3 {
+		d.schema = CoreSchema
+	}
+}
+
+// TypeError is returned by a Decoder set to a go-yaml Compatibility when
+// values could not be decoded into the types given.  The other values were
+// decoded.  Like go-yaml's TypeError, Errors holds one message per value,
+// e.g. "line 3: cannot unmarshal !!str `abc` into int".
+type TypeError struct {
+	Errors []string
+}
+
+func (e *TypeError) Error() string {
+	return "yaml: unmarshal errors:\n  " + strings.Join(e.Errors, "\n  ")
+}
+
+// MergeError is returned when the value of a '<<' merge key is not a
+// mapping, an alias to one or a sequence of those.  At is the position of
+// the value.
+type MergeError struct {
+	At YAML_mark_t
+}
+
+func (e *MergeError) Error() string {
+	return fmt.Sprintf("yaml: map merge requires map or sequence of maps as the value at line %d, column %d", e.At.Line(), e.At.Column())
+}
+
+// typeError records that the current node could not be decoded into a value
+// of type t.
+func (d *Decoder) typeError(t reflect.Type) {
+	e := &d.event
+	var what string
+	switch e.event_type {
+	case yaml_MAPPING_START_EVENT:
+		what = "!!map"
+	case yaml_SEQUENCE_START_EVENT:
+		what = "!!seq"
+	default:
+		value := string(e.value)
+		if len(value) > 10 {
+			value = value[:7] + "..."
+		}
+		what = scalarTag(e) + " `" + value + "`"
+	}
+	d.typeErrorList = append(d.typeErrorList,
+		fmt.Sprintf("line %d: cannot unmarshal %s into %s", e.start_mark.line+1, what, t))
+}
+
+// scalarTag returns the short tag of the scalar event e as go-yaml reports
+// it.
+func scalarTag(e *yaml_event_t) string {
+	if len(e.tag) > 0 {
+		tag := string(e.tag)
+		if strings.HasPrefix(tag, standardTagPrefix) {
+			return "!!" + tag[len(standardTagPrefix):]
+		}
+		return tag
+	}
+	if yaml_scalar_style_t(e.style) != yaml_PLAIN_SCALAR_STYLE {
+		return "!!str"
+	}
+
+	switch YAML11Schema.Resolve(string(e.value)).(type) {
+	case nil:
+		return "!!null"
+	case bool:
+		return "!!bool"
+	case int, int64, uint64:
+		return "!!int"
+	case float64:
+		return "!!float"
+	case time.Time:
+		return "!!timestamp"
+	}
+	return "!!str"
+}
+
+// stringMap returns m as a map[string]interface{}, or nil if one of its keys
+// is not a string.
+func stringMap(m map[interface{}]interface{}) map[string]interface{} {
+	sm := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		s, ok := k.(string)
+		if !ok {
+			return nil
+		}
+		sm[s] = v
+	}
+	return sm
+}
+
+// mergedKeys holds the keys set in a mapping, which the mappings merged
+// into it do not override.  A nil mergedKeys records nothing.
+type mergedKeys map[interface{}]bool
+
+// add records key and reports whether it had not been recorded before.
+func (m mergedKeys) add(key interface{}) bool {
+	if m == nil || (key != nil && !reflect.TypeOf(key).Comparable()) {
+		return true
+	}
+	if m[key] {
+		return false
+	}
+	m[key] = true
+	return true
+}
+
+// isMergeKey reports whether the current event is a '<<' key that merges
+// mappings into the mapping it is in.
+func (d *Decoder) isMergeKey() bool {
+	e := &d.event
+	if !d.mergeKeys || e.event_type != yaml_SCALAR_EVENT {
+		return false
+	}
+	if string(e.tag) == MergeTag {
+		return true
+	}
+	return len(e.tag) == 0 && yaml_scalar_style_t(e.style) == yaml_PLAIN_SCALAR_STYLE &&
+		string(e.value) == "<<"
+}
+
+// merge reads a '<<' key and its value, calling entry for each entry of the
+// mappings it merges into a value of type t, first to last.  The entries of
+// merge keys inside them are passed on as well.
+func (d *Decoder) merge(t reflect.Type, entry func(merged bool)) {
+	d.nextEvent()
+	if d.event.event_type != yaml_SEQUENCE_START_EVENT {
+		d.mergeMapping(t, entry)
+		return
+	}
+
+	d.nextEvent()
+	for d.event.event_type != yaml_SEQUENCE_END_EVENT {
+		d.mergeMapping(t, entry)
+	}
+	d.nextEvent()
+}
+
+func (d *Decoder) mergeMapping(t reflect.Type, entry func(merged bool)) {
+	if d.event.event_type == yaml_ALIAS_EVENT {
+		d.replayAlias(t)
+	}
+	if d.event.event_type != yaml_MAPPING_START_EVENT {
+		d.error(&MergeError{At: d.event.start_mark})
+	}
+
+	d.enter()
+	d.nextEvent()
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		if d.isMergeKey() {
+			d.merge(t, entry)
+			continue
+		}
+		entry(true)
+	}
+	d.leave()
+	d.nextEvent()
+}
+
+// Merge keys may replay replayFree events, and beyond that replayRatio
+// times as many events as were parsed, so that mappings merging each other
+// over and over cannot make a short document take hours to decode.
+const (
+	replayFree  = 100000
+	replayRatio = 10
+)
+
+// replayAlias replaces the current alias event with the events of the
+// mapping it refers to, so that they can be decoded again into the value
+// of type t merging it.
+func (d *Decoder) replayAlias(t reflect.Type) {
+	d.countAlias()
+	name := string(d.event.anchor)
+	events, ok := d.recorded[name]
+	if !ok {
+		if r, open := d.recursive(); open {
+			d.error(&RecursiveAliasError{Anchor: name, Type: t, At: r.at})
+		}
+		if _, defined := d.anchors[name]; defined {
+			d.error(&MergeError{At: d.event.start_mark})
+		}
+		d.error(&UnknownAnchorError{Anchor: name, At: d.event.start_mark})
+	}
+
+	d.replayed += len(events)
+	if budget := replayRatio * (d.events - d.replayed); d.replayed > replayFree && d.replayed > budget {
+		d.error(&LimitError{Limit: "merge replay", Max: int64(budget), At: d.event.start_mark})
+	}
+
+	// The anchors were defined the first time round.
+	replay := make([]yaml_event_t, 0, len(events)+len(d.replay))
+	for _, e := range events {
+		if e.event_type != yaml_ALIAS_EVENT {
+			e.anchor = nil
+		}
+		replay = append(replay, e)
+	}
+	d.event = replay[0]
+	d.replay = append(replay[1:], d.replay...)
+}
+
+// recording holds the events of an anchored mapping being read.
+type recording struct {
+	anchor string
+	events []yaml_event_t
+	level  int
+}
+
+// record keeps the events of anchored mappings for merge keys to replay.
+func (d *Decoder) record() {
+	e := d.event
+	if d.recorded == nil || (e.event_type == yaml_DOCUMENT_START_EVENT && d.documentAnchors) {
+		d.recorded = make(map[string][]yaml_event_t)
+	}
+	active := d.recordings[:0]
+	for _, r := range d.recordings {
+		r.events = append(r.events, e)
+		switch e.event_type {
+		case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+			r.level++
+		case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+			r.level--
+		}
+		if r.level == 0 {
+			d.recorded[r.anchor] = r.events
+			continue
+		}
+		active = append(active, r)
+	}
+	d.recordings = active
+
+	if len(e.anchor) == 0 || e.event_type == yaml_ALIAS_EVENT {
+		return
+	}
+	delete(d.recorded, string(e.anchor))
+	if e.event_type == yaml_MAPPING_START_EVENT {
+		d.recordings = append(d.recordings, &recording{
+			anchor: string(e.anchor),
+			events: []yaml_event_t{e},
+			level:  1,
+		})
+	}
+}
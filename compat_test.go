@@ -0,0 +1,164 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Compatibility", func() {
+	decode := func(c Compatibility, data string, v interface{}) error {
+		d := NewDecoder(strings.NewReader(data))
+		d.SetCompatibility(c)
+		return d.Decode(v)
+	}
+
+	Context("GoYAMLv2", func() {
+		It("decodes mappings into map[interface{}]interface{}", func() {
+			var v interface{}
+			err := decode(GoYAMLv2, "a: {b: 1}\n", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{
+				"a": map[interface{}]interface{}{"b": int64(1)},
+			}))
+		})
+
+		It("resolves YAML 1.1 booleans and leaves timestamps as strings", func() {
+			var v interface{}
+			err := decode(GoYAMLv2, "[yes, off, 2001-12-14]", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal([]interface{}{true, false, "2001-12-14"}))
+		})
+	})
+
+	Context("GoYAMLv3", func() {
+		It("decodes mappings with string keys into map[string]interface{}", func() {
+			var v interface{}
+			err := decode(GoYAMLv3, "a: {b: 1}\nc: {1: 2}\n", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[string]interface{}{
+				"a": map[string]interface{}{"b": int64(1)},
+				"c": map[interface{}]interface{}{int64(1): int64(2)},
+			}))
+		})
+
+		It("fills in recursive aliases", func() {
+			var v interface{}
+			err := decode(GoYAMLv3, "&a {self: *a}", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			m := v.(map[string]interface{})
+			Ω(m["self"].(map[string]interface{})).Should(HaveKey("self"))
+		})
+
+		It("resolves YAML 1.2 booleans, except into bool fields", func() {
+			var v interface{}
+			err := decode(GoYAMLv3, "[yes, true]", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal([]interface{}{"yes", true}))
+
+			var s struct{ On bool }
+			err = decode(GoYAMLv3, "on: yes", &s)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(s.On).Should(BeTrue())
+		})
+	})
+
+	Context("merge keys", func() {
+		type config struct {
+			Host string
+			Port int
+			User string
+		}
+
+		It("merges aliased mappings into structs", func() {
+			var v map[string]config
+			err := decode(GoYAMLv2, `
+base: &base {host: localhost, port: 80}
+dev:
+  <<: *base
+  port: 8080
+`, &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v["dev"]).Should(Equal(config{Host: "localhost", Port: 8080}))
+		})
+
+		It("keeps explicit keys and the first of several merged mappings", func() {
+			var v map[string]interface{}
+			err := decode(GoYAMLv3, `
+a: &a {x: 1, y: 1}
+b: &b {x: 2, y: 2, z: 2}
+c:
+  y: 3
+  <<: [*a, *b]
+`, &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v["c"]).Should(Equal(map[string]interface{}{"x": int64(1), "y": int64(3), "z": int64(2)}))
+		})
+
+		It("merges mappings that merge others", func() {
+			var v map[string]map[string]int
+			err := decode(GoYAMLv2, `
+a: &a {x: 1}
+b: &b {<<: *a, y: 2}
+c: {<<: *b, z: 3}
+`, &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v["c"]).Should(Equal(map[string]int{"x": 1, "y": 2, "z": 3}))
+		})
+
+		It("merges inline mappings", func() {
+			var v interface{}
+			err := decode(GoYAMLv2, "<<: {a: 1}\nb: 2\n", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{"a": int64(1), "b": int64(2)}))
+		})
+
+		It("rejects merging a scalar", func() {
+			var v interface{}
+			err := decode(GoYAMLv2, "a: &a 1\nb: {<<: *a}\n", &v)
+			Ω(err).Should(BeAssignableToTypeOf(&MergeError{}))
+			at := err.(*MergeError).At
+			Ω([]int{at.Line(), at.Column()}).Should(Equal([]int{2, 9}))
+		})
+
+		It("stops mappings that merge each other over and over", func() {
+			data := "l0: &l0 {a: 1, b: 2}\n"
+			for i := 1; i < 10; i++ {
+				data += fmt.Sprintf("l%d: &l%d {<<: [%s]}\n", i, i, strings.Repeat(fmt.Sprintf("*l%d, ", i-1), 8)+fmt.Sprintf("*l%d", i-1))
+			}
+			var v interface{}
+			err := decode(GoYAMLv2, data, &v)
+			Ω(errors.Is(err, ErrLimitExceeded)).Should(BeTrue())
+			Ω(err.(*LimitError).Limit).Should(Equal("merge replay"))
+		})
+
+		It("keeps '<<' as a key by default", func() {
+			var v map[string]interface{}
+			err := NewDecoder(strings.NewReader("<<: {a: 1}")).Decode(&v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(HaveKey("<<"))
+		})
+	})
+
+	Context("type errors", func() {
+		It("collects them the way go-yaml words them", func() {
+			var v struct {
+				A int
+				B []string
+				C bool
+				D string
+			}
+			err := decode(GoYAMLv2, "a: abc\nb: {x: 1}\nc: 'true and false'\nd: ok\n", &v)
+
+			var te *TypeError
+			Ω(errors.As(err, &te)).Should(BeTrue())
+			Ω(te.Error()).Should(Equal("yaml: unmarshal errors:\n" +
+				"  line 1: cannot unmarshal !!str `abc` into int\n" +
+				"  line 2: cannot unmarshal !!map into []string\n" +
+				"  line 3: cannot unmarshal !!str `true an...` into bool"))
+			Ω(v.D).Should(Equal("ok"))
+		})
+	})
+})
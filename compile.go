@@ -0,0 +1,51 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"strings"
+)
+
+// A Compiled holds a YAML document parsed once by Compile, to be decoded
+// many times without scanning and parsing it again, as services decoding
+// the same large template for every request do.  A Compiled is never
+// changed after Compile, so any number of goroutines may decode it at once.
+type Compiled struct {
+	events []yaml_event_t
+}
+
+// Compile parses data and keeps what it parsed for Compiled.Decode.  It
+// returns the first syntax error in data, if any.
+func Compile(data []byte) (c *Compiled, err error) {
+	defer recoverError(&err)
+
+	d := NewDecoder(bytes.NewReader(data))
+	c = &Compiled{}
+	for d.nextEvent(); ; d.nextEvent() {
+		c.events = append(c.events, d.event)
+		if d.event.event_type == yaml_STREAM_END_EVENT {
+			return c, nil
+		}
+	}
+}
+
+// Decode decodes the compiled document into v as Unmarshal would decode
+// data, with a Decoder set up with opts, so that each decode gets values of
+// its own: overrides can then be applied to v without affecting the next.
+// Errors give the positions in data.  Decoding into a Node does not keep
+// comments or blank lines, which Compile does not record.
+func (c *Compiled) Decode(v interface{}, opts ...DecodeOption) error {
+	d := NewDecoder(strings.NewReader(""))
+	for _, opt := range opts {
+		opt(d)
+	}
+	// Decoders make new slices of the events they replay, so one never
+	// changes what another is replaying.
+	d.replay = c.events[:len(c.events):len(c.events)]
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if d.event.event_type != yaml_STREAM_END_EVENT {
+		return &TrailingContentError{At: d.event.start_mark}
+	}
+	return nil
+}
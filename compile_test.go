@@ -0,0 +1,112 @@
+package candiedyaml
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compile", func() {
+	type container struct {
+		Name  string   `yaml:"name"`
+		Image string   `yaml:"image"`
+		Args  []string `yaml:"args"`
+	}
+	type deployment struct {
+		Replicas   int               `yaml:"replicas"`
+		Labels     map[string]string `yaml:"labels"`
+		Selector   map[string]string `yaml:"selector"`
+		Containers []container       `yaml:"containers"`
+	}
+
+	template := []byte(`replicas: 1
+labels: &labels
+  app: web
+selector: *labels
+containers:
+- name: web
+  image: nginx
+  args: &args [--port, "80"]
+- name: sidecar
+  image: envoy
+  args: *args
+`)
+
+	It("decodes the document as Unmarshal does, as often as needed", func() {
+		c, err := Compile(template)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var want deployment
+		Ω(Unmarshal(template, &want)).Should(Succeed())
+		Ω(want.Containers).Should(HaveLen(2))
+		Ω(want.Containers[1].Args).Should(Equal([]string{"--port", "80"}))
+		for i := 0; i < 3; i++ {
+			var got deployment
+			Ω(c.Decode(&got)).Should(Succeed())
+			Ω(got).Should(Equal(want))
+		}
+	})
+
+	It("gives each decode values of its own", func() {
+		c, err := Compile(template)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var first, second deployment
+		Ω(c.Decode(&first)).Should(Succeed())
+		first.Replicas = 3
+		first.Labels["tier"] = "front"
+		first.Containers[0].Args[0] = "--debug"
+
+		Ω(c.Decode(&second)).Should(Succeed())
+		Ω(second.Replicas).Should(Equal(1))
+		Ω(second.Labels).Should(Equal(map[string]string{"app": "web"}))
+		Ω(second.Containers[0].Args).Should(Equal([]string{"--port", "80"}))
+	})
+
+	It("takes decode options", func() {
+		c, err := Compile([]byte("replicas: 1\nreplicas: 2\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var d deployment
+		Ω(c.Decode(&d)).Should(Succeed())
+		Ω(errors.Is(c.Decode(&d, Strict()), ErrDuplicateKey)).Should(BeTrue())
+	})
+
+	It("reports errors at their place in the source", func() {
+		_, err := Compile([]byte("a: [1\n"))
+		Ω(err).Should(HaveOccurred())
+
+		c, err := Compile([]byte("labels: {}\nreplicas: *count\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		var d deployment
+		var aerr *UnknownAnchorError
+		Ω(errors.As(c.Decode(&d), &aerr)).Should(BeTrue())
+		Ω(aerr.At.line + 1).Should(Equal(2))
+
+		c, err = Compile([]byte("replicas: 1\n---\nreplicas: 2\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(errors.Is(c.Decode(&d), ErrTrailingContent)).Should(BeTrue())
+	})
+
+	It("can be decoded by many goroutines at once", func() {
+		c, err := Compile(template)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				var d deployment
+				errs[i] = c.Decode(&d)
+			}(i)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+	})
+})
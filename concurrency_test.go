@@ -0,0 +1,71 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type concurrentID int
+
+type concurrentFlags uint
+
+type concurrentRecord struct {
+	ID      concurrentID      `yaml:"id"`
+	Timeout time.Duration     `yaml:"timeout"`
+	Tags    map[string]string `yaml:"tags"`
+}
+
+// These specs are meant to be run with the race detector, go test -race,
+// which fails them if the state shared between Decoders and Encoders is not
+// safe for concurrent use.
+var _ = Describe("Concurrent use", func() {
+	run := func(n int, f func(i int)) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				f(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	It("decodes and encodes with distinct Decoders and Encoders", func() {
+		run(8, func(i int) {
+			data := fmt.Sprintf("id: %d\ntimeout: 1s\ntags:\n  a: b\n", i)
+			var r concurrentRecord
+			Ω(NewDecoder(bytes.NewBufferString(data)).Decode(&r)).Should(Succeed())
+			Ω(r.ID).Should(Equal(concurrentID(i)))
+
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf)
+			enc.SetSchema(CoreSchema)
+			Ω(enc.Encode(r)).Should(Succeed())
+			Ω(buf.String()).Should(Equal(data))
+		})
+	})
+
+	It("registers adapters while others decode", func() {
+		run(8, func(i int) {
+			if i%2 == 0 {
+				RegisterFlags(reflect.TypeOf(concurrentFlags(0)), map[string]uint64{"a": 1, "b": 2})
+				return
+			}
+			var r []concurrentRecord
+			Ω(Unmarshal([]byte("- {id: 1, timeout: 2m}\n"), &r)).Should(Succeed())
+			_, err := Marshal(r)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			var f []concurrentFlags
+			Ω(Unmarshal([]byte("[3]"), &f)).Should(Succeed())
+			Ω(f).Should(Equal([]concurrentFlags{3}))
+		})
+	})
+})
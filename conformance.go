@@ -0,0 +1,32 @@
+package candiedyaml
+
+// Conformance selects how the parser behaves where libyaml, which this
+// package is ported from, and the YAML 1.2 specification disagree.
+type Conformance int
+
+const (
+	// LibyamlConformance follows libyaml.  This is the default.
+	LibyamlConformance Conformance = iota
+
+	// YAML12Conformance follows the YAML 1.2.2 specification as exercised
+	// by the yaml-test-suite.  Compared to LibyamlConformance it
+	//
+	//   - accepts any %YAML 1.x directive and ignores reserved directives
+	//   - accepts any character but whitespace and flow indicators in anchors
+	//   - rejects directives that follow a document without a '...' marker
+	//   - rejects content on the same line as a '...' marker
+	//   - rejects comments that are not preceded by whitespace
+	//   - rejects the \' escape in double-quoted scalars
+	//   - rejects '-', '?' and ':' followed by a flow indicator in flow context
+	//   - rejects flow indicators in tag shorthands
+	//   - rejects continuation lines of flow collections and quoted scalars
+	//     that are not indented past the enclosing block
+	//   - rejects leading empty lines of a block scalar that are indented
+	//     more than its first non-empty line
+	YAML12Conformance
+)
+
+// SetConformance selects which set of parsing rules the Decoder follows.
+func (d *Decoder) SetConformance(c Conformance) {
+	d.parser.conformance = c
+}
@@ -0,0 +1,124 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Conformance", func() {
+	decode := func(c Conformance, data string) (interface{}, error) {
+		var v interface{}
+		d := NewDecoder(bytes.NewBufferString(data))
+		d.SetConformance(c)
+		err := d.Decode(&v)
+		return v, err
+	}
+
+	for _, c := range []Conformance{LibyamlConformance, YAML12Conformance} {
+		c := c
+
+		It("accepts the %YAML 1.2 directive", func() {
+			v, err := decode(c, "%YAML 1.2\n---\na: b\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{"a": "b"}))
+		})
+
+		It("accepts empty keys", func() {
+			v, err := decode(c, ": a\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{nil: "a"}))
+
+			v, err = decode(c, "[: a]")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal([]interface{}{map[interface{}]interface{}{nil: "a"}}))
+		})
+
+		It("accepts a bare document after a document end marker", func() {
+			v, err := decode(c, "...\n...\na: b\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{"a": "b"}))
+		})
+
+		It("accepts the escaped slash", func() {
+			v, err := decode(c, `"a\/b"`)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal("a/b"))
+		})
+
+		It("accepts zero-indented block scalars", func() {
+			v, err := decode(c, "--- |\nfoo\n...\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal("foo\n"))
+		})
+
+		It("accepts tabs separating values", func() {
+			v, err := decode(c, "a:\tb\nc:\t[1]\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{"a": "b", "c": []interface{}{int64(1)}}))
+		})
+	}
+
+	Context("Libyaml", func() {
+		It("rejects reserved directives", func() {
+			_, err := decode(LibyamlConformance, "%FOO bar\n---\na\n")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("accepts libyaml extensions", func() {
+			for _, data := range []string{
+				`"a"#c`,
+				`"\'"`,
+				"a: b\n%YAML 1.1\n---\nc\n",
+				"- !!str, a\n",
+			} {
+				_, err := decode(LibyamlConformance, data)
+				Ω(err).ShouldNot(HaveOccurred(), data)
+			}
+		})
+	})
+
+	Context("YAML 1.2", func() {
+		It("ignores reserved directives", func() {
+			v, err := decode(YAML12Conformance, "%FOO bar baz # comment\n---\na\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal("a"))
+		})
+
+		It("accepts anchors with any non-indicator character", func() {
+			parser := yaml_parser_t{}
+			yaml_parser_initialize(&parser)
+			yaml_parser_set_input_string(&parser, []byte("a: &:x: b\nc: *:x:\n"))
+			parser.conformance = YAML12Conformance
+
+			var anchors []string
+			event := yaml_event_t{}
+			for event.event_type != yaml_STREAM_END_EVENT {
+				Ω(yaml_parser_parse(&parser, &event)).Should(BeTrue())
+				if len(event.anchor) > 0 {
+					anchors = append(anchors, string(event.anchor))
+				}
+			}
+			Ω(anchors).Should(Equal([]string{":x:", ":x:"}))
+		})
+
+		It("rejects what the spec does not allow", func() {
+			for _, data := range []string{
+				`"a"#c`,
+				"[a,#c\n]",
+				"a: >#c\n  b\n",
+				`"\'"`,
+				"[-]",
+				"- !!str, a\n",
+				"a: [b,\nc]\n",
+				"a: \"b\nc\"\n",
+				"a: |\n   \n  b\n",
+				"a: b\n%YAML 1.2\n---\nc\n",
+				"---\na\n... b\n",
+			} {
+				_, err := decode(YAML12Conformance, data)
+				Ω(err).Should(HaveOccurred(), data)
+			}
+		})
+	})
+})
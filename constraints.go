@@ -0,0 +1,218 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// constraints are the min, max, pattern and enum options of a field's tag,
+// which a Decoder checks the values it decodes into the field against:
+//
+//	Replicas int    `yaml:"replicas,min=1,max=100"`
+//	Level    string `yaml:"level,enum=debug|info|warn"`
+//	Name     string `yaml:"name,pattern=[a-z][a-z0-9-]*"`
+//
+// min and max bound numbers, and the length of strings, slices, arrays and
+// maps.  pattern is a regular expression the whole of a string must match,
+// and enum lists the values a scalar may take, compared with its text as
+// written, so that 0x10 matches enum=0x10 but not enum=16.  Values of other
+// nodes, such as aliases, are compared as fmt prints them.  Fields that are
+// absent or null are not checked.  Since tag options are separated by
+// commas, a pattern cannot contain one.
+type constraints struct {
+	min, max string
+	pattern  *regexp.Regexp
+	enum     []string
+
+	// source is the pattern as the tag gives it.
+	source string
+
+	// err is the error in the options, reported when the field is decoded.
+	err error
+}
+
+// parseConstraints returns the constraints in opts, or nil if there are
+// none.
+func parseConstraints(opts tagOptions) *constraints {
+	var c *constraints
+	for _, opt := range strings.Split(string(opts), ",") {
+		eq := strings.IndexByte(opt, '=')
+		if eq < 0 {
+			continue
+		}
+		name, value := opt[:eq], opt[eq+1:]
+		switch name {
+		case "min", "max", "pattern", "enum":
+		default:
+			continue
+		}
+		if c == nil {
+			c = &constraints{}
+		}
+		switch name {
+		case "min":
+			c.min = value
+		case "max":
+			c.max = value
+		case "pattern":
+			re, err := regexp.Compile("^(?:" + value + ")$")
+			if err != nil && c.err == nil {
+				c.err = fmt.Errorf("invalid pattern: %v", err)
+			}
+			c.pattern, c.source = re, value
+		case "enum":
+			c.enum = strings.Split(value, "|")
+		}
+	}
+	return c
+}
+
+// check returns a description of how v, decoded from a scalar written as
+// text if scalar is set, breaks the constraints, or "" if it keeps to them.
+// Nil pointers and interfaces keep to any constraint.
+func (c *constraints) check(v reflect.Value, text string, scalar bool) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if c.min != "" || c.max != "" {
+		if problem, err := c.checkRange(v); problem != "" || err != nil {
+			return problem, err
+		}
+	}
+	if c.pattern != nil {
+		if v.Kind() != reflect.String {
+			return "", fmt.Errorf("pattern cannot apply to %s", v.Type())
+		}
+		if !c.pattern.MatchString(v.String()) {
+			return fmt.Sprintf("'%s' does not match the pattern %s", v.String(), c.pattern), nil
+		}
+	}
+	if c.enum != nil {
+		if !scalar {
+			text = fmt.Sprint(v.Interface())
+		}
+		for _, e := range c.enum {
+			if e == text {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("'%s' is not one of %s", text, strings.Join(c.enum, ", ")), nil
+	}
+	return "", nil
+}
+
+// checkRange checks v against min and max.
+func (c *constraints) checkRange(v reflect.Value) (string, error) {
+	var below, above bool
+	var what string
+	var err error
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		what = strconv.FormatInt(v.Int(), 10)
+		below, above, err = c.compare(func(bound string) (int, error) {
+			b, err := strconv.ParseInt(bound, 0, 64)
+			return compareInts(v.Int(), b), err
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		what = strconv.FormatUint(v.Uint(), 10)
+		below, above, err = c.compare(func(bound string) (int, error) {
+			b, err := strconv.ParseUint(bound, 0, 64)
+			switch {
+			case v.Uint() < b:
+				return -1, err
+			case v.Uint() > b:
+				return 1, err
+			}
+			return 0, err
+		})
+	case reflect.Float32, reflect.Float64:
+		what = strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+		below, above, err = c.compare(func(bound string) (int, error) {
+			b, err := strconv.ParseFloat(bound, 64)
+			switch {
+			case v.Float() < b:
+				return -1, err
+			case v.Float() > b:
+				return 1, err
+			}
+			return 0, err
+		})
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		what = "length " + strconv.Itoa(v.Len())
+		below, above, err = c.compare(func(bound string) (int, error) {
+			b, err := strconv.ParseInt(bound, 10, 64)
+			return compareInts(int64(v.Len()), b), err
+		})
+	default:
+		return "", fmt.Errorf("min and max cannot apply to %s", v.Type())
+	}
+
+	switch {
+	case err != nil:
+		return "", err
+	case below:
+		return fmt.Sprintf("%s is less than the minimum %s", what, c.min), nil
+	case above:
+		return fmt.Sprintf("%s is greater than the maximum %s", what, c.max), nil
+	}
+	return "", nil
+}
+
+// compare reports whether the value cmp compares with its bounds is below
+// min or above max.
+func (c *constraints) compare(cmp func(bound string) (int, error)) (below, above bool, err error) {
+	if c.min != "" {
+		n, err := cmp(c.min)
+		if err != nil {
+			return false, false, fmt.Errorf("invalid min '%s'", c.min)
+		}
+		below = n < 0
+	}
+	if c.max != "" {
+		n, err := cmp(c.max)
+		if err != nil {
+			return false, false, fmt.Errorf("invalid max '%s'", c.max)
+		}
+		above = n > 0
+	}
+	return below, above, nil
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// checkConstraints checks the value just decoded into the field f of the
+// struct type t from the node that started with the event value.  Plain
+// null scalars, which leave the field at its zero value, are not checked.
+func (d *Decoder) checkConstraints(t reflect.Type, f *field, v reflect.Value, value yaml_event_t) {
+	if f.constraints == nil || !v.IsValid() {
+		return
+	}
+	if value.event_type == yaml_SCALAR_EVENT && value.implicit && null_values[string(value.value)] {
+		return
+	}
+	problem, err := f.constraints.check(v, string(value.value), value.event_type == yaml_SCALAR_EVENT)
+	if err != nil {
+		d.error(fmt.Errorf("yaml: field '%s' of %s: %v", f.name, t, err))
+	}
+	if problem != "" {
+		d.error(&ConstraintError{Field: f.name, Type: t, Problem: problem, At: value.start_mark})
+	}
+}
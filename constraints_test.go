@@ -0,0 +1,124 @@
+package candiedyaml
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Constraint tag options", func() {
+	type deployment struct {
+		Replicas int      `yaml:"replicas,min=1,max=100"`
+		Ratio    float64  `yaml:"ratio,omitempty,min=0,max=1"`
+		Level    string   `yaml:"level,enum=debug|info|warn"`
+		Name     string   `yaml:"name,pattern=[a-z][a-z0-9-]*"`
+		Port     *uint16  `yaml:"port,min=1024"`
+		Zones    []string `yaml:"zones,min=1"`
+		Code     int      `yaml:"code,enum=200|404"`
+	}
+
+	constraintError := func(err error) *ConstraintError {
+		var ce *ConstraintError
+		Ω(errors.As(err, &ce)).Should(BeTrue(), "%v", err)
+		Ω(errors.Is(err, ErrConstraint)).Should(BeTrue())
+		return ce
+	}
+
+	It("accepts values that keep to the constraints", func() {
+		var d deployment
+		err := Unmarshal([]byte(`
+replicas: 100
+ratio: 0.5
+level: info
+name: web-1
+port: 8080
+zones: [a, b]
+code: 404
+`), &d)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(d.Replicas).Should(Equal(100))
+		Ω(*d.Port).Should(Equal(uint16(8080)))
+	})
+
+	It("does not check fields that are absent or null", func() {
+		var d deployment
+		Ω(Unmarshal([]byte("port: null\nreplicas:\n"), &d)).Should(Succeed())
+		Ω(d.Replicas).Should(BeZero())
+
+		err := Unmarshal([]byte("level: ''\n"), &d)
+		Ω(err).Should(MatchError(ErrConstraint))
+	})
+
+	It("reports numbers out of range where they are", func() {
+		var d deployment
+		err := Unmarshal([]byte("level: warn\nreplicas: 0\n"), &d)
+		ce := constraintError(err)
+		Ω(ce.Field).Should(Equal("replicas"))
+		Ω(err.Error()).Should(Equal("yaml: field 'replicas' of candiedyaml.deployment: 0 is less than the minimum 1 at line 2, column 11"))
+
+		err = Unmarshal([]byte("ratio: 1.5\n"), &d)
+		Ω(constraintError(err).Problem).Should(Equal("1.5 is greater than the maximum 1"))
+
+		err = Unmarshal([]byte("port: 80\n"), &d)
+		Ω(constraintError(err).Problem).Should(Equal("80 is less than the minimum 1024"))
+	})
+
+	It("bounds the length of collections", func() {
+		var d deployment
+		err := Unmarshal([]byte("zones: []\n"), &d)
+		Ω(constraintError(err).Problem).Should(Equal("length 0 is less than the minimum 1"))
+	})
+
+	It("checks enums against the values as written", func() {
+		var d deployment
+		err := Unmarshal([]byte("level: trace\n"), &d)
+		Ω(constraintError(err).Problem).Should(Equal("'trace' is not one of debug, info, warn"))
+
+		err = Unmarshal([]byte("code: 500\n"), &d)
+		Ω(constraintError(err).Problem).Should(Equal("'500' is not one of 200, 404"))
+
+		var p struct {
+			Port int `yaml:"port,enum=0x10|80"`
+		}
+		Ω(Unmarshal([]byte("port: 0x10\n"), &p)).Should(Succeed())
+		Ω(p.Port).Should(Equal(16))
+		err = Unmarshal([]byte("port: 16\n"), &p)
+		Ω(constraintError(err).Problem).Should(Equal("'16' is not one of 0x10, 80"))
+	})
+
+	It("matches patterns against the whole string", func() {
+		var d deployment
+		err := Unmarshal([]byte("name: Web\n"), &d)
+		Ω(constraintError(err).Problem).Should(Equal("'Web' does not match the pattern ^(?:[a-z][a-z0-9-]*)$"))
+
+		err = Unmarshal([]byte("name: web!\n"), &d)
+		Ω(err).Should(MatchError(ErrConstraint))
+	})
+
+	It("checks values merged in from other mappings", func() {
+		var d deployment
+		err := Unmarshal([]byte(`
+base: &base {replicas: 500}
+<<: *base
+`), &d, func(d *Decoder) { d.SetCompatibility(GoYAMLv2) })
+		Ω(constraintError(err).At.line).Should(Equal(1))
+	})
+
+	It("reports options that cannot apply to the field", func() {
+		var v struct {
+			Enabled bool   `yaml:"enabled,min=1"`
+			Name    string `yaml:"name,pattern=(["`
+			Size    int    `yaml:"size,max=big"`
+		}
+		Ω(Unmarshal([]byte("enabled: true\n"), &v)).Should(MatchError(HaveSuffix("min and max cannot apply to bool")))
+		Ω(Unmarshal([]byte("name: x\n"), &v)).Should(MatchError(ContainSubstring("invalid pattern")))
+		Ω(Unmarshal([]byte("size: 1\n"), &v)).Should(MatchError(ContainSubstring("invalid max 'big'")))
+	})
+
+	It("does not affect encoding", func() {
+		out, err := Marshal(deployment{Level: "trace"}, EncodeOption(func(e *Encoder) { e.SetSchema(YAML11Schema) }))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(ContainSubstring("level: trace\n"))
+	})
+})
@@ -0,0 +1,90 @@
+package candiedyaml
+
+import "reflect"
+
+// CopyAliases causes each alias to decode to a deep copy of the value
+// decoded at its anchor.  By default the anchored subtree is decoded once
+// and aliases share its maps, slices and pointers, which keeps documents
+// that use an anchor many times cheap to decode but means changing the
+// value through one alias changes it through all of them.
+func (d *Decoder) CopyAliases(copy bool) {
+	d.copyAliases = copy
+}
+
+// aliasValue returns the value an alias to the anchored value v decodes to.
+func (d *Decoder) aliasValue(v reflect.Value) reflect.Value {
+	if !d.copyAliases {
+		return v
+	}
+	return deepCopy(v, make(map[copied]reflect.Value))
+}
+
+// copied identifies a map, slice or pointer already copied, so that values
+// referred to twice are copied once and cycles terminate.
+type copied struct {
+	t   reflect.Type
+	ptr uintptr
+	len int
+}
+
+// deepCopy returns a copy of v that shares no maps, slice backing arrays or
+// pointers with it.  Unexported struct fields are copied shallowly.
+func deepCopy(v reflect.Value, seen map[copied]reflect.Value) reflect.Value {
+	var key copied
+	switch v.Kind() {
+	case reflect.Map, reflect.Ptr, reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		key = copied{v.Type(), v.Pointer(), 0}
+		if v.Kind() == reflect.Slice {
+			key.len = v.Len()
+		}
+		if c, ok := seen[key]; ok {
+			return c
+		}
+	case reflect.Interface:
+		// Numbers and strings need no copying, nor boxing again.
+		if v.IsNil() || v.Elem().Kind() <= reflect.Complex128 || v.Elem().Kind() == reflect.String {
+			return v
+		}
+	case reflect.Array, reflect.Struct:
+	default:
+		return v
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Map:
+		c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		seen[key] = c
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), seen))
+		}
+	case reflect.Ptr:
+		c.Set(reflect.New(v.Type().Elem()))
+		seen[key] = c
+		c.Elem().Set(deepCopy(v.Elem(), seen))
+	case reflect.Slice:
+		c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		seen[key] = c
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopy(v.Index(i), seen))
+		}
+	case reflect.Interface:
+		c.Set(deepCopy(v.Elem(), seen))
+	case reflect.Struct:
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopy(v.Field(i), seen))
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,75 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+type copyTarget struct {
+	Base   *copyLimits
+	First  *copyLimits
+	Second *copyLimits
+}
+
+type copyLimits struct {
+	Max   int
+	Hosts []string
+}
+
+var _ = Describe("Copying aliases", func() {
+	const doc = "base: &b {max: 1, hosts: [a, b]}\nfirst: *b\nsecond: *b\n"
+
+	decode := func(copy bool, v interface{}) {
+		d := NewDecoder(strings.NewReader(doc))
+		d.CopyAliases(copy)
+		Ω(d.Decode(v)).Should(Succeed())
+	}
+
+	It("shares the anchored value by default", func() {
+		var v map[interface{}]interface{}
+		decode(false, &v)
+
+		v["first"].(map[interface{}]interface{})["max"] = 2
+		Ω(v["second"]).Should(HaveKeyWithValue("max", 2))
+		Ω(v["base"]).Should(HaveKeyWithValue("max", 2))
+	})
+
+	It("decodes a deep copy for each alias", func() {
+		var v map[interface{}]interface{}
+		decode(true, &v)
+
+		first := v["first"].(map[interface{}]interface{})
+		first["max"] = 2
+		first["hosts"].([]interface{})[0] = "c"
+		Ω(v["second"]).Should(Equal(map[interface{}]interface{}{"max": int64(1), "hosts": []interface{}{"a", "b"}}))
+		Ω(v["base"]).Should(Equal(v["second"]))
+	})
+
+	It("copies typed values", func() {
+		var shared, copied copyTarget
+		decode(false, &shared)
+		decode(true, &copied)
+
+		Ω(shared.First).Should(BeIdenticalTo(shared.Second))
+
+		Ω(copied.First).ShouldNot(BeIdenticalTo(copied.Second))
+		copied.First.Hosts[0] = "c"
+		Ω(copied.Second).Should(Equal(&copyLimits{Max: 1, Hosts: []string{"a", "b"}}))
+		Ω(copied.Base).Should(Equal(copied.Second))
+	})
+
+	It("keeps the cycles of recursive values", func() {
+		d := NewDecoder(strings.NewReader("a: &a [x, *a]\nb: *a\n"))
+		d.CopyAliases(true)
+		var v map[interface{}]interface{}
+		Ω(d.Decode(&v)).Should(Succeed())
+
+		b := v["b"].([]interface{})
+		Ω(b[0]).Should(Equal("x"))
+		inner := b[1].([]interface{})
+		inner[0] = "y"
+		Ω(b[0]).Should(Equal("y"))
+		Ω(v["a"].([]interface{})[0]).Should(Equal("x"))
+	})
+})
@@ -8,13 +8,105 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 )
 
+// A Decoder reads and decodes YAML documents from an input stream.  A
+// Decoder must be used by one goroutine at a time, but distinct Decoders may
+// be used concurrently: the state they share, such as registered adapters,
+// schemas and the struct field cache, is read-only or locked.
 type Decoder struct {
 	parser yaml_parser_t
 	event  yaml_event_t
 
 	anchors map[string]reflect.Value
+	schema  *Schema
+
+	// open holds the anchors of the collections being decoded, with the
+	// functions that fill in recursive aliases to them once they are done.
+	open map[string][]func(interface{})
+
+	mergeMaps        bool
+	appendSlices     bool
+	rejectDuplicates bool
+	rejectAmbiguous  bool
+	rejectUnknown    bool
+	noTimestamps     bool
+	explicitTags     bool
+	literalStrings   bool
+	preferLossless   bool
+	copyAliases      bool
+	positions        bool
+	skipElements     bool
+	documentAnchors  bool
+	orderedMaps      bool
+	mergeKeys        bool
+	stringMaps       bool
+	typeErrors       bool
+	overflow         OverflowPolicy
+	leadingZeros     LeadingZeroPolicy
+
+	tracer Tracer
+	field  string
+	offset int
+
+	// keyEqual, if set, decides which keys of a mapping are duplicates.
+	keyEqual func(a, b interface{}) bool
+
+	// raw keeps the input from the start of the current document on.
+	raw *rawReader
+
+	// spans, if set, records where in the input each Node decoded was.
+	spans map[*Node]span
+
+	// key is set while a mapping key is decoded.
+	key bool
+
+	// level is the number of collections the current event is nested in,
+	// and events counts the events read.
+	level  int
+	events int
+
+	elementErrors ElementErrors
+	typeErrorList []string
+
+	// fields records the struct fields present for DecodeWithFieldSet, and
+	// fieldPath is the path to the value being decoded.
+	fields    FieldSet
+	fieldPath []string
+
+	// indexes, set by DecodeFields, holds the index paths the keys of the
+	// document's root mapping go to.
+	indexes map[string][]int
+
+	// recorded holds the events of the anchored mappings read so far, and
+	// recordings those still being read, for merge keys to replay.  replay
+	// holds the events to read before going on with the parser, and
+	// replayed counts the events merge keys have replayed.
+	recorded   map[string][]yaml_event_t
+	recordings []*recording
+	replay     []yaml_event_t
+	replayed   int
+
+	maxDepth   int
+	maxAliases int
+	depth      int
+	aliases    int
+
+	// deepest is the deepest nesting of the current document, for stats.
+	deepest int
+	stats   StatsCollector
+
+	// preprocess, if set, is passed each document as a Node before it is
+	// decoded.
+	preprocess func(doc *Node) error
+
+	// postprocess, if set, is passed each value decoded into.
+	postprocess func(v interface{}) error
+
+	// fieldDecoder is passed to the UnmarshalYAMLField method of the struct
+	// being decoded.
+	fieldDecoder FieldDecoder
 }
 
 type ParserError struct {
@@ -23,12 +115,27 @@ type ParserError struct {
 	ContextMark YAML_mark_t
 	Problem     string
 	ProblemMark YAML_mark_t
+
+	cause   error
+	snippet string
 }
 
 func (e *ParserError) Error() string {
 	return fmt.Sprintf("yaml: [%s] %s at line %d, column %d", e.Context, e.Problem, e.ProblemMark.line+1, e.ProblemMark.column+1)
 }
 
+// Snippet returns the source line the problem was found on with a caret
+// pointing at the offending column, or "" if the line is not available.
+func (e *ParserError) Snippet() string {
+	return e.snippet
+}
+
+// Unwrap returns the sentinel error describing the kind of failure, or the
+// error returned by the underlying reader.
+func (e *ParserError) Unwrap() error {
+	return e.cause
+}
+
 type UnexpectedEventError struct {
 	Value     string
 	EventType yaml_event_type_t
@@ -39,36 +146,167 @@ func (e *UnexpectedEventError) Error() string {
 	return fmt.Sprintf("yaml: Unexpect event [%d]: '%s' at line %d, column %d", e.EventType, e.Value, e.At.line+1, e.At.column+1)
 }
 
-func Unmarshal(data []byte, v interface{}) error {
+// Unmarshal decodes the YAML document in data into v with a Decoder set up
+// with opts.  If another document follows it, Unmarshal decodes the first
+// and returns a TrailingContentError; use a Decoder to read each document of
+// a stream in turn.
+func Unmarshal(data []byte, v interface{}, opts ...DecodeOption) error {
 	d := NewDecoder(bytes.NewBuffer(data))
-	return d.Decode(v)
+	for _, opt := range opts {
+		opt(d)
+	}
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if d.event.event_type != yaml_STREAM_END_EVENT {
+		return &TrailingContentError{At: d.event.start_mark}
+	}
+	return nil
+}
+
+// UnmarshalAll decodes every document in data into a new element of the
+// slice v points to.
+func UnmarshalAll(data []byte, v interface{}) error {
+	return NewDecoder(bytes.NewBuffer(data)).DecodeAll(v)
 }
 
 func NewDecoder(r io.Reader) *Decoder {
 	d := &Decoder{
 		anchors: make(map[string]reflect.Value),
+		schema:  YAML11Schema,
 	}
+	d.raw = &rawReader{r: r, parser: &d.parser}
 	yaml_parser_initialize(&d.parser)
-	yaml_parser_set_input_reader(&d.parser, r)
+	yaml_parser_set_input_reader(&d.parser, d.raw)
 	return d
 }
 
-func (d *Decoder) Decode(v interface{}) (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			if _, ok := r.(runtime.Error); ok {
-				panic(r)
-			}
-			switch r := r.(type) {
-			case error:
-				err = r
-			case string:
-				err = errors.New(r)
-			default:
-				err = errors.New("Unknown panic: " + reflect.TypeOf(r).String())
-			}
-		}
-	}()
+// InputOffset returns the number of bytes of input read up to the end of the
+// last document decoded, which can be used to report progress.  Comments
+// between two documents count towards the first unless it ends with a '...'
+// marker.
+func (d *Decoder) InputOffset() int64 {
+	return int64(d.offset)
+}
+
+// MergeMaps causes the Decoder to merge mappings into map values that
+// already hold an entry for the decoded key rather than starting from the
+// zero value, so that repeated decodes layer on top of each other.
+func (d *Decoder) MergeMaps(merge bool) {
+	d.mergeMaps = merge
+}
+
+// AppendSlices causes the Decoder to append sequence items to slices that
+// already hold elements instead of overwriting them.
+func (d *Decoder) AppendSlices(append bool) {
+	d.appendSlices = append
+}
+
+// RejectDuplicateKeys causes Decode to fail with a DuplicateKeyError when a
+// mapping contains the same key more than once.  By default the last value
+// wins.
+func (d *Decoder) RejectDuplicateKeys(reject bool) {
+	d.rejectDuplicates = reject
+}
+
+// SetKeyEqual sets the function that decides whether two keys of a mapping
+// are duplicates, for rules stricter than Go's ==, such as EqualFoldKeys.
+// Keys that are equal but not identical are still decoded as separate
+// entries unless duplicates are rejected.  A nil equal restores ==.
+func (d *Decoder) SetKeyEqual(equal func(a, b interface{}) bool) {
+	d.keyEqual = equal
+}
+
+// EqualFoldKeys reports whether a and b are equal, comparing strings under
+// Unicode case-folding.  Pass it to SetKeyEqual to treat Name and name as
+// the same key.
+func EqualFoldKeys(a, b interface{}) bool {
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return strings.EqualFold(as, bs)
+	}
+	return a == b
+}
+
+// RejectAmbiguousFields causes Decode to fail with an AmbiguousFieldError
+// when a key matches a name that more than one embedded struct promotes at
+// the same depth, such as Name in
+//
+//	struct {
+//		Person
+//		Company
+//	}
+//
+// when both have a Name field.  Such fields are left out as in encoding/json,
+// and by default the key is skipped with a warning.
+func (d *Decoder) RejectAmbiguousFields(reject bool) {
+	d.rejectAmbiguous = reject
+}
+
+// RejectUnknownFields causes Decode to fail with an UnknownFieldError when a
+// key matches no field of the struct being decoded into.  By default the key
+// is skipped with a warning.
+func (d *Decoder) RejectUnknownFields(reject bool) {
+	d.rejectUnknown = reject
+}
+
+// AllowCrossDocumentAnchors selects whether aliases may refer to anchors
+// defined in earlier documents of the stream, which the spec does not allow.
+// They may by default.  Decoding into a Node never allows them.
+func (d *Decoder) AllowCrossDocumentAnchors(allow bool) {
+	d.documentAnchors = !allow
+}
+
+// SetSchema selects the schema untagged plain scalars are resolved with when
+// decoding into an interface{}.  The default is YAML11Schema.
+func (d *Decoder) SetSchema(s *Schema) {
+	d.schema = s
+}
+
+// ExplicitTagsOnly causes untagged scalars decoded into interface{} values
+// to be left as strings, so that only scalars with an explicit tag such as
+// !!int or !!bool get another type.  This keeps input such as `no` or `0755`
+// from changing type unexpectedly.  Scalars decoded into typed fields are
+// still converted to the type of the field.
+func (d *Decoder) ExplicitTagsOnly(only bool) {
+	d.explicitTags = only
+}
+
+// LiteralStrings causes scalars decoded into strings, such as the values of
+// a map[string]string or the elements of a []string, to be kept exactly as
+// written.  By default null scalars such as `~` and `null` decode to the
+// empty string; with LiteralStrings they decode to "~" and "null", as
+// numbers and booleans such as `1.50` and `yes` already decode to "1.50" and
+// "yes".
+func (d *Decoder) LiteralStrings(literal bool) {
+	d.literalStrings = literal
+}
+
+// scalarSchema returns the schema untagged scalars are resolved with.
+func (d *Decoder) scalarSchema() *Schema {
+	if d.explicitTags {
+		return FailsafeSchema
+	}
+	return d.schema
+}
+
+// Decode reads the next document of the stream into v.  It returns io.EOF
+// once there are no more documents.
+func (d *Decoder) Decode(v interface{}) error {
+	if d.stats == nil {
+		return d.decode(v)
+	}
+	start, offset := time.Now(), d.offset
+	err := d.decode(v)
+	if err != io.EOF {
+		d.reportStats(start, offset, err)
+	}
+	return err
+}
+
+func (d *Decoder) decode(v interface{}) (err error) {
+	defer recoverError(&err)
 
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
@@ -80,6 +318,51 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 		return errors.New("Invalid type: " + msg)
 	}
 
+	// Comments and blank lines are only kept once they may end up in a
+	// Node, and are kept from then on, as the next document may already
+	// have been read.
+	if d.preprocess != nil || holdsNodes(rv.Type()) {
+		d.parser.keep_comments = true
+	}
+	if err := d.startDocument(); err != nil {
+		return err
+	}
+	d.raw.decoding = true
+	d.depth, d.aliases, d.deepest = 0, 0, 0
+	d.open = make(map[string][]func(interface{}))
+	if d.documentAnchors {
+		d.anchors = make(map[string]reflect.Value)
+	}
+
+	if n, ok := v.(*Node); ok {
+		d.documentNode(n)
+		if d.preprocess != nil {
+			if err := d.preprocess(n); err != nil {
+				return err
+			}
+		}
+		return d.finish(v)
+	}
+	if d.preprocess != nil {
+		d.preprocessDocument()
+	}
+
+	d.elementErrors, d.typeErrorList = nil, nil
+	d.fieldPath = d.fieldPath[:0]
+	d.key = false
+	d.document(rv)
+	if d.typeErrorList != nil {
+		return &TypeError{Errors: d.typeErrorList}
+	}
+	if d.elementErrors != nil {
+		return d.elementErrors
+	}
+	return d.finish(v)
+}
+
+// startDocument reads up to the start of the next document.  It returns
+// io.EOF at the end of the stream.
+func (d *Decoder) startDocument() error {
 	if d.event.event_type == yaml_NO_EVENT {
 		d.nextEvent()
 
@@ -90,7 +373,34 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 		d.nextEvent()
 	}
 
-	d.document(rv)
+	if d.event.event_type == yaml_STREAM_END_EVENT {
+		return io.EOF
+	}
+	return nil
+}
+
+// DecodeAll decodes the remaining documents of the stream into new elements
+// of the slice v points to, replacing its contents.
+func (d *Decoder) DecodeAll(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("DecodeAll: not a pointer to a slice: %T", v)
+	}
+
+	s := reflect.MakeSlice(rv.Elem().Type(), 0, 0)
+	for {
+		elem := reflect.New(s.Type().Elem())
+		err := d.Decode(elem.Interface())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s = reflect.Append(s, elem.Elem())
+	}
+
+	rv.Elem().Set(s)
 	return nil
 }
 
@@ -98,21 +408,73 @@ func (d *Decoder) error(err error) {
 	panic(err)
 }
 
+// recoverError stores the error a Decoder panicked with in err.  It must be
+// deferred directly.
+func recoverError(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); ok {
+			panic(r)
+		}
+		switch r := r.(type) {
+		case error:
+			*err = r
+		case string:
+			*err = errors.New(r)
+		default:
+			*err = errors.New("Unknown panic: " + reflect.TypeOf(r).String())
+		}
+	}
+}
+
 func (d *Decoder) nextEvent() {
 	if d.event.event_type == yaml_STREAM_END_EVENT {
 		d.error(errors.New("The stream is closed"))
 	}
 
+	switch d.event.event_type {
+	case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+		d.level++
+	case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+		d.level--
+	}
+	d.events++
+
+	if len(d.replay) > 0 {
+		d.event = d.replay[0]
+		d.replay = d.replay[1:]
+		return
+	}
+
+	state := d.parser.state
 	if !yaml_parser_parse(&d.parser, &d.event) {
 		yaml_event_delete(&d.event)
 
-		d.error(&ParserError{
+		err := &ParserError{
 			ErrorType:   d.parser.error,
 			Context:     d.parser.context,
 			ContextMark: d.parser.context_mark,
 			Problem:     d.parser.problem,
 			ProblemMark: d.parser.problem_mark,
-		})
+			cause:       parserErrorCause(&d.parser),
+		}
+		if d.parser.error != yaml_READER_ERROR {
+			err.snippet = renderSnippet(&d.parser, d.parser.problem_mark)
+		}
+		d.error(err)
+	}
+
+	switch d.event.event_type {
+	case yaml_DOCUMENT_END_EVENT, yaml_STREAM_END_EVENT:
+		d.raw.discard(d.offset)
+		d.raw.decoding = false
+		d.offset = d.event.end_mark.offset
+	}
+
+	if d.tracer != nil {
+		d.traceEvent(state)
+	}
+	if d.mergeKeys {
+		d.record()
 	}
 }
 
@@ -122,7 +484,14 @@ func (d *Decoder) document(rv reflect.Value) {
 	}
 
 	d.nextEvent()
-	d.parse(rv)
+	if d.indexes != nil && d.event.event_type == yaml_MAPPING_START_EVENT {
+		if d.tracer != nil {
+			d.traceTarget(rv)
+		}
+		d.mappingFields(rv.Elem(), d.indexes)
+	} else {
+		d.parse(rv)
+	}
 
 	if d.event.event_type != yaml_DOCUMENT_END_EVENT {
 		d.error(fmt.Errorf("Expected document end - found %d", d.event.event_type))
@@ -132,22 +501,44 @@ func (d *Decoder) document(rv reflect.Value) {
 }
 
 func (d *Decoder) parse(rv reflect.Value) {
+	if d.tracer != nil {
+		d.traceTarget(rv)
+	}
+
 	if !rv.IsValid() {
 		// skip ahead since we cannot store
 		d.valueInterface()
 		return
 	}
 
+	if t := rv.Type(); t == nodeType || (t.Kind() == reflect.Ptr && t.Elem() == nodeType) {
+		n := d.node(make(map[string]*Node))
+		d.lineComments(n)
+		d.indirect(rv).Set(reflect.ValueOf(*n))
+		return
+	}
+
+	if d.adapt(rv) || d.unmarshalNode(rv) {
+		return
+	}
+
+	anchor := string(d.event.anchor)
 	switch d.event.event_type {
 	case yaml_SEQUENCE_START_EVENT:
-		d.anchor(rv)
+		d.enter()
+		d.openAnchor(anchor)
 		d.sequence(rv)
+		d.leave()
+		d.anchor(anchor, rv)
 	case yaml_MAPPING_START_EVENT:
-		d.anchor(rv)
+		d.enter()
+		d.openAnchor(anchor)
 		d.mapping(rv)
+		d.leave()
+		d.anchor(anchor, rv)
 	case yaml_SCALAR_EVENT:
-		d.anchor(rv)
 		d.scalar(rv)
+		d.anchor(anchor, rv)
 	case yaml_ALIAS_EVENT:
 		d.alias(rv)
 	case yaml_DOCUMENT_END_EVENT:
@@ -160,12 +551,80 @@ func (d *Decoder) parse(rv reflect.Value) {
 	}
 }
 
-func (d *Decoder) anchor(rv reflect.Value) {
-	if d.event.anchor != nil {
-		d.anchors[string(d.event.anchor)] = rv
+// openAnchor marks the anchor of a collection as being decoded, so that
+// aliases to it from inside the collection are recognised as recursive.
+func (d *Decoder) openAnchor(anchor string) {
+	if anchor != "" {
+		d.open[anchor] = nil
 	}
 }
 
+// anchor remembers the value decoded into rv under its anchor and fills in
+// any recursive aliases to it.  The value is copied since rv may be reused
+// for the next map entry.
+func (d *Decoder) anchor(anchor string, rv reflect.Value) {
+	if anchor != "" {
+		v := reflect.New(rv.Type()).Elem()
+		v.Set(rv)
+		d.anchors[anchor] = v
+
+		fixups := d.open[anchor]
+		delete(d.open, anchor)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		for _, set := range fixups {
+			set(v.Interface())
+		}
+	}
+}
+
+func isEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+func setInterface(v reflect.Value, a interface{}) {
+	if a != nil {
+		v.Set(reflect.ValueOf(a))
+	}
+}
+
+func setMapInterface(m, k reflect.Value, a interface{}) {
+	if a == nil {
+		m.SetMapIndex(k, reflect.Zero(m.Type().Elem()))
+		return
+	}
+	m.SetMapIndex(k, reflect.ValueOf(a))
+}
+
+// recursiveAlias stands in for the value of an alias to a collection that
+// is still being decoded.
+type recursiveAlias struct {
+	anchor string
+	at     YAML_mark_t
+}
+
+// recursive returns the current event as a recursiveAlias if it is an alias
+// to an open anchor.
+func (d *Decoder) recursive() (recursiveAlias, bool) {
+	if d.event.event_type != yaml_ALIAS_EVENT {
+		return recursiveAlias{}, false
+	}
+	anchor := string(d.event.anchor)
+	_, ok := d.open[anchor]
+	return recursiveAlias{anchor, d.event.start_mark}, ok
+}
+
+// resolveLater arranges for set to be called with the value of v once its
+// anchor is complete, if v is a recursiveAlias.
+func (d *Decoder) resolveLater(v interface{}, set func(interface{})) bool {
+	r, ok := v.(recursiveAlias)
+	if ok {
+		d.open[r.anchor] = append(d.open[r.anchor], set)
+	}
+	return ok
+}
+
 func (d *Decoder) indirect(v reflect.Value) reflect.Value {
 	// If v is a named type and is addressable,
 	// start with its address, so that if the type has pointer methods,
@@ -211,12 +670,25 @@ func (d *Decoder) sequence(v reflect.Value) {
 	case reflect.Interface:
 		if v.NumMethod() == 0 {
 			// Decoding into nil interface?  Switch to non-reflect code.
+			if existing, ok := v.Interface().([]interface{}); ok && d.appendSlices {
+				v.Set(reflect.ValueOf(append(existing, d.sequenceInterface()...)))
+				return
+			}
 			v.Set(reflect.ValueOf(d.sequenceInterface()))
 			return
 		}
 		// Otherwise it's invalid.
 		fallthrough
 	default:
+		if isSet(v.Type()) {
+			d.sequenceSet(v)
+			return
+		}
+		if d.typeErrors {
+			d.typeError(v.Type())
+			d.sequenceInterface()
+			return
+		}
 		d.error(errors.New("sequence: invalid type: " + v.Type().String()))
 	case reflect.Array:
 	case reflect.Slice:
@@ -226,7 +698,15 @@ func (d *Decoder) sequence(v reflect.Value) {
 	d.nextEvent()
 
 	i := 0
-	for {
+	if d.appendSlices && v.Kind() == reflect.Slice {
+		i = v.Len()
+	}
+	start := i
+	elemt := v.Type().Elem()
+	root := d.depth == 1
+	var pending []recursiveAlias
+	var pendingIndex []int
+	for index := 0; ; index++ {
 		if d.event.event_type == yaml_SEQUENCE_END_EVENT {
 			break
 		}
@@ -248,9 +728,23 @@ func (d *Decoder) sequence(v reflect.Value) {
 			}
 		}
 
-		if i < v.Len() {
+		if r, ok := d.recursive(); ok && i < v.Len() && isEmptyInterface(elemt) {
+			// Fill in once the backing array no longer moves.
+			d.countAlias()
+			d.nextEvent()
+			pending = append(pending, r)
+			pendingIndex = append(pendingIndex, i)
+		} else if root && d.skipElements && i < v.Len() {
+			// Leave out elements that cannot be decoded.
+			if !d.parseElement(v.Index(i), index) {
+				v.Index(i).Set(reflect.Zero(elemt))
+				i--
+			}
+		} else if i < v.Len() {
 			// Decode into element.
+			d.enterField(i, false)
 			d.parse(v.Index(i))
+			d.leaveField()
 		} else {
 			// Ran out of fixed array: skip.
 			d.parse(reflect.Value{})
@@ -269,9 +763,13 @@ func (d *Decoder) sequence(v reflect.Value) {
 			v.SetLen(i)
 		}
 	}
-	if i == 0 && v.Kind() == reflect.Slice {
+	if i == 0 && start == 0 && v.Kind() == reflect.Slice {
 		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
 	}
+	for j, r := range pending {
+		elem := v.Index(pendingIndex[j])
+		d.resolveLater(r, func(a interface{}) { setInterface(elem, a) })
+	}
 
 	d.nextEvent()
 }
@@ -280,10 +778,20 @@ func (d *Decoder) mapping(v reflect.Value) {
 	pv := d.indirect(v)
 	v = pv
 
+	if v.Type() == mapSliceType {
+		v.Set(reflect.ValueOf(d.mapSliceInterface()))
+		return
+	}
+
 	// Decoding into nil interface?  Switch to non-reflect code.
 	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
-		v.Set(reflect.ValueOf(d.mappingInterface()))
-		return
+		if !d.mergeMaps || v.IsNil() || v.Elem().Kind() != reflect.Map {
+			v.Set(reflect.ValueOf(d.mappingInterface()))
+			return
+		}
+
+		// Merge into the map already held by the interface.
+		v = v.Elem()
 	}
 
 	// Check type of target: struct or map[X]Y
@@ -293,6 +801,11 @@ func (d *Decoder) mapping(v reflect.Value) {
 		return
 	case reflect.Map:
 	default:
+		if d.typeErrors {
+			d.typeError(v.Type())
+			d.mappingInterface()
+			return
+		}
 		d.error(errors.New("mapping: invalid type: " + v.Type().String()))
 	}
 
@@ -306,13 +819,25 @@ func (d *Decoder) mapping(v reflect.Value) {
 	keyt := mapt.Key()
 	mapElemt := mapt.Elem()
 
+	seen := make(map[interface{}]bool)
+	var keys mergedKeys
+	if d.mergeKeys {
+		keys = make(mergedKeys)
+	}
 	var mapElem reflect.Value
-	for {
-		if d.event.event_type == yaml_MAPPING_END_EVENT {
-			break
-		}
+	entry := func(merged bool) {
+		mark := d.event.start_mark
 		key := reflect.New(keyt)
+		d.key = true
 		d.parse(key.Elem())
+		d.key = false
+		if !keys.add(key.Elem().Interface()) && merged {
+			d.parse(reflect.Value{})
+			return
+		}
+		if !merged {
+			d.checkDuplicate(seen, key.Elem().Interface(), mark)
+		}
 
 		if !mapElem.IsValid() {
 			mapElem = reflect.New(mapElemt).Elem()
@@ -320,10 +845,33 @@ func (d *Decoder) mapping(v reflect.Value) {
 			mapElem.Set(reflect.Zero(mapElemt))
 		}
 
+		if d.mergeMaps {
+			if existing := v.MapIndex(key.Elem()); existing.IsValid() {
+				mapElem.Set(existing)
+			}
+		}
+
+		if r, ok := d.recursive(); ok && isEmptyInterface(mapElemt) {
+			d.countAlias()
+			d.nextEvent()
+			m, k := reflect.ValueOf(v.Interface()), key.Elem()
+			d.resolveLater(r, func(a interface{}) { setMapInterface(m, k, a) })
+			return
+		}
+
+		d.enterField(key.Elem(), false)
 		d.parse(mapElem)
+		d.leaveField()
 
 		v.SetMapIndex(key.Elem(), mapElem)
 	}
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		if d.isMergeKey() {
+			d.merge(v.Type(), entry)
+			continue
+		}
+		entry(false)
+	}
 
 	d.nextEvent()
 }
@@ -331,16 +879,27 @@ func (d *Decoder) mapping(v reflect.Value) {
 func (d *Decoder) mappingStruct(v reflect.Value) {
 
 	structt := v.Type()
-	fields := cachedTypeFields(structt)
+	sf := cachedTypeFields(structt)
+	fields := sf.list
+	var fu FieldUnmarshaler
+	if v.CanAddr() && reflect.PtrTo(structt).Implements(fieldUnmarshalerType) {
+		fu = v.Addr().Interface().(FieldUnmarshaler)
+	}
 
 	d.nextEvent()
 
-	for {
-		if d.event.event_type == yaml_MAPPING_END_EVENT {
-			break
-		}
+	seen := make(map[interface{}]bool)
+	var keys mergedKeys
+	if d.mergeKeys {
+		keys = make(mergedKeys)
+	}
+	entry := func(merged bool) {
+		mark := d.event.start_mark
 		key := ""
 		d.parse(reflect.ValueOf(&key))
+		if !merged {
+			d.checkDuplicate(seen, key, mark)
+		}
 
 		// Figure out field corresponding to key.
 		var subv reflect.Value
@@ -358,7 +917,19 @@ func (d *Decoder) mappingStruct(v reflect.Value) {
 			}
 		}
 
+		var set interface{} = key
 		if f != nil {
+			set = f
+		}
+		if !keys.add(set) && merged {
+			d.parse(reflect.Value{})
+			return
+		}
+
+		if f != nil {
+			if d.tracer != nil {
+				d.field = structt.Name() + "." + structt.FieldByIndex(f.index).Name
+			}
 			subv = v
 			for _, i := range f.index {
 				if subv.Kind() == reflect.Ptr {
@@ -369,30 +940,172 @@ func (d *Decoder) mappingStruct(v reflect.Value) {
 				}
 				subv = subv.Field(i)
 			}
+		} else if name, ok := ambiguousField(sf, key); ok {
+			if d.rejectAmbiguous {
+				d.error(&AmbiguousFieldError{Key: key, Type: structt, At: mark})
+			}
+			d.warn(mark, "ambiguous field '%s' in %s, promoted from more than one embedded struct", name, structt)
+		} else {
+			if d.rejectUnknown {
+				d.error(&UnknownFieldError{Key: key, Type: structt, At: mark})
+			}
+			d.warn(mark, "unknown field '%s' in %s", key, structt)
+		}
+		if f != nil {
+			d.enterField(structt.FieldByIndex(f.index).Name, true)
+		}
+		value := d.event
+		if fu != nil && f != nil && len(f.index) == 1 {
+			d.unmarshalField(fu, f.index[0], subv)
+		} else {
+			d.parse(subv)
 		}
-		d.parse(subv)
+		if f != nil {
+			d.leaveField()
+			d.checkConstraints(structt, f, subv, value)
+		}
+	}
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		if d.isMergeKey() {
+			d.merge(structt, entry)
+			continue
+		}
+		entry(false)
 	}
 
 	d.nextEvent()
 }
 
+// ambiguousField returns the ambiguous field name of sf that key matches,
+// preferring an exact match.
+func ambiguousField(sf structFields, key string) (string, bool) {
+	if sf.ambiguous[key] {
+		return key, true
+	}
+	for name := range sf.ambiguous {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (d *Decoder) checkDuplicate(seen map[interface{}]bool, key interface{}, mark YAML_mark_t) {
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return
+	}
+
+	if d.keyEqual != nil && !seen[key] {
+		for k := range seen {
+			if d.keyEqual(k, key) {
+				if d.rejectDuplicates {
+					d.error(&DuplicateKeyError{Key: fmt.Sprint(key), At: mark})
+				}
+				d.warn(mark, "duplicate key '%v', equal to '%v'", key, k)
+				break
+			}
+		}
+	}
+
+	if !seen[key] {
+		seen[key] = true
+		return
+	}
+
+	if d.rejectDuplicates {
+		d.error(&DuplicateKeyError{Key: fmt.Sprint(key), At: mark})
+	}
+	d.warn(mark, "duplicate key '%v', keeping the last value", key)
+}
+
+func (d *Decoder) warn(mark YAML_mark_t, format string, args ...interface{}) {
+	d.parser.warnings = append(d.parser.warnings, Warning{
+		Message: fmt.Sprintf(format, args...),
+		At:      mark,
+	})
+}
+
 func (d *Decoder) scalar(v reflect.Value) {
 	pv := d.indirect(v)
 
 	v = pv
 
-	err := resolve(d.event, v)
+	if d.literalStrings && v.Kind() == reflect.String && v.Type() != numberType {
+		v.SetString(string(d.event.value))
+		d.nextEvent()
+		return
+	}
+
+	err := resolve(d.event, v, d.scalarSchema())
+	if oe, ok := err.(*overflowError); ok && oe.apply(v, d.overflow) {
+		err = nil
+	}
+	if err != nil && d.typeErrors {
+		d.typeError(v.Type())
+		d.nextEvent()
+		return
+	}
 	if err != nil {
 		d.error(err)
 	}
+	if d.noTimestamps && v.Kind() == reflect.Interface && !v.IsNil() && v.Elem().Type() == timeTimeType {
+		v.Set(reflect.ValueOf(string(d.event.value)))
+	}
+	if d.leadingZeros != LeadingZerosResolve && v.Kind() == reflect.Interface && !v.IsNil() {
+		v.Set(reflect.ValueOf(d.keepLeadingZeros(v.Elem().Interface())))
+	}
+	if d.preferLossless && v.Kind() == reflect.Interface && !v.IsNil() {
+		v.Set(reflect.ValueOf(d.losslessScalar(v.Elem().Interface())))
+	}
+	if d.positions && isEmptyInterface(v.Type()) {
+		var i interface{}
+		if !v.IsNil() {
+			i = v.Elem().Interface()
+		}
+		v.Set(reflect.ValueOf(d.positioned(i)))
+	}
+	d.checkScalar(v)
 
 	d.nextEvent()
 }
 
+// checkScalar warns about plain scalars that were resolved to something
+// their author may not have meant.
+func (d *Decoder) checkScalar(v reflect.Value) {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() || len(d.event.tag) > 0 || !d.event.implicit {
+		return
+	}
+
+	val := string(d.event.value)
+	switch v.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(val) {
+		case "y", "yes", "n", "no", "on", "off":
+			d.warn(d.event.start_mark, "'%s' is a YAML 1.1 boolean, YAML 1.2 reads it as a string", val)
+		}
+	case reflect.Float32, reflect.Float64:
+		if isTruncatedFloat(val, v.Float(), v.Type().Bits()) {
+			d.warn(d.event.start_mark, "'%s' loses precision as %s", val, v.Type())
+		}
+	}
+}
+
 func (d *Decoder) alias(rv reflect.Value) {
-	if val, ok := d.anchors[string(d.event.anchor)]; ok {
-		rv.Set(val)
+	d.countAlias()
+	if r, ok := d.recursive(); ok {
+		d.error(&RecursiveAliasError{Anchor: r.anchor, Type: rv.Type(), At: r.at})
+	}
+	val, ok := d.anchors[string(d.event.anchor)]
+	if !ok {
+		d.error(&UnknownAnchorError{Anchor: string(d.event.anchor), At: d.event.start_mark})
+	}
+	if !val.Type().AssignableTo(rv.Type()) && val.Kind() == reflect.Interface && !val.IsNil() {
+		val = val.Elem()
 	}
+	rv.Set(d.aliasValue(val))
 
 	d.nextEvent()
 }
@@ -407,44 +1120,124 @@ func (d *Decoder) sequenceInterface() []interface{} {
 			break
 		}
 
-		v = append(v, d.valueInterface())
+		item := d.valueInterface()
+		i := len(v)
+		if d.resolveLater(item, func(a interface{}) { v[i] = a }) {
+			item = nil
+		}
+		v = append(v, item)
 	}
 
 	d.nextEvent()
 	return v
 }
 
-// objectInterface is like object but returns map[string]interface{}.
-func (d *Decoder) mappingInterface() map[interface{}]interface{} {
+// objectInterface is like object but returns map[interface{}]interface{},
+// map[string]interface{} for GoYAMLv3 if it can, or a MapSlice with
+// OrderedMaps.
+func (d *Decoder) mappingInterface() interface{} {
+	if d.orderedMaps {
+		return d.mapSliceInterface()
+	}
+
 	m := make(map[interface{}]interface{})
+	// sm is m with string keys, once it is known that they all are.
+	var sm map[string]interface{}
 
 	d.nextEvent()
 
-	for {
-		if d.event.event_type == yaml_MAPPING_END_EVENT {
-			break
-		}
-
+	seen := make(map[interface{}]bool)
+	var keys mergedKeys
+	if d.mergeKeys {
+		keys = make(mergedKeys)
+	}
+	entry := func(merged bool) {
+		mark := d.event.start_mark
+		d.key = true
 		key := d.valueInterface()
+		d.key = false
+		d.checkKey(key)
+		if !keys.add(key) && merged {
+			d.valueInterface()
+			return
+		}
+		if !merged {
+			d.checkDuplicate(seen, key, mark)
+		}
 
 		// Read value.
-		m[key] = d.valueInterface()
+		value := d.valueInterface()
+		if d.resolveLater(value, func(a interface{}) {
+			m[key] = a
+			if sm != nil {
+				sm[key.(string)] = a
+			}
+		}) {
+			value = nil
+		}
+		m[key] = value
+	}
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		if d.isMergeKey() {
+			d.merge(reflect.TypeOf(m), entry)
+			continue
+		}
+		entry(false)
 	}
 
 	d.nextEvent()
+	if d.stringMaps {
+		if sm = stringMap(m); sm != nil {
+			return sm
+		}
+	}
 	return m
 }
 
+// checkKey fails on a recursive alias used as a mapping key, which could
+// never be hashed.
+func (d *Decoder) checkKey(key interface{}) {
+	if r, ok := key.(recursiveAlias); ok {
+		d.error(&RecursiveAliasError{Anchor: r.anchor, Type: reflect.TypeOf(&key).Elem(), At: r.at})
+	}
+}
+
+// valueInterface decodes the current node into an interface{}.  An alias to
+// a collection that is still being decoded returns a recursiveAlias, which
+// the caller has to pass to resolveLater.
 func (d *Decoder) valueInterface() interface{} {
+	anchor := string(d.event.anchor)
 	switch d.event.event_type {
 	case yaml_SEQUENCE_START_EVENT:
-		return d.sequenceInterface()
+		d.enter()
+		defer d.leave()
+		d.openAnchor(anchor)
+		v := d.sequenceInterface()
+		d.anchor(anchor, reflect.ValueOf(v))
+		return v
 	case yaml_MAPPING_START_EVENT:
-		return d.mappingInterface()
+		d.enter()
+		defer d.leave()
+		d.openAnchor(anchor)
+		v := d.mappingInterface()
+		d.anchor(anchor, reflect.ValueOf(&v).Elem())
+		return v
 	case yaml_SCALAR_EVENT:
-		return d.scalarInterface()
+		v := d.scalarInterface()
+		d.anchor(anchor, reflect.ValueOf(&v).Elem())
+		return v
 	case yaml_ALIAS_EVENT:
-		d.error(errors.New("alias interface??"))
+		d.countAlias()
+		if r, ok := d.recursive(); ok {
+			d.nextEvent()
+			return r
+		}
+		val, ok := d.anchors[string(d.event.anchor)]
+		if !ok {
+			d.error(&UnknownAnchorError{Anchor: string(d.event.anchor), At: d.event.start_mark})
+		}
+		d.nextEvent()
+		return d.aliasValue(val).Interface()
 	case yaml_DOCUMENT_END_EVENT:
 	}
 
@@ -458,7 +1251,23 @@ func (d *Decoder) valueInterface() interface{} {
 }
 
 func (d *Decoder) scalarInterface() interface{} {
-	v := resolveInterface(d.event)
+	v, err := resolveInterface(d.event, d.scalarSchema())
+	if err != nil {
+		d.error(err)
+	}
+	if _, ok := v.(time.Time); ok && d.noTimestamps {
+		v = string(d.event.value)
+	}
+	if d.leadingZeros != LeadingZerosResolve {
+		v = d.keepLeadingZeros(v)
+	}
+	if d.preferLossless {
+		v = d.losslessScalar(v)
+	}
+	if d.positions {
+		v = d.positioned(v)
+	}
+	d.checkScalar(reflect.ValueOf(v))
 
 	d.nextEvent()
 	return v
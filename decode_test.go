@@ -1,10 +1,14 @@
 package candiedyaml
 
 import (
+	"errors"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io"
 	"math"
 	"os"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -310,13 +314,33 @@ var _ = Describe("Decode", func() {
 		err := d.Decode(&v)
 		Ω(err).ShouldNot(HaveOccurred())
 		Ω(v).Should(Equal(map[string]time.Time{
-			"canonical": time.Date(2001, time.December, 15, 2, 59, 43, int(1*time.Millisecond), time.UTC),
-			"iso8601":   time.Date(2001, time.December, 14, 21, 59, 43, int(10*time.Millisecond), time.FixedZone("", -5*3600)),
-			"spaced":    time.Date(2001, time.December, 14, 21, 59, 43, int(10*time.Millisecond), time.FixedZone("", -5*3600)),
+			"canonical": time.Date(2001, time.December, 15, 2, 59, 43, int(100*time.Millisecond), time.UTC),
+			"iso8601":   time.Date(2001, time.December, 14, 21, 59, 43, int(100*time.Millisecond), time.FixedZone("", -5*3600)),
+			"spaced":    time.Date(2001, time.December, 14, 21, 59, 43, int(100*time.Millisecond), time.FixedZone("", -5*3600)),
 			"date":      time.Date(2002, time.December, 14, 0, 0, 0, 0, time.UTC),
 		}))
 	})
 
+	It("Decodes tagged timestamps into strings and time types", func() {
+		type Date time.Time
+		var v struct {
+			Text  string
+			Date  Date
+			Ptr   *Date
+			Other interface{}
+		}
+
+		err := Unmarshal([]byte("text: !!timestamp 2001-12-14\ndate: !!timestamp 2001-12-14\nptr: 2002-01-02\nother: !!timestamp 2003-02-03\n"), &v)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v.Text).Should(Equal("2001-12-14"))
+		Ω(time.Time(v.Date)).Should(Equal(time.Date(2001, time.December, 14, 0, 0, 0, 0, time.UTC)))
+		Ω(time.Time(*v.Ptr)).Should(Equal(time.Date(2002, time.January, 2, 0, 0, 0, 0, time.UTC)))
+		Ω(v.Other).Should(Equal(time.Date(2003, time.February, 3, 0, 0, 0, 0, time.UTC)))
+
+		var bad struct{ Date struct{ Day int } }
+		Ω(Unmarshal([]byte("date: !!timestamp 2001-12-14\n"), &bad)).ShouldNot(Succeed())
+	})
+
 	It("Respects tags", func() {
 		f, _ := os.Open("fixtures/specification/example2_23_non_date.yaml")
 		d := NewDecoder(f)
@@ -329,6 +353,56 @@ var _ = Describe("Decode", func() {
 		}))
 	})
 
+	It("Decodes standard tags into interfaces", func() {
+		var v interface{}
+		err := Unmarshal([]byte("[!!str 5, !!int '7', !!float 1, !!bool yes, !!null '', !!binary YWJj, '', !!timestamp 2001-12-14]"), &v)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(Equal([]interface{}{
+			"5", int64(7), float64(1), true, nil, []byte("abc"), "",
+			time.Date(2001, time.December, 14, 0, 0, 0, 0, time.UTC),
+		}))
+
+		Ω(Unmarshal([]byte("!!int seven"), &v)).Should(MatchError("Integer: seven"))
+	})
+
+	It("Leaves untagged scalars as strings with ExplicitTagsOnly", func() {
+		var v interface{}
+		d := NewDecoder(strings.NewReader("{port: 8080, mode: 0755, debug: no, empty: ~, retries: !!int 3, ratio: !!float 1}"))
+		d.ExplicitTagsOnly(true)
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(v).Should(Equal(map[interface{}]interface{}{
+			"port": "8080", "mode": "0755", "debug": "no", "empty": "~",
+			"retries": int64(3), "ratio": float64(1),
+		}))
+
+		var c struct {
+			Port  int
+			Debug bool
+		}
+		d = NewDecoder(strings.NewReader("port: 8080\ndebug: no\n"))
+		d.ExplicitTagsOnly(true)
+		Ω(d.Decode(&c)).Should(Succeed())
+		Ω(c.Port).Should(Equal(8080))
+		Ω(c.Debug).Should(BeFalse())
+	})
+
+	It("Keeps scalars decoded into strings as written with LiteralStrings", func() {
+		var labels map[string]string
+		d := NewDecoder(strings.NewReader("{replicas: 3, ratio: 1.50, enabled: yes, owner: ~, team: null, quoted: 'null', empty: }"))
+		d.LiteralStrings(true)
+		Ω(d.Decode(&labels)).Should(Succeed())
+		Ω(labels).Should(Equal(map[string]string{
+			"replicas": "3", "ratio": "1.50", "enabled": "yes", "owner": "~",
+			"team": "null", "quoted": "null", "empty": "",
+		}))
+
+		var list []string
+		d = NewDecoder(strings.NewReader("[0x1F, ~, true]"))
+		d.LiteralStrings(true)
+		Ω(d.Decode(&list)).Should(Succeed())
+		Ω(list).Should(Equal([]string{"0x1F", "~", "true"}))
+	})
+
 	It("Decodes binary/base64", func() {
 		f, _ := os.Open("fixtures/specification/example2_23_picture.yaml")
 		d := NewDecoder(f)
@@ -359,4 +433,310 @@ var _ = Describe("Decode", func() {
 			"rbi": []string{"Sammy Sosa", "Ken Griffey"},
 		}))
 	})
+	Context("Embedded structs", func() {
+		type Person struct {
+			Name string
+			Age  int
+		}
+		type Company struct {
+			Name string
+			Size int
+		}
+		type Employee struct {
+			Person
+		}
+		type Label struct {
+			Text string `yaml:"name"`
+		}
+
+		It("prefers the shallowest field", func() {
+			var v struct {
+				Employee
+				Company
+			}
+			Ω(Unmarshal([]byte("name: n\nage: 1\nsize: 2\n"), &v)).Should(Succeed())
+			Ω(v.Company.Name).Should(Equal("n"))
+			Ω(v.Employee.Name).Should(BeEmpty())
+			Ω(v.Age).Should(Equal(1))
+		})
+
+		It("prefers a tagged field at the same depth", func() {
+			var v struct {
+				Person
+				Label
+			}
+			Ω(Unmarshal([]byte("name: n\n"), &v)).Should(Succeed())
+			Ω(v.Label.Text).Should(Equal("n"))
+			Ω(v.Person.Name).Should(BeEmpty())
+		})
+
+		It("skips ambiguous fields with a warning", func() {
+			var v struct {
+				Person
+				Company
+			}
+			d := NewDecoder(strings.NewReader("Name: x\nage: 1\n"))
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v.Person.Name).Should(BeEmpty())
+			Ω(v.Company.Name).Should(BeEmpty())
+			Ω(v.Age).Should(Equal(1))
+			Ω(d.Warnings()).Should(HaveLen(1))
+			Ω(d.Warnings()[0].Message).Should(ContainSubstring("ambiguous field 'Name'"))
+		})
+	})
+
+	Context("Named types", func() {
+		type port uint16
+		type level string
+		type blob []byte
+
+		It("decodes into named scalars, including as map keys", func() {
+			var v struct {
+				Ports  map[port]level
+				Levels []level
+				Addr   uintptr
+				Data   blob
+			}
+			Ω(Unmarshal([]byte("ports: {80: info, 0x1bb: debug}\nlevels: [warn]\naddr: 0xff\ndata: !!binary aGk=\n"), &v)).Should(Succeed())
+			Ω(v.Ports).Should(Equal(map[port]level{80: "info", 443: "debug"}))
+			Ω(v.Levels).Should(Equal([]level{"warn"}))
+			Ω(v.Addr).Should(Equal(uintptr(255)))
+			Ω(v.Data).Should(Equal(blob("hi")))
+		})
+
+		It("decodes into named interfaces", func() {
+			type any interface{}
+			var v map[string]any
+			Ω(Unmarshal([]byte("a: 1\nb: [x]\n"), &v)).Should(Succeed())
+			Ω(v).Should(Equal(map[string]any{"a": int64(1), "b": []interface{}{"x"}}))
+		})
+	})
+
+	Context("Merging", func() {
+		It("replaces existing map values by default", func() {
+			v := map[string]map[string]int{"a": {"x": 1}}
+
+			err := Unmarshal([]byte("a:\n  y: 2\n"), &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[string]map[string]int{"a": {"y": 2}}))
+		})
+
+		It("merges into existing map values", func() {
+			v := map[string]map[string]int{"a": {"x": 1}, "b": {"z": 3}}
+
+			d := NewDecoder(strings.NewReader("a:\n  y: 2\nc:\n  w: 4\n"))
+			d.MergeMaps(true)
+			err := d.Decode(&v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[string]map[string]int{
+				"a": {"x": 1, "y": 2},
+				"b": {"z": 3},
+				"c": {"w": 4},
+			}))
+		})
+
+		It("merges into maps held by interfaces", func() {
+			v := map[string]interface{}{
+				"a": map[interface{}]interface{}{"x": int64(1)},
+			}
+
+			d := NewDecoder(strings.NewReader("a:\n  z: 2\n"))
+			d.MergeMaps(true)
+			err := d.Decode(&v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[string]interface{}{
+				"a": map[interface{}]interface{}{"x": int64(1), "z": int64(2)},
+			}))
+		})
+
+		It("overwrites slices by default", func() {
+			v := []string{"a", "b", "c"}
+
+			err := Unmarshal([]byte("- d\n"), &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal([]string{"d"}))
+		})
+
+		It("appends to existing slices", func() {
+			type config struct {
+				Names []string
+				Any   interface{}
+			}
+			v := config{Names: []string{"a", "b"}, Any: []interface{}{"x"}}
+
+			d := NewDecoder(strings.NewReader("Names: [c]\nAny: [z]\n"))
+			d.AppendSlices(true)
+			err := d.Decode(&v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(config{
+				Names: []string{"a", "b", "c"},
+				Any:   []interface{}{"x", "z"},
+			}))
+		})
+
+		It("keeps existing elements when appending an empty sequence", func() {
+			v := []string{"a"}
+
+			d := NewDecoder(strings.NewReader("[]\n"))
+			d.AppendSlices(true)
+			err := d.Decode(&v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal([]string{"a"}))
+		})
+	})
+	Context("Multiple documents", func() {
+		It("decodes one document per call", func() {
+			d := NewDecoder(strings.NewReader("a: 1\n---\nb: 2\n"))
+
+			var v map[string]int
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal(map[string]int{"a": 1}))
+
+			v = nil
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal(map[string]int{"b": 2}))
+
+			Ω(d.Decode(&v)).Should(Equal(io.EOF))
+		})
+
+		It("decodes all documents into a slice", func() {
+			var v []map[string]int
+			Ω(UnmarshalAll([]byte("a: 1\n---\nb: 2\n...\n---\n"), &v)).Should(Succeed())
+			Ω(v).Should(Equal([]map[string]int{{"a": 1}, {"b": 2}, nil}))
+
+			Ω(UnmarshalAll([]byte(""), &v)).Should(Succeed())
+			Ω(v).Should(BeEmpty())
+
+			Ω(UnmarshalAll([]byte("a"), v)).Should(MatchError("DecodeAll: not a pointer to a slice: []map[string]int"))
+		})
+
+		It("stops at the first bad document", func() {
+			var v []string
+			Ω(UnmarshalAll([]byte("a\n---\n[b\n"), &v)).ShouldNot(Succeed())
+		})
+
+		It("reports how much input has been decoded", func() {
+			data := "a: é\n# c\n---\nb: 2\n... # end\n--- [3]\n"
+			d := NewDecoder(strings.NewReader(data))
+			Ω(d.InputOffset()).Should(BeZero())
+
+			var v interface{}
+			var offsets []int64
+			for d.Decode(&v) == nil {
+				offsets = append(offsets, d.InputOffset())
+			}
+			Ω(offsets).Should(Equal([]int64{
+				int64(strings.Index(data, "---")),
+				int64(strings.Index(data, " # end")),
+				int64(len(data)),
+			}))
+		})
+
+		It("counts input bytes rather than characters", func() {
+			d := NewDecoder(strings.NewReader("\xef\xbb\xbfa: é\r\n"))
+			var v interface{}
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(d.InputOffset()).Should(Equal(int64(10)))
+
+			d = NewDecoder(strings.NewReader("\xff\xfea\x00\r\x00\n\x00b\x00"))
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal("a b"))
+			Ω(d.InputOffset()).Should(Equal(int64(10)))
+
+			d = NewDecoder(strings.NewReader("\xfe\xff\x00a\x00\n\xd8\x3d\xde\x00"))
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal("a \U0001F600"))
+			Ω(d.InputOffset()).Should(Equal(int64(10)))
+		})
+	})
+	Context("Anchors", func() {
+		It("resolves aliases", func() {
+			var v map[string][]int
+			Ω(Unmarshal([]byte("a: &x [1, 2]\nb: *x\n"), &v)).Should(Succeed())
+			Ω(v).Should(Equal(map[string][]int{"a": {1, 2}, "b": {1, 2}}))
+		})
+
+		It("rejects undefined aliases", func() {
+			var v map[string][]int
+			err := Unmarshal([]byte("a: [1]\nb: *x\n"), &v)
+			Ω(errors.Is(err, ErrUnknownAnchor)).Should(BeTrue())
+			Ω(err).Should(MatchError("yaml: unknown anchor 'x' referenced at line 2, column 4"))
+
+			var i interface{}
+			Ω(errors.Is(Unmarshal([]byte("[*x]"), &i), ErrUnknownAnchor)).Should(BeTrue())
+
+			var n Node
+			Ω(errors.Is(Unmarshal([]byte("[*x]"), &n), ErrUnknownAnchor)).Should(BeTrue())
+		})
+
+		It("can keep anchors within their document", func() {
+			data := "a: &x 1\n---\nb: *x\n"
+
+			var v []map[string]int
+			Ω(UnmarshalAll([]byte(data), &v)).Should(Succeed())
+			Ω(v[1]).Should(Equal(map[string]int{"b": 1}))
+
+			d := NewDecoder(strings.NewReader(data))
+			d.AllowCrossDocumentAnchors(false)
+			err := d.DecodeAll(&v)
+			Ω(errors.Is(err, ErrUnknownAnchor)).Should(BeTrue())
+		})
+
+		It("resolves aliases into interface{}", func() {
+			var v interface{}
+			Ω(Unmarshal([]byte("a: &x [1, &y 2]\nb: *x\nc: *y\n"), &v)).Should(Succeed())
+			Ω(v).Should(Equal(map[interface{}]interface{}{
+				"a": []interface{}{int64(1), int64(2)},
+				"b": []interface{}{int64(1), int64(2)},
+				"c": int64(2),
+			}))
+		})
+
+		It("builds cycles for recursive aliases into interface{}", func() {
+			var v interface{}
+			Ω(Unmarshal([]byte("&a [1, [*a]]"), &v)).Should(Succeed())
+			s := v.([]interface{})
+			Ω(s[0]).Should(Equal(int64(1)))
+			Ω(&s[1].([]interface{})[0].([]interface{})[0]).Should(BeIdenticalTo(&s[0]))
+
+			var m interface{}
+			Ω(Unmarshal([]byte("&a {name: x, self: *a}"), &m)).Should(Succeed())
+			self := m.(map[interface{}]interface{})["self"]
+			Ω(reflect.ValueOf(self).Pointer()).Should(Equal(reflect.ValueOf(m).Pointer()))
+
+			var o MapSlice
+			Ω(Unmarshal([]byte("&a {self: *a, name: x}"), &o)).Should(Succeed())
+			Ω(o[0].Value.(MapSlice)[1]).Should(Equal(MapItem{"name", "x"}))
+		})
+
+		It("builds cycles in slices and maps of interface{}", func() {
+			var s []interface{}
+			Ω(Unmarshal([]byte("&a [1, *a]"), &s)).Should(Succeed())
+			Ω(&s[1].([]interface{})[0]).Should(BeIdenticalTo(&s[0]))
+
+			var m map[string]interface{}
+			Ω(Unmarshal([]byte("&a {self: *a}"), &m)).Should(Succeed())
+			Ω(m["self"].(map[string]interface{})).Should(HaveKey("self"))
+		})
+
+		It("rejects recursive aliases other types cannot hold", func() {
+			type node struct {
+				Next *node
+			}
+			var n node
+			err := Unmarshal([]byte("&a {next: *a}"), &n)
+			Ω(errors.Is(err, ErrRecursiveAlias)).Should(BeTrue())
+			Ω(err).Should(MatchError("yaml: cannot represent recursive alias 'a' in type *candiedyaml.node at line 1, column 11"))
+
+			var v interface{}
+			err = Unmarshal([]byte("&a {*a: 1}"), &v)
+			Ω(errors.Is(err, ErrRecursiveAlias)).Should(BeTrue())
+		})
+
+		It("links recursive aliases in nodes", func() {
+			var n Node
+			Ω(Unmarshal([]byte("&a [*a]"), &n)).Should(Succeed())
+			Ω(n.Content[0].Content[0].Alias).Should(BeIdenticalTo(n.Content[0]))
+		})
+	})
 })
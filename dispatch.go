@@ -0,0 +1,114 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"io"
+)
+
+// DispatchError is returned by DecodeDispatch for a document whose root is
+// not a mapping with a scalar under the discriminator key, or whose
+// discriminator names no registered type.  At is the position of the root,
+// or of the discriminator when it names no registered type.
+type DispatchError struct {
+	Key   string
+	Value string
+	At    YAML_mark_t
+}
+
+func (e *DispatchError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("yaml: no scalar '%s' in the root mapping of the document at line %d, column %d", e.Key, e.At.Line(), e.At.Column())
+	}
+	return fmt.Sprintf("yaml: no type registered for %s '%s' at line %d, column %d", e.Key, e.Value, e.At.Line(), e.At.Column())
+}
+
+// DecodeDispatch decodes every document of the stream read from r into a new
+// value of the type registered for it, as Kubernetes manifests are: the
+// value under key in the document's root mapping, such as its kind, selects
+// the function in types that returns a pointer to decode into.
+//
+//	objs, err := candiedyaml.DecodeDispatch(r, "kind", map[string]func() interface{}{
+//		"Deployment": func() interface{} { return &Deployment{} },
+//		"Service":    func() interface{} { return &Service{} },
+//	})
+//
+// It returns the values decoded up to the first error.
+func DecodeDispatch(r io.Reader, key string, types map[string]func() interface{}) ([]interface{}, error) {
+	d := NewDecoder(r)
+	var vs []interface{}
+	for {
+		v, err := d.DecodeDispatch(key, types)
+		if err == io.EOF {
+			return vs, nil
+		}
+		if err != nil {
+			return vs, err
+		}
+		vs = append(vs, v)
+	}
+}
+
+// DecodeDispatch reads the next document of the stream and decodes it into
+// the value types returns for the value under key in its root mapping, as
+// the DecodeDispatch function does.  It returns io.EOF once there are no
+// more documents.
+func (d *Decoder) DecodeDispatch(key string, types map[string]func() interface{}) (v interface{}, err error) {
+	defer recoverError(&err)
+
+	if err := d.startDocument(); err != nil {
+		return nil, err
+	}
+
+	// Read the whole document, to be decoded again once its type is known.
+	events := []yaml_event_t{d.event}
+	for d.event.event_type != yaml_DOCUMENT_END_EVENT {
+		d.nextEvent()
+		events = append(events, d.event)
+	}
+	d.nextEvent()
+	d.replay = append(append(events[1:], d.event), d.replay...)
+	d.event = events[0]
+
+	value, at := discriminator(events, key)
+	newValue, ok := types[value]
+	if value == "" || !ok {
+		for d.event.event_type != yaml_DOCUMENT_END_EVENT {
+			d.nextEvent()
+		}
+		d.nextEvent()
+		return nil, &DispatchError{Key: key, Value: value, At: at}
+	}
+
+	v = newValue()
+	return v, d.Decode(v)
+}
+
+// discriminator returns the scalar under key in the root mapping of the
+// document made up of events, and where it is.
+func discriminator(events []yaml_event_t, key string) (string, YAML_mark_t) {
+	root := events[1]
+	if root.event_type != yaml_MAPPING_START_EVENT {
+		return "", root.start_mark
+	}
+
+	level := 0
+	entries := 0
+	for i := 1; i < len(events)-1; i++ {
+		e := &events[i]
+		if level == 1 {
+			if entries%2 == 0 && e.event_type == yaml_SCALAR_EVENT && string(e.value) == key {
+				if v := &events[i+1]; v.event_type == yaml_SCALAR_EVENT {
+					return string(v.value), v.start_mark
+				}
+			}
+			entries++
+		}
+		switch e.event_type {
+		case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+			level++
+		case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+			level--
+		}
+	}
+	return "", root.start_mark
+}
@@ -0,0 +1,68 @@
+package candiedyaml
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"strings"
+)
+
+var _ = Describe("DecodeDispatch", func() {
+	type deployment struct {
+		Kind     string
+		Replicas int
+	}
+	type service struct {
+		Kind  string
+		Ports []int
+	}
+	types := map[string]func() interface{}{
+		"Deployment": func() interface{} { return &deployment{} },
+		"Service":    func() interface{} { return &service{} },
+	}
+
+	It("decodes each document into the type registered for its kind", func() {
+		vs, err := DecodeDispatch(strings.NewReader(`
+replicas: 3
+kind: Deployment
+---
+kind: Service
+ports: [80, 443]
+`), "kind", types)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(vs).Should(Equal([]interface{}{
+			&deployment{Kind: "Deployment", Replicas: 3},
+			&service{Kind: "Service", Ports: []int{80, 443}},
+		}))
+	})
+
+	It("only looks at the root mapping", func() {
+		_, err := DecodeDispatch(strings.NewReader("spec: {kind: Service}\n"), "kind", types)
+		Ω(err).Should(MatchError("yaml: no scalar 'kind' in the root mapping of the document at line 1, column 1"))
+	})
+
+	It("reports unknown kinds and carries on with the next document", func() {
+		d := NewDecoder(strings.NewReader("kind: Secret\n---\nkind: Deployment\nreplicas: 1\n"))
+
+		_, err := d.DecodeDispatch("kind", types)
+		var de *DispatchError
+		Ω(errors.As(err, &de)).Should(BeTrue())
+		Ω(de.Value).Should(Equal("Secret"))
+		Ω([]int{de.At.Line(), de.At.Column()}).Should(Equal([]int{1, 7}))
+		Ω(err).Should(MatchError("yaml: no type registered for kind 'Secret' at line 1, column 7"))
+
+		v, err := d.DecodeDispatch("kind", types)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(Equal(&deployment{Kind: "Deployment", Replicas: 1}))
+
+		_, err = d.DecodeDispatch("kind", types)
+		Ω(err).Should(Equal(io.EOF))
+	})
+
+	It("returns the values decoded before an error", func() {
+		vs, err := DecodeDispatch(strings.NewReader("kind: Service\n---\nkind: Deployment\nreplicas: many\n"), "kind", types)
+		Ω(err).Should(MatchError("Integer: many"))
+		Ω(vs).Should(Equal([]interface{}{&service{Kind: "Service"}}))
+	})
+})
@@ -0,0 +1,228 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"unicode/utf16"
+)
+
+// SplitDocuments reads a stream of YAML documents from r and returns the
+// text of each one.  The stream is parsed, so '---' inside a block scalar or
+// a quoted string does not split it.  Each piece keeps its directives, its
+// document markers and its comments; comments before a '---' stay with the
+// previous document.  Appending the pieces gives back the input, with a
+// byte order mark removed and UTF-16 converted to UTF-8.
+func SplitDocuments(r io.Reader) (docs [][]byte, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := toUTF8(data)
+
+	d := NewDecoder(bytes.NewReader(text))
+	defer func() {
+		if err != nil {
+			docs = nil
+		}
+	}()
+	defer recoverError(&err)
+
+	start, end := 0, -1
+	for d.nextEvent(); d.event.event_type != yaml_STREAM_END_EVENT; d.nextEvent() {
+		switch d.event.event_type {
+		case yaml_DOCUMENT_START_EVENT:
+			if end >= 0 {
+				cut := d.event.start_mark.offset
+				if end < cut {
+					cut = end
+				}
+				docs = append(docs, text[start:cut])
+				start = cut
+			}
+		case yaml_DOCUMENT_END_EVENT:
+			end = d.event.end_mark.offset
+			if !d.event.implicit {
+				// The rest of the line after '...' can only be a comment.
+				if i := bytes.IndexByte(text[end:], '\n'); i >= 0 {
+					end += i + 1
+				} else {
+					end = len(text)
+				}
+			}
+		}
+	}
+
+	if end >= 0 {
+		docs = append(docs, text[start:])
+	}
+	return docs, nil
+}
+
+// toUTF8 removes the byte order mark from data, converting it from UTF-16 if
+// the mark says so.
+func toUTF8(data []byte) []byte {
+	var order func(b []byte) uint16
+	switch {
+	case bytes.HasPrefix(data, []byte(BOM_UTF8)):
+		return data[len(BOM_UTF8):]
+	case bytes.HasPrefix(data, []byte(BOM_UTF16LE)):
+		order = func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 }
+	case bytes.HasPrefix(data, []byte(BOM_UTF16BE)):
+		order = func(b []byte) uint16 { return uint16(b[1]) | uint16(b[0])<<8 }
+	default:
+		return data
+	}
+
+	units := make([]uint16, 0, len(data)/2)
+	for i := 2; i+1 < len(data); i += 2 {
+		units = append(units, order(data[i:]))
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// JoinDocuments joins documents, each holding a single YAML document such as
+// those returned by SplitDocuments, into one stream.  A '---' marker is
+// written before every document after the first that does not start with
+// one, and a '...' marker before directives that do not follow one.
+func JoinDocuments(docs ...[]byte) []byte {
+	var buf bytes.Buffer
+	ended := true
+	for i, doc := range docs {
+		ended = joinDocument(&buf, doc, i == 0, ended)
+	}
+	return buf.Bytes()
+}
+
+// joinDocument appends doc to buf with the markers JoinDocuments writes
+// before it, given whether it is the first document and whether the one
+// before it ended with '...'.  It returns whether doc does.
+func joinDocument(buf *bytes.Buffer, doc []byte, first, ended bool) bool {
+	switch line := firstLine(doc); {
+	case bytes.HasPrefix(line, []byte("%")):
+		if !ended {
+			buf.WriteString("...\n")
+		}
+	case !first && !isMarker(line, "---"):
+		buf.WriteString("---\n")
+	}
+
+	buf.Write(doc)
+	if len(doc) > 0 && doc[len(doc)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return isMarker(lastLine(doc), "...")
+}
+
+// firstLine returns the first line of doc that is neither blank nor a
+// comment.
+func firstLine(doc []byte) []byte {
+	lines := bytes.Split(doc, []byte("\n"))
+	for _, line := range lines {
+		if isContent(line) {
+			return line
+		}
+	}
+	return nil
+}
+
+// lastLine returns the last line of doc that is neither blank nor a comment.
+func lastLine(doc []byte) []byte {
+	lines := bytes.Split(doc, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		if isContent(lines[i]) {
+			return lines[i]
+		}
+	}
+	return nil
+}
+
+func isContent(line []byte) bool {
+	line = bytes.TrimLeft(line, " \t\r")
+	return len(line) > 0 && line[0] != '#'
+}
+
+// isMarker reports whether line starts with the document marker m.
+func isMarker(line []byte, m string) bool {
+	line = bytes.TrimRight(line, "\r")
+	return bytes.HasPrefix(line, []byte(m)) &&
+		(len(line) == len(m) || line[len(m)] == ' ' || line[len(m)] == '\t')
+}
+
+// SkipDocument reads past the next document of the stream without decoding
+// it and returns its source exactly as it was read, from the end of the
+// previous document, so comments and a '---' marker before it are
+// included.  It returns io.EOF once there are no more documents.  Filters
+// can look at documents cheaply, e.g. with a Node or a small struct, and
+// pass on the ones they do not decode untouched.
+func (d *Decoder) SkipDocument() (raw []byte, err error) {
+	defer recoverError(&err)
+
+	if err := d.startDocument(); err != nil {
+		return nil, err
+	}
+	start := d.offset
+	for d.event.event_type != yaml_DOCUMENT_END_EVENT {
+		d.nextEvent()
+	}
+	raw = d.raw.slice(start, d.event.end_mark.offset)
+	d.nextEvent()
+	return raw, nil
+}
+
+// rawReader keeps the input read since the start of the current document
+// for SkipDocument.  The Decoder discards the input of earlier documents as
+// it goes, and while it decodes a document, which is never sliced, the
+// input the parser has scanned as well.
+type rawReader struct {
+	r      io.Reader
+	parser *yaml_parser_t
+
+	// buf holds the input from offset start on.
+	buf   []byte
+	start int
+
+	// decoding is set while a document is decoded rather than skipped, and
+	// streaming if no document will be sliced.
+	decoding, streaming bool
+}
+
+func (r *rawReader) Read(p []byte) (int, error) {
+	if r.decoding || r.streaming {
+		r.discard(r.scanned())
+	}
+	n, err := r.r.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	return n, err
+}
+
+// scanned returns the offset of the first byte of input the parser may
+// still produce an event from: that of the first token it has yet to parse,
+// or else of the next character to scan.
+func (r *rawReader) scanned() int {
+	offset := r.parser.mark.offset
+	if r.parser.tokens_head < len(r.parser.tokens) {
+		if t := r.parser.tokens[r.parser.tokens_head].start_mark.offset; t < offset {
+			offset = t
+		}
+	}
+	return offset
+}
+
+// discard drops the input before offset.
+func (r *rawReader) discard(offset int) {
+	if offset > r.start {
+		n := copy(r.buf, r.buf[offset-r.start:])
+		r.buf = r.buf[:n]
+		r.start = offset
+	}
+}
+
+// slice returns a copy of the input from offset start to end, or from the
+// earliest input kept if decoding a document discarded some of it.
+func (r *rawReader) slice(start, end int) []byte {
+	if start < r.start {
+		start = r.start
+	}
+	return append([]byte(nil), r.buf[start-r.start:end-r.start]...)
+}
@@ -0,0 +1,160 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"strings"
+)
+
+// keptReader calls read before each read, to look at what the Decoder
+// reading from it keeps.
+type keptReader struct {
+	io.Reader
+	read func()
+}
+
+func (r keptReader) Read(p []byte) (int, error) {
+	r.read()
+	return r.Reader.Read(p)
+}
+
+var _ = Describe("Documents", func() {
+	split := func(data string) []string {
+		docs, err := SplitDocuments(strings.NewReader(data))
+		Ω(err).ShouldNot(HaveOccurred())
+		var s []string
+		for _, doc := range docs {
+			s = append(s, string(doc))
+		}
+		return s
+	}
+
+	Context("Splitting", func() {
+		It("splits on document markers", func() {
+			Ω(split("a: 1\n---\nb: 2\n--- c\n")).Should(Equal(
+				[]string{"a: 1\n", "---\nb: 2\n", "--- c\n"}))
+		})
+
+		It("ignores markers that are not document boundaries", func() {
+			data := "a: |\n  ---\n  x\nb: \"\n  ---\"\n# ---\n---\nc\n"
+			Ω(split(data)).Should(Equal([]string{
+				"a: |\n  ---\n  x\nb: \"\n  ---\"\n# ---\n",
+				"---\nc\n",
+			}))
+		})
+
+		It("keeps end markers, directives and comments", func() {
+			data := "# head\na\n... # end\n%YAML 1.1\n---\nb\n...\nc\n# tail\n"
+			Ω(split(data)).Should(Equal([]string{
+				"# head\na\n... # end\n",
+				"%YAML 1.1\n---\nb\n...\n",
+				"c\n# tail\n",
+			}))
+		})
+
+		It("keeps empty documents", func() {
+			Ω(split("---\n---\n")).Should(Equal([]string{"---\n", "---\n"}))
+			Ω(split("# nothing\n")).Should(BeEmpty())
+		})
+
+		It("reads UTF-16", func() {
+			Ω(split("\xff\xfea\x00\n\x00-\x00-\x00-\x00\n\x00b\x00\n\x00")).Should(Equal(
+				[]string{"a\n", "---\nb\n"}))
+		})
+
+		It("returns parse errors", func() {
+			_, err := SplitDocuments(strings.NewReader("a\n---\n[b\n"))
+			Ω(errors.Is(err, ErrUnexpectedEOF)).Should(BeTrue())
+		})
+	})
+
+	Context("Joining", func() {
+		join := func(docs ...string) string {
+			var b [][]byte
+			for _, doc := range docs {
+				b = append(b, []byte(doc))
+			}
+			return string(JoinDocuments(b...))
+		}
+
+		It("separates documents", func() {
+			Ω(join("a: 1", "b: 2\n", "# c\n--- c\n")).Should(Equal("a: 1\n---\nb: 2\n# c\n--- c\n"))
+		})
+
+		It("ends documents before directives", func() {
+			Ω(join("a\n", "%YAML 1.1\n---\nb\n")).Should(Equal("a\n...\n%YAML 1.1\n---\nb\n"))
+			Ω(join("a\n...\n", "%YAML 1.1\n---\nb\n")).Should(Equal("a\n...\n%YAML 1.1\n---\nb\n"))
+		})
+
+		It("reverses SplitDocuments", func() {
+			data := "a: 1\n---\nb: |\n  ---\n...\n%YAML 1.1\n--- c\n"
+			docs, err := SplitDocuments(strings.NewReader(data))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(docs).Should(HaveLen(3))
+			Ω(JoinDocuments(docs...)).Should(Equal([]byte(data)))
+
+			var v []interface{}
+			Ω(NewDecoder(bytes.NewReader(JoinDocuments(docs[2], docs[0]))).DecodeAll(&v)).Should(Succeed())
+			Ω(v).Should(Equal([]interface{}{"c", map[interface{}]interface{}{"a": int64(1)}}))
+		})
+	})
+
+	Context("SkipDocument", func() {
+		It("returns the source of the documents it skips", func() {
+			data := "# first\na: 1\n---\nb: [2,   3] # odd spacing\n...\n--- c\n"
+			d := NewDecoder(strings.NewReader(data))
+
+			raw, err := d.SkipDocument()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(raw)).Should(Equal("# first\na: 1\n"))
+
+			var v interface{}
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal(map[interface{}]interface{}{"b": []interface{}{int64(2), int64(3)}}))
+
+			raw, err = d.SkipDocument()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(raw)).Should(Equal("\n--- c\n"))
+
+			_, err = d.SkipDocument()
+			Ω(err).Should(Equal(io.EOF))
+		})
+
+		It("does not keep the input of the documents it decodes", func() {
+			data := strings.Repeat("key: value\n", 10000) + "---\nskipped: true\n"
+			var d *Decoder
+			kept := 0
+			d = NewDecoder(keptReader{strings.NewReader(data), func() {
+				if len(d.raw.buf) > kept {
+					kept = len(d.raw.buf)
+				}
+			}})
+
+			var v interface{}
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(kept).Should(BeNumerically("<", 4096))
+
+			raw, err := d.SkipDocument()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(raw)).Should(Equal("---\nskipped: true\n"))
+		})
+
+		It("gives back the input when every document is skipped", func() {
+			data := "a: 1\n---\nb: |\n  ---\n...\n%YAML 1.1\n--- c\n"
+			d := NewDecoder(strings.NewReader(data))
+			var out []byte
+			for {
+				raw, err := d.SkipDocument()
+				if err == io.EOF {
+					break
+				}
+				Ω(err).ShouldNot(HaveOccurred())
+				out = append(out, raw...)
+			}
+			Ω(string(out)).Should(Equal(data))
+		})
+	})
+})
@@ -0,0 +1,217 @@
+package candiedyaml
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A DynamicValue is a dynamically typed value in the form of
+// google.protobuf.Value, as structpb.Value is, whose AsInterface method
+// returns it as nil, a bool, a float64, a string, a []interface{} or a
+// map[string]interface{}.  structpb.Struct and structpb.ListValue have
+// AsMap and AsSlice methods instead; wrap them with structpb.NewStructValue
+// and structpb.NewListValue.
+type DynamicValue interface {
+	AsInterface() interface{}
+}
+
+// maxExactFloat is the largest magnitude up to which float64 holds every
+// integer.
+const maxExactFloat = 1 << 53
+
+// ToDynamic converts v, typically a value decoded into an interface{}, into
+// the form structpb.NewValue takes and google.protobuf.Value can hold:
+// mappings become map[string]interface{}, sequences []interface{}, and all
+// numbers float64.  Timestamps become RFC 3339 strings and binary values
+// base64 strings.  Keys that are not strings are written as Marshal writes
+// them, so true and 1 become "true" and "1".  Mappings lose the order of
+// their keys.  It returns an error for integers a float64 cannot hold
+// exactly and for keys that are collections.
+//
+//	var v interface{}
+//	err := candiedyaml.Unmarshal(data, &v)
+//	...
+//	tree, err := candiedyaml.ToDynamic(v)
+//	...
+//	pb, err := structpb.NewValue(tree)
+func ToDynamic(v interface{}) (interface{}, error) {
+	return toDynamic(reflect.ValueOf(v), "")
+}
+
+func toDynamic(v reflect.Value, path string) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339Nano), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(x), nil
+	case Number:
+		if i, err := x.Int64(); err == nil {
+			return toDynamic(reflect.ValueOf(i), path)
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("yaml: ToDynamic: %s: %v", dynamicPath(path), err)
+		}
+		return f, nil
+	case MapSlice:
+		m := make(map[string]interface{}, len(x))
+		for _, item := range x {
+			if err := setDynamic(m, reflect.ValueOf(item.Key), reflect.ValueOf(item.Value), path); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toDynamic(v.Elem(), path)
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		if i > maxExactFloat || i < -maxExactFloat {
+			return nil, fmt.Errorf("yaml: ToDynamic: %s: %d cannot be held exactly by a float64", dynamicPath(path), i)
+		}
+		return float64(i), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > maxExactFloat {
+			return nil, fmt.Errorf("yaml: ToDynamic: %s: %d cannot be held exactly by a float64", dynamicPath(path), u)
+		}
+		return float64(u), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			e, err := toDynamic(v.Index(i), path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+			s[i] = e
+		}
+		return s, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := setDynamic(m, iter.Key(), iter.Value(), path); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("yaml: ToDynamic: %s: cannot convert %s", dynamicPath(path), v.Type())
+}
+
+// setDynamic sets the entry of m for the mapping key k to the value v.
+func setDynamic(m map[string]interface{}, k, v reflect.Value, path string) error {
+	for k.IsValid() && k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+	var key string
+	switch {
+	case !k.IsValid() || k.Kind() == reflect.Interface:
+		key = "null"
+	case k.Kind() == reflect.String:
+		key = k.String()
+	case k.Kind() == reflect.Map || k.Kind() == reflect.Slice || k.Kind() == reflect.Array:
+		return fmt.Errorf("yaml: ToDynamic: %s: cannot convert the %s key of a mapping", dynamicPath(path), k.Type())
+	default:
+		out, err := Marshal(k.Interface(), EncodeOption(func(e *Encoder) { e.SetSchema(YAML11Schema) }))
+		if err != nil {
+			return fmt.Errorf("yaml: ToDynamic: %s: %v", dynamicPath(path), err)
+		}
+		key = string(trimNewline(out))
+	}
+	e, err := toDynamic(v, path+"."+key)
+	if err != nil {
+		return err
+	}
+	m[key] = e
+	return nil
+}
+
+func trimNewline(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		return b[:n-1]
+	}
+	return b
+}
+
+func dynamicPath(path string) string {
+	if path == "" {
+		return "the value"
+	}
+	return "at " + path
+}
+
+// FromDynamic converts v, in the form DynamicValue.AsInterface returns, into
+// the value to encode for it: floats holding integers a float64 holds
+// exactly become int64, so that they are written as 3 rather than 3.0, and
+// mappings become MapSlice values in key order.
+func FromDynamic(v interface{}) interface{} {
+	switch x := v.(type) {
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) <= maxExactFloat {
+			return int64(x)
+		}
+		return x
+	case []interface{}:
+		s := make([]interface{}, len(x))
+		for i, e := range x {
+			s[i] = FromDynamic(e)
+		}
+		return s
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		m := make(MapSlice, len(keys))
+		for i, k := range keys {
+			m[i] = MapItem{Key: k, Value: FromDynamic(x[k])}
+		}
+		return m
+	}
+	return v
+}
+
+// MarshalDynamic returns the YAML encoding of the value v holds, converted
+// by FromDynamic.
+func MarshalDynamic(v DynamicValue, opts ...EncodeOption) ([]byte, error) {
+	return Marshal(FromDynamic(v.AsInterface()), opts...)
+}
+
+// UnmarshalDynamic decodes the document in data into an interface{}, with
+// mappings as MapSlice values so that any key can be reported, and converts
+// it with ToDynamic, ready for structpb.NewValue.
+func UnmarshalDynamic(data []byte, opts ...DecodeOption) (interface{}, error) {
+	var v interface{}
+	opts = append([]DecodeOption{func(d *Decoder) { d.OrderedMaps(true) }}, opts...)
+	if err := Unmarshal(data, &v, opts...); err != nil {
+		return nil, err
+	}
+	return ToDynamic(v)
+}
@@ -0,0 +1,83 @@
+package candiedyaml
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// structValue stands in for structpb.Value, holding what its AsInterface
+// method returns.
+type structValue struct {
+	v interface{}
+}
+
+func (s structValue) AsInterface() interface{} {
+	return s.v
+}
+
+var _ = Describe("Dynamic values", func() {
+	It("converts decoded values into the form of google.protobuf.Value", func() {
+		tree, err := UnmarshalDynamic([]byte(`name: web
+replicas: 3
+ratio: 0.5
+enabled: yes
+ports: [80, 443]
+created: 2001-12-14T21:59:43Z
+data: !!binary aGk=
+1: one
+~: none
+nested: {a: [~]}
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tree).Should(Equal(map[string]interface{}{
+			"name":     "web",
+			"replicas": 3.0,
+			"ratio":    0.5,
+			"enabled":  true,
+			"ports":    []interface{}{80.0, 443.0},
+			"created":  "2001-12-14T21:59:43Z",
+			"data":     "aGk=",
+			"1":        "one",
+			"null":     "none",
+			"nested":   map[string]interface{}{"a": []interface{}{nil}},
+		}))
+	})
+
+	It("converts ordered mappings and Numbers", func() {
+		tree, err := ToDynamic(MapSlice{{Key: "n", Value: Number("0x10")}, {Key: true, Value: []string{"a"}}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tree).Should(Equal(map[string]interface{}{"n": 16.0, "true": []interface{}{"a"}}))
+	})
+
+	It("rejects what google.protobuf.Value cannot hold", func() {
+		_, err := ToDynamic(map[string]interface{}{"big": []interface{}{int64(1) << 60}})
+		Ω(err).Should(MatchError(ContainSubstring("at .big[0]: 1152921504606846976 cannot be held exactly")))
+
+		_, err = UnmarshalDynamic([]byte("? [a]\n: b\n"))
+		Ω(err).Should(MatchError(ContainSubstring("cannot convert the []interface {} key")))
+
+		_, err = ToDynamic(func() {})
+		Ω(err).Should(MatchError(ContainSubstring("the value: cannot convert func()")))
+	})
+
+	It("encodes dynamic values with integers written as integers", func() {
+		out, err := MarshalDynamic(structValue{map[string]interface{}{
+			"b": []interface{}{3.0, 2.5, nil},
+			"a": "x",
+			"c": math.Inf(1),
+		}}, EncodeOption(func(e *Encoder) { e.SetSchema(YAML11Schema) }))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("a: x\nb:\n- 3\n- 2.5\n- null\nc: +.inf\n"))
+	})
+
+	It("round-trips through the dynamic form", func() {
+		doc := "a: 1\nb:\n- x\n- true\n"
+		tree, err := UnmarshalDynamic([]byte(doc))
+		Ω(err).ShouldNot(HaveOccurred())
+		out, err := MarshalDynamic(structValue{tree}, EncodeOption(func(e *Encoder) { e.SetSchema(YAML11Schema) }))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal(doc))
+	})
+})
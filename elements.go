@@ -0,0 +1,86 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// ElementError records why an element of a sequence could not be decoded.
+type ElementError struct {
+	Index int
+	Err   error
+	At    YAML_mark_t
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("yaml: element %d at line %d, column %d: %v", e.Index, e.At.line+1, e.At.column+1, e.Err)
+}
+
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ElementErrors is returned by a Decoder that skips invalid elements when
+// some elements were left out.  It lists them in order.
+type ElementErrors []*ElementError
+
+func (e ElementErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e ElementErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// SkipInvalidElements causes a sequence at the root of a document that is
+// decoded into a slice or array to keep only the elements that decode.
+// Decode then fills in the rest and returns an ElementErrors listing the
+// elements left out, so that one malformed record does not lose a whole
+// batch.  Syntax errors and exceeded limits still stop decoding.
+func (d *Decoder) SkipInvalidElements(skip bool) {
+	d.skipElements = skip
+}
+
+// parseElement decodes the current node into v, the element at index of a
+// sequence.  If that fails, it records the error, skips the rest of the
+// node and returns false.
+func (d *Decoder) parseElement(v reflect.Value, index int) (ok bool) {
+	level, events, depth := d.level, d.events, d.depth
+	mark := d.event.start_mark
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err, isErr := r.(error)
+		if _, fatal := r.(runtime.Error); fatal || !isErr {
+			panic(r)
+		}
+		switch err.(type) {
+		case *ParserError, *LimitError:
+			panic(r)
+		}
+
+		// Move past the node unless it was read to its end.
+		for d.events == events || d.level > level {
+			d.nextEvent()
+		}
+		d.depth = depth
+		d.elementErrors = append(d.elementErrors, &ElementError{Index: index, Err: err, At: mark})
+		ok = false
+	}()
+
+	d.parse(v)
+	return true
+}
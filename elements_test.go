@@ -0,0 +1,87 @@
+package candiedyaml
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/url"
+	"strings"
+)
+
+var _ = Describe("SkipInvalidElements", func() {
+	type record struct {
+		ID   int
+		Tags []string
+		Link url.URL
+	}
+
+	decode := func(data string, v interface{}) error {
+		d := NewDecoder(strings.NewReader(data))
+		d.SkipInvalidElements(true)
+		return d.Decode(v)
+	}
+
+	It("keeps the elements that decode", func() {
+		var v []record
+		err := decode(`
+- id: 1
+- id: one
+  tags: [a, b]
+- id: 3
+  tags: {a: b}
+- id: 4
+  link: "http://[::1"
+- id: 5
+  tags: [c]
+`, &v)
+		Ω(v).Should(Equal([]record{{ID: 1}, {ID: 5, Tags: []string{"c"}}}))
+
+		var errs ElementErrors
+		Ω(errors.As(err, &errs)).Should(BeTrue())
+		Ω(errs).Should(HaveLen(3))
+		Ω(errs[0].Index).Should(Equal(1))
+		Ω(errs[0].Error()).Should(Equal("yaml: element 1 at line 3, column 3: Integer: one"))
+		Ω(errs[1].Index).Should(Equal(2))
+		Ω(errs[2].Index).Should(Equal(3))
+		Ω(err.Error()).Should(HavePrefix("yaml: element 1 at line 3"))
+	})
+
+	It("returns no error when every element decodes", func() {
+		var v []int
+		Ω(decode("[1, 2, 3]", &v)).Should(Succeed())
+		Ω(v).Should(Equal([]int{1, 2, 3}))
+	})
+
+	It("matches the errors of the elements", func() {
+		var v []struct{ A int }
+		d := NewDecoder(strings.NewReader("- {A: 1}\n- {A: 1, A: 2}\n"))
+		d.SkipInvalidElements(true)
+		d.RejectDuplicateKeys(true)
+		err := d.Decode(&v)
+		Ω(errors.Is(err, ErrDuplicateKey)).Should(BeTrue())
+		Ω(v).Should(HaveLen(1))
+	})
+
+	It("fills arrays from the front", func() {
+		var v [3]int
+		Ω(decode("[1, x, 3]", &v)).ShouldNot(Succeed())
+		Ω(v).Should(Equal([3]int{1, 3, 0}))
+	})
+
+	It("only applies to the sequence at the root", func() {
+		var v struct{ Items []int }
+		Ω(decode("items: [1, x]", &v)).ShouldNot(BeAssignableToTypeOf(ElementErrors{}))
+	})
+
+	It("stops at syntax errors", func() {
+		var v []int
+		err := decode("[1, x, [3", &v)
+		var perr *ParserError
+		Ω(errors.As(err, &perr)).Should(BeTrue())
+	})
+
+	It("fails on any bad element by default", func() {
+		var v []int
+		Ω(Unmarshal([]byte("[1, x, 3]"), &v)).Should(MatchError("Integer: x"))
+	})
+})
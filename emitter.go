@@ -2,6 +2,8 @@ package candiedyaml
 
 import (
 	"bytes"
+	"fmt"
+	"unicode/utf8"
 )
 
 var default_tag_directives = []yaml_tag_directive_t{
@@ -68,8 +70,8 @@ func write(emitter *yaml_emitter_t, src []byte, src_pos *int) bool {
 	if !flush(emitter) {
 		return false
 	}
+	emitter.column += column_width(src, *src_pos)
 	copy_bytes(emitter.buffer, &emitter.buffer_pos, src, src_pos)
-	emitter.column++
 	return true
 }
 
@@ -94,6 +96,18 @@ func write_break(emitter *yaml_emitter_t, src []byte, src_pos *int) bool {
 	return true
 }
 
+/*
+ * Check if the character at the specified position must be escaped.
+ */
+
+func is_escaped_at(emitter *yaml_emitter_t, value []byte, i int) bool {
+	if len(emitter.escape) == 0 {
+		return false
+	}
+	r, _ := utf8.DecodeRune(value[i:])
+	return emitter.escape[r]
+}
+
 /*
  * Set an emitter error and return 0.
  */
@@ -104,6 +118,20 @@ func yaml_emitter_set_emitter_error(emitter *yaml_emitter_t, problem string) boo
 	return false
 }
 
+/*
+ * Append a comment to those waiting to be written.
+ */
+
+func join_comments(pending []byte, comment []byte, sep byte) []byte {
+	if len(comment) == 0 {
+		return pending
+	}
+	if len(pending) > 0 {
+		pending = append(pending, sep)
+	}
+	return append(pending, comment...)
+}
+
 /*
  * Emit an event.
  */
@@ -115,9 +143,35 @@ func yaml_emitter_emit(emitter *yaml_emitter_t, event *yaml_event_t) bool {
 		if !yaml_emitter_analyze_event(emitter, event) {
 			return false
 		}
+		if emitter.minify {
+			event.blank_lines = 0
+			event.head_comment, event.line_comment = nil, nil
+		}
+		if emitter.flow_level == 0 {
+			emitter.blank_lines += event.blank_lines
+			emitter.head_comment = join_comments(emitter.head_comment, event.head_comment, '\n')
+		}
+
+		/*
+		 * A line comment goes after the event, except that block scalars
+		 * take it after their header.
+		 */
+
+		block_scalar := event.event_type == yaml_SCALAR_EVENT &&
+			(event.style == yaml_style_t(yaml_LITERAL_SCALAR_STYLE) ||
+				event.style == yaml_style_t(yaml_FOLDED_SCALAR_STYLE))
+		if block_scalar {
+			emitter.line_comment = join_comments(emitter.line_comment, event.line_comment, ' ')
+		}
 		if !yaml_emitter_state_machine(emitter, event) {
 			return false
 		}
+		if !block_scalar {
+			emitter.line_comment = join_comments(emitter.line_comment, event.line_comment, ' ')
+		}
+		if emitter.flow_level > 0 {
+			emitter.head_comment = nil
+		}
 		yaml_event_delete(event)
 		emitter.events_head++
 	}
@@ -141,6 +195,8 @@ func yaml_emitter_need_more_events(emitter *yaml_emitter_t) bool {
 	accumulate := 0
 	switch emitter.events[emitter.events_head].event_type {
 	case yaml_DOCUMENT_START_EVENT:
+		/* The mappings of earlier documents have all been emitted. */
+		emitter.measured = nil
 		accumulate = 1
 	case yaml_SEQUENCE_START_EVENT:
 		accumulate = 2
@@ -150,6 +206,34 @@ func yaml_emitter_need_more_events(emitter *yaml_emitter_t) bool {
 		return false
 	}
 
+	/*
+	 * Lining up values needs the whole mapping.  The events are scanned
+	 * once, carrying on from where the last call stopped, and the keys of
+	 * the mapping and those inside it are measured when it ends.
+	 */
+	if emitter.align && accumulate == 3 {
+		head := emitter.events_head
+		if _, ok := emitter.measured[head]; ok {
+			return false
+		}
+		if emitter.scan_head != head+1 {
+			emitter.scan_head, emitter.scan_pos, emitter.scan_level = head+1, head, 0
+		}
+		for ; emitter.scan_pos < len(emitter.events); emitter.scan_pos++ {
+			switch emitter.events[emitter.scan_pos].event_type {
+			case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+				emitter.scan_level++
+			case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+				emitter.scan_level--
+			}
+			if emitter.scan_level == 0 {
+				yaml_emitter_measure_keys(emitter, head, emitter.scan_pos)
+				emitter.scan_head = 0
+				return false
+			}
+		}
+		return true
+	}
 	if len(emitter.events)-emitter.events_head > accumulate {
 		return false
 	}
@@ -379,6 +463,7 @@ func yaml_emitter_emit_document_start(emitter *yaml_emitter_t,
 			if !yaml_emitter_write_indicator(emitter, []byte("..."), true, false, false) {
 				return false
 			}
+			emitter.open_ended = false
 			if !yaml_emitter_write_indent(emitter) {
 				return false
 			}
@@ -390,7 +475,8 @@ func yaml_emitter_emit_document_start(emitter *yaml_emitter_t,
 				return false
 			}
 
-			if !yaml_emitter_write_indicator(emitter, []byte("1.1"), true, false, false) {
+			version := fmt.Sprintf("%d.%d", event.version_directive.major, event.version_directive.minor)
+			if !yaml_emitter_write_indicator(emitter, []byte(version), true, false, false) {
 				return false
 			}
 
@@ -442,10 +528,8 @@ func yaml_emitter_emit_document_start(emitter *yaml_emitter_t,
 
 		return true
 	} else if event.event_type == yaml_STREAM_END_EVENT {
-		if emitter.open_ended {
-			if !yaml_emitter_write_indicator(emitter, []byte("..."), true, false, false) {
-				return false
-			}
+		/* Nothing follows, so an open ended document needs no '...'. */
+		if emitter.head_comment != nil {
 			if !yaml_emitter_write_indent(emitter) {
 				return false
 			}
@@ -492,9 +576,13 @@ func yaml_emitter_emit_document_end(emitter *yaml_emitter_t, event *yaml_event_t
 		if !yaml_emitter_write_indicator(emitter, []byte("..."), true, false, false) {
 			return false
 		}
+		emitter.open_ended = false
 		if !yaml_emitter_write_indent(emitter) {
 			return false
 		}
+	} else {
+		/* Directives may not follow without a '...' marker. */
+		emitter.open_ended = true
 	}
 	if !yaml_emitter_flush(emitter) {
 		return false
@@ -543,7 +631,7 @@ func yaml_emitter_emit_flow_sequence_item(emitter *yaml_emitter_t, event *yaml_e
 	}
 
 	if !first {
-		if !yaml_emitter_write_indicator(emitter, []byte(","), false, false, false) {
+		if !yaml_emitter_write_indicator(emitter, []byte(","), false, emitter.minify, false) {
 			return false
 		}
 	}
@@ -600,7 +688,7 @@ func yaml_emitter_emit_flow_mapping_key(emitter *yaml_emitter_t,
 	}
 
 	if !first {
-		if !yaml_emitter_write_indicator(emitter, []byte(","), false, false, false) {
+		if !yaml_emitter_write_indicator(emitter, []byte(","), false, emitter.minify, false) {
 			return false
 		}
 	}
@@ -695,11 +783,17 @@ func yaml_emitter_emit_block_mapping_key(emitter *yaml_emitter_t,
 		if !yaml_emitter_increase_indent(emitter, false, false) {
 			return false
 		}
+		if emitter.align {
+			emitter.key_widths = append(emitter.key_widths, yaml_emitter_key_width(emitter))
+		}
 	}
 
 	if event.event_type == yaml_MAPPING_END_EVENT {
 		emitter.indent = emitter.indents[len(emitter.indents)-1]
 		emitter.indents = emitter.indents[:len(emitter.indents)-1]
+		if emitter.align {
+			emitter.key_widths = emitter.key_widths[:len(emitter.key_widths)-1]
+		}
 
 		emitter.state = emitter.states[len(emitter.states)-1]
 		emitter.states = emitter.states[:len(emitter.states)-1]
@@ -736,6 +830,9 @@ func yaml_emitter_emit_block_mapping_value(emitter *yaml_emitter_t,
 		if !yaml_emitter_write_indicator(emitter, []byte(":"), false, false, false) {
 			return false
 		}
+		if emitter.align && !yaml_emitter_align_value(emitter, event) {
+			return false
+		}
 	} else {
 		if !yaml_emitter_write_indent(emitter) {
 			return false
@@ -749,6 +846,137 @@ func yaml_emitter_emit_block_mapping_value(emitter *yaml_emitter_t,
 	return yaml_emitter_emit_node(emitter, event, false, false, true, false)
 }
 
+/*
+ * Find the width of the widest simple key of each mapping from the event at
+ * start to the one at end, or -1 if it has none, and keep them until their
+ * first key is emitted.
+ */
+
+func yaml_emitter_measure_keys(emitter *yaml_emitter_t, start, end int) {
+	type frame struct {
+		start, width int
+		mapping, key bool
+	}
+	if emitter.measured == nil {
+		emitter.measured = make(map[int]int)
+	}
+
+	var stack []frame
+	value := func() {
+		if top := len(stack) - 1; top >= 0 && stack[top].mapping {
+			stack[top].key = !stack[top].key
+		}
+	}
+	for i := start; i <= end; i++ {
+		event := &emitter.events[i]
+		switch event.event_type {
+		case yaml_SCALAR_EVENT, yaml_ALIAS_EVENT:
+			if top := len(stack) - 1; top >= 0 && stack[top].mapping && stack[top].key {
+				if w := yaml_emitter_simple_key_width(emitter, event); w > stack[top].width {
+					stack[top].width = w
+				}
+			}
+			value()
+		case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+			stack = append(stack, frame{start: i, width: -1,
+				mapping: event.event_type == yaml_MAPPING_START_EVENT, key: true})
+		case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if f.mapping {
+				emitter.measured[f.start] = f.width
+			}
+			value()
+		}
+	}
+}
+
+/*
+ * Take the width of the keys of the block mapping whose first key is the
+ * head event, measured when the mapping was queued.
+ */
+
+func yaml_emitter_key_width(emitter *yaml_emitter_t) int {
+	width, ok := emitter.measured[emitter.events_head-1]
+	if !ok {
+		return -1
+	}
+	delete(emitter.measured, emitter.events_head-1)
+	return width
+}
+
+/*
+ * Find the width of a key written as a simple key, or -1 if it cannot be,
+ * by writing it with a scratch emitter.
+ */
+
+func yaml_emitter_simple_key_width(emitter *yaml_emitter_t, event *yaml_event_t) int {
+	var output []byte
+	var scratch yaml_emitter_t
+	yaml_emitter_initialize(&scratch)
+	yaml_emitter_set_output_string(&scratch, &output)
+	scratch.best_indent = emitter.best_indent
+	scratch.best_width = emitter.best_width
+	scratch.unicode = emitter.unicode
+	scratch.escape = emitter.escape
+	scratch.line_break = emitter.line_break
+	scratch.encoding = yaml_UTF8_ENCODING
+	scratch.tag_directives = emitter.tag_directives
+	scratch.indent = -1
+	scratch.whitespace = true
+	scratch.indention = true
+	scratch.states = []yaml_emitter_state_t{yaml_EMIT_END_STATE}
+	scratch.state = yaml_EMIT_BLOCK_MAPPING_FIRST_KEY_STATE
+
+	key := *event
+	key.blank_lines, key.head_comment, key.line_comment = 0, nil, nil
+	var value yaml_event_t
+	yaml_scalar_event_initialize(&value, nil, nil, []byte("x"), true, true, yaml_PLAIN_SCALAR_STYLE)
+	if !yaml_emitter_emit(&scratch, &key) || !yaml_emitter_emit(&scratch, &value) ||
+		!yaml_emitter_flush(&scratch) {
+		return -1
+	}
+
+	if bytes.HasPrefix(output, []byte("? ")) || bytes.IndexByte(output, '\n') != -1 {
+		return -1
+	}
+	return scratch.column - len(": x")
+}
+
+/*
+ * Pad a simple key so that the value after it lines up with those of the
+ * other keys of the mapping.  Values on lines of their own are left alone.
+ */
+
+func yaml_emitter_align_value(emitter *yaml_emitter_t, event *yaml_event_t) bool {
+	switch event.event_type {
+	case yaml_SCALAR_EVENT:
+		if len(event.value) == 0 && len(event.anchor) == 0 && len(event.tag) == 0 &&
+			yaml_scalar_style_t(event.style) == yaml_PLAIN_SCALAR_STYLE {
+			return true
+		}
+	case yaml_SEQUENCE_START_EVENT:
+		if yaml_sequence_style_t(event.style) != yaml_FLOW_SEQUENCE_STYLE &&
+			!yaml_emitter_check_empty_sequence(emitter) {
+			return true
+		}
+	case yaml_MAPPING_START_EVENT:
+		if yaml_mapping_style_t(event.style) != yaml_FLOW_MAPPING_STYLE &&
+			!yaml_emitter_check_empty_mapping(emitter) {
+			return true
+		}
+	}
+
+	width := emitter.key_widths[len(emitter.key_widths)-1]
+	for emitter.column < emitter.indent+width+2 {
+		if !put(emitter, ' ') {
+			return false
+		}
+		emitter.whitespace = true
+	}
+	return true
+}
+
 /*
  * Expect a node.
  */
@@ -813,6 +1041,14 @@ func yaml_emitter_emit_scalar(emitter *yaml_emitter_t, event *yaml_event_t) bool
 	if !yaml_emitter_increase_indent(emitter, true, false) {
 		return false
 	}
+	if k := emitter.scalar_data.indent; k > 0 && k < 10 &&
+		(emitter.scalar_data.style == yaml_LITERAL_SCALAR_STYLE ||
+			emitter.scalar_data.style == yaml_FOLDED_SCALAR_STYLE) {
+		emitter.indent = k
+		if parent := emitter.indents[len(emitter.indents)-1]; parent >= 0 {
+			emitter.indent += parent
+		}
+	}
 	if !yaml_emitter_process_scalar(emitter) {
 		return false
 	}
@@ -837,7 +1073,7 @@ func yaml_emitter_emit_sequence_start(emitter *yaml_emitter_t, event *yaml_event
 		return false
 	}
 
-	if emitter.flow_level > 0 || emitter.canonical ||
+	if emitter.flow_level > 0 || emitter.canonical || emitter.minify ||
 		event.style == yaml_style_t(yaml_FLOW_SEQUENCE_STYLE) ||
 		yaml_emitter_check_empty_sequence(emitter) {
 		emitter.state = yaml_EMIT_FLOW_SEQUENCE_FIRST_ITEM_STATE
@@ -860,7 +1096,7 @@ func yaml_emitter_emit_mapping_start(emitter *yaml_emitter_t, event *yaml_event_
 		return false
 	}
 
-	if emitter.flow_level > 0 || emitter.canonical ||
+	if emitter.flow_level > 0 || emitter.canonical || emitter.minify ||
 		event.style == yaml_style_t(yaml_FLOW_MAPPING_STYLE) ||
 		yaml_emitter_check_empty_mapping(emitter) {
 		emitter.state = yaml_EMIT_FLOW_MAPPING_FIRST_KEY_STATE
@@ -980,6 +1216,11 @@ func yaml_emitter_select_scalar_style(emitter *yaml_emitter_t, event *yaml_event
 		style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
 	}
 
+	if emitter.minify && (emitter.scalar_data.multiline ||
+		style == yaml_LITERAL_SCALAR_STYLE || style == yaml_FOLDED_SCALAR_STYLE) {
+		style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+	}
+
 	if style == yaml_PLAIN_SCALAR_STYLE {
 		if (emitter.flow_level > 0 && !emitter.scalar_data.flow_plain_allowed) ||
 			(emitter.flow_level == 0 && !emitter.scalar_data.block_plain_allowed) {
@@ -1080,35 +1321,44 @@ func yaml_emitter_process_tag(emitter *yaml_emitter_t) bool {
  */
 
 func yaml_emitter_process_scalar(emitter *yaml_emitter_t) bool {
+	switch emitter.scalar_data.style {
+	case yaml_LITERAL_SCALAR_STYLE:
+		return yaml_emitter_write_literal_scalar(emitter,
+			emitter.scalar_data.value)
+
+	case yaml_FOLDED_SCALAR_STYLE:
+		return yaml_emitter_write_folded_scalar(emitter,
+			emitter.scalar_data.value)
+	}
+
+	/* Keep waiting comments out of scalars that span lines. */
+
+	head_comment, line_comment := emitter.head_comment, emitter.line_comment
+	emitter.head_comment, emitter.line_comment = nil, nil
+
+	var ok bool
 	switch emitter.scalar_data.style {
 	case yaml_PLAIN_SCALAR_STYLE:
-		return yaml_emitter_write_plain_scalar(emitter,
+		ok = yaml_emitter_write_plain_scalar(emitter,
 			emitter.scalar_data.value,
 			!emitter.simple_key_context)
 
 	case yaml_SINGLE_QUOTED_SCALAR_STYLE:
-		return yaml_emitter_write_single_quoted_scalar(emitter,
+		ok = yaml_emitter_write_single_quoted_scalar(emitter,
 			emitter.scalar_data.value,
 			!emitter.simple_key_context)
 
 	case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
-		return yaml_emitter_write_double_quoted_scalar(emitter,
+		ok = yaml_emitter_write_double_quoted_scalar(emitter,
 			emitter.scalar_data.value,
 			!emitter.simple_key_context)
 
-	case yaml_LITERAL_SCALAR_STYLE:
-		return yaml_emitter_write_literal_scalar(emitter,
-			emitter.scalar_data.value)
-
-	case yaml_FOLDED_SCALAR_STYLE:
-		return yaml_emitter_write_folded_scalar(emitter,
-			emitter.scalar_data.value)
-
 	default:
 		panic("unknown scalar")
 	}
 
-	return false
+	emitter.head_comment, emitter.line_comment = head_comment, line_comment
+	return ok
 }
 
 /*
@@ -1117,7 +1367,7 @@ func yaml_emitter_process_scalar(emitter *yaml_emitter_t) bool {
 
 func yaml_emitter_analyze_version_directive(emitter *yaml_emitter_t,
 	version_directive yaml_version_directive_t) bool {
-	if version_directive.major != 1 || version_directive.minor != 1 {
+	if version_directive.major != 1 || version_directive.minor != 1 && version_directive.minor != 2 {
 		return yaml_emitter_set_emitter_error(emitter,
 			"incompatible %YAML directive")
 	}
@@ -1149,7 +1399,7 @@ func yaml_emitter_analyze_tag_directive(emitter *yaml_emitter_t,
 			"tag handle must end with '!'")
 	}
 
-	for i := 1; i < len(handle)-1; width(handle[i]) {
+	for i := 1; i < len(handle)-1; i += width(handle[i]) {
 		if !is_alpha(handle[i]) {
 			return yaml_emitter_set_emitter_error(emitter,
 				"tag handle must contain alphanumerical characters only")
@@ -1252,6 +1502,17 @@ func yaml_emitter_analyze_scalar(emitter *yaml_emitter_t, value []byte) bool {
 		return true
 	}
 
+	// Bytes that are not UTF-8 can only be written as escapes.
+	if !utf8.Valid(value) {
+		emitter.scalar_data.multiline = false
+		emitter.scalar_data.flow_plain_allowed = false
+		emitter.scalar_data.block_plain_allowed = false
+		emitter.scalar_data.single_quoted_allowed = false
+		emitter.scalar_data.block_allowed = false
+
+		return true
+	}
+
 	if (value[0] == '-' && value[1] == '-' && value[2] == '-') ||
 		(value[0] == '.' && value[1] == '.' && value[2] == '.') {
 		block_indicators = true
@@ -1262,7 +1523,7 @@ func yaml_emitter_analyze_scalar(emitter *yaml_emitter_t, value []byte) bool {
 
 	for i, w := 0, 0; i < len(value); i += w {
 		w = width(value[i])
-		followed_by_whitespace = i+w >= len(value) || is_blankz_at(value, w)
+		followed_by_whitespace = i+w >= len(value) || is_blankz_at(value, i+w)
 
 		if i == 0 {
 			switch value[i] {
@@ -1297,7 +1558,8 @@ func yaml_emitter_analyze_scalar(emitter *yaml_emitter_t, value []byte) bool {
 			}
 		}
 
-		if !is_printable_at(value, i) || (!is_ascii(value[i]) && !emitter.unicode) {
+		if !is_printable_at(value, i) || (!is_ascii(value[i]) && !emitter.unicode) ||
+			is_escaped_at(emitter, value, i) {
 			special_characters = true
 		}
 
@@ -1416,6 +1678,8 @@ func yaml_emitter_analyze_event(emitter *yaml_emitter_t, event *yaml_event_t) bo
 		if !yaml_emitter_analyze_scalar(emitter, event.value) {
 			return false
 		}
+		emitter.scalar_data.chomping = event.chomping
+		emitter.scalar_data.indent = event.indent
 	case yaml_SEQUENCE_START_EVENT:
 		if len(event.anchor) > 0 {
 			if !yaml_emitter_analyze_anchor(emitter,
@@ -1474,23 +1738,94 @@ func yaml_emitter_write_indent(emitter *yaml_emitter_t) bool {
 
 	if !emitter.indention || emitter.column > indent ||
 		(emitter.column == indent && !emitter.whitespace) {
+		if !yaml_emitter_write_line_comment(emitter) {
+			return false
+		}
 		if !put_break(emitter) {
 			return false
 		}
 	}
 
+	if emitter.column == 0 && emitter.flow_level == 0 {
+		for ; emitter.blank_lines > 0; emitter.blank_lines-- {
+			if !put_break(emitter) {
+				return false
+			}
+		}
+	}
+	emitter.blank_lines = 0
+
 	for emitter.column < indent {
 		if !put(emitter, ' ') {
 			return false
 		}
 	}
 
+	if emitter.flow_level == 0 && len(emitter.head_comment) > 0 {
+		if !yaml_emitter_write_comment(emitter, emitter.head_comment, indent) {
+			return false
+		}
+		emitter.head_comment = nil
+	}
+
 	emitter.whitespace = true
 	emitter.indention = true
 
 	return true
 }
 
+/*
+ * Write a comment, one '#' line for each of its lines, and indent the line
+ * after it.
+ */
+func yaml_emitter_write_comment(emitter *yaml_emitter_t, comment []byte, indent int) bool {
+	for _, line := range bytes.Split(comment, []byte{'\n'}) {
+		if len(line) > 0 {
+			for emitter.column < indent {
+				if !put(emitter, ' ') {
+					return false
+				}
+			}
+			for i := 0; i < len(line); {
+				if !write(emitter, line, &i) {
+					return false
+				}
+			}
+		}
+		if !put_break(emitter) {
+			return false
+		}
+	}
+	for emitter.column < indent {
+		if !put(emitter, ' ') {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ * Write the comment waiting for the end of the current line.
+ */
+
+func yaml_emitter_write_line_comment(emitter *yaml_emitter_t) bool {
+	if len(emitter.line_comment) == 0 {
+		return true
+	}
+	if !emitter.whitespace {
+		if !put(emitter, ' ') {
+			return false
+		}
+	}
+	for i := 0; i < len(emitter.line_comment); {
+		if !write(emitter, emitter.line_comment, &i) {
+			return false
+		}
+	}
+	emitter.line_comment = nil
+	return true
+}
+
 func yaml_emitter_write_indicator(emitter *yaml_emitter_t,
 	indicator []byte, need_whitespace bool,
 	is_whitespace bool, is_indention bool) bool {
@@ -1613,7 +1948,8 @@ func yaml_emitter_write_plain_scalar(emitter *yaml_emitter_t, value []byte,
 	spaces := false
 	breaks := false
 
-	if !emitter.whitespace {
+	// An empty document needs no space after its "---".
+	if (len(value) > 0 || !emitter.root_context) && !emitter.whitespace {
 		if !put(emitter, ' ') {
 			return false
 		}
@@ -1745,9 +2081,17 @@ func yaml_emitter_write_double_quoted_scalar(emitter *yaml_emitter_t, value []by
 	}
 
 	for i := 0; i < len(value); {
-		if !is_printable_at(value, i) || (!emitter.unicode && !is_ascii(value[i])) ||
+		if r, w := utf8.DecodeRune(value[i:]); r == utf8.RuneError && w == 1 {
+			const hex = "0123456789ABCDEF"
+			if !put(emitter, '\\') || !put(emitter, 'x') ||
+				!put(emitter, hex[value[i]>>4]) || !put(emitter, hex[value[i]&0x0F]) {
+				return false
+			}
+			i++
+			spaces = false
+		} else if !is_printable_at(value, i) || (!emitter.unicode && !is_ascii(value[i])) ||
 			is_bom_at(value, i) || is_break_at(value, i) ||
-			value[i] == '"' || value[i] == '\\' {
+			value[i] == '"' || value[i] == '\\' || is_escaped_at(emitter, value, i) {
 			octet := value[i]
 
 			var w int
@@ -1862,7 +2206,7 @@ func yaml_emitter_write_double_quoted_scalar(emitter *yaml_emitter_t, value []by
 				for k := (w - 1) * 4; k >= 0; k -= 4 {
 					digit := byte((v >> uint(k)) & 0x0F)
 					c := digit + '0'
-					if c > 9 {
+					if digit > 9 {
 						c = digit + 'A' - 10
 					}
 					if !put(emitter, c) {
@@ -1910,7 +2254,12 @@ func yaml_emitter_write_double_quoted_scalar(emitter *yaml_emitter_t, value []by
 
 func yaml_emitter_write_block_scalar_hints(emitter *yaml_emitter_t, value []byte) bool {
 
-	if is_space(value[0]) || is_break_at(value, 0) {
+	if k := emitter.scalar_data.indent; k > 0 && k < 10 {
+		indent_hint := []byte{'0' + byte(k)}
+		if !yaml_emitter_write_indicator(emitter, indent_hint, false, false, false) {
+			return false
+		}
+	} else if len(value) > 0 && (is_space(value[0]) || is_break_at(value, 0)) {
 		indent_hint := []byte{'0' + byte(emitter.best_indent)}
 		if !yaml_emitter_write_indicator(emitter, indent_hint, false, false, false) {
 			return false
@@ -1934,6 +2283,7 @@ func yaml_emitter_write_block_scalar_hints(emitter *yaml_emitter_t, value []byte
 			chomp_hint[0] = '+'
 			emitter.open_ended = true
 		} else {
+			i--
 			for value[i]&0xC0 == 0x80 {
 				i--
 			}
@@ -1945,6 +2295,12 @@ func yaml_emitter_write_block_scalar_hints(emitter *yaml_emitter_t, value []byte
 		}
 	}
 
+	// Keeping a single final break reads back the same as clipping it.
+	if chomp_hint[0] == 0 && emitter.scalar_data.chomping > 0 {
+		chomp_hint[0] = '+'
+		emitter.open_ended = true
+	}
+
 	if chomp_hint[0] != 0 {
 		if !yaml_emitter_write_indicator(emitter, chomp_hint[:], false, false, false) {
 			return false
@@ -1965,6 +2321,9 @@ func yaml_emitter_write_literal_scalar(emitter *yaml_emitter_t, value []byte) bo
 	if !yaml_emitter_write_block_scalar_hints(emitter, value) {
 		return false
 	}
+	if !yaml_emitter_write_line_comment(emitter) {
+		return false
+	}
 
 	if !put_break(emitter) {
 		return false
@@ -2007,6 +2366,9 @@ func yaml_emitter_write_folded_scalar(emitter *yaml_emitter_t, value []byte) boo
 	if !yaml_emitter_write_block_scalar_hints(emitter, value) {
 		return false
 	}
+	if !yaml_emitter_write_line_comment(emitter) {
+		return false
+	}
 	if !put_break(emitter) {
 		return false
 	}
@@ -2017,10 +2379,10 @@ func yaml_emitter_write_folded_scalar(emitter *yaml_emitter_t, value []byte) boo
 		if is_break_at(value, i) {
 			if !breaks && !leading_spaces && value[i] == '\n' {
 				k := i
-				for is_break_at(value, k) {
+				for k < len(value) && is_break_at(value, k) {
 					k += width(value[k])
 				}
-				if !is_blankz_at(value, k) {
+				if k < len(value) && !is_blankz_at(value, k) {
 					if !put_break(emitter) {
 						return false
 					}
@@ -2038,7 +2400,8 @@ func yaml_emitter_write_folded_scalar(emitter *yaml_emitter_t, value []byte) boo
 				}
 				leading_spaces = is_blank(value[i])
 			}
-			if !breaks && is_space(value[i]) && !is_space(value[i+1]) &&
+			if !breaks && is_space(value[i]) &&
+				(i+1 == len(value) || !is_space(value[i+1])) &&
 				emitter.column > emitter.best_width {
 				if !yaml_emitter_write_indent(emitter) {
 					return false
@@ -1,48 +1,478 @@
 package candiedyaml
 
 import (
+	"bytes"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"reflect"
 	"runtime"
-	"runtime/debug"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var timeTimeType = reflect.TypeOf(time.Time{})
 
-// An Encoder writes JSON objects to an output stream.
+// An Encoder writes YAML documents to an output stream.  Like a Decoder, it
+// must be used by one goroutine at a time, but distinct Encoders may be used
+// concurrently.
 type Encoder struct {
 	w       io.Writer
 	emitter yaml_emitter_t
 	event   yaml_event_t
 	flow    bool
 	err     error
+	schema  *Schema
+	floats  FloatFormat
+	ints    intFormat
+	compact int
+	root    bool
+	comment string
+
+	stringer    bool
+	sortKeys    bool
+	invalidUTF8 InvalidUTF8Policy
+	fold        bool
+	setTag      bool
+	frontMatter bool
+
+	// separate is set by SeparateTopLevel.  level is the number of
+	// collections open, top whether the root is a mapping, and entries the
+	// number of nodes written into it.
+	separate bool
+	level    int
+	top      bool
+	entries  int
+
+	// keys is how string keys are quoted, and key is set while a key is
+	// written.
+	keys KeyQuoting
+	key  bool
+
+	tagDirectives []yaml_tag_directive_t
+
+	// version, set by Format, is the %YAML directive to start documents
+	// with.
+	version *yaml_version_directive_t
+
+	// aliases holds the values registered with Alias, and anchor the
+	// anchor to put on the next node written.
+	aliases map[aliasKey]*alias
+	anchor  []byte
+
+	// naming is set by AutoAlias.  auto holds the values it found more than
+	// once in the document, named the anchor names taken so far, including
+	// those registered with Alias, and autoNamed the number it has named.
+	naming    AnchorNamer
+	auto      map[aliasKey]*alias
+	named     map[string]bool
+	autoNamed int
+
+	// limit holds back the output when it is limited by SetMaxOutputBytes.
+	limit *outputLimit
+
+	// path holds the struct fields, map keys and slice indexes down to the
+	// value being written, for MarshalPanicErrors.
+	path []interface{}
+
+	// measure is set on the copies fitsFlow writes with.
+	measure *measure
+}
+
+// aliasKey identifies a pointer, map or slice by what it refers to.
+type aliasKey struct {
+	t   reflect.Type
+	p   uintptr
+	len int
+}
+
+type alias struct {
+	name    string
+	written bool
+}
+
+// A Commenter supplies the comments written above the fields of a struct.
+// YAMLComment is called with the name of each field as it appears in the
+// output and returns its comment, or "" for none.  Fields can also be given
+// a fixed comment with a yamlcomment struct tag:
+//
+//	Port int `yaml:"port" yamlcomment:"port the server listens on"`
+//
+// Comments are written in block style only.
+type Commenter interface {
+	YAMLComment(field string) string
 }
 
+// FloatFormat describes how an Encoder writes floats.
+type FloatFormat struct {
+	// Format and Precision are passed to strconv.FormatFloat.
+	Format    byte
+	Precision int
+
+	// DecimalPoint adds ".0" to floats that would otherwise be written
+	// without a decimal point, so that whole floats such as 1.0 read back
+	// as floats rather than integers.
+	DecimalPoint bool
+
+	// SpecialCase is the capitalization of NaN and the infinities, and
+	// UnsignedInf writes positive infinity as .inf rather than +.inf, for
+	// consumers that accept only some of the forms YAML allows.
+	SpecialCase FloatCase
+	UnsignedInf bool
+}
+
+// FloatCase selects how an Encoder capitalizes NaN and the infinities.
+type FloatCase int
+
+const (
+	// LowerCase writes .nan and .inf.  It is the default.
+	LowerCase FloatCase = iota
+	// TitleCase writes .NaN and .Inf.
+	TitleCase
+	// UpperCase writes .NAN and .INF.
+	UpperCase
+)
+
+// DefaultFloatFormat writes the shortest representation that reads back as
+// the same value, e.g. 1, 0.1 or 1.2e+23.
+var DefaultFloatFormat = FloatFormat{Format: 'g', Precision: -1}
+
+// KeyQuoting selects when an Encoder quotes string mapping keys.
+type KeyQuoting int
+
+const (
+	// QuoteKeysAsValues quotes keys by the same rules as string values.
+	// It is the default.
+	QuoteKeysAsValues KeyQuoting = iota
+
+	// QuoteKeysAlways writes every string key in double quotes.
+	QuoteKeysAlways
+
+	// QuoteKeysNever writes string keys plain wherever YAML allows it,
+	// even where they would read back as another type, e.g. "true".
+	QuoteKeysNever
+
+	// QuoteKeysWhenNeeded writes string keys in double quotes only where
+	// they could not be written plain, or would read back as something
+	// other than the same string under either the YAML 1.1 or the YAML
+	// 1.2 core schema, e.g. "on", "1.0", "a: b" or "#x".
+	QuoteKeysWhenNeeded
+)
+
 // NewEncoder returns a new encoder that writes to w.
 func NewEncoder(w io.Writer) *Encoder {
-	e := &Encoder{w: w}
+	e := &Encoder{w: w, floats: DefaultFloatFormat}
 	yaml_emitter_initialize(&e.emitter)
 	yaml_emitter_set_output_writer(&e.emitter, e.w)
 	yaml_stream_start_event_initialize(&e.event, yaml_UTF8_ENCODING)
 	e.emit()
-	yaml_document_start_event_initialize(&e.event, nil, nil, true)
-	e.emit()
 
 	return e
 }
 
+// Marshal returns the YAML encoding of v, written by an Encoder set up with
+// opts.
+func Marshal(v interface{}, opts ...EncodeOption) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	for _, opt := range opts {
+		opt(e)
+	}
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalAll returns a stream with one document for each element of the
+// slice or array v.
+func MarshalAll(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, errors.New("MarshalAll: not a slice: " + rv.Kind().String())
+	}
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	for i := 0; i < rv.Len(); i++ {
+		if err := e.Encode(rv.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// SetSchema causes strings to be written as plain scalars unless s would
+// resolve them to something other than the same string.  By default every
+// string is double-quoted.
+func (e *Encoder) SetSchema(s *Schema) {
+	e.schema = s
+}
+
+// SetFloatFormat selects how floats are written.  By default NaN and the
+// infinities are written as .nan, +.inf and -.inf.
+func (e *Encoder) SetFloatFormat(f FloatFormat) {
+	e.floats = f
+}
+
+// SetCompact causes mappings and sequences below the document root to be
+// written in flow style, e.g. {x: 1, y: 2}, when that takes no more than
+// width characters, and in block style otherwise.  A width of 0 turns compact
+// mode off.
+func (e *Encoder) SetCompact(width int) {
+	e.compact = width
+}
+
+// SetIndent sets the number of spaces, from 2 to 9, that nested block
+// collections are indented by.  The default is 2.
+func (e *Encoder) SetIndent(n int) {
+	yaml_emitter_set_indent(&e.emitter, n)
+}
+
+// SetWidth sets the width at which long scalars are folded onto the next
+// line.  A negative width means no limit.  The default is 80.
+func (e *Encoder) SetWidth(n int) {
+	switch {
+	case n < 0:
+		n = 1<<31 - 1
+	case n <= e.emitter.best_indent*2:
+		n = 80
+	}
+	yaml_emitter_set_width(&e.emitter, n)
+}
+
+// SetUnicode writes characters outside ASCII as they are, in UTF-8.  By
+// default the output is ASCII only: strings holding other characters are
+// double-quoted with each of them written as a \u or \U escape.
+func (e *Encoder) SetUnicode(unicode bool) {
+	yaml_emitter_set_unicode(&e.emitter, unicode)
+}
+
+// EscapeRunes always writes the runes given as escapes, double-quoting the
+// strings that hold them.  Use it for characters that the consumers of the
+// output mishandle, such as U+2028 or the zero-width and bidirectional
+// formatting characters, which are otherwise written as they are when
+// SetUnicode is on.  Control characters are always escaped.
+func (e *Encoder) EscapeRunes(runes ...rune) {
+	yaml_emitter_set_escape(&e.emitter, runes)
+}
+
+// SetMinify causes documents to be written as briefly as possible: in flow
+// style throughout, on a single line, without comments or optional spaces.
+// This suits YAML carried in a single field, such as an environment
+// variable.
+func (e *Encoder) SetMinify(minify bool) {
+	e.emitter.minify = minify
+	if minify {
+		e.SetWidth(-1)
+	}
+}
+
+// SetAlign causes the keys of block mappings to be padded so that their
+// values line up in a column, as in hand-maintained configuration files:
+//
+//	name:     web
+//	image:    nginx
+//	replicas: 2
+//
+// Each mapping is aligned on its own, and values on lines of their own are
+// left alone.
+func (e *Encoder) SetAlign(align bool) {
+	e.emitter.align = align
+}
+
+// FoldLongStrings causes strings that are longer than the width set with
+// SetWidth and have no line breaks to be written as folded block scalars,
+// broken at the first space past the width:
+//
+//	description: >-
+//	  A string too long for one line is carried over onto as many lines
+//	  as it needs.
+//
+// Strings in flow collections stay on one line.
+func (e *Encoder) FoldLongStrings(fold bool) {
+	e.fold = fold
+}
+
+// foldable reports whether s is long enough to fold and can be folded.
+func (e *Encoder) foldable(s string) bool {
+	return string_width(s) > e.emitter.best_width && strings.Contains(s, " ") && !strings.Contains(s, "\n")
+}
+
+// SeparateTopLevel causes a blank line to be written between the entries of
+// a document's root mapping or the items of its root sequence, which makes
+// long generated lists easier to read.
+func (e *Encoder) SeparateTopLevel(separate bool) {
+	e.separate = separate
+}
+
+// separateTopLevel puts a blank line before the event if it starts an entry
+// of the root collection other than the first.
+func (e *Encoder) separateTopLevel() {
+	switch e.event.event_type {
+	case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+		e.level--
+		return
+	case yaml_SCALAR_EVENT, yaml_ALIAS_EVENT, yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+	default:
+		return
+	}
+
+	if e.level == 1 {
+		if e.entries > 0 && (!e.top || e.entries%2 == 0) && e.event.blank_lines == 0 {
+			e.event.blank_lines = 1
+		}
+		e.entries++
+	}
+	switch e.event.event_type {
+	case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+		if e.level == 0 {
+			e.top = e.event.event_type == yaml_MAPPING_START_EVENT
+			e.entries = 0
+		}
+		e.level++
+	}
+}
+
+// QuoteKeys sets when string mapping keys are quoted.
+func (e *Encoder) QuoteKeys(q KeyQuoting) {
+	e.keys = q
+}
+
+// UseStringer causes values implementing fmt.Stringer to be written as the
+// string their String method returns, which suits enums and identifiers.
+// Times are still written as timestamps, and Numbers as numbers.
+func (e *Encoder) UseStringer(use bool) {
+	e.stringer = use
+}
+
+// SortKeys causes the fields of structs and the entries of MapSlices to be
+// written in key order too, rather than in the order they were declared or
+// appended in.  Maps are always written in key order.
+func (e *Encoder) SortKeys(sort bool) {
+	e.sortKeys = sort
+}
+
+// SetTagHandle declares the tag handle for prefix in a %TAG directive at the
+// start of every document, and writes tags starting with prefix in the
+// shorthand form.  For example, after
+//
+//	e.SetTagHandle("!k!", "tag:example.com,2024:")
+//
+// the tag "tag:example.com,2024:Deployment" is written as !k!Deployment.
+func (e *Encoder) SetTagHandle(handle, prefix string) error {
+	directive := yaml_tag_directive_t{handle: []byte(handle), prefix: []byte(prefix)}
+
+	var emitter yaml_emitter_t
+	if !yaml_emitter_analyze_tag_directive(&emitter, &directive) {
+		return errors.New("yaml: " + emitter.problem)
+	}
+
+	for i := range e.tagDirectives {
+		if string(e.tagDirectives[i].handle) == handle {
+			e.tagDirectives[i] = directive
+			return nil
+		}
+	}
+	e.tagDirectives = append(e.tagDirectives, directive)
+	return nil
+}
+
+// Alias makes each document write the value v refers to only once: where
+// it first occurs it is given the anchor &name, and everywhere else it is
+// written as the alias *name.  v must be a pointer, map or slice, and is
+// matched by identity, so a copy of what it refers to is written in full.
+// A value that refers to itself can be encoded once it has an alias.
+func (e *Encoder) Alias(name string, v interface{}) error {
+	var emitter yaml_emitter_t
+	if !yaml_emitter_analyze_anchor(&emitter, []byte(name), false) {
+		return errors.New("yaml: " + emitter.problem)
+	}
+
+	rv := reflect.ValueOf(v)
+	key, ok := aliasKeyOf(rv)
+	if !ok {
+		return fmt.Errorf("yaml: cannot alias %v, only a non-nil pointer, map or slice", rv.Type())
+	}
+
+	if e.aliases == nil {
+		e.aliases = make(map[aliasKey]*alias)
+	}
+	e.aliases[key] = &alias{name: name}
+	return nil
+}
+
+func aliasKeyOf(v reflect.Value) (aliasKey, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if !v.IsNil() {
+			return aliasKey{t: v.Type(), p: v.Pointer()}, true
+		}
+	case reflect.Slice:
+		if !v.IsNil() {
+			return aliasKey{t: v.Type(), p: v.Pointer(), len: v.Len()}, true
+		}
+	}
+	return aliasKey{}, false
+}
+
+// emitAlias writes v as an alias if it was registered with Alias and has
+// been written before, and otherwise anchors the node written for it.
+func (e *Encoder) emitAlias(v reflect.Value) bool {
+	key, ok := aliasKeyOf(v)
+	if !ok {
+		return false
+	}
+	a := e.aliases[key]
+	if a == nil {
+		a = e.auto[key]
+	}
+	if a == nil {
+		return false
+	}
+	if !a.written {
+		a.written = true
+		if a.name == "" {
+			a.name = e.anchorName(v)
+		}
+		if e.named != nil {
+			e.named[a.name] = true
+		}
+		e.anchor = []byte(a.name)
+		return false
+	}
+
+	yaml_alias_event_initialize(&e.event, []byte(a.name))
+	if e.comment != "" {
+		e.event.head_comment = fieldComment(e.comment)
+		e.comment = ""
+	}
+	e.flow = false
+	e.emit()
+	return true
+}
+
+// Encode writes v as the next document of the stream.  Documents after the
+// first start with a '---' marker.  A document that fails leaves the stream
+// unfinished, so once Encode returns an error it returns that error again
+// without writing anything.
 func (e *Encoder) Encode(v interface{}) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			if _, ok := r.(runtime.Error); ok {
 				panic(r)
 			}
+			if e.limit != nil {
+				if e.limit.truncated {
+					err = e.limit.truncate()
+					return
+				}
+				e.limit.buf.Reset()
+			}
 			switch r := r.(type) {
 			case error:
 				err = r
@@ -51,38 +481,114 @@ func (e *Encoder) Encode(v interface{}) (err error) {
 			default:
 				err = errors.New("Unknown panic: " + reflect.TypeOf(r).String())
 			}
-
-			debug.PrintStack()
+			e.err = err
 		}
 	}()
 
 	if e.err != nil {
 		return e.err
 	}
+	if e.limit != nil && e.limit.truncated {
+		return nil
+	}
+
+	// A document node carries the comments around the document.
+	var doc *Node
+	switch n := v.(type) {
+	case Node:
+		doc = &n
+	case *Node:
+		doc = n
+	}
+	if doc == nil || doc.Kind != DocumentNode {
+		doc = &Node{}
+	}
 
+	for _, a := range e.aliases {
+		a.written = false
+	}
+	e.findAutoAliases(reflect.ValueOf(v))
+	e.anchor = nil
+	e.level = 0
+	e.path = e.path[:0]
+
+	yaml_document_start_event_initialize(&e.event, e.version, e.tagDirectives, !e.frontMatter)
+	e.event.head_comment = commentLines(doc.HeadComment)
+	e.emit()
+
+	e.root = true
 	e.marshal("", reflect.ValueOf(v))
+	e.root = false
 
 	yaml_document_end_event_initialize(&e.event, true)
+	e.event.head_comment = commentLines(doc.FootComment)
 	e.emit()
-	e.emitter.open_ended = false
-	yaml_stream_end_event_initialize(&e.event)
-	e.emit()
+	if e.frontMatter {
+		e.closeFrontMatter()
+	}
 
+	if e.limit != nil {
+		return e.limit.commit()
+	}
 	return nil
 }
 
 func (e *Encoder) emit() {
+	if e.anchor != nil {
+		switch e.event.event_type {
+		case yaml_SCALAR_EVENT, yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+			if len(e.event.anchor) == 0 {
+				e.event.anchor = e.anchor
+			}
+			e.anchor = nil
+		}
+	}
+	if e.separate {
+		e.separateTopLevel()
+	}
+	e.checkUTF8()
 	if !yaml_emitter_emit(&e.emitter, &e.event) {
 		panic("bad emit")
 	}
+	if e.measure != nil && e.measure.out.Len()+e.emitter.buffer_pos > e.measure.max {
+		panic(errTooLong)
+	}
 }
 
 func (e *Encoder) marshal(tag string, v reflect.Value) {
+	// A nil interface, such as Marshal(nil), has no value to look at.
+	if !v.IsValid() {
+		e.emitNil()
+		return
+	}
+
+	if (e.aliases != nil || e.auto != nil) && e.emitAlias(v) {
+		return
+	}
+
+	if e.adapt(tag, v) || e.marshalWithOptions(tag, v) {
+		return
+	}
+
+	if e.stringer {
+		if s, ok := stringer(v); ok {
+			var str string
+			e.call(func() {
+				str = s.String()
+			})
+			e.emitString(tag, reflect.ValueOf(str))
+			return
+		}
+	}
+
 	switch v.Kind() {
 	case reflect.Interface:
-		if v.IsNil() {
+		switch {
+		case v.IsNil():
 			e.emitNil()
-		} else {
+		case v.Type().Implements(errorType):
+			e.emitError(tag, v)
+		default:
 			e.marshal(tag, v.Elem())
 		}
 	case reflect.Map:
@@ -112,41 +618,204 @@ func (e *Encoder) marshal(tag string, v reflect.Value) {
 	}
 }
 
+// checkCompact switches to flow style for the collection v if compact mode
+// is on and v fits.
+func (e *Encoder) checkCompact(v reflect.Value) {
+	if e.root {
+		e.root = false
+		return
+	}
+	if e.compact > 0 && !e.flow {
+		e.flow = e.fitsFlow(v)
+	}
+}
+
+// fitsFlow reports whether v written in flow style takes no more than
+// e.compact characters on a single line.  It writes v with a copy of the
+// Encoder, settings, aliases and all, so that it measures what would be
+// written, and gives up as soon as the output is too long to fit, so that
+// nested collections that do not fit are not written out in full again at
+// each level.
+func (e *Encoder) fitsFlow(v reflect.Value) (fits bool) {
+	buf := &bytes.Buffer{}
+	f := *e
+	f.w, f.flow, f.comment, f.separate, f.limit = buf, true, "", false, nil
+	f.path = append([]interface{}(nil), e.path...)
+	f.aliases, f.auto = copyAliases(e.aliases), copyAliases(e.auto)
+	if e.named != nil {
+		f.named = make(map[string]bool, len(e.named))
+		for name := range e.named {
+			f.named[name] = true
+		}
+	}
+	// Characters take up to four bytes, so longer output cannot fit.
+	f.measure = &measure{out: buf, max: 4 * e.compact}
+
+	f.event = yaml_event_t{}
+	f.emitter = yaml_emitter_t{}
+	yaml_emitter_initialize(&f.emitter)
+	yaml_emitter_set_output_writer(&f.emitter, buf)
+	yaml_emitter_set_width(&f.emitter, -1)
+	f.emitter.unicode = e.emitter.unicode
+	f.emitter.escape = e.emitter.escape
+	f.emitter.minify = e.emitter.minify
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errTooLong {
+				panic(r)
+			}
+			fits = false
+		}
+	}()
+
+	yaml_stream_start_event_initialize(&f.event, yaml_UTF8_ENCODING)
+	f.emit()
+	yaml_document_start_event_initialize(&f.event, nil, nil, true)
+	f.emit()
+	f.marshal("", v)
+	yaml_document_end_event_initialize(&f.event, true)
+	f.emit()
+	if !yaml_emitter_flush(&f.emitter) {
+		panic("bad emit")
+	}
+
+	s := strings.TrimSuffix(buf.String(), "\n")
+	return !strings.Contains(s, "\n") && string_width(s) <= e.compact
+}
+
+// measure stops the Encoder fitsFlow writes with once out holds, or its
+// emitter has buffered, more than max bytes.
+type measure struct {
+	out *bytes.Buffer
+	max int
+}
+
+var errTooLong = errors.New("yaml: too long to fit")
+
+func copyAliases(aliases map[aliasKey]*alias) map[aliasKey]*alias {
+	if aliases == nil {
+		return nil
+	}
+	c := make(map[aliasKey]*alias, len(aliases))
+	for key, a := range aliases {
+		copied := *a
+		c[key] = &copied
+	}
+	return c
+}
+
+// stringer returns v as a fmt.Stringer if it, or its address, is one.
+// Times and Numbers are left to be written as they are without it.
+func stringer(v reflect.Value) (fmt.Stringer, bool) {
+	if !v.IsValid() || !v.CanInterface() || v.Kind() == reflect.Interface {
+		return nil, false
+	}
+	t := v.Type()
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		t = t.Elem()
+	}
+	if t == timeTimeType || t == numberType {
+		return nil, false
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s, true
+	}
+	if v.CanAddr() {
+		s, ok := v.Addr().Interface().(fmt.Stringer)
+		return s, ok
+	}
+	return nil, false
+}
+
 func (e *Encoder) emitMap(tag string, v reflect.Value) {
+	if isSet(v.Type()) {
+		e.emitSet(tag, v)
+		return
+	}
+
+	e.checkCompact(v)
 	e.mapping(tag, func() {
 		var keys stringValues = v.MapKeys()
 		sort.Sort(keys)
 		for _, k := range keys {
-			e.marshal("", k)
+			e.marshalKey(k)
+			e.enterPath(k)
 			e.marshal("", v.MapIndex(k))
+			e.leavePath()
 		}
 	})
 }
 
 func (e *Encoder) emitStruct(tag string, v reflect.Value) {
-	if v.Type() == timeTimeType {
+	if v.Type().ConvertibleTo(timeTimeType) {
 		e.emitTime(tag, v)
 		return
 	}
 
-	fields := cachedTypeFields(v.Type())
+	if v.Type() == nodeType {
+		n := v.Interface().(Node)
+		if e.flow {
+			e.flow = false
+			if n.Kind == SequenceNode || n.Kind == MappingNode {
+				n.Style = FlowStyle
+			}
+		}
+		e.emitNode(&n)
+		return
+	}
+
+	e.checkCompact(v)
+	fields := cachedTypeFields(v.Type()).list
+	if e.sortKeys {
+		fields = append([]field(nil), fields...)
+		sort.SliceStable(fields, func(i, j int) bool {
+			return fields[i].name < fields[j].name
+		})
+	}
+
+	var commenter Commenter
+	if v.CanInterface() {
+		commenter, _ = v.Interface().(Commenter)
+		if commenter == nil && v.CanAddr() {
+			commenter, _ = v.Addr().Interface().(Commenter)
+		}
+	}
 
 	e.mapping(tag, func() {
+		ints := e.ints
 		for _, f := range fields {
 			fv := fieldByIndex(v, f.index)
 			if !fv.IsValid() || f.omitEmpty && isEmptyValue(fv) {
 				continue
 			}
 
-			e.marshal("", reflect.ValueOf(f.name))
+			e.enterPath(f.name)
+			e.comment = f.comment
+			if commenter != nil {
+				var c string
+				e.call(func() {
+					c = commenter.YAMLComment(f.name)
+				})
+				if c != "" {
+					e.comment = c
+				}
+			}
+			e.marshalKey(reflect.ValueOf(f.name))
 			e.flow = f.flow
+			e.ints = f.ints
 			e.marshal("", fv)
+			e.leavePath()
 		}
+		e.ints = ints
 	})
 }
 
 func (e *Encoder) emitTime(tag string, v reflect.Value) {
-	t := v.Interface().(time.Time)
+	t := v.Convert(timeTimeType).Interface().(time.Time)
 	s := t.Format(time.RFC3339)
 	e.emitScalar(s, "", tag, yaml_PLAIN_SCALAR_STYLE)
 }
@@ -165,11 +834,21 @@ func isEmptyValue(v reflect.Value) bool {
 		return v.Float() == 0
 	case reflect.Interface, reflect.Ptr:
 		return v.IsNil()
+	case reflect.Struct:
+		return v.Type() == nodeType && v.Interface().(Node).Kind == 0
 	}
 	return false
 }
 
+// marshalKey encodes the mapping key k.
+func (e *Encoder) marshalKey(k reflect.Value) {
+	e.key = true
+	e.marshal("", k)
+	e.key = false
+}
+
 func (e *Encoder) mapping(tag string, f func()) {
+	e.key = false
 	implicit := tag == ""
 	style := yaml_BLOCK_MAPPING_STYLE
 	if e.flow {
@@ -186,11 +865,29 @@ func (e *Encoder) mapping(tag string, f func()) {
 }
 
 func (e *Encoder) emitSlice(tag string, v reflect.Value) {
-	if v.Type() == byteSliceType {
+	if isByteSlice(v.Type()) {
 		e.emitBase64(tag, v)
 		return
 	}
 
+	e.checkCompact(v)
+	if v.Type() == mapSliceType {
+		e.emitMapSlice(tag, v)
+		return
+	}
+
+	e.sequence(tag, func() {
+		n := v.Len()
+		for i := 0; i < n; i++ {
+			e.enterPath(i)
+			e.marshal("", v.Index(i))
+			e.leavePath()
+		}
+	})
+}
+
+func (e *Encoder) sequence(tag string, f func()) {
+	e.key = false
 	implicit := tag == ""
 	style := yaml_BLOCK_SEQUENCE_STYLE
 	if e.flow {
@@ -200,10 +897,7 @@ func (e *Encoder) emitSlice(tag string, v reflect.Value) {
 	yaml_sequence_start_event_initialize(&e.event, nil, []byte(tag), implicit, style)
 	e.emit()
 
-	n := v.Len()
-	for i := 0; i < n; i++ {
-		e.marshal("", v.Index(i))
-	}
+	f()
 
 	yaml_sequence_end_event_initialize(&e.event)
 	e.emit()
@@ -224,46 +918,142 @@ func (e *Encoder) emitBase64(tag string, v reflect.Value) {
 }
 
 func (e *Encoder) emitString(tag string, v reflect.Value) {
+	if v.Type() == numberType {
+		e.emitNumber(tag, v)
+		return
+	}
+
 	var style yaml_scalar_style_t
 	s := v.String()
 
 	style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+	switch {
+	case e.key && e.keys != QuoteKeysAsValues:
+		style = e.keyStyle(s)
+	case e.fold && !e.key && e.foldable(s):
+		style = yaml_FOLDED_SCALAR_STYLE
+	case e.schema != nil && !e.schema.needsQuotes(s):
+		style = yaml_PLAIN_SCALAR_STYLE
+	}
 	e.emitScalar(s, "", tag, style)
 }
 
+// keyStyle returns the style to write the string key s in.
+func (e *Encoder) keyStyle(s string) yaml_scalar_style_t {
+	switch e.keys {
+	case QuoteKeysNever:
+		return yaml_PLAIN_SCALAR_STYLE
+	case QuoteKeysWhenNeeded:
+		var emitter yaml_emitter_t
+		yaml_emitter_analyze_scalar(&emitter, []byte(s))
+		plain := emitter.scalar_data.flow_plain_allowed && emitter.scalar_data.block_plain_allowed &&
+			!YAML11Schema.needsQuotes(s) && !CoreSchema.needsQuotes(s) &&
+			(e.schema == nil || !e.schema.needsQuotes(s))
+		if plain {
+			return yaml_PLAIN_SCALAR_STYLE
+		}
+	}
+	return yaml_DOUBLE_QUOTED_SCALAR_STYLE
+}
+
 func (e *Encoder) emitBool(tag string, v reflect.Value) {
 	s := strconv.FormatBool(v.Bool())
 	e.emitScalar(s, "", tag, yaml_PLAIN_SCALAR_STYLE)
 }
 
 func (e *Encoder) emitInt(tag string, v reflect.Value) {
-	s := strconv.FormatInt(v.Int(), 10)
+	i := v.Int()
+	var s string
+	if i < 0 {
+		s = "-" + formatInt(uint64(-i), e.ints)
+	} else {
+		s = formatInt(uint64(i), e.ints)
+	}
 	e.emitScalar(s, "", tag, yaml_PLAIN_SCALAR_STYLE)
 }
 
 func (e *Encoder) emitUint(tag string, v reflect.Value) {
-	s := strconv.FormatUint(v.Uint(), 10)
+	s := formatInt(v.Uint(), e.ints)
 	e.emitScalar(s, "", tag, yaml_PLAIN_SCALAR_STYLE)
 }
 
+// formatInt writes u in the base of f with the prefix the decoder expects:
+// 0b for binary, 0 for octal and 0x for hexadecimal.
+func formatInt(u uint64, f intFormat) string {
+	base, prefix, group := 10, "", 3
+	switch f.base {
+	case 2:
+		base, prefix, group = 2, "0b", 4
+	case 8:
+		base, prefix = 8, "0"
+	case 16:
+		base, prefix, group = 16, "0x", 4
+	}
+
+	digits := strconv.FormatUint(u, base)
+	if base == 8 && u == 0 {
+		prefix = ""
+	}
+
+	if f.separated && len(digits) > group {
+		n := len(digits) % group
+		if n == 0 {
+			n = group
+		}
+		grouped := digits[:n]
+		for ; n < len(digits); n += group {
+			grouped += "_" + digits[n:n+group]
+		}
+		digits = grouped
+	}
+
+	return prefix + digits
+}
+
 func (e *Encoder) emitFloat(tag string, v reflect.Value) {
 	f := v.Float()
 
 	var s string
 	switch {
 	case math.IsNaN(f):
-		s = ".nan"
+		s = e.floats.special(".nan", ".NaN", ".NAN")
 	case math.IsInf(f, 1):
-		s = "+.inf"
+		s = e.floats.special(".inf", ".Inf", ".INF")
+		if !e.floats.UnsignedInf {
+			s = "+" + s
+		}
 	case math.IsInf(f, -1):
-		s = "-.inf"
+		s = "-" + e.floats.special(".inf", ".Inf", ".INF")
 	default:
-		s = strconv.FormatFloat(f, 'g', -1, v.Type().Bits())
+		s = formatFloat(f, v.Type().Bits(), e.floats)
 	}
 
 	e.emitScalar(s, "", tag, yaml_PLAIN_SCALAR_STYLE)
 }
 
+// special returns the form of a special float for the case format selects.
+func (format FloatFormat) special(lower, title, upper string) string {
+	switch format.SpecialCase {
+	case TitleCase:
+		return title
+	case UpperCase:
+		return upper
+	}
+	return lower
+}
+
+func formatFloat(f float64, bits int, format FloatFormat) string {
+	s := strconv.FormatFloat(f, format.Format, format.Precision, bits)
+	if format.DecimalPoint && !strings.Contains(s, ".") {
+		i := strings.IndexAny(s, "eE")
+		if i < 0 {
+			i = len(s)
+		}
+		s = s[:i] + ".0" + s[i:]
+	}
+	return s
+}
+
 func (e *Encoder) emitNil() {
 	e.emitScalar("null", "", "", yaml_PLAIN_SCALAR_STYLE)
 }
@@ -275,5 +1065,22 @@ func (e *Encoder) emitScalar(value, anchor, tag string, style yaml_scalar_style_
 	}
 
 	yaml_scalar_event_initialize(&e.event, []byte(anchor), []byte(tag), []byte(value), implicit, implicit, style)
+	if e.comment != "" {
+		e.event.head_comment = fieldComment(e.comment)
+		e.comment = ""
+	}
 	e.emit()
 }
+
+// fieldComment returns the comment for a struct field as comment lines.
+func fieldComment(text string) []byte {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "#"
+		} else {
+			lines[i] = "# " + line
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
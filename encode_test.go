@@ -2,9 +2,13 @@ package candiedyaml
 
 import (
 	"bytes"
+	"fmt"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io"
 	"math"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -57,6 +61,50 @@ var _ = Describe("Encode", func() {
 				enc.Encode(math.Inf(-1))
 				Ω(buf.String()).Should(Equal("-.inf\n"))
 			})
+
+			It("uses the float format", func() {
+				v := []interface{}{1.0, 0.5, float32(0.1), 1.2e23, math.Inf(1)}
+
+				enc.Encode(v)
+				Ω(buf.String()).Should(Equal("- 1\n- 0.5\n- 0.1\n- 1.2e+23\n- +.inf\n"))
+
+				for _, f := range []struct {
+					format   FloatFormat
+					expected string
+				}{
+					{FloatFormat{Format: 'g', Precision: -1, DecimalPoint: true}, "- 1.0\n- 0.5\n- 0.1\n- 1.2e+23\n- +.inf\n"},
+					{FloatFormat{Format: 'f', Precision: 2}, "- 1.00\n- 0.50\n- 0.10\n- 120000000000000000000000.00\n- +.inf\n"},
+					{FloatFormat{Format: 'e', Precision: -1, DecimalPoint: true}, "- 1.0e+00\n- 5.0e-01\n- 1.0e-01\n- 1.2e+23\n- +.inf\n"},
+				} {
+					buf.Reset()
+					enc = NewEncoder(buf)
+					enc.SetFloatFormat(f.format)
+					enc.Encode(v)
+					Ω(buf.String()).Should(Equal(f.expected))
+				}
+			})
+
+			It("capitalizes NaN and the infinities as asked", func() {
+				v := []float64{math.NaN(), math.Inf(1), math.Inf(-1)}
+				for _, f := range []struct {
+					format   FloatFormat
+					expected string
+				}{
+					{FloatFormat{Format: 'g', Precision: -1, SpecialCase: TitleCase}, "- .NaN\n- +.Inf\n- -.Inf\n"},
+					{FloatFormat{Format: 'g', Precision: -1, SpecialCase: UpperCase, UnsignedInf: true}, "- .NAN\n- .INF\n- -.INF\n"},
+				} {
+					buf.Reset()
+					enc = NewEncoder(buf)
+					enc.SetFloatFormat(f.format)
+					Ω(enc.Encode(v)).Should(Succeed())
+					Ω(buf.String()).Should(Equal(f.expected))
+
+					var back []float64
+					Ω(Unmarshal(buf.Bytes(), &back)).Should(Succeed())
+					Ω(math.IsNaN(back[0])).Should(BeTrue())
+					Ω(back[1:]).Should(Equal(v[1:]))
+				}
+			})
 		})
 
 		It("handles bools", func() {
@@ -70,9 +118,20 @@ var _ = Describe("Encode", func() {
 			Ω(buf.String()).Should(Equal(t.Format(time.RFC3339) + "\n"))
 		})
 
+		It("handles types defined as time.Time", func() {
+			type Date time.Time
+			enc.Encode(Date(time.Date(2001, 12, 14, 0, 0, 0, 0, time.UTC)))
+			Ω(buf.String()).Should(Equal("2001-12-14T00:00:00Z\n"))
+		})
+
 		Context("Null", func() {
-			It("fails on nil", func() {
-				Ω(enc.Encode(nil)).Should(HaveOccurred())
+			It("writes nil as null", func() {
+				Ω(enc.Encode(nil)).Should(Succeed())
+				Ω(buf.String()).Should(Equal("null\n"))
+
+				data, err := Marshal(nil)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(data)).Should(Equal("null\n"))
 			})
 		})
 
@@ -337,6 +396,397 @@ var _ = Describe("Encode", func() {
 		})
 	})
 
+	Context("Named types", func() {
+		It("sorts map keys by value", func() {
+			type port uint16
+			enc.Encode(map[port]int{443: 1, 80: 2, 8080: 3})
+			Ω(buf.String()).Should(Equal("80: 2\n443: 1\n8080: 3\n"))
+		})
+
+		It("sorts keys of mixed kinds", func() {
+			enc.Encode(map[interface{}]int{"b": 1, 10: 2, "a": 3, 9: 4, true: 5})
+			Ω(buf.String()).Should(Equal("true: 5\n9: 4\n10: 2\n\"a\": 3\n\"b\": 1\n"))
+		})
+
+		It("writes named byte slices as binary", func() {
+			type blob []byte
+			enc.Encode(blob("hi"))
+			Ω(buf.String()).Should(Equal("!!binary aGk=\n"))
+		})
+	})
+
+	Context("Stringers", func() {
+		It("writes values with a String method as strings", func() {
+			ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			enc.SetSchema(CoreSchema)
+			enc.UseStringer(true)
+			Ω(enc.Encode(map[color]interface{}{
+				red:  []color{green, red},
+				blue: &ts,
+				3:    ts,
+			})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`red:
+- green
+- red
+blue: 2024-01-02T03:04:05Z
+color(3): 2024-01-02T03:04:05Z
+`))
+		})
+
+		It("ignores String methods by default", func() {
+			Ω(enc.Encode([]color{green})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("- 1\n"))
+		})
+	})
+
+	Context("Compact", func() {
+		It("flows collections that fit", func() {
+			type server struct {
+				Host  string
+				Ports []int
+				Tags  map[string]string
+			}
+
+			enc.SetSchema(CoreSchema)
+			enc.SetCompact(20)
+			Ω(enc.Encode(map[string]interface{}{
+				"servers": []server{
+					{Host: "a", Ports: []int{80, 443}},
+					{Host: "example.com", Ports: []int{8080}, Tags: map[string]string{"env": "production"}},
+				},
+			})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`servers:
+- Host: a
+  Ports: [80, 443]
+  Tags: {}
+- Host: example.com
+  Ports: [8080]
+  Tags: {env: production}
+`))
+		})
+
+		It("measures collections as they are written", func() {
+			enc.SetSchema(CoreSchema)
+			enc.UseStringer(true)
+			enc.SetCompact(16)
+			Ω(enc.Encode(map[string][]color{"a": {red}, "b": {red, green, blue}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("a: [red]\nb:\n- red\n- green\n- blue\n"))
+		})
+
+		It("keeps the document root in block style", func() {
+			enc.SetCompact(80)
+			Ω(enc.Encode([]int{1, 2})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("- 1\n- 2\n"))
+		})
+	})
+
+	Context("Minify", func() {
+		It("writes each document on one line", func() {
+			type service struct {
+				Name  string            `yaml:"name" yamlcomment:"name of the service"`
+				Ports []int             `yaml:"ports"`
+				Env   map[string]string `yaml:"env"`
+				Notes string            `yaml:"notes"`
+			}
+
+			enc.SetSchema(CoreSchema)
+			enc.SetMinify(true)
+			Ω(enc.Encode(service{
+				Name:  "web",
+				Ports: []int{80, 443},
+				Env:   map[string]string{"mode": "production"},
+				Notes: "first\nsecond\n",
+			})).Should(Succeed())
+			Ω(enc.Encode([]string{})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(
+				"{name: web,ports: [80,443],env: {mode: production},notes: \"first\\nsecond\\n\"}\n--- []\n"))
+
+			var v []interface{}
+			d := NewDecoder(buf)
+			for {
+				var doc interface{}
+				if err := d.Decode(&doc); err != nil {
+					Ω(err).Should(Equal(io.EOF))
+					break
+				}
+				v = append(v, doc)
+			}
+			Ω(v).Should(HaveLen(2))
+			Ω(v[0]).Should(HaveKeyWithValue("notes", "first\nsecond\n"))
+		})
+
+		It("drops comments and block styles from nodes", func() {
+			var n Node
+			Ω(Unmarshal([]byte("# about\na: |\n  text\nb: [1,   2] # two\n"), &n)).Should(Succeed())
+
+			enc.SetMinify(true)
+			Ω(enc.Encode(&n)).Should(Succeed())
+			Ω(buf.String()).Should(Equal("{a: \"text\\n\",b: [1,2]}\n"))
+		})
+	})
+
+	Context("Align", func() {
+		It("lines up the values of each mapping", func() {
+			enc.SetAlign(true)
+			Ω(enc.Encode(MapSlice{
+				{"name", "web"},
+				{"replicas", 2},
+				{"ports", []int{80}},
+				{"env", MapSlice{{"a", 1}, {"long_name", MapSlice{}}}},
+			})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`"name":     "web"
+"replicas": 2
+"ports":
+- 80
+"env":
+  "a":         1
+  "long_name": {}
+`))
+		})
+
+		It("leaves keys that are not simple alone", func() {
+			var n Node
+			Ω(Unmarshal([]byte("a: 1\n? [b, c]\n: 2\ndd: 3\n"), &n)).Should(Succeed())
+			enc.SetAlign(true)
+			Ω(enc.Encode(&n)).Should(Succeed())
+			Ω(buf.String()).Should(Equal("a:  1\n? [b, c]\n: 2\ndd: 3\n"))
+		})
+
+		It("measures each mapping of each document on its own", func() {
+			var n Node
+			Ω(Unmarshal([]byte("- a: 1\n  bbb:\n    c: 2\n    dd: 3\n- eeee: 4\n  f: 5\n"), &n)).Should(Succeed())
+			enc.SetAlign(true)
+			Ω(enc.Encode(&n)).Should(Succeed())
+			Ω(enc.Encode(MapSlice{{"g", 6}, {"hh", 7}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`- a:   1
+  bbb:
+    c:  2
+    dd: 3
+- eeee: 4
+  f:    5
+---
+"g":  6
+"hh": 7
+`))
+		})
+	})
+
+	Context("SeparateTopLevel", func() {
+		It("puts blank lines between the entries of the root mapping", func() {
+			enc.SeparateTopLevel(true)
+			Ω(enc.Encode(MapSlice{{"a", 1}, {"b", MapSlice{{"c", 2}, {"d", 3}}}, {"e", []int{4, 5}}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`"a": 1
+
+"b":
+  "c": 2
+  "d": 3
+
+"e":
+- 4
+- 5
+`))
+		})
+
+		It("puts blank lines between the items of the root sequence", func() {
+			enc.SeparateTopLevel(true)
+			Ω(enc.Encode([]interface{}{MapSlice{{"name", "x"}, {"port", 1}}, MapSlice{{"name", "y"}}, "z"})).Should(Succeed())
+			Ω(enc.Encode("scalar")).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`- "name": "x"
+  "port": 1
+
+- "name": "y"
+
+- "z"
+--- "scalar"
+`))
+		})
+	})
+
+	Context("Folding long strings", func() {
+		long := "a sentence that goes on well past the width of the line it is written on"
+
+		It("writes long strings as folded scalars", func() {
+			enc.SetWidth(40)
+			enc.FoldLongStrings(true)
+			Ω(enc.Encode(MapSlice{{"long", long}, {"short", "a b"}, {"word", strings.Repeat("x", 50)}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`"long": >-
+  a sentence that goes on well past the width
+  of the line it is written on
+"short": "a b"
+"word": "` + strings.Repeat("x", 50) + `"
+`))
+
+			var v map[string]string
+			Ω(Unmarshal(buf.Bytes(), &v)).Should(Succeed())
+			Ω(v["long"]).Should(Equal(long))
+		})
+	})
+
+	Context("Escaping", func() {
+		It("writes ASCII only by default", func() {
+			Ω(enc.Encode([]string{"日本", "héllo"})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("- \"\\u65E5\\u672C\"\n- \"h\\xE9llo\"\n"))
+		})
+
+		It("writes UTF-8 with SetUnicode", func() {
+			enc.SetUnicode(true)
+			enc.SetSchema(CoreSchema)
+			Ω(enc.Encode(map[string]string{"日本": "héllo"})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("日本: héllo\n"))
+		})
+
+		It("escapes the runes given to EscapeRunes", func() {
+			enc.SetUnicode(true)
+			enc.SetSchema(CoreSchema)
+			enc.EscapeRunes('\u200b', '\u202e')
+			Ω(enc.Encode([]string{"zero\u200bwidth", "\u202eevil", "日本"})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("- \"zero\\u200Bwidth\"\n- \"\\u202Eevil\"\n- 日本\n"))
+
+			var v []string
+			Ω(Unmarshal(buf.Bytes(), &v)).Should(Succeed())
+			Ω(v).Should(Equal([]string{"zero\u200bwidth", "\u202eevil", "日本"}))
+		})
+	})
+
+	Context("Key quoting", func() {
+		keys := MapSlice{{"name", "a"}, {"on", "b"}, {"1.0", "c"}, {"a: b", "d"}, {"#x", "e"}, {"", "f"}}
+
+		It("quotes keys like values by default", func() {
+			Ω(enc.Encode(MapSlice{{"name", "a"}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("\"name\": \"a\"\n"))
+		})
+
+		It("quotes keys only when needed", func() {
+			enc.QuoteKeys(QuoteKeysWhenNeeded)
+			Ω(enc.Encode(keys)).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`name: "a"
+"on": "b"
+"1.0": "c"
+"a: b": "d"
+"#x": "e"
+"": "f"
+`))
+
+			var back MapSlice
+			Ω(Unmarshal(buf.Bytes(), &back)).Should(Succeed())
+			Ω(back[1].Key).Should(Equal("on"))
+		})
+
+		It("quotes every key", func() {
+			enc.SetSchema(CoreSchema)
+			enc.QuoteKeys(QuoteKeysAlways)
+			Ω(enc.Encode(struct{ Name string }{"a"})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("\"Name\": a\n"))
+		})
+
+		It("quotes keys only where YAML requires it", func() {
+			enc.QuoteKeys(QuoteKeysNever)
+			Ω(enc.Encode(keys)).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`name: "a"
+on: "b"
+1.0: "c"
+'a: b': "d"
+'#x': "e"
+'': "f"
+`))
+		})
+
+		It("applies to string keys at every level and not to values", func() {
+			enc.QuoteKeys(QuoteKeysWhenNeeded)
+			Ω(enc.Encode(map[interface{}]int{1: 2})).Should(Succeed())
+			Ω(enc.Encode(MapSlice{{"k", MapSlice{{"a", "b"}}}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("1: 2\n---\nk:\n  a: \"b\"\n"))
+		})
+	})
+
+	Context("Comments", func() {
+		It("writes comments from struct tags", func() {
+			type listener struct {
+				Host string `yaml:"host"`
+				Port int    `yaml:"port" yamlcomment:"port the server listens on"`
+			}
+			type config struct {
+				Name      string     `yaml:"name" yamlcomment:"name of the service\n\nmust be unique"`
+				Listeners []listener `yaml:"listeners"`
+			}
+
+			enc.SetSchema(CoreSchema)
+			Ω(enc.Encode(config{
+				Name:      "web",
+				Listeners: []listener{{Host: "a", Port: 80}},
+			})).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`# name of the service
+#
+# must be unique
+name: web
+listeners:
+- host: a
+  # port the server listens on
+  port: 80
+`))
+
+			var c config
+			Ω(Unmarshal(buf.Bytes(), &c)).Should(Succeed())
+			Ω(c.Listeners[0].Port).Should(Equal(80))
+		})
+
+		It("writes comments from a Commenter", func() {
+			enc.SetSchema(CoreSchema)
+			Ω(enc.Encode([]commented{{A: 1, B: 2}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("- # about A\n  A: 1\n  # about B\n  B: 2\n"))
+		})
+
+		It("leaves comments out of flow style", func() {
+			type o struct {
+				C commented `yaml:"c,flow"`
+			}
+			enc.SetSchema(CoreSchema)
+			Ω(enc.Encode(o{C: commented{A: 1, B: 2}})).Should(Succeed())
+			Ω(buf.String()).Should(Equal("c: {A: 1, B: 2}\n"))
+		})
+	})
+
+	Context("Integer formats", func() {
+		type perms struct {
+			Mode  os.FileMode `yaml:"mode,octal"`
+			Mask  uint8       `yaml:"mask,hex"`
+			Flags []int       `yaml:"flags,binary,flow"`
+			Size  int64       `yaml:"size,separated"`
+			Addr  uint32      `yaml:"addr,hex,separated"`
+			Plain int         `yaml:"plain"`
+		}
+
+		It("writes integers in the radix of the field", func() {
+			v := perms{
+				Mode:  0644,
+				Mask:  0xff,
+				Flags: []int{5, -2, 0},
+				Size:  -1234567,
+				Addr:  0xdeadbeef,
+				Plain: 10,
+			}
+			enc.Encode(v)
+			Ω(buf.String()).Should(Equal(`"mode": 0644
+"mask": 0xff
+"flags": [0b101, -0b10, 0b0]
+"size": -1_234_567
+"addr": 0xdead_beef
+"plain": 10
+`))
+
+			var decoded perms
+			Ω(Unmarshal(buf.Bytes(), &decoded)).Should(Succeed())
+			Ω(decoded).Should(Equal(v))
+		})
+
+		It("writes zero without an octal prefix", func() {
+			enc.Encode(perms{})
+			Ω(buf.String()).Should(HavePrefix(`"mode": 0
+"mask": 0x0
+`))
+		})
+	})
+
 	Context("Omit empty", func() {
 		It("omits nil ptrs", func() {
 			type i struct {
@@ -367,5 +817,167 @@ var _ = Describe("Encode", func() {
 `))
 		})
 	})
+	Context("Multiple documents", func() {
+		It("separates the documents written by each call", func() {
+			enc.Encode(map[string]int{"a": 1})
+			enc.Encode("b")
+			Ω(buf.String()).Should(Equal(`"a": 1
+--- "b"
+`))
+		})
 
+		It("keeps returning the error that left a document unfinished", func() {
+			err := enc.Encode(map[string]interface{}{"f": func() {}})
+			Ω(err).Should(HaveOccurred())
+			Ω(enc.Encode(1)).Should(Equal(err))
+
+			buf.Reset()
+			enc = NewEncoder(buf)
+			err = enc.Encode([]string{"a", "\xff"})
+			Ω(err).Should(HaveOccurred())
+			Ω(enc.Encode("b")).Should(Equal(err))
+			Ω(buf.String()).ShouldNot(ContainSubstring("b"))
+		})
+
+		It("marshals a slice of documents", func() {
+			data, err := MarshalAll([]interface{}{1, []int{2}})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(data)).Should(Equal("1\n---\n- 2\n"))
+
+			var v []interface{}
+			Ω(UnmarshalAll(data, &v)).Should(Succeed())
+			Ω(v).Should(Equal([]interface{}{int64(1), []interface{}{int64(2)}}))
+
+			_, err = MarshalAll(1)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("marshals a single document", func() {
+			data, err := Marshal(map[string]int{"a": 1})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(data)).Should(Equal("\"a\": 1\n"))
+		})
+	})
+	Context("Tag handles", func() {
+		It("writes tags with a declared handle in shorthand form", func() {
+			var n Node
+			Ω(Unmarshal([]byte("--- !<tag:example.com,2024:Deployment>\nspec: !<tag:example.com,2024:Spec> {a: 1}\n"), &n)).Should(Succeed())
+
+			Ω(enc.SetTagHandle("!k!", "tag:example.com,2024:")).Should(Succeed())
+			Ω(enc.Encode(&n)).Should(Succeed())
+			Ω(enc.Encode(&n)).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`%TAG !k! tag:example.com,2024:
+--- !k!Deployment
+spec: !k!Spec {a: 1}
+...
+%TAG !k! tag:example.com,2024:
+--- !k!Deployment
+spec: !k!Spec {a: 1}
+`))
+
+			var docs []Node
+			Ω(UnmarshalAll(buf.Bytes(), &docs)).Should(Succeed())
+			Ω(docs).Should(HaveLen(2))
+			Ω(docs[1].Content[0].Tag).Should(Equal("tag:example.com,2024:Deployment"))
+		})
+
+		It("rejects invalid handles", func() {
+			Ω(enc.SetTagHandle("k!", "tag:example.com,2024:")).Should(MatchError("yaml: tag handle must start with '!'"))
+			Ω(enc.SetTagHandle("!k", "tag:example.com,2024:")).Should(MatchError("yaml: tag handle must end with '!'"))
+			Ω(enc.SetTagHandle("!k!", "")).Should(MatchError("yaml: tag prefix must not be empty"))
+		})
+	})
+
+	Context("Aliases", func() {
+		type server struct {
+			Host string
+			Port int
+		}
+
+		It("writes registered values once and aliases them after", func() {
+			db := &server{Host: "db", Port: 5432}
+			tags := []string{"a", "b"}
+			Ω(enc.Alias("db", db)).Should(Succeed())
+			Ω(enc.Alias("tags", tags)).Should(Succeed())
+
+			v := MapSlice{{"primary", db}, {"replica", db}, {"copy", *db}, {"tags", tags}, {"more", tags}}
+			Ω(enc.Encode(v)).Should(Succeed())
+			Ω(enc.Encode(v)).Should(Succeed())
+			Ω(buf.String()).Should(Equal(`"primary": &db
+  "Host": "db"
+  "Port": 5432
+"replica": *db
+"copy":
+  "Host": "db"
+  "Port": 5432
+"tags": &tags
+- "a"
+- "b"
+"more": *tags
+---
+"primary": &db
+  "Host": "db"
+  "Port": 5432
+"replica": *db
+"copy":
+  "Host": "db"
+  "Port": 5432
+"tags": &tags
+- "a"
+- "b"
+"more": *tags
+`))
+
+			var decoded map[string]server
+			Ω(Unmarshal(buf.Bytes()[:bytes.Index(buf.Bytes(), []byte(`"tags"`))], &decoded)).Should(Succeed())
+			Ω(decoded["replica"]).Should(Equal(*db))
+		})
+
+		It("encodes values that refer to themselves", func() {
+			type node struct {
+				Name string
+				Next *node
+			}
+			n := &node{Name: "loop"}
+			n.Next = n
+			Ω(enc.Alias("loop", n)).Should(Succeed())
+			Ω(enc.Encode(n)).Should(Succeed())
+			Ω(buf.String()).Should(Equal("&loop\n\"Name\": \"loop\"\n\"Next\": *loop\n"))
+		})
+
+		It("rejects values without identity and bad names", func() {
+			Ω(enc.Alias("a", server{})).Should(MatchError("yaml: cannot alias candiedyaml.server, only a non-nil pointer, map or slice"))
+			Ω(enc.Alias("a", (*server)(nil))).Should(HaveOccurred())
+			Ω(enc.Alias("a b", &server{})).Should(MatchError("yaml: anchor value must contain alphanumerical characters only"))
+		})
+	})
 })
+
+type commented struct {
+	A int
+	B int `yamlcomment:"replaced"`
+}
+
+func (c commented) YAMLComment(field string) string {
+	return "about " + field
+}
+
+type color int
+
+const (
+	red color = iota
+	green
+	blue
+)
+
+func (c color) String() string {
+	switch c {
+	case red:
+		return "red"
+	case green:
+		return "green"
+	case blue:
+		return "blue"
+	}
+	return fmt.Sprintf("color(%d)", int(c))
+}
@@ -0,0 +1,268 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors that can be matched with errors.Is against the errors
+// returned by Decoders and Encoders.
+var (
+	// ErrUnexpectedEOF means the input ended in the middle of a document.
+	ErrUnexpectedEOF = errors.New("yaml: unexpected end of stream")
+	// ErrInvalidIndentation means a block node was not indented correctly.
+	ErrInvalidIndentation = errors.New("yaml: invalid indentation")
+	// ErrInvalidEncoding means the input could not be decoded as UTF-8 or UTF-16.
+	ErrInvalidEncoding = errors.New("yaml: invalid encoding")
+	// ErrSyntax means the input is not well-formed YAML.
+	ErrSyntax = errors.New("yaml: syntax error")
+	// ErrDuplicateKey means a mapping contained the same key more than once.
+	ErrDuplicateKey = errors.New("yaml: duplicate key")
+	// ErrLimitExceeded means the input went past a limit set on the Decoder.
+	ErrLimitExceeded = errors.New("yaml: limit exceeded")
+	// ErrUnknownAnchor means an alias referred to an anchor that was not
+	// defined before it.
+	ErrUnknownAnchor = errors.New("yaml: unknown anchor")
+	// ErrRecursiveAlias means an alias referred to a collection it is part
+	// of, and the value being decoded into cannot hold a cycle.
+	ErrRecursiveAlias = errors.New("yaml: recursive alias")
+	// ErrAmbiguousField means a key matched a field name promoted from more
+	// than one embedded struct.
+	ErrAmbiguousField = errors.New("yaml: ambiguous field")
+	// ErrInvalidUTF8 means an Encoder was given a string that is not valid
+	// UTF-8.
+	ErrInvalidUTF8 = errors.New("yaml: invalid UTF-8")
+	// ErrUnknownField means a key matched no field of the struct being
+	// decoded into.
+	ErrUnknownField = errors.New("yaml: unknown field")
+	// ErrTrailingContent means Unmarshal was given more than one document.
+	ErrTrailingContent = errors.New("yaml: trailing content")
+	// ErrUnclosedFrontMatter means a front matter block had no closing
+	// '---' line.
+	ErrUnclosedFrontMatter = errors.New("yaml: unclosed front matter")
+	// ErrConstraint means a value broke the min, max, pattern or enum
+	// option of the struct field it was decoded into.
+	ErrConstraint = errors.New("yaml: constraint violated")
+	// ErrUnsupportedMediaType means NewHTTPDecoder was given a request
+	// whose body is not YAML.
+	ErrUnsupportedMediaType = errors.New("yaml: unsupported media type")
+)
+
+// Line returns the line of the position, counting from 1 as Node does.
+func (m YAML_mark_t) Line() int {
+	return m.line + 1
+}
+
+// Column returns the column of the position, counting from 1.
+func (m YAML_mark_t) Column() int {
+	return m.column + 1
+}
+
+// TrailingContentError is returned by Unmarshal when the document it decoded
+// is followed by another, which starts at At.
+type TrailingContentError struct {
+	At YAML_mark_t
+}
+
+func (e *TrailingContentError) Error() string {
+	return fmt.Sprintf("yaml: trailing content after the document at line %d, column %d", e.At.Line(), e.At.Column())
+}
+
+func (e *TrailingContentError) Unwrap() error {
+	return ErrTrailingContent
+}
+
+// DuplicateKeyError is returned when a Decoder that rejects duplicate keys
+// finds a key that was already seen in the same mapping.
+type DuplicateKeyError struct {
+	Key string
+	At  YAML_mark_t
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("yaml: duplicate key '%s' at line %d, column %d", e.Key, e.At.line+1, e.At.column+1)
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return ErrDuplicateKey
+}
+
+// AmbiguousFieldError is returned when a Decoder that rejects ambiguous
+// fields finds a key matching a name that more than one embedded struct of
+// Type promotes.
+type AmbiguousFieldError struct {
+	Key  string
+	Type reflect.Type
+	At   YAML_mark_t
+}
+
+func (e *AmbiguousFieldError) Error() string {
+	return fmt.Sprintf("yaml: ambiguous field '%s' in %s at line %d, column %d", e.Key, e.Type, e.At.line+1, e.At.column+1)
+}
+
+func (e *AmbiguousFieldError) Unwrap() error {
+	return ErrAmbiguousField
+}
+
+// UnknownFieldError is returned when a Decoder that rejects unknown fields
+// finds a key that matches no field of Type.
+type UnknownFieldError struct {
+	Key  string
+	Type reflect.Type
+	At   YAML_mark_t
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("yaml: unknown field '%s' in %s at line %d, column %d", e.Key, e.Type, e.At.line+1, e.At.column+1)
+}
+
+func (e *UnknownFieldError) Unwrap() error {
+	return ErrUnknownField
+}
+
+// ConstraintError is returned when the value at At breaks the min, max,
+// pattern or enum tag option of the field Field of Type, as Problem says.
+type ConstraintError struct {
+	Field   string
+	Type    reflect.Type
+	Problem string
+	At      YAML_mark_t
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("yaml: field '%s' of %s: %s at line %d, column %d", e.Field, e.Type, e.Problem, e.At.line+1, e.At.column+1)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return ErrConstraint
+}
+
+// UnknownAnchorError is returned when an alias refers to an anchor that was
+// not defined earlier in the document.
+type UnknownAnchorError struct {
+	Anchor string
+	At     YAML_mark_t
+}
+
+func (e *UnknownAnchorError) Error() string {
+	return fmt.Sprintf("yaml: unknown anchor '%s' referenced at line %d, column %d", e.Anchor, e.At.line+1, e.At.column+1)
+}
+
+func (e *UnknownAnchorError) Unwrap() error {
+	return ErrUnknownAnchor
+}
+
+// RecursiveAliasError is returned when an alias inside an anchored
+// collection refers back to that collection and is decoded into a value of
+// a type other than interface{}.
+type RecursiveAliasError struct {
+	Anchor string
+	Type   reflect.Type
+	At     YAML_mark_t
+}
+
+func (e *RecursiveAliasError) Error() string {
+	return fmt.Sprintf("yaml: cannot represent recursive alias '%s' in type %s at line %d, column %d", e.Anchor, e.Type, e.At.line+1, e.At.column+1)
+}
+
+func (e *RecursiveAliasError) Unwrap() error {
+	return ErrRecursiveAlias
+}
+
+// parserErrorCause classifies the error recorded on the parser so that the
+// resulting ParserError can be matched against the sentinel errors.
+func parserErrorCause(parser *yaml_parser_t) error {
+	switch parser.error {
+	case yaml_READER_ERROR:
+		if parser.read_error != nil {
+			return parser.read_error
+		}
+		return ErrInvalidEncoding
+	case yaml_SCANNER_ERROR, yaml_PARSER_ERROR:
+	default:
+		return nil
+	}
+
+	if parserAtEOF(parser) {
+		return ErrUnexpectedEOF
+	}
+
+	switch parser.problem {
+	case "found a tab character where an intendation space is expected",
+		"found a tab character that violate intendation",
+		"found an intendation indicator equal to 0",
+		"did not find expected key",
+		"did not find expected '-' indicator":
+		return ErrInvalidIndentation
+	}
+
+	return ErrSyntax
+}
+
+// parserAtEOF reports whether the error recorded on the parser was caused by
+// running out of input.
+func parserAtEOF(parser *yaml_parser_t) bool {
+	if !parser.eof {
+		return false
+	}
+
+	if parser.error == yaml_PARSER_ERROR {
+		return parser.tokens_head < len(parser.tokens) &&
+			parser.tokens[parser.tokens_head].token_type == yaml_STREAM_END_TOKEN
+	}
+
+	return parser.problem == "found unexpected end of stream" ||
+		(parser.buffer_pos < len(parser.buffer) && is_z(parser.buffer[parser.buffer_pos]))
+}
+
+// renderSnippet renders the source line containing mark with a caret below
+// the marked column, or returns "" when the line is no longer retained.
+func renderSnippet(parser *yaml_parser_t, mark YAML_mark_t) string {
+	line := mark.line - parser.history_line
+	if line < 0 || (line == 0 && parser.history_partial) {
+		return ""
+	}
+
+	source := make([]byte, 0, len(parser.history)+len(parser.buffer))
+	source = append(source, parser.history...)
+	source = append(source, parser.buffer...)
+
+	for ; line > 0; line-- {
+		i := bytes.IndexByte(source, '\n')
+		if i < 0 {
+			// The end of the stream sits on the line after an unterminated
+			// last line.
+			if line == 1 && bytes.IndexByte(source, 0) >= 0 {
+				source = nil
+				break
+			}
+			return ""
+		}
+		source = source[i+1:]
+	}
+
+	if i := bytes.IndexAny(source, "\r\n\x00"); i >= 0 {
+		source = source[:i]
+	}
+
+	// Pad with the same whitespace as the source so that tabs line up.
+	var pad bytes.Buffer
+	for i, r := range []rune(string(source)) {
+		if i >= mark.column {
+			break
+		}
+		if r == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+
+	number := strconv.Itoa(mark.line + 1)
+	gutter := strings.Repeat(" ", len(number))
+	return fmt.Sprintf("%s | %s\n%s | %s^\n", number, source, gutter, pad.String())
+}
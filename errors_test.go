@@ -0,0 +1,197 @@
+package candiedyaml
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+var _ = Describe("Errors", func() {
+	decode := func(data string) error {
+		var v interface{}
+		return Unmarshal([]byte(data), &v)
+	}
+
+	It("reports an unexpected end of stream", func() {
+		for _, data := range []string{"a: [1, 2", "a: 'abc", "a: {b"} {
+			err := decode(data)
+			Ω(errors.Is(err, ErrUnexpectedEOF)).Should(BeTrue(), data)
+		}
+	})
+
+	It("reports invalid indentation", func() {
+		for _, data := range []string{"a:\n  b: 1\n c: 2\n", "- a\nb: c\n"} {
+			err := decode(data)
+			Ω(errors.Is(err, ErrInvalidIndentation)).Should(BeTrue(), data)
+		}
+	})
+
+	It("reports other syntax errors", func() {
+		err := decode("a: b: c")
+		Ω(errors.Is(err, ErrSyntax)).Should(BeTrue())
+		Ω(errors.Is(err, ErrUnexpectedEOF)).Should(BeFalse())
+
+		var perr *ParserError
+		Ω(errors.As(err, &perr)).Should(BeTrue())
+		Ω(perr.Problem).Should(Equal("mapping values are not allowed in this context"))
+	})
+
+	It("reports invalid encodings", func() {
+		err := decode("\xff\xfe\x00")
+		Ω(errors.Is(err, ErrInvalidEncoding)).Should(BeTrue())
+	})
+
+	It("wraps errors from the reader", func() {
+		readErr := errors.New("disk on fire")
+		var v interface{}
+		err := NewDecoder(failingReader{readErr}).Decode(&v)
+		Ω(errors.Is(err, readErr)).Should(BeTrue())
+	})
+
+	Context("Duplicate keys", func() {
+		decodeStrict := func(data string, v interface{}) error {
+			d := NewDecoder(strings.NewReader(data))
+			d.RejectDuplicateKeys(true)
+			return d.Decode(v)
+		}
+
+		It("keeps the last value by default", func() {
+			v := map[string]int{}
+			err := Unmarshal([]byte("a: 1\na: 2\n"), &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[string]int{"a": 2}))
+		})
+
+		It("rejects duplicates in maps", func() {
+			v := map[string]int{}
+			err := decodeStrict("a: 1\nb: 2\na: 3\n", &v)
+			Ω(errors.Is(err, ErrDuplicateKey)).Should(BeTrue())
+
+			var derr *DuplicateKeyError
+			Ω(errors.As(err, &derr)).Should(BeTrue())
+			Ω(derr.Key).Should(Equal("a"))
+			Ω(err.Error()).Should(Equal("yaml: duplicate key 'a' at line 3, column 1"))
+		})
+
+		It("rejects duplicates in structs", func() {
+			var v struct{ A int }
+			err := decodeStrict("A: 1\nA: 2\n", &v)
+			Ω(errors.Is(err, ErrDuplicateKey)).Should(BeTrue())
+		})
+
+		It("rejects duplicates in interfaces", func() {
+			var v interface{}
+			err := decodeStrict("a: 1\nb:\n  c: 1\n  c: 2\n", &v)
+			Ω(errors.Is(err, ErrDuplicateKey)).Should(BeTrue())
+		})
+
+		It("allows keys already present in the target map", func() {
+			v := map[string]int{"a": 1}
+			err := decodeStrict("a: 2\n", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[string]int{"a": 2}))
+		})
+
+		It("compares keys with the function given", func() {
+			var v interface{}
+			d := NewDecoder(strings.NewReader("Name: a\nport: 1\nNAME: b\n"))
+			d.RejectDuplicateKeys(true)
+			d.SetKeyEqual(EqualFoldKeys)
+			err := d.Decode(&v)
+
+			var derr *DuplicateKeyError
+			Ω(errors.As(err, &derr)).Should(BeTrue())
+			Ω(derr.Key).Should(Equal("NAME"))
+			Ω(derr.At.line).Should(Equal(2))
+
+			err = decodeStrict("Name: a\nNAME: b\n", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+	Context("Trailing content", func() {
+		It("rejects a second document in Unmarshal", func() {
+			var v map[string]int
+			err := Unmarshal([]byte("a: 1\n---\nb: 2\n"), &v)
+			Ω(errors.Is(err, ErrTrailingContent)).Should(BeTrue())
+			Ω(err.Error()).Should(Equal("yaml: trailing content after the document at line 2, column 1"))
+			var terr *TrailingContentError
+			Ω(errors.As(err, &terr)).Should(BeTrue())
+			Ω([]int{terr.At.Line(), terr.At.Column()}).Should(Equal([]int{2, 1}))
+			Ω(v).Should(Equal(map[string]int{"a": 1}))
+		})
+
+		It("rejects content after a document end marker", func() {
+			var v interface{}
+			err := Unmarshal([]byte("a: 1\n...\nb: [\n"), &v)
+			Ω(errors.Is(err, ErrTrailingContent)).Should(BeTrue())
+		})
+
+		It("allows comments after the document", func() {
+			var v interface{}
+			Ω(Unmarshal([]byte("a: 1\n...\n# done\n"), &v)).Should(Succeed())
+		})
+
+		It("leaves the Decoder reading every document", func() {
+			d := NewDecoder(strings.NewReader("a: 1\n---\nb: 2\n"))
+			var v interface{}
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(d.Decode(&v)).Should(Succeed())
+		})
+	})
+	Context("Ambiguous fields", func() {
+		type A struct{ Name string }
+		type B struct{ Name string }
+
+		It("rejects keys matching ambiguous fields", func() {
+			var v struct {
+				A
+				B
+			}
+			d := NewDecoder(strings.NewReader("x: 1\nname: n\n"))
+			d.RejectAmbiguousFields(true)
+			err := d.Decode(&v)
+			Ω(errors.Is(err, ErrAmbiguousField)).Should(BeTrue())
+
+			var aerr *AmbiguousFieldError
+			Ω(errors.As(err, &aerr)).Should(BeTrue())
+			Ω(aerr.Key).Should(Equal("name"))
+			Ω(err.Error()).Should(HavePrefix("yaml: ambiguous field 'name' in struct"))
+			Ω(err.Error()).Should(HaveSuffix("at line 2, column 1"))
+		})
+	})
+
+	Context("Snippets", func() {
+		snippet := func(data string) string {
+			err := decode(data)
+			var perr *ParserError
+			Ω(errors.As(err, &perr)).Should(BeTrue())
+			return perr.Snippet()
+		}
+
+		It("points at the offending column", func() {
+			Ω(snippet("a:\n  b: 1\n c: 2\n")).Should(Equal("3 |  c: 2\n  |  ^\n"))
+		})
+
+		It("keeps tabs so the caret lines up", func() {
+			Ω(snippet("a: \t'b': c: d\n")).Should(Equal("1 | a: \t'b': c: d\n  |    \t   ^\n"))
+		})
+
+		It("renders lines far into the input", func() {
+			long := strings.Repeat("k: v\n", 2000)
+			Ω(snippet(long + "a: b: c\n" + long)).Should(Equal("2001 | a: b: c\n     |     ^\n"))
+		})
+
+		It("renders the end of the stream", func() {
+			Ω(snippet("a: [1, 2")).Should(Equal("2 | \n  | ^\n"))
+		})
+	})
+})
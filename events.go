@@ -0,0 +1,88 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// eventJSON is an event as EventsJSON writes it.
+type eventJSON struct {
+	Type     string       `json:"type"`
+	Value    *string      `json:"value,omitempty"`
+	Tag      string       `json:"tag,omitempty"`
+	Anchor   string       `json:"anchor,omitempty"`
+	Style    string       `json:"style,omitempty"`
+	Implicit *bool        `json:"implicit,omitempty"`
+	Start    positionJSON `json:"start"`
+	End      positionJSON `json:"end"`
+}
+
+// positionJSON is a position in the input, with the line and column
+// counting from 1 and the byte offset from 0.
+type positionJSON struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// EventsJSON parses data and returns the events the parser produces as a
+// JSON array, one object per event, for debugging and for comparing the
+// parser with others.  For example, "a: 1" starts with
+//
+//	{"type": "STREAM_START", "start": {"line": 1, "column": 1, "offset": 0}, ...}
+//
+// Each object has the type of the event, named as a Tracer names it, and
+// the positions where the event starts and ends.  Scalars add their value
+// and style, aliases the anchor they name, nodes their tag and anchor, and
+// collections their style, "block" or "flow".  Document starts and ends
+// report whether their '---' or '...' marker is implicit.
+//
+// If data does not parse, EventsJSON returns the events before the error
+// together with the error.
+func EventsJSON(data []byte) ([]byte, error) {
+	d := NewDecoder(bytes.NewReader(data))
+
+	events := []eventJSON{}
+	err := func() (err error) {
+		defer recoverError(&err)
+		for d.event.event_type != yaml_STREAM_END_EVENT {
+			d.nextEvent()
+			events = append(events, d.eventJSON())
+		}
+		return nil
+	}()
+
+	out, jerr := json.MarshalIndent(events, "", "  ")
+	if jerr != nil {
+		return nil, jerr
+	}
+	return append(out, '\n'), err
+}
+
+func (d *Decoder) eventJSON() eventJSON {
+	e := &d.event
+	ev := d.currentEvent()
+	j := eventJSON{
+		Type:   e.event_type.String(),
+		Tag:    ev.Tag,
+		Anchor: ev.Anchor,
+		Start:  positionJSON{e.start_mark.line + 1, e.start_mark.column + 1, e.start_mark.offset},
+		End:    positionJSON{e.end_mark.line + 1, e.end_mark.column + 1, e.end_mark.offset},
+	}
+
+	switch ev.Kind {
+	case ScalarEvent:
+		j.Value = &ev.Value
+		j.Style = ev.Style.String()
+	case AliasEvent:
+		j.Anchor = ev.Value
+	case SequenceStartEvent, MappingStartEvent:
+		j.Style = "block"
+		if ev.Style == FlowStyle {
+			j.Style = "flow"
+		}
+	case DocumentStartEvent, DocumentEndEvent:
+		j.Implicit = &ev.Implicit
+	}
+	return j
+}
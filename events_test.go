@@ -0,0 +1,64 @@
+package candiedyaml
+
+import (
+	"encoding/json"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventsJSON", func() {
+	It("lists the events of a stream", func() {
+		out, err := EventsJSON([]byte("a: &x 'b'\nc: [*x]\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var events []map[string]interface{}
+		Ω(json.Unmarshal(out, &events)).Should(Succeed())
+
+		var types []string
+		for _, ev := range events {
+			types = append(types, ev["type"].(string))
+		}
+		Ω(types).Should(Equal([]string{
+			"STREAM_START", "DOCUMENT_START", "MAPPING_START", "SCALAR", "SCALAR", "SCALAR",
+			"SEQUENCE_START", "ALIAS", "SEQUENCE_END", "MAPPING_END", "DOCUMENT_END", "STREAM_END",
+		}))
+
+		Ω(events[1]).Should(HaveKeyWithValue("implicit", true))
+		Ω(events[2]).Should(HaveKeyWithValue("style", "block"))
+		Ω(events[4]).Should(Equal(map[string]interface{}{
+			"type":   "SCALAR",
+			"value":  "b",
+			"anchor": "x",
+			"style":  "single-quoted",
+			"start":  map[string]interface{}{"line": 1.0, "column": 4.0, "offset": 3.0},
+			"end":    map[string]interface{}{"line": 1.0, "column": 10.0, "offset": 9.0},
+		}))
+		Ω(events[6]).Should(HaveKeyWithValue("style", "flow"))
+		Ω(events[7]).Should(HaveKeyWithValue("anchor", "x"))
+		Ω(events[7]).ShouldNot(HaveKey("value"))
+	})
+
+	It("keeps empty values and tags", func() {
+		out, err := EventsJSON([]byte("--- !!str\n...\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var events []map[string]interface{}
+		Ω(json.Unmarshal(out, &events)).Should(Succeed())
+		Ω(events[1]).Should(HaveKeyWithValue("implicit", false))
+		Ω(events[2]).Should(HaveKeyWithValue("value", ""))
+		Ω(events[2]).Should(HaveKeyWithValue("tag", StrTag))
+		Ω(events[3]).Should(HaveKeyWithValue("implicit", false))
+	})
+
+	It("returns the events before an error", func() {
+		out, err := EventsJSON([]byte("a: [b\n"))
+		var perr *ParserError
+		Ω(errors.As(err, &perr)).Should(BeTrue())
+
+		var events []map[string]interface{}
+		Ω(json.Unmarshal(out, &events)).Should(Succeed())
+		Ω(events).Should(HaveLen(6))
+		Ω(events[5]).Should(HaveKeyWithValue("value", "b"))
+	})
+})
@@ -0,0 +1,123 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+)
+
+// DecodeFields decodes the mapping document in data into the struct target
+// points to, as Unmarshal would, except that the value of each key goes to
+// the field at fields[key], an index path as reflect's FieldByIndex takes,
+// rather than to the field its name or struct tag matches.  Code generators
+// can work out the paths once and decode without the struct tags of the
+// target being looked at.  Values are decoded as usual, so structs below
+// the top level still use their tags.
+func DecodeFields(data []byte, target interface{}, fields map[string][]int, opts ...DecodeOption) error {
+	d := NewDecoder(bytes.NewReader(data))
+	for _, opt := range opts {
+		opt(d)
+	}
+	if err := d.DecodeFields(target, fields); err != nil {
+		return err
+	}
+	if d.event.event_type != yaml_STREAM_END_EVENT {
+		return &TrailingContentError{At: d.event.start_mark}
+	}
+	return nil
+}
+
+// DecodeFields reads the next document of the stream into the struct v
+// points to, putting the value of each key of a mapping into the field at
+// fields[key].  Keys fields has no path for are skipped with a warning, or
+// rejected with an UnknownFieldError if RejectUnknownFields is set.
+// Documents that are not mappings are decoded as Decode would decode them.
+// Everything else Decode does, such as preprocessing, Default and Validate
+// methods and stats, is done as for Decode.  It returns io.EOF once there
+// are no more documents.
+func (d *Decoder) DecodeFields(v interface{}, fields map[string][]int) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeFields: not a pointer to a struct: %T", v)
+	}
+	for key, index := range fields {
+		if err := checkFieldIndex(rv.Elem().Type(), index); err != nil {
+			return fmt.Errorf("DecodeFields: key '%s': %v", key, err)
+		}
+	}
+
+	if fields == nil {
+		fields = map[string][]int{}
+	}
+	d.indexes = fields
+	defer func() { d.indexes = nil }()
+	return d.Decode(v)
+}
+
+// checkFieldIndex returns an error if index is not the path of an exported
+// field of the struct type t, through embedded structs and pointers to them.
+func checkFieldIndex(t reflect.Type, index []int) error {
+	if len(index) == 0 {
+		return fmt.Errorf("empty index path")
+	}
+	for _, i := range index {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || i < 0 || i >= t.NumField() {
+			return fmt.Errorf("no field %v in %s", index, t)
+		}
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			return fmt.Errorf("field %s of %s is not exported", f.Name, t)
+		}
+		t = f.Type
+	}
+	return nil
+}
+
+// mappingFields decodes the mapping at the current event into the struct
+// v by the index paths in fields.
+func (d *Decoder) mappingFields(v reflect.Value, fields map[string][]int) {
+	structt := v.Type()
+	anchor := string(d.event.anchor)
+	d.enter()
+	d.openAnchor(anchor)
+	d.nextEvent()
+
+	seen := make(map[interface{}]bool)
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		mark := d.event.start_mark
+		key := ""
+		d.parse(reflect.ValueOf(&key))
+		d.checkDuplicate(seen, key, mark)
+
+		index, ok := fields[key]
+		if !ok {
+			if d.rejectUnknown {
+				d.error(&UnknownFieldError{Key: key, Type: structt, At: mark})
+			}
+			d.warn(mark, "unknown field '%s' in %s", key, structt)
+			d.parse(reflect.Value{})
+			continue
+		}
+
+		subv := v
+		for _, i := range index {
+			if subv.Kind() == reflect.Ptr {
+				if subv.IsNil() {
+					subv.Set(reflect.New(subv.Type().Elem()))
+				}
+				subv = subv.Elem()
+			}
+			subv = subv.Field(i)
+		}
+		d.enterField(structt.FieldByIndex(index).Name, true)
+		d.parse(subv)
+		d.leaveField()
+	}
+
+	d.leave()
+	d.nextEvent()
+	d.anchor(anchor, v)
+}
@@ -0,0 +1,120 @@
+package candiedyaml
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodeFields", func() {
+	type Meta struct {
+		Owner string
+	}
+	type limits struct {
+		CPU string `yaml:"cpu"`
+	}
+	type job struct {
+		*Meta
+		Name    string `yaml:"title"`
+		Retries int
+		Limits  limits
+		Tags    []string
+	}
+	fields := map[string][]int{
+		"name":    {1},
+		"retries": {2},
+		"limits":  {3},
+		"tags":    {4},
+		"owner":   {0, 0},
+	}
+
+	It("puts each value in the field at its index path", func() {
+		var j job
+		Ω(DecodeFields([]byte(`name: build
+retries: 3
+limits: {cpu: 500m}
+tags: [ci, nightly]
+owner: ops
+`), &j, fields)).Should(Succeed())
+		Ω(j).Should(Equal(job{
+			Meta:    &Meta{Owner: "ops"},
+			Name:    "build",
+			Retries: 3,
+			Limits:  limits{CPU: "500m"},
+			Tags:    []string{"ci", "nightly"},
+		}))
+	})
+
+	It("does not look at the names and tags of the fields", func() {
+		var j job
+		Ω(DecodeFields([]byte("title: x\nRetries: 2\n"), &j, fields)).Should(Succeed())
+		Ω(j).Should(Equal(job{}))
+	})
+
+	It("skips unknown keys with a warning, or rejects them", func() {
+		d := NewDecoder(strings.NewReader("name: a\nextra: 1\n"))
+		var j job
+		Ω(d.DecodeFields(&j, fields)).Should(Succeed())
+		Ω(j.Name).Should(Equal("a"))
+		Ω(d.Warnings()).Should(HaveLen(1))
+		Ω(d.Warnings()[0].Message).Should(ContainSubstring("unknown field 'extra'"))
+
+		err := DecodeFields([]byte("name: a\nextra: 1\n"), &j, fields, Strict())
+		Ω(errors.Is(err, ErrUnknownField)).Should(BeTrue())
+	})
+
+	It("rejects index paths that lead to no field", func() {
+		var j job
+		for _, index := range [][]int{{}, {9}, {2, 0}, {0, 5}} {
+			err := DecodeFields([]byte("name: a\n"), &j, map[string][]int{"bad": index})
+			Ω(err).Should(MatchError(ContainSubstring("DecodeFields: key 'bad'")))
+		}
+
+		type hidden struct {
+			secret string
+		}
+		err := DecodeFields([]byte("a: 1\n"), &hidden{}, map[string][]int{"a": {0}})
+		Ω(err).Should(MatchError(ContainSubstring("not exported")))
+
+		Ω(DecodeFields([]byte("a: 1\n"), j, fields)).Should(MatchError(ContainSubstring("not a pointer to a struct")))
+	})
+
+	It("decodes documents that are not mappings as Unmarshal does", func() {
+		j, want := job{Name: "a"}, job{Name: "a"}
+		Ω(DecodeFields([]byte("~\n"), &j, fields)).Should(Succeed())
+		Ω(Unmarshal([]byte("~\n"), &want)).Should(Succeed())
+		Ω(j).Should(Equal(want))
+
+		Ω(DecodeFields([]byte("[1]\n"), &j, fields)).ShouldNot(Succeed())
+	})
+	It("runs the steps around decoding that Decode runs", func() {
+		paths := map[string][]int{"n": {1}, "p": {2}}
+		var c lifecycleConfig
+		var post []string
+		d := NewDecoder(strings.NewReader("name: app\np: {host: a}\n---\np: {host: b}\n"))
+		d.SetPreprocess(func(doc *Node) error {
+			if name := doc.GetPath("name"); name != nil {
+				doc.DeletePath("name")
+				return doc.SetPath(name.Value, "n")
+			}
+			return nil
+		})
+		d.SetPostprocess(func(v interface{}) error {
+			post = append(post, v.(*lifecycleConfig).Name)
+			return nil
+		})
+
+		Ω(d.DecodeFields(&c, paths)).Should(Succeed())
+		Ω(c.Name).Should(Equal("app"))
+		Ω(c.Region).Should(Equal("eu"))
+		Ω(c.Primary.Port).Should(Equal(8080))
+		Ω(post).Should(Equal([]string{"app"}))
+
+		var unnamed lifecycleConfig
+		err := d.DecodeFields(&unnamed, paths)
+		var ve *ValidationError
+		Ω(errors.As(err, &ve)).Should(BeTrue())
+	})
+})
@@ -0,0 +1,74 @@
+package candiedyaml
+
+import (
+	"reflect"
+)
+
+// FieldInfo describes how a struct field is encoded and decoded.
+type FieldInfo struct {
+	// Name is the mapping key of the field.
+	Name string
+
+	// Index is the index sequence of the field for reflect.Value.FieldByIndex,
+	// which goes through embedded structs.
+	Index []int
+
+	// Type is the Go type of the field.
+	Type reflect.Type
+
+	// Tagged reports whether Name comes from a yaml struct tag.
+	Tagged bool
+
+	// OmitEmpty and Flow report the omitempty and flow tag options.
+	OmitEmpty bool
+	Flow      bool
+
+	// Base is 2, 8 or 16 for integer fields tagged binary, octal or hex, and
+	// 0 otherwise.  Separated reports the separated option.
+	Base      int
+	Separated bool
+
+	// Comment is the yamlcomment tag of the field.
+	Comment string
+
+	// Min, Max, Pattern and Enum are the min, max, pattern and enum tag
+	// options, which Decoders check the values of the field against, and
+	// are empty for options the tag does not give.
+	Min, Max string
+	Pattern  string
+	Enum     []string
+}
+
+// FieldsOf returns the fields of the struct type t, or the struct t points
+// to, in the order they are encoded, with the same names and promotion
+// rules Encoders and Decoders use.  It returns nil for other types.
+func FieldsOf(t reflect.Type) []FieldInfo {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := cachedTypeFields(t).list
+	infos := make([]FieldInfo, len(fields))
+	for i, f := range fields {
+		infos[i] = FieldInfo{
+			Name:      f.name,
+			Index:     append([]int(nil), f.index...),
+			Type:      t.FieldByIndex(f.index).Type,
+			Tagged:    f.tag,
+			OmitEmpty: f.omitEmpty,
+			Flow:      f.flow,
+			Base:      f.ints.base,
+			Separated: f.ints.separated,
+			Comment:   f.comment,
+		}
+		if c := f.constraints; c != nil {
+			infos[i].Min, infos[i].Max = c.min, c.max
+			infos[i].Pattern = c.source
+			infos[i].Enum = append([]string(nil), c.enum...)
+		}
+	}
+	return infos
+}
@@ -0,0 +1,75 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"reflect"
+)
+
+var _ = Describe("FieldsOf", func() {
+	type Meta struct {
+		Name   string
+		Labels map[string]string `yaml:"labels,omitempty,flow"`
+	}
+	type Other struct {
+		Name string
+	}
+	type Spec struct {
+		*Meta
+		Other
+		Mode  uint32 `yaml:"mode,octal" yamlcomment:"file mode"`
+		Count int    `yaml:",separated"`
+		skip  int
+		Gone  int `yaml:"-"`
+	}
+
+	It("lists the fields the codec uses", func() {
+		fields := FieldsOf(reflect.TypeOf(Spec{}))
+
+		names := []string{}
+		for _, f := range fields {
+			names = append(names, f.Name)
+		}
+		Ω(names).Should(Equal([]string{"labels", "mode", "Count"}))
+
+		Ω(fields[0]).Should(Equal(FieldInfo{
+			Name:      "labels",
+			Index:     []int{0, 1},
+			Type:      reflect.TypeOf(map[string]string{}),
+			Tagged:    true,
+			OmitEmpty: true,
+			Flow:      true,
+		}))
+		Ω(fields[1].Base).Should(Equal(8))
+		Ω(fields[1].Comment).Should(Equal("file mode"))
+		Ω(fields[2].Tagged).Should(BeFalse())
+		Ω(fields[2].Separated).Should(BeTrue())
+	})
+
+	It("lists the constraints of the fields", func() {
+		type limited struct {
+			Level string `yaml:"level,enum=debug|info"`
+			Name  string `yaml:"name,min=1,max=63,pattern=[a-z]+"`
+		}
+		fields := FieldsOf(reflect.TypeOf(limited{}))
+		Ω(fields[0].Enum).Should(Equal([]string{"debug", "info"}))
+		Ω(fields[0].Min).Should(BeEmpty())
+		Ω(fields[1].Min).Should(Equal("1"))
+		Ω(fields[1].Max).Should(Equal("63"))
+		Ω(fields[1].Pattern).Should(Equal("[a-z]+"))
+		Ω(fields[1].Enum).Should(BeNil())
+	})
+
+	It("accepts pointers to structs", func() {
+		Ω(FieldsOf(reflect.TypeOf(&Spec{}))).Should(Equal(FieldsOf(reflect.TypeOf(Spec{}))))
+	})
+
+	It("returns nil for other types", func() {
+		Ω(FieldsOf(reflect.TypeOf(1))).Should(BeNil())
+	})
+
+	It("returns copies of the index sequences", func() {
+		FieldsOf(reflect.TypeOf(Spec{}))[0].Index[0] = 5
+		Ω(FieldsOf(reflect.TypeOf(Spec{}))[0].Index).Should(Equal([]int{0, 1}))
+	})
+})
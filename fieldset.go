@@ -0,0 +1,55 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A FieldSet holds the struct fields that were present in a document, by
+// path.  A path names the Go fields from the value decoded into down to the
+// field, separated by dots, with map keys and slice indexes in between where
+// the field is inside a map or slice, e.g. "Server.Port" or
+// "Backends.web.Weight" or "Users.0.Name".
+type FieldSet map[string]bool
+
+// Has reports whether the field at path was present.
+func (fs FieldSet) Has(path string) bool {
+	return fs[path]
+}
+
+// DecodeWithFieldSet decodes the first document of data into v like
+// Unmarshal and returns the struct fields the document set, so that a
+// configuration layered over defaults can override only what was written,
+// without making every field a pointer:
+//
+//	fs, err := candiedyaml.DecodeWithFieldSet(data, &override)
+//	if fs.Has("Server.Port") {
+//		cfg.Server.Port = override.Server.Port
+//	}
+//
+// A field is present even if its value is null.
+func DecodeWithFieldSet(data []byte, v interface{}) (FieldSet, error) {
+	d := NewDecoder(bytes.NewReader(data))
+	d.fields = make(FieldSet)
+	err := d.Decode(v)
+	return d.fields, err
+}
+
+// enterField adds the struct field, map key or slice index name to the path
+// of the value being decoded, recording it as present if it is a field.
+func (d *Decoder) enterField(name interface{}, field bool) {
+	if d.fields == nil {
+		return
+	}
+	d.fieldPath = append(d.fieldPath, fmt.Sprint(name))
+	if field {
+		d.fields[strings.Join(d.fieldPath, ".")] = true
+	}
+}
+
+func (d *Decoder) leaveField() {
+	if d.fields != nil {
+		d.fieldPath = d.fieldPath[:len(d.fieldPath)-1]
+	}
+}
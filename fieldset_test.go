@@ -0,0 +1,59 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodeWithFieldSet", func() {
+	type server struct {
+		Host string
+		Port int
+	}
+	type backend struct {
+		Weight int
+		Tags   []string
+	}
+	type config struct {
+		Name     string
+		Debug    bool
+		Server   server
+		Backends map[string]backend
+		Users    []server
+	}
+
+	It("reports the fields present in the document", func() {
+		var c config
+		fs, err := DecodeWithFieldSet([]byte(`
+debug: false
+server:
+  port: 8080
+backends:
+  web: {weight: 0}
+users:
+- host: a
+- port: 22
+`), &c)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(fs).Should(Equal(FieldSet{
+			"Debug":               true,
+			"Server":              true,
+			"Server.Port":         true,
+			"Backends":            true,
+			"Backends.web.Weight": true,
+			"Users":               true,
+			"Users.0.Host":        true,
+			"Users.1.Port":        true,
+		}))
+		Ω(fs.Has("Debug")).Should(BeTrue())
+		Ω(fs.Has("Name")).Should(BeFalse())
+		Ω(fs.Has("Server.Host")).Should(BeFalse())
+	})
+
+	It("counts fields set to null", func() {
+		var c config
+		fs, err := DecodeWithFieldSet([]byte("name: ~\n"), &c)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(fs.Has("Name")).Should(BeTrue())
+	})
+})
@@ -0,0 +1,207 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A FieldUnmarshaler decodes the fields of a struct itself, as the methods
+// gen/yamlgen writes do.  The Decoder reads the keys of the struct's
+// mapping as for any other struct, so unknown, duplicate and merge keys,
+// field constraints and every other Decoder setting apply as they do
+// without the method.  For the value of each key naming a field, it calls
+// UnmarshalYAMLField with the index of the field in the struct, as
+// reflect's Field takes it.  If the method returns nil without reading the
+// value, the Decoder decodes the field as usual, so the method need only
+// handle the fields and values it reads itself:
+//
+//	func (c *Config) UnmarshalYAMLField(field int, d *candiedyaml.FieldDecoder) error {
+//		if field == 0 {
+//			if name, ok := d.String(); ok {
+//				c.Name = name
+//			}
+//		}
+//		return nil
+//	}
+//
+// Fields promoted from embedded structs are always decoded by the Decoder.
+type FieldUnmarshaler interface {
+	UnmarshalYAMLField(field int, d *FieldDecoder) error
+}
+
+var fieldUnmarshalerType = reflect.TypeOf((*FieldUnmarshaler)(nil)).Elem()
+
+// A FieldDecoder reads the value of a field for a FieldUnmarshaler.  Its
+// methods read the value at most once, and only during the call to
+// UnmarshalYAMLField it was passed to.  String, Bool, Int, Uint and Float
+// read the value only if it is a scalar that the Decoder, with its current
+// settings, would decode into a field of their type as they do, and
+// otherwise report false and leave it to be decoded.
+type FieldDecoder struct {
+	d *Decoder
+
+	// events is the count of events read when the call began.
+	events int
+}
+
+// Decode decodes the value into v, which must be a pointer, as the Decoder
+// decodes any other value.
+func (f *FieldDecoder) Decode(v interface{}) error {
+	if f.read() {
+		return errors.New("yaml: a value can only be unmarshalled once")
+	}
+	f.d.parse(reflect.ValueOf(v))
+	return nil
+}
+
+// String returns the value if it is an untagged scalar other than null.
+func (f *FieldDecoder) String() (string, bool) {
+	s, ok := f.scalar(stringType, false)
+	if !ok || null_values[s] {
+		return "", false
+	}
+	f.d.nextEvent()
+	return s, true
+}
+
+// Bool returns the value if it is the plain scalar true or false.
+func (f *FieldDecoder) Bool() (bool, bool) {
+	s, ok := f.scalar(boolType, true)
+	if !ok || s != "true" && s != "false" {
+		return false, false
+	}
+	f.d.nextEvent()
+	return s == "true", true
+}
+
+// Int returns the value if it is a plain decimal integer that fits in an
+// integer of bits bits, or in an int for 0.
+func (f *FieldDecoder) Int(bits int) (int64, bool) {
+	s, ok := f.scalar(intTypes[bits], true)
+	if !ok || !isDecimal(strings.TrimPrefix(s, "-")) {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(s, 10, bits)
+	if err != nil {
+		return 0, false
+	}
+	f.d.nextEvent()
+	return i, true
+}
+
+// Uint returns the value if it is a plain decimal integer, with no sign,
+// that fits in an unsigned integer of bits bits, or in a uint for 0.
+func (f *FieldDecoder) Uint(bits int) (uint64, bool) {
+	s, ok := f.scalar(uintTypes[bits], true)
+	if !ok || !isDecimal(s) {
+		return 0, false
+	}
+	u, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, false
+	}
+	f.d.nextEvent()
+	return u, true
+}
+
+// Float returns the value if it is a plain decimal number, with or without
+// a fraction, that a float of bits bits holds without losing precision.
+func (f *FieldDecoder) Float(bits int) (float64, bool) {
+	s, ok := f.scalar(floatTypes[bits], true)
+	if !ok {
+		return 0, false
+	}
+	whole, frac := strings.TrimPrefix(s, "-"), ""
+	if i := strings.IndexByte(whole, '.'); i >= 0 {
+		whole, frac = whole[:i], whole[i+1:]
+		if !allDigits(frac) {
+			return 0, false
+		}
+	}
+	if !allDigits(whole) {
+		return 0, false
+	}
+	x, err := strconv.ParseFloat(s, bits)
+	if err != nil || isTruncatedFloat(s, x, bits) {
+		return 0, false
+	}
+	f.d.nextEvent()
+	return x, true
+}
+
+// read reports whether the value has been read.
+func (f *FieldDecoder) read() bool {
+	return f.d.events != f.events
+}
+
+// scalar returns the text of the value if it is an untagged scalar without
+// an anchor, plain if plain is set, that a field of type t would be decoded
+// from without an adapter or a tracer being involved.
+func (f *FieldDecoder) scalar(t reflect.Type, plain bool) (string, bool) {
+	e := &f.d.event
+	if f.read() || e.event_type != yaml_SCALAR_EVENT || len(e.anchor) > 0 || len(e.tag) > 0 ||
+		plain && yaml_scalar_style_t(e.style) != yaml_PLAIN_SCALAR_STYLE {
+		return "", false
+	}
+	if f.d.tracer != nil || t == nil {
+		return "", false
+	}
+	if _, ok := adapterFor(t); ok {
+		return "", false
+	}
+	return string(e.value), true
+}
+
+var (
+	stringType = reflect.TypeOf("")
+	boolType   = reflect.TypeOf(false)
+	intTypes   = map[int]reflect.Type{
+		0: reflect.TypeOf(int(0)), 8: reflect.TypeOf(int8(0)), 16: reflect.TypeOf(int16(0)),
+		32: reflect.TypeOf(int32(0)), 64: reflect.TypeOf(int64(0)),
+	}
+	uintTypes = map[int]reflect.Type{
+		0: reflect.TypeOf(uint(0)), 8: reflect.TypeOf(uint8(0)), 16: reflect.TypeOf(uint16(0)),
+		32: reflect.TypeOf(uint32(0)), 64: reflect.TypeOf(uint64(0)),
+	}
+	floatTypes = map[int]reflect.Type{32: reflect.TypeOf(float32(0)), 64: reflect.TypeOf(float64(0))}
+)
+
+// isDecimal reports whether s is a decimal integer without a sign or a
+// leading zero, which YAML would read as octal.
+func isDecimal(s string) bool {
+	return allDigits(s) && (s == "0" || s[0] != '0')
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// unmarshalField decodes the value of the field at index i of the struct
+// fu into fv, through fu's UnmarshalYAMLField method if it reads it.
+func (d *Decoder) unmarshalField(fu FieldUnmarshaler, i int, fv reflect.Value) {
+	mark, events := d.event.start_mark, d.events
+
+	// The FieldDecoder is kept in the Decoder, and put back after fields
+	// of structs inside the value have used it.
+	outer := d.fieldDecoder
+	d.fieldDecoder = FieldDecoder{d: d, events: events}
+	err := fu.UnmarshalYAMLField(i, &d.fieldDecoder)
+	d.fieldDecoder = outer
+	if err != nil {
+		d.error(fmt.Errorf("yaml: line %d, column %d: %v", mark.line+1, mark.column+1, err))
+	}
+	if d.events == events {
+		d.parse(fv)
+	}
+}
@@ -0,0 +1,127 @@
+package candiedyaml
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fieldRecorder reads its fields through a FieldDecoder, and records the
+// values it read.
+type fieldRecorder struct {
+	S string         `yaml:"s"`
+	I int8           `yaml:"i"`
+	U uint           `yaml:"u"`
+	F float32        `yaml:"f"`
+	B bool           `yaml:"b"`
+	N *fieldRecorder `yaml:"n"`
+
+	read []interface{}
+	fail bool
+}
+
+func (r *fieldRecorder) UnmarshalYAMLField(field int, d *FieldDecoder) error {
+	if r.fail {
+		return errors.New("refused")
+	}
+	var v interface{}
+	var ok bool
+	switch field {
+	case 0:
+		r.S, ok = d.String()
+		v = r.S
+	case 1:
+		var i int64
+		i, ok = d.Int(8)
+		r.I, v = int8(i), i
+	case 2:
+		var u uint64
+		u, ok = d.Uint(0)
+		r.U, v = uint(u), u
+	case 3:
+		var f float64
+		f, ok = d.Float(32)
+		r.F, v = float32(f), f
+	case 4:
+		r.B, ok = d.Bool()
+		v = r.B
+	case 5:
+		r.N = &fieldRecorder{}
+		if err := d.Decode(r.N); err != nil {
+			return err
+		}
+		if err := d.Decode(r.N); err == nil {
+			return errors.New("decoded twice")
+		}
+		ok = true
+	}
+	if ok {
+		r.read = append(r.read, v)
+	}
+	return nil
+}
+
+var _ = Describe("FieldUnmarshaler", func() {
+	decode := func(doc string) fieldRecorder {
+		var r fieldRecorder
+		Ω(Unmarshal([]byte(doc), &r)).Should(Succeed())
+		return r
+	}
+
+	It("reads only values the Decoder would decode the same way", func() {
+		r := decode("s: \"a b\"\ni: -42\nu: 0\nf: -1.25\nb: true\n")
+		Ω(r.read).Should(Equal([]interface{}{"a b", int64(-42), uint64(0), float64(-1.25), true}))
+
+		for _, doc := range []string{
+			"s: ~", "s: !!str a", "s: &a a",
+			"i: 0755", "i: 0x1F", "i: 1_000", "i: 300", "i: '1'", "i: !!int 1",
+			"u: -1",
+			"f: .inf", "f: 1e3", "f: 1.", "f: 1e40", "f: 0.1000000001",
+			"b: yes",
+		} {
+			var r fieldRecorder
+			var plain struct {
+				S string  `yaml:"s"`
+				I int8    `yaml:"i"`
+				U uint    `yaml:"u"`
+				F float32 `yaml:"f"`
+				B bool    `yaml:"b"`
+			}
+			err := Unmarshal([]byte(doc), &r)
+			want := Unmarshal([]byte(doc), &plain)
+			Ω(r.read).Should(BeEmpty(), doc)
+			if want != nil {
+				Ω(err).Should(HaveOccurred(), doc)
+				continue
+			}
+			Ω(err).ShouldNot(HaveOccurred(), doc)
+			Ω([]interface{}{r.S, r.I, r.U, r.F, r.B}).Should(Equal([]interface{}{plain.S, plain.I, plain.U, plain.F, plain.B}), doc)
+		}
+	})
+
+	It("decodes values through Decode once", func() {
+		r := decode("n: {s: x, n: {b: false}}\n")
+		Ω(r.N.S).Should(Equal("x"))
+		Ω(r.N.read).Should(Equal([]interface{}{"x", nil}))
+		Ω(r.N.N.read).Should(Equal([]interface{}{false}))
+	})
+
+	It("leaves keys to the Decoder", func() {
+		var r fieldRecorder
+		d := NewDecoder(strings.NewReader("s: a\nx: 1\ns: b\n"))
+		d.RejectUnknownFields(true)
+		Ω(d.Decode(&r)).Should(MatchError(ContainSubstring("x")))
+
+		r = fieldRecorder{}
+		d = NewDecoder(strings.NewReader("s: a\ns: b\n"))
+		d.RejectDuplicateKeys(true)
+		Ω(d.Decode(&r)).ShouldNot(Succeed())
+	})
+
+	It("reports errors at the value", func() {
+		r := fieldRecorder{fail: true}
+		Ω(Unmarshal([]byte("b: true\ns: x\n"), &r)).Should(MatchError("yaml: line 1, column 4: refused"))
+	})
+})
@@ -0,0 +1,181 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flatten returns the scalars of the document in data under dotted paths
+// of the keys and sequence indexes leading to them, with their values as
+// written, for passing configuration on to systems that take environment
+// variables or flags:
+//
+//	server:
+//	  ports: [8080, 8443]
+//
+// gives server.ports.0=8080 and server.ports.1=8443.  Empty sequences and
+// mappings are given as [] and {}.  Aliases are followed.  It returns an
+// error if two paths come out the same, as a.b: 1 and a: {b: 2} do, or if
+// a key is not a scalar.
+func Flatten(data []byte) (map[string]string, error) {
+	flat := make(map[string]string)
+	var doc Node
+	if err := Unmarshal(data, &doc); err == io.EOF {
+		return flat, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return flat, nil
+	}
+	if err := flatten(flat, "", doc.Content[0]); err != nil {
+		return nil, err
+	}
+	return flat, nil
+}
+
+func flatten(flat map[string]string, path string, n *Node) error {
+	for n.Kind == AliasNode {
+		n = n.Alias
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch n.Kind {
+	case SequenceNode:
+		if len(n.Content) == 0 {
+			return setFlat(flat, path, "[]", n)
+		}
+		for i, item := range n.Content {
+			if err := flatten(flat, join(strconv.Itoa(i)), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case MappingNode:
+		if len(n.Content) == 0 {
+			return setFlat(flat, path, "{}", n)
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			for key.Kind == AliasNode {
+				key = key.Alias
+			}
+			if key.Kind != ScalarNode {
+				return fmt.Errorf("yaml: Flatten: line %d, column %d: a %s key cannot be part of a path", key.Line, key.Column, key.Kind)
+			}
+			if err := flatten(flat, join(key.Value), n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setFlat(flat, path, n.Value, n)
+}
+
+func setFlat(flat map[string]string, path, value string, n *Node) error {
+	if _, ok := flat[path]; ok {
+		return fmt.Errorf("yaml: Flatten: line %d, column %d: path '%s' occurs more than once", n.Line, n.Column, path)
+	}
+	flat[path] = value
+	return nil
+}
+
+// Unflatten returns the document Flatten would have flattened into flat.
+// The entries under a path are a sequence if their keys are the indexes 0
+// to n-1, and a mapping in key order otherwise.  Values are written plain
+// where they can be, so that they read as YAML reads them, [] and {} are
+// written as empty collections, and an empty map as null.  It returns an
+// error if a path is both a value and the parent of others, as a=1 and
+// a.b=2 are.
+func Unflatten(flat map[string]string) ([]byte, error) {
+	if value, ok := flat[""]; ok && len(flat) == 1 {
+		return Marshal((&flatEntry{value: &value}).node())
+	}
+
+	root := &flatEntry{}
+	paths := make([]string, 0, len(flat))
+	for path := range flat {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		e := root
+		for _, key := range strings.Split(path, ".") {
+			if e.value != nil {
+				return nil, fmt.Errorf("yaml: Unflatten: '%s' has both a value and entries under it", path)
+			}
+			if e.children == nil {
+				e.children = make(map[string]*flatEntry)
+			}
+			child := e.children[key]
+			if child == nil {
+				child = &flatEntry{}
+				e.children[key] = child
+				e.keys = append(e.keys, key)
+			}
+			e = child
+		}
+		if e.children != nil {
+			return nil, fmt.Errorf("yaml: Unflatten: '%s' has both a value and entries under it", path)
+		}
+		value := flat[path]
+		e.value = &value
+	}
+
+	if root.children == nil {
+		return []byte("null\n"), nil
+	}
+	return Marshal(root.node())
+}
+
+// flatEntry is a path of the map given to Unflatten, with either a value
+// or the entries under it.
+type flatEntry struct {
+	value    *string
+	keys     []string
+	children map[string]*flatEntry
+}
+
+func (e *flatEntry) node() *Node {
+	if e.value != nil {
+		switch *e.value {
+		case "[]":
+			return &Node{Kind: SequenceNode, Style: FlowStyle}
+		case "{}":
+			return &Node{Kind: MappingNode, Style: FlowStyle}
+		}
+		return &Node{Kind: ScalarNode, Value: *e.value}
+	}
+
+	if e.isSequence() {
+		n := &Node{Kind: SequenceNode}
+		for i := range e.keys {
+			n.Content = append(n.Content, e.children[strconv.Itoa(i)].node())
+		}
+		return n
+	}
+	n := &Node{Kind: MappingNode}
+	for _, key := range e.keys {
+		n.Content = append(n.Content, &Node{Kind: ScalarNode, Value: key}, e.children[key].node())
+	}
+	return n
+}
+
+// isSequence reports whether the keys of e are the indexes 0 to n-1.
+func (e *flatEntry) isSequence() bool {
+	for i := range e.keys {
+		if e.children[strconv.Itoa(i)] == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Flatten", func() {
+	const doc = `server:
+  host: example.com
+  ports: [8080, 8443]
+  tls: {}
+defaults: &d
+  retries: 3
+client: *d
+note: "a: b"
+tags: []
+`
+	flat := map[string]string{
+		"server.host":      "example.com",
+		"server.ports.0":   "8080",
+		"server.ports.1":   "8443",
+		"server.tls":       "{}",
+		"defaults.retries": "3",
+		"client.retries":   "3",
+		"note":             "a: b",
+		"tags":             "[]",
+	}
+
+	It("gives the scalars of a document under dotted paths", func() {
+		Ω(Flatten([]byte(doc))).Should(Equal(flat))
+		Ω(Flatten([]byte("plain\n"))).Should(Equal(map[string]string{"": "plain"}))
+		Ω(Flatten(nil)).Should(BeEmpty())
+	})
+
+	It("rejects paths that come out the same and keys that are collections", func() {
+		_, err := Flatten([]byte("a.b: 1\na: {b: 2}\n"))
+		Ω(err).Should(MatchError(ContainSubstring("path 'a.b' occurs more than once")))
+		_, err = Flatten([]byte("? [a]\n: 1\n"))
+		Ω(err).Should(MatchError(ContainSubstring("sequence key")))
+	})
+
+	It("builds the document back from the flat map", func() {
+		out, err := Unflatten(flat)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal(`client:
+  retries: 3
+defaults:
+  retries: 3
+note: 'a: b'
+server:
+  host: example.com
+  ports:
+  - 8080
+  - 8443
+  tls: {}
+tags: []
+`))
+		Ω(Flatten(out)).Should(Equal(flat))
+
+		var v map[string]interface{}
+		Ω(Unmarshal(out, &v)).Should(Succeed())
+		Ω(v["server"]).Should(HaveKeyWithValue("ports", []interface{}{int64(8080), int64(8443)}))
+	})
+
+	It("writes a lone value as a document of its own", func() {
+		out, err := Unflatten(map[string]string{"": "plain"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("plain\n"))
+	})
+
+	It("round-trips empty documents", func() {
+		flat, err := Flatten([]byte(""))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(flat).Should(BeEmpty())
+
+		out, err := Unflatten(flat)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("null\n"))
+	})
+
+	It("makes sequences only of indexes from 0 on", func() {
+		out, err := Unflatten(map[string]string{"a.1": "x", "a.2": "y"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("a:\n  1: x\n  2: y\n"))
+	})
+
+	It("rejects paths that are both values and parents", func() {
+		_, err := Unflatten(map[string]string{"a": "1", "a.b": "2"})
+		Ω(err).Should(MatchError(ContainSubstring("'a.b' has both a value and entries under it")))
+	})
+})
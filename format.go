@@ -0,0 +1,123 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+)
+
+// A QuoteStyle selects how Format writes quoted scalars.
+type QuoteStyle int
+
+const (
+	// KeepQuotes leaves quoted scalars as they are written.
+	KeepQuotes QuoteStyle = iota
+
+	// MinimalQuotes removes quotes the scalar does not need and uses single
+	// quotes for the others.
+	MinimalQuotes
+
+	// SingleQuotes writes quoted scalars in single quotes.
+	SingleQuotes
+
+	// DoubleQuotes writes quoted scalars in double quotes.
+	DoubleQuotes
+)
+
+// FormatOptions controls the layout Format writes.
+type FormatOptions struct {
+	// Indent is the number of spaces nested block collections are indented
+	// by, from 2 to 9.  Zero means 2.
+	Indent int
+
+	// Width is the width at which long scalars are folded.  Zero means 80
+	// and a negative width means no limit.
+	Width int
+
+	// Quotes selects how quoted scalars are written.
+	Quotes QuoteStyle
+
+	// Align lines up the values of each block mapping in a column.
+	Align bool
+}
+
+// Format rewrites a stream of YAML documents with consistent indentation
+// and quoting, keeping comments, blank lines between entries, anchors, tags,
+// %YAML and %TAG directives and the order of mapping keys.  Plain scalars
+// and scalars that are not quoted in data are written as they are.
+func Format(data []byte, opts FormatOptions) ([]byte, error) {
+	d := NewDecoder(bytes.NewReader(data))
+	d.parser.keep_comments = true
+
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.SetIndent(opts.Indent)
+	e.SetWidth(opts.Width)
+	e.SetAlign(opts.Align)
+
+	for {
+		version, tags, err := d.directives()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		e.version, e.tagDirectives = version, tags
+
+		var n Node
+		if err := d.Decode(&n); err != nil {
+			return nil, err
+		}
+
+		requote(&n, opts.Quotes)
+		if err := e.Encode(&n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// directives reads up to the start of the next document and returns the
+// %YAML and %TAG directives before it.
+func (d *Decoder) directives() (version *yaml_version_directive_t, tags []yaml_tag_directive_t, err error) {
+	defer recoverError(&err)
+	if err := d.startDocument(); err != nil {
+		return nil, nil, err
+	}
+	if v := d.event.version_directive; v != nil {
+		version = &yaml_version_directive_t{major: v.major, minor: v.minor}
+	}
+	for _, t := range d.event.tag_directives {
+		tags = append(tags, yaml_tag_directive_t{
+			handle: append([]byte(nil), t.handle...),
+			prefix: append([]byte(nil), t.prefix...),
+		})
+	}
+	return version, tags, nil
+}
+
+// requote changes the style of the quoted scalars under n.  Only untagged
+// scalars are changed, as a tag decides what they read back as.
+func requote(n *Node, quotes QuoteStyle) {
+	for _, c := range n.Content {
+		requote(c, quotes)
+	}
+	if n.Kind != ScalarNode || n.Tag != "" {
+		return
+	}
+	if n.Style != SingleQuotedStyle && n.Style != DoubleQuotedStyle {
+		return
+	}
+
+	switch quotes {
+	case MinimalQuotes:
+		n.Style = SingleQuotedStyle
+		if !YAML11Schema.needsQuotes(n.Value) {
+			n.Style = 0
+		}
+	case SingleQuotes:
+		n.Style = SingleQuotedStyle
+	case DoubleQuotes:
+		n.Style = DoubleQuotedStyle
+	}
+}
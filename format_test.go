@@ -0,0 +1,92 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Format", func() {
+	format := func(data string, opts FormatOptions) string {
+		out, err := Format([]byte(data), opts)
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(out)
+	}
+
+	It("reindents and keeps comments", func() {
+		data := `# settings
+server:
+      host: example.com   # the host
+      ports:
+          - 80
+
+          - 443
+`
+		Ω(format(data, FormatOptions{})).Should(Equal(`# settings
+server:
+  host: example.com # the host
+  ports:
+  - 80
+
+  - 443
+`))
+		Ω(format(data, FormatOptions{Indent: 4})).Should(Equal(`# settings
+server:
+    host: example.com # the host
+    ports:
+    - 80
+
+    - 443
+`))
+	})
+
+	It("folds long scalars at the width", func() {
+		data := "text: one two three four five six\n"
+		Ω(format(data, FormatOptions{Width: 20})).Should(Equal("text: one two three four\n  five six\n"))
+		Ω(format(data, FormatOptions{Width: -1})).Should(Equal(data))
+	})
+
+	It("changes quotes", func() {
+		data := "a: 'x'\nb: \"yes\"\nc: \"it's\"\nd: plain\ne: !!str 'y'\n"
+
+		Ω(format(data, FormatOptions{})).Should(Equal(data))
+		Ω(format(data, FormatOptions{Quotes: MinimalQuotes})).Should(Equal(
+			"a: x\nb: 'yes'\nc: it's\nd: plain\ne: !!str 'y'\n"))
+		Ω(format(data, FormatOptions{Quotes: SingleQuotes})).Should(Equal(
+			"a: 'x'\nb: 'yes'\nc: 'it''s'\nd: plain\ne: !!str 'y'\n"))
+		Ω(format(data, FormatOptions{Quotes: DoubleQuotes})).Should(Equal(
+			"a: \"x\"\nb: \"yes\"\nc: \"it's\"\nd: plain\ne: !!str 'y'\n"))
+	})
+
+	It("formats every document", func() {
+		data := "a:   1\n---\n# second\nb:   [1,2]\n"
+		Ω(format(data, FormatOptions{})).Should(Equal("a: 1\n---\n# second\nb: [1, 2]\n"))
+	})
+
+	It("keeps directives", func() {
+		Ω(format("%YAML 1.1\n---\na:   1\n...\n", FormatOptions{})).Should(Equal("%YAML 1.1\n---\na: 1\n"))
+		Ω(format("%YAML 1.2\n---\na: 1\n", FormatOptions{})).Should(Equal("%YAML 1.2\n---\na: 1\n"))
+		Ω(format("%TAG !e! tag:example.com,2000:\n---\na: !e!x 1\n", FormatOptions{})).Should(Equal(
+			"%TAG !e! tag:example.com,2000:\n---\na: !e!x 1\n"))
+	})
+
+	It("writes empty documents without trailing spaces", func() {
+		Ω(format("a: 1\n---\n", FormatOptions{})).Should(Equal("a: 1\n---\n"))
+		Ω(format("a: 1\n--- ~\n", FormatOptions{})).Should(Equal("a: 1\n--- ~\n"))
+	})
+
+	It("returns parse errors", func() {
+		_, err := Format([]byte("a: [1\n"), FormatOptions{})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("aligns values", func() {
+		data := "# servers\nweb: &w {host: a, port: 80} # main\nbackup: *w\nlimits:\n  cpu: 2\n  memory: 1Gi\n"
+		Ω(format(data, FormatOptions{Align: true})).Should(Equal(`# servers
+web:    &w {host: a, port: 80} # main
+backup: *w
+limits:
+  cpu:    2
+  memory: 1Gi
+`))
+	})
+})
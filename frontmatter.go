@@ -0,0 +1,72 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// ExtractFrontMatter reads a file such as a Markdown page that may start
+// with a YAML front matter block: a '---' line, the YAML, and a closing
+// '---' or '...' line.  meta is the YAML between the lines, to be passed to
+// Unmarshal, and body everything after the closing line.  If r does not
+// start with a '---' line, meta is nil and body is all of r.  A block with
+// no closing line fails with ErrUnclosedFrontMatter.
+func ExtractFrontMatter(r io.Reader) (meta []byte, body []byte, err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	line, rest := cutLine(text)
+	if fenceLine(line) != "---" {
+		return nil, data, nil
+	}
+
+	start := len(text) - len(rest)
+	for pos := start; pos < len(text); {
+		line, rest = cutLine(text[pos:])
+		if fence := fenceLine(line); fence == "---" || fence == "..." {
+			return text[start:pos], text[len(text)-len(rest):], nil
+		}
+		pos = len(text) - len(rest)
+	}
+	return nil, nil, ErrUnclosedFrontMatter
+}
+
+// cutLine returns the first line of text, without its '\n', and the text
+// after it.
+func cutLine(text []byte) (line, rest []byte) {
+	if i := bytes.IndexByte(text, '\n'); i >= 0 {
+		return text[:i], text[i+1:]
+	}
+	return text, nil
+}
+
+// fenceLine returns line without the spaces and carriage return that may
+// follow a '---' or '...' fencing front matter.
+func fenceLine(line []byte) string {
+	return string(bytes.TrimRight(line, " \t\r"))
+}
+
+// SetFrontMatter causes each document to be written as a front matter
+// block, between '---' lines, so that the body of the file can be written
+// after it:
+//
+//	---
+//	title: Hello
+//	---
+func (e *Encoder) SetFrontMatter(front bool) {
+	e.frontMatter = front
+}
+
+// closeFrontMatter writes the line that ends a front matter block.
+func (e *Encoder) closeFrontMatter() {
+	if !yaml_emitter_flush(&e.emitter) {
+		panic("bad emit")
+	}
+	if _, err := e.emitter.output_writer.Write([]byte("---\n")); err != nil {
+		panic(err)
+	}
+}
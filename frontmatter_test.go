@@ -0,0 +1,78 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+type frontPage struct {
+	Title string   `yaml:"title"`
+	Tags  []string `yaml:"tags"`
+}
+
+var _ = Describe("Front matter", func() {
+	extract := func(text string) (string, string, error) {
+		meta, body, err := ExtractFrontMatter(strings.NewReader(text))
+		return string(meta), string(body), err
+	}
+
+	It("splits the front matter from the body", func() {
+		meta, body, err := extract("---\ntitle: Hello\ntags: [a, b]\n---\n# Hello\n\nText.\n")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(meta).Should(Equal("title: Hello\ntags: [a, b]\n"))
+		Ω(body).Should(Equal("# Hello\n\nText.\n"))
+
+		var page frontPage
+		Ω(Unmarshal([]byte(meta), &page)).Should(Succeed())
+		Ω(page).Should(Equal(frontPage{"Hello", []string{"a", "b"}}))
+	})
+
+	It("accepts '...', CRLF line endings and a byte order mark", func() {
+		meta, body, err := extract("\xef\xbb\xbf---\r\ntitle: Hello\r\n...\r\nText.\r\n")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(meta).Should(Equal("title: Hello\r\n"))
+		Ω(body).Should(Equal("Text.\r\n"))
+	})
+
+	It("allows empty front matter and bodies", func() {
+		meta, body, err := extract("---\n---\n")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(meta).Should(BeEmpty())
+		Ω(body).Should(BeEmpty())
+	})
+
+	It("returns everything as the body without front matter", func() {
+		meta, body, err := extract("# Hello\n---\ntitle: no\n---\n")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(meta).Should(BeEmpty())
+		Ω(body).Should(Equal("# Hello\n---\ntitle: no\n---\n"))
+
+		_, body, err = extract("--- title\n")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(body).Should(Equal("--- title\n"))
+	})
+
+	It("fails on front matter with no closing line", func() {
+		_, _, err := extract("---\ntitle: Hello\n--- x\n")
+		Ω(err).Should(Equal(ErrUnclosedFrontMatter))
+	})
+
+	It("writes front matter blocks", func() {
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(YAML11Schema)
+		e.SetFrontMatter(true)
+		Ω(e.Encode(frontPage{"Hello", []string{"a"}})).Should(Succeed())
+		buf.WriteString("Text.\n")
+		Ω(buf.String()).Should(Equal("---\ntitle: Hello\ntags:\n- a\n---\nText.\n"))
+
+		meta, body, err := ExtractFrontMatter(buf)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(body)).Should(Equal("Text.\n"))
+		var page frontPage
+		Ω(Unmarshal(meta, &page)).Should(Succeed())
+		Ω(page.Title).Should(Equal("Hello"))
+	})
+})
@@ -0,0 +1,54 @@
+package gen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fraenkel/candiedyaml"
+)
+
+func benchmarkServices() []byte {
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("- name: web\n  Enabled: true\n  replicas: 3\n  Port: 8080\n  Weight: 0.5\n  limits: {cpu: 1.5, memory: 1024}\n")
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkUnmarshalGenerated(b *testing.B) {
+	data := benchmarkServices()
+	for i := 0; i < b.N; i++ {
+		var s []service
+		if err := candiedyaml.Unmarshal(data, &s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalReflected(b *testing.B) {
+	data := benchmarkServices()
+	for i := 0; i < b.N; i++ {
+		var s []reflected
+		if err := candiedyaml.Unmarshal(data, &s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalGenerated(b *testing.B) {
+	s := make([]service, 200)
+	for i := 0; i < b.N; i++ {
+		if _, err := candiedyaml.Marshal(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalReflected(b *testing.B) {
+	s := make([]reflected, 200)
+	for i := 0; i < b.N; i++ {
+		if _, err := candiedyaml.Marshal(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package gen_test
+
+import (
+	"time"
+)
+
+type level int
+
+// service is decoded by the methods in fixtures_yamlgen_test.go, which
+// TestGenerate checks are what Generate writes for this file.
+//
+//yamlgen:generate
+type service struct {
+	Name     string `yaml:"name"`
+	Enabled  bool
+	Replicas int `yaml:"replicas,omitempty"`
+	Port     uint16
+	Weight   float64 `yaml:",omitempty"`
+	Level    level   `yaml:"level,omitempty"`
+	Timeout  time.Duration
+	Tags     []string          `yaml:"tags,omitempty"`
+	Env      map[string]string `yaml:"env,omitempty"`
+	Limits   *limits           `yaml:"limits,omitempty"`
+	Defaults limits            `yaml:"defaults"`
+	Extra    interface{}       `yaml:"extra,omitempty"`
+	Skipped  string            `yaml:"-"`
+	internal string
+}
+
+// limits is generated for too, and decoded through its own method inside
+// service.
+//
+//yamlgen:generate
+type limits struct {
+	CPU    float32 `yaml:"cpu"`
+	Memory int64   `yaml:"memory"`
+}
+
+// reflected has the fields of service without its methods, to compare
+// the generated methods with Unmarshal and Marshal.
+type reflected service
@@ -0,0 +1,90 @@
+// Code generated by yamlgen from fixtures_test.go. DO NOT EDIT.
+
+package gen_test
+
+import (
+	"github.com/fraenkel/candiedyaml"
+	"github.com/fraenkel/candiedyaml/gen"
+)
+
+// MarshalYAMLWithOptions implements candiedyaml.MarshalerWithOptions.
+func (v service) MarshalYAMLWithOptions(candiedyaml.EncoderSettings) (interface{}, error) {
+	m := make(candiedyaml.MapSlice, 0, 12)
+	m = append(m, candiedyaml.MapItem{Key: "name", Value: v.Name})
+	m = append(m, candiedyaml.MapItem{Key: "Enabled", Value: v.Enabled})
+	if v.Replicas != 0 {
+		m = append(m, candiedyaml.MapItem{Key: "replicas", Value: v.Replicas})
+	}
+	m = append(m, candiedyaml.MapItem{Key: "Port", Value: v.Port})
+	if v.Weight != 0 {
+		m = append(m, candiedyaml.MapItem{Key: "Weight", Value: v.Weight})
+	}
+	if !gen.IsEmpty(v.Level) {
+		m = append(m, candiedyaml.MapItem{Key: "level", Value: v.Level})
+	}
+	m = append(m, candiedyaml.MapItem{Key: "Timeout", Value: v.Timeout})
+	if len(v.Tags) != 0 {
+		m = append(m, candiedyaml.MapItem{Key: "tags", Value: v.Tags})
+	}
+	if len(v.Env) != 0 {
+		m = append(m, candiedyaml.MapItem{Key: "env", Value: v.Env})
+	}
+	if v.Limits != nil {
+		m = append(m, candiedyaml.MapItem{Key: "limits", Value: v.Limits})
+	}
+	m = append(m, candiedyaml.MapItem{Key: "defaults", Value: v.Defaults})
+	if v.Extra != nil {
+		m = append(m, candiedyaml.MapItem{Key: "extra", Value: v.Extra})
+	}
+	return m, nil
+}
+
+// UnmarshalYAMLField implements candiedyaml.FieldUnmarshaler.
+func (v *service) UnmarshalYAMLField(field int, d *candiedyaml.FieldDecoder) error {
+	switch field {
+	case 0:
+		if x, ok := d.String(); ok {
+			v.Name = x
+		}
+	case 1:
+		if x, ok := d.Bool(); ok {
+			v.Enabled = x
+		}
+	case 2:
+		if x, ok := d.Int(0); ok {
+			v.Replicas = int(x)
+		}
+	case 3:
+		if x, ok := d.Uint(16); ok {
+			v.Port = uint16(x)
+		}
+	case 4:
+		if x, ok := d.Float(64); ok {
+			v.Weight = x
+		}
+	}
+	return nil
+}
+
+// MarshalYAMLWithOptions implements candiedyaml.MarshalerWithOptions.
+func (v limits) MarshalYAMLWithOptions(candiedyaml.EncoderSettings) (interface{}, error) {
+	m := make(candiedyaml.MapSlice, 0, 2)
+	m = append(m, candiedyaml.MapItem{Key: "cpu", Value: v.CPU})
+	m = append(m, candiedyaml.MapItem{Key: "memory", Value: v.Memory})
+	return m, nil
+}
+
+// UnmarshalYAMLField implements candiedyaml.FieldUnmarshaler.
+func (v *limits) UnmarshalYAMLField(field int, d *candiedyaml.FieldDecoder) error {
+	switch field {
+	case 0:
+		if x, ok := d.Float(32); ok {
+			v.CPU = float32(x)
+		}
+	case 1:
+		if x, ok := d.Int(64); ok {
+			v.Memory = x
+		}
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// Package gen generates MarshalYAMLWithOptions and UnmarshalYAMLField
+// methods for structs, and holds the helpers the generated code calls.  The
+// yamlgen command runs it on a file:
+//
+//	//go:generate go run github.com/fraenkel/candiedyaml/gen/yamlgen config.go
+//
+// Structs are generated for when their doc comment has the line
+//
+//	//yamlgen:generate
+//
+// Their fields are named as Marshal names them, by their yaml tag or else
+// their Go name, and fields tagged "-" and unexported fields are left out.
+// MarshalYAMLWithOptions returns the fields as a MapSlice, whose values the
+// Encoder writes as usual.  When decoding, the Decoder matches keys to
+// fields as for any struct, and fields of the basic types are read through
+// a FieldDecoder where their scalar is plainly written; the Decoder decodes
+// the others.  Parsing and emitting take most of the time either way, so
+// the methods make little difference to speed.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Directive marks the structs Generate writes methods for.
+const Directive = "//yamlgen:generate"
+
+// field is a struct field the generated methods encode and decode.
+type field struct {
+	goName string
+	name   string
+
+	// index is the index of the field in the struct.
+	index int
+
+	// typ is the name of the basic type of the field, or "" for others,
+	// and empty how it is tested for being empty if it is omitempty.
+	typ   string
+	empty string
+
+	// expr is the type of the field as declared.
+	expr ast.Expr
+}
+
+// target is a struct the generated methods are for.
+type target struct {
+	name   string
+	fields []field
+}
+
+// Generate returns the source of a file, in the package of the Go file
+// filename holding src, with the methods of the structs in it marked with
+// Directive.  Structs with embedded fields or yaml tag options other than
+// omitempty are rejected, as are files marking no structs.
+func Generate(filename string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []target
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			if !marked(doc) {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s is not a struct", fset.Position(ts.Pos()), ts.Name.Name)
+			}
+			t, err := structTarget(ts.Name.Name, st)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", fset.Position(ts.Pos()), err)
+			}
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s: no struct is marked %s", filename, Directive)
+	}
+
+	var methods bytes.Buffer
+	for _, t := range targets {
+		writeMarshal(&methods, t)
+		writeUnmarshal(&methods, t)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by yamlgen from %s. DO NOT EDIT.\n\n", filepath.Base(filename))
+	fmt.Fprintf(&b, "package %s\n\n", f.Name.Name)
+	b.WriteString("import (\n\t\"github.com/fraenkel/candiedyaml\"\n")
+	if bytes.Contains(methods.Bytes(), []byte("gen.")) {
+		b.WriteString("\t\"github.com/fraenkel/candiedyaml/gen\"\n")
+	}
+	b.WriteString(")\n")
+	b.Write(methods.Bytes())
+	return format.Source(b.Bytes())
+}
+
+// marked reports whether doc holds Directive on a line of its own.
+func marked(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == Directive {
+			return true
+		}
+	}
+	return false
+}
+
+// structTarget collects the fields of the struct st named name.
+func structTarget(name string, st *ast.StructType) (target, error) {
+	t := target{name: name}
+	index := 0
+	for _, f := range st.Fields.List {
+		index += len(f.Names)
+		if len(f.Names) == 0 {
+			return t, fmt.Errorf("%s: embedded fields are not supported", name)
+		}
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return t, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		if tag.Get("yamlcomment") != "" {
+			return t, fmt.Errorf("%s: yamlcomment tags are not supported", name)
+		}
+		yamlTag := tag.Get("yaml")
+		if yamlTag == "-" {
+			continue
+		}
+		tagName, opts := yamlTag, ""
+		if i := strings.IndexByte(yamlTag, ','); i >= 0 {
+			tagName, opts = yamlTag[:i], yamlTag[i+1:]
+		}
+		omitEmpty := false
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "":
+			case "omitempty":
+				omitEmpty = true
+			default:
+				return t, fmt.Errorf("%s: yaml tag option %q is not supported", name, opt)
+			}
+		}
+
+		for i, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			fd := field{goName: n.Name, name: tagName, index: index - len(f.Names) + i, typ: basicType(f.Type), expr: f.Type}
+			if fd.name == "" {
+				fd.name = n.Name
+			}
+			if omitEmpty {
+				fd.empty = emptyKind(f.Type, fd.typ)
+			}
+			t.fields = append(t.fields, fd)
+		}
+	}
+	return t, nil
+}
+
+// basicTypes maps the basic types fields are read straight from scalars
+// for to their size in bits, 0 for int and uint.
+var basicTypes = map[string]int{
+	"string": 0, "bool": 0,
+	"int": 0, "int8": 8, "int16": 16, "int32": 32, "int64": 64, "rune": 32,
+	"uint": 0, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64, "byte": 8,
+	"float32": 32, "float64": 64,
+}
+
+// basicType returns the name of expr if it is a basic type, and "" if not.
+func basicType(expr ast.Expr) string {
+	if id, ok := expr.(*ast.Ident); ok {
+		if _, ok := basicTypes[id.Name]; ok {
+			return id.Name
+		}
+	}
+	return ""
+}
+
+// emptyKind returns how an omitempty field of type expr is tested for
+// being empty: as the basic type basic, by "len", by "nil", or else by
+// "IsEmpty".
+func emptyKind(expr ast.Expr, basic string) string {
+	if basic != "" {
+		return basic
+	}
+	switch expr.(type) {
+	case *ast.ArrayType, *ast.MapType:
+		return "len"
+	case *ast.StarExpr, *ast.InterfaceType:
+		return "nil"
+	}
+	return "IsEmpty"
+}
+
+func writeMarshal(b *bytes.Buffer, t target) {
+	fmt.Fprintf(b, "\n// MarshalYAMLWithOptions implements candiedyaml.MarshalerWithOptions.\n")
+	fmt.Fprintf(b, "func (v %s) MarshalYAMLWithOptions(candiedyaml.EncoderSettings) (interface{}, error) {\n", t.name)
+	fmt.Fprintf(b, "m := make(candiedyaml.MapSlice, 0, %d)\n", len(t.fields))
+	for _, f := range t.fields {
+		item := fmt.Sprintf("m = append(m, candiedyaml.MapItem{Key: %q, Value: v.%s})\n", f.name, f.goName)
+		if cond := nonEmptyTest(f); cond != "" {
+			fmt.Fprintf(b, "if %s {\n%s}\n", cond, item)
+		} else {
+			b.WriteString(item)
+		}
+	}
+	b.WriteString("return m, nil\n}\n")
+}
+
+// nonEmptyTest returns the condition under which the field f is written,
+// or "" if it is not omitempty.
+func nonEmptyTest(f field) string {
+	switch f.empty {
+	case "":
+		return ""
+	case "string":
+		return fmt.Sprintf(`v.%s != ""`, f.goName)
+	case "bool":
+		return "v." + f.goName
+	case "len":
+		return fmt.Sprintf("len(v.%s) != 0", f.goName)
+	case "nil":
+		return fmt.Sprintf("v.%s != nil", f.goName)
+	case "IsEmpty":
+		return fmt.Sprintf("!gen.IsEmpty(v.%s)", f.goName)
+	}
+	return fmt.Sprintf("v.%s != 0", f.goName)
+}
+
+func writeUnmarshal(b *bytes.Buffer, t target) {
+	fmt.Fprintf(b, "\n// UnmarshalYAMLField implements candiedyaml.FieldUnmarshaler.\n")
+	fmt.Fprintf(b, "func (v *%s) UnmarshalYAMLField(field int, d *candiedyaml.FieldDecoder) error {\n", t.name)
+	var cases bytes.Buffer
+	for _, f := range t.fields {
+		if read := readScalar(f); read != "" {
+			fmt.Fprintf(&cases, "case %d:\n%s", f.index, read)
+		}
+	}
+	if cases.Len() > 0 {
+		fmt.Fprintf(b, "switch field {\n%s}\n", cases.Bytes())
+	}
+	b.WriteString("return nil\n}\n")
+}
+
+// readScalar returns the statements reading the field f straight from a
+// plainly written scalar, or "" if f is not of a basic type.
+func readScalar(f field) string {
+	typ := f.typ
+	if typ == "" {
+		return ""
+	}
+	bits := basicTypes[typ]
+	var call string
+	switch {
+	case typ == "string":
+		call = "d.String()"
+	case typ == "bool":
+		call = "d.Bool()"
+	case strings.HasPrefix(typ, "float"):
+		call = fmt.Sprintf("d.Float(%d)", bits)
+	case strings.HasPrefix(typ, "int") || typ == "rune":
+		call = fmt.Sprintf("d.Int(%d)", bits)
+	default:
+		call = fmt.Sprintf("d.Uint(%d)", bits)
+	}
+	set := "x"
+	if typ != "string" && typ != "bool" && typ != "int64" && typ != "uint64" && typ != "float64" {
+		set = typ + "(x)"
+	}
+	return fmt.Sprintf("if x, ok := %s; ok {\nv.%s = %s\n}\n", call, f.goName, set)
+}
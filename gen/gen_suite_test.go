@@ -0,0 +1,13 @@
+package gen_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGen(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gen Suite")
+}
@@ -0,0 +1,139 @@
+package gen_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/fraenkel/candiedyaml"
+	"github.com/fraenkel/candiedyaml/gen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	It("writes the methods checked in for the fixtures", func() {
+		src, err := ioutil.ReadFile("fixtures_test.go")
+		Ω(err).ShouldNot(HaveOccurred())
+		want, err := ioutil.ReadFile("fixtures_yamlgen_test.go")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		out, err := gen.Generate("fixtures_test.go", src)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal(string(want)))
+	})
+
+	It("rejects what the generated methods cannot handle", func() {
+		for src, msg := range map[string]string{
+			"type t struct{}":                                                "no struct is marked",
+			"//yamlgen:generate\ntype t int":                                 "t is not a struct",
+			"//yamlgen:generate\ntype t struct{ u }":                         "embedded fields",
+			"//yamlgen:generate\ntype t struct{ A int `yaml:\",flow\"` }":    `option "flow"`,
+			"//yamlgen:generate\ntype t struct{ A int `yamlcomment:\"x\"` }": "yamlcomment",
+			"//yamlgen:generate\ntype t struct{ A int `yaml:\"a,min=1\"` }":  `option "min=1"`,
+		} {
+			_, err := gen.Generate("t.go", []byte("package p\n\n"+src+"\n"))
+			Ω(err).Should(MatchError(ContainSubstring(msg)), src)
+		}
+	})
+})
+
+var _ = Describe("generated methods", func() {
+	decode := func(doc string) (service, reflected) {
+		var s service
+		var r reflected
+		Ω(candiedyaml.Unmarshal([]byte(doc), &s)).Should(Succeed())
+		Ω(candiedyaml.Unmarshal([]byte(doc), &r)).Should(Succeed())
+		return s, r
+	}
+
+	It("decode as Unmarshal decodes without them", func() {
+		for _, doc := range []string{
+			`name: web
+Enabled: true
+replicas: 3
+Port: 8080
+Weight: 0.5
+level: 2
+Timeout: 1m30s
+tags: [a, b]
+env: {A: "1"}
+limits: {cpu: 1.5, memory: 1024}
+defaults: {cpu: 0.25}
+extra: [1, x]
+`,
+			`NAME: "007"
+enabled: yes
+Replicas: 0x10
+port: '80'
+weight: 1e3
+limits: {cpu: 2, memory: 1_000}
+Skipped: x
+internal: y
+unknown: z
+`,
+			"name: ~\nEnabled: false\nreplicas: -5\nWeight: -2.25\nlimits: ~\ndefaults: ~\n",
+			"name: 'null'\nPort: 0\nreplicas: 012\nWeight: 012.5\n",
+		} {
+			s, r := decode(doc)
+			Ω(reflected(s)).Should(Equal(r), doc)
+		}
+	})
+
+	It("report errors as Unmarshal does", func() {
+		for _, doc := range []string{"Port: 70000\n", "Enabled: maybe\n", "[1]\n", "limits: {cpu: x}\n"} {
+			var s service
+			var r reflected
+			err := candiedyaml.Unmarshal([]byte(doc), &s)
+			want := candiedyaml.Unmarshal([]byte(doc), &r)
+			Ω(want).Should(HaveOccurred(), doc)
+			Ω(err).Should(MatchError(strings.Replace(want.Error(), "reflected", "service", -1)), doc)
+		}
+	})
+
+	It("keep the Decoder's settings", func() {
+		for _, opt := range []func(*candiedyaml.Decoder){
+			func(d *candiedyaml.Decoder) { d.RejectUnknownFields(true) },
+			func(d *candiedyaml.Decoder) { d.RejectDuplicateKeys(true) },
+		} {
+			var s service
+			var r reflected
+			doc := "name: a\nname: b\nbogus: 1\n"
+			err := candiedyaml.Unmarshal([]byte(doc), &s, opt)
+			want := candiedyaml.Unmarshal([]byte(doc), &r, opt)
+			Ω(want).Should(HaveOccurred())
+			Ω(err).Should(MatchError(strings.Replace(want.Error(), "reflected", "service", -1)))
+		}
+
+		var s service
+		d := candiedyaml.NewDecoder(strings.NewReader("name: a\nbogus: 1\n"))
+		Ω(d.Decode(&s)).Should(Succeed())
+		Ω(d.Warnings()).Should(HaveLen(1))
+		Ω(d.Warnings()[0].Message).Should(ContainSubstring("bogus"))
+
+		merge := func(d *candiedyaml.Decoder) { d.SetCompatibility(candiedyaml.GoYAMLv2) }
+		doc := "base: &b {name: a, Port: 80}\nservice:\n  <<: *b\n  Port: 81\n"
+		var ms struct{ Service service }
+		var mr struct{ Service reflected }
+		Ω(candiedyaml.Unmarshal([]byte(doc), &ms, merge)).Should(Succeed())
+		Ω(candiedyaml.Unmarshal([]byte(doc), &mr, merge)).Should(Succeed())
+		Ω(reflected(ms.Service)).Should(Equal(mr.Service))
+		Ω(ms.Service.Name).Should(Equal("a"))
+		Ω(ms.Service.Port).Should(Equal(uint16(81)))
+	})
+
+	It("encode as Marshal encodes without them", func() {
+		for _, s := range []service{
+			{Name: "web", Enabled: true, Replicas: 3, Port: 80, Weight: 0.5, Level: 1,
+				Tags: []string{"a"}, Env: map[string]string{"A": "1"},
+				Limits: &limits{CPU: 0.5, Memory: 64}, Defaults: limits{Memory: 1}, Extra: "x", Skipped: "s", internal: "i"},
+			{},
+		} {
+			want, err := candiedyaml.Marshal(reflected(s))
+			Ω(err).ShouldNot(HaveOccurred())
+			out, err := candiedyaml.Marshal(s)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(out)).Should(Equal(string(want)))
+		}
+	})
+})
@@ -0,0 +1,33 @@
+package gen
+
+import (
+	"reflect"
+
+	"github.com/fraenkel/candiedyaml"
+)
+
+// IsEmpty reports whether v is empty as an omitempty field, for fields
+// whose type generated code cannot tell apart from its declaration.
+func IsEmpty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	if n, ok := v.(candiedyaml.Node); ok {
+		return n.Kind == 0
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+// Command yamlgen writes MarshalYAMLWithOptions and UnmarshalYAMLField
+// methods for the structs marked //yamlgen:generate in each Go file named
+// on its command line, to a file beside it with _yamlgen added to its name:
+// config.go gets config_yamlgen.go, and config_test.go config_yamlgen_test.go.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/fraenkel/candiedyaml/gen"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: yamlgen file.go...")
+		os.Exit(2)
+	}
+	status := 0
+	for _, name := range os.Args[1:] {
+		if err := generate(name); err != nil {
+			fmt.Fprintln(os.Stderr, "yamlgen:", err)
+			status = 1
+		}
+	}
+	os.Exit(status)
+}
+
+func generate(name string) error {
+	src, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	out, err := gen.Generate(name, src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(outputName(name), out, 0644)
+}
+
+// outputName returns the name of the file generated from name.
+func outputName(name string) string {
+	if strings.HasSuffix(name, "_test.go") {
+		return strings.TrimSuffix(name, "_test.go") + "_yamlgen_test.go"
+	}
+	return strings.TrimSuffix(name, ".go") + "_yamlgen.go"
+}
@@ -0,0 +1,20 @@
+//go:build go1.18
+// +build go1.18
+
+package candiedyaml
+
+// UnmarshalTo decodes data into a new value of type T, with the same
+// options as Unmarshal.
+func UnmarshalTo[T any](data []byte, opts ...DecodeOption) (T, error) {
+	var v T
+	err := Unmarshal(data, &v, opts...)
+	return v, err
+}
+
+// DecodeTo reads the next document from d into a new value of type T.  Like
+// Decode it returns io.EOF once there are no more documents.
+func DecodeTo[T any](d *Decoder) (T, error) {
+	var v T
+	err := d.Decode(&v)
+	return v, err
+}
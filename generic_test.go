@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"strings"
+)
+
+var _ = Describe("Generic helpers", func() {
+	type config struct {
+		Name  string
+		Ports []int
+	}
+
+	It("unmarshals into a new value", func() {
+		c, err := UnmarshalTo[config]([]byte("name: web\nports: [80, 443]\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(c).Should(Equal(config{Name: "web", Ports: []int{80, 443}}))
+
+		p, err := UnmarshalTo[*config]([]byte("name: web\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(p).Should(Equal(&config{Name: "web"}))
+
+		_, err = UnmarshalTo[int]([]byte("abc"))
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("decodes documents one at a time", func() {
+		d := NewDecoder(strings.NewReader("1\n--- 2\n"))
+
+		for _, expected := range []int{1, 2} {
+			i, err := DecodeTo[int](d)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(i).Should(Equal(expected))
+		}
+
+		_, err := DecodeTo[int](d)
+		Ω(err).Should(Equal(io.EOF))
+	})
+})
@@ -0,0 +1,74 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MediaType is the media type WriteResponse gives YAML bodies.
+const MediaType = "application/yaml"
+
+// yamlMediaTypes are the media types NewHTTPDecoder accepts, including
+// those in use before application/yaml was registered.
+var yamlMediaTypes = map[string]bool{
+	MediaType:            true,
+	"application/x-yaml": true,
+	"text/yaml":          true,
+	"text/x-yaml":        true,
+}
+
+// NewHTTPDecoder returns a Decoder, set up as NewSafeDecoder sets one up,
+// that reads the body of req as it arrives:
+//
+//	func (s *Server) create(w http.ResponseWriter, req *http.Request) {
+//		d, err := candiedyaml.NewHTTPDecoder(req)
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+//			return
+//		}
+//		var spec Spec
+//		if err := d.Decode(&spec); err != nil {
+//			http.Error(w, err.Error(), http.StatusBadRequest)
+//			return
+//		}
+//		...
+//	}
+//
+// It returns an error matching ErrUnsupportedMediaType if the Content-Type
+// of req is not a YAML media type, or has a charset other than UTF-8 or
+// UTF-16.  Requests with no Content-Type are taken to be YAML.
+func NewHTTPDecoder(req *http.Request) (*Decoder, error) {
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		mt, params, err := mime.ParseMediaType(ct)
+		if err != nil || !yamlMediaTypes[mt] {
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedMediaType, ct)
+		}
+		switch charset := strings.ToLower(params["charset"]); charset {
+		case "", "utf-8", "utf8", "utf-16", "utf-16le", "utf-16be":
+		default:
+			return nil, fmt.Errorf("%w: charset %q", ErrUnsupportedMediaType, charset)
+		}
+	}
+	return NewSafeDecoder(req.Body), nil
+}
+
+// WriteResponse writes v as the YAML body of a response with the status
+// code, by an Encoder set up with opts.  The body is written as it is
+// encoded, so an error encoding v is returned after the status has been
+// sent; Marshal first if v may not encode.  Responses with a status that
+// allows no body get only the headers.
+func WriteResponse(w http.ResponseWriter, code int, v interface{}, opts ...EncodeOption) error {
+	w.Header().Set("Content-Type", MediaType+"; charset=utf-8")
+	w.WriteHeader(code)
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < 200 {
+		return nil
+	}
+
+	e := NewEncoder(w)
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e.Encode(v)
+}
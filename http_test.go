@@ -0,0 +1,58 @@
+package candiedyaml
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP helpers", func() {
+	request := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return req
+	}
+
+	It("decodes YAML request bodies", func() {
+		for _, ct := range []string{"", "application/yaml", "application/x-yaml; charset=UTF-8", "text/yaml"} {
+			d, err := NewHTTPDecoder(request(ct, "name: web\n"))
+			Ω(err).ShouldNot(HaveOccurred(), ct)
+			var v map[string]string
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal(map[string]string{"name": "web"}))
+		}
+	})
+
+	It("rejects other media types and charsets", func() {
+		for _, ct := range []string{"application/json", "text/yaml; charset=latin1", "not a type"} {
+			_, err := NewHTTPDecoder(request(ct, "a: 1\n"))
+			Ω(errors.Is(err, ErrUnsupportedMediaType)).Should(BeTrue(), ct)
+		}
+	})
+
+	It("applies the limits of NewSafeDecoder", func() {
+		d, err := NewHTTPDecoder(request("application/yaml", "a: 1\na: 2\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		var v map[string]int
+		Ω(errors.Is(d.Decode(&v), ErrDuplicateKey)).Should(BeTrue())
+	})
+
+	It("writes YAML responses", func() {
+		rec := httptest.NewRecorder()
+		Ω(WriteResponse(rec, http.StatusCreated, map[string]int{"id": 7}, SortKeys())).Should(Succeed())
+		Ω(rec.Code).Should(Equal(http.StatusCreated))
+		Ω(rec.Header().Get("Content-Type")).Should(Equal("application/yaml; charset=utf-8"))
+		Ω(rec.Body.String()).Should(Equal("\"id\": 7\n"))
+
+		rec = httptest.NewRecorder()
+		Ω(WriteResponse(rec, http.StatusNoContent, map[string]int{"id": 7})).Should(Succeed())
+		Ω(rec.Code).Should(Equal(http.StatusNoContent))
+		Ω(rec.Body.Len()).Should(BeZero())
+	})
+})
@@ -0,0 +1,32 @@
+//go:build go1.23
+// +build go1.23
+
+package candiedyaml
+
+import "iter"
+
+// Pairs returns an iterator over the keys and values of m in order.
+func (m MapSlice) Pairs() iter.Seq2[interface{}, interface{}] {
+	return func(yield func(interface{}, interface{}) bool) {
+		for _, item := range m {
+			if !yield(item.Key, item.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Pairs returns an iterator over the keys and values of a MappingNode in
+// order.  It yields nothing for other kinds of node.
+func (n *Node) Pairs() iter.Seq2[*Node, *Node] {
+	return func(yield func(*Node, *Node) bool) {
+		if n.Kind != MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if !yield(n.Content[i], n.Content[i+1]) {
+				return
+			}
+		}
+	}
+}
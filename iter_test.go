@@ -0,0 +1,39 @@
+//go:build go1.23
+// +build go1.23
+
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pairs", func() {
+	It("iterates over a MapSlice in order", func() {
+		var keys, values []interface{}
+		for k, v := range (MapSlice{{"z", 1}, {"a", 2}, {"m", 3}}).Pairs() {
+			keys = append(keys, k)
+			values = append(values, v)
+			if k == "a" {
+				break
+			}
+		}
+		Ω(keys).Should(Equal([]interface{}{"z", "a"}))
+		Ω(values).Should(Equal([]interface{}{1, 2}))
+	})
+
+	It("iterates over a mapping node in order", func() {
+		var n Node
+		Ω(Unmarshal([]byte("z: 1\na: 2\n"), &n)).Should(Succeed())
+
+		var pairs []string
+		for k, v := range n.Content[0].Pairs() {
+			pairs = append(pairs, k.Value+"="+v.Value)
+		}
+		Ω(pairs).Should(Equal([]string{"z=1", "a=2"}))
+
+		for range n.Pairs() {
+			Fail("a document node has no pairs")
+		}
+	})
+})
@@ -0,0 +1,152 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// IssueKind identifies what CheckJSONCompatible found.
+type IssueKind int
+
+const (
+	// SyntaxIssue is a stream that does not parse.  Nothing after it is
+	// checked.
+	SyntaxIssue IssueKind = iota + 1
+	// DocumentsIssue is a document after the first.
+	DocumentsIssue
+	// AnchorIssue is an anchor or an alias, which JSON has no way to share
+	// a value with.
+	AnchorIssue
+	// TagIssue is a tag other than those of strings, numbers, booleans,
+	// null, sequences and mappings.
+	TagIssue
+	// KeyIssue is a mapping key that is not a string.
+	KeyIssue
+	// FloatIssue is a NaN or infinite float.
+	FloatIssue
+)
+
+// An Issue is something in a YAML stream that would not survive conversion
+// to JSON.
+type Issue struct {
+	Kind    IssueKind
+	Message string
+	At      YAML_mark_t
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("yaml: line %d, column %d: %s", i.At.line+1, i.At.column+1, i.Message)
+}
+
+// jsonTags are the tags of the values JSON has.
+var jsonTags = map[string]bool{
+	"!":            true,
+	yaml_STR_TAG:   true,
+	yaml_INT_TAG:   true,
+	yaml_FLOAT_TAG: true,
+	yaml_BOOL_TAG:  true,
+	yaml_NULL_TAG:  true,
+	yaml_SEQ_TAG:   true,
+	yaml_MAP_TAG:   true,
+}
+
+// CheckJSONCompatible parses data and returns everything in it that would
+// not survive conversion to JSON, in the order it occurs: more than one
+// document, anchors and aliases, tags JSON has no type for, keys that are
+// not strings and NaN or infinite floats.  Untagged scalars are resolved as
+// Unmarshal resolves them, so `yes: 1` has a key that is not a string.  It
+// returns nil for a stream JSON can hold as it is.
+func CheckJSONCompatible(data []byte) []Issue {
+	var issues []Issue
+	if err := checkJSONCompatible(data, &issues); err != nil {
+		issue := Issue{Kind: SyntaxIssue, Message: err.Error()}
+		if perr, ok := err.(*ParserError); ok {
+			issue.At = perr.ProblemMark
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func checkJSONCompatible(data []byte, issues *[]Issue) (err error) {
+	defer recoverError(&err)
+
+	d := NewDecoder(bytes.NewReader(data))
+	add := func(kind IssueKind, format string, args ...interface{}) {
+		*issues = append(*issues, Issue{
+			Kind:    kind,
+			Message: fmt.Sprintf(format, args...),
+			At:      d.event.start_mark,
+		})
+	}
+
+	// open holds the collections open, with whether the next node of a
+	// mapping is a key.
+	type collection struct{ mapping, key bool }
+	var open []collection
+	documents := 0
+	for d.nextEvent(); d.event.event_type != yaml_STREAM_END_EVENT; d.nextEvent() {
+		e := &d.event
+		switch e.event_type {
+		case yaml_DOCUMENT_START_EVENT:
+			if documents++; documents == 2 {
+				add(DocumentsIssue, "more than one document")
+			}
+			continue
+		case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+			open = open[:len(open)-1]
+			continue
+		case yaml_SCALAR_EVENT, yaml_ALIAS_EVENT, yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+		default:
+			continue
+		}
+
+		// Work out whether this node is a key before the collection it
+		// starts is pushed.
+		key := false
+		if n := len(open); n > 0 && open[n-1].mapping {
+			key = open[n-1].key
+			open[n-1].key = !key
+		}
+
+		if e.event_type == yaml_ALIAS_EVENT {
+			add(AnchorIssue, "alias *%s", e.anchor)
+		} else if len(e.anchor) > 0 {
+			add(AnchorIssue, "anchor &%s", e.anchor)
+		}
+		if len(e.tag) > 0 && !jsonTags[string(e.tag)] {
+			add(TagIssue, "tag %s", ShortTag(string(e.tag)))
+		}
+
+		switch e.event_type {
+		case yaml_SEQUENCE_START_EVENT:
+			if key {
+				add(KeyIssue, "key is a sequence")
+			}
+			open = append(open, collection{})
+		case yaml_MAPPING_START_EVENT:
+			if key {
+				add(KeyIssue, "key is a mapping")
+			}
+			open = append(open, collection{mapping: true, key: true})
+		case yaml_ALIAS_EVENT:
+			if key {
+				add(KeyIssue, "key is an alias")
+			}
+		case yaml_SCALAR_EVENT:
+			// Scalars that do not resolve fail to decode, but not for JSON.
+			v, err := resolveInterface(*e, YAML11Schema)
+			if err != nil {
+				continue
+			}
+			if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				add(FloatIssue, "float %s", e.value)
+			}
+			if _, ok := v.(string); key && !ok {
+				add(KeyIssue, "key %s is not a string", e.value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckJSONCompatible", func() {
+	messages := func(issues []Issue) []string {
+		var s []string
+		for _, issue := range issues {
+			s = append(s, issue.String())
+		}
+		return s
+	}
+
+	It("finds nothing in JSON-compatible YAML", func() {
+		Ω(CheckJSONCompatible([]byte("name: app\nports: [80, 443]\nratio: 0.5\nenabled: true\nnone: null\n'yes': !!str 1\n"))).Should(BeEmpty())
+		Ω(CheckJSONCompatible([]byte(`{"a": [1, {"b": null}]}`))).Should(BeEmpty())
+	})
+
+	It("reports what JSON cannot hold, in order", func() {
+		issues := CheckJSONCompatible([]byte(`base: &base {x: 1}
+copy: *base
+data: !!binary aGk=
+when: !custom x
+1: one
+yes: no
+[a]: seq
+{k: v}: map
+*base : alias
+limits: [.nan, -.inf, .Inf, 1.5]
+---
+second
+`))
+		Ω(messages(issues)).Should(Equal([]string{
+			"yaml: line 1, column 7: anchor &base",
+			"yaml: line 2, column 7: alias *base",
+			"yaml: line 3, column 7: tag !!binary",
+			"yaml: line 4, column 7: tag !custom",
+			"yaml: line 5, column 1: key 1 is not a string",
+			"yaml: line 6, column 1: key yes is not a string",
+			"yaml: line 7, column 1: key is a sequence",
+			"yaml: line 8, column 1: key is a mapping",
+			"yaml: line 9, column 1: alias *base",
+			"yaml: line 9, column 1: key is an alias",
+			"yaml: line 10, column 10: float .nan",
+			"yaml: line 10, column 16: float -.inf",
+			"yaml: line 10, column 23: float .Inf",
+			"yaml: line 11, column 1: more than one document",
+		}))
+		Ω(issues[0].Kind).Should(Equal(AnchorIssue))
+		Ω(issues[2].Kind).Should(Equal(TagIssue))
+		Ω(issues[4].Kind).Should(Equal(KeyIssue))
+		Ω(issues[10].Kind).Should(Equal(FloatIssue))
+		Ω(issues[13].Kind).Should(Equal(DocumentsIssue))
+	})
+
+	It("stops at a syntax error", func() {
+		issues := CheckJSONCompatible([]byte("a: &x 1\nb: [c\n"))
+		Ω(issues).Should(HaveLen(2))
+		Ω(issues[0].Kind).Should(Equal(AnchorIssue))
+		Ω(issues[1].Kind).Should(Equal(SyntaxIssue))
+		Ω(issues[1].At.line).Should(Equal(2))
+	})
+})
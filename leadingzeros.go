@@ -0,0 +1,60 @@
+package candiedyaml
+
+// A LeadingZeroPolicy decides what untagged plain scalars made of digits
+// with a leading zero, such as phone numbers, IDs and file modes, decode to
+// in interface{} values.
+type LeadingZeroPolicy int
+
+const (
+	// LeadingZerosResolve resolves them by the schema like any other
+	// scalar, so that with YAML11Schema 0755 is the octal 493 and
+	// 0123456789, which is not octal, the float 123456789.  It is the
+	// default.
+	LeadingZerosResolve LeadingZeroPolicy = iota
+
+	// LeadingZerosUnlessOctal leaves them as strings unless they are valid
+	// octal numbers, such as 0755, which are still resolved by the schema.
+	LeadingZerosUnlessOctal
+
+	// LeadingZerosAsStrings leaves all of them as strings.
+	LeadingZerosAsStrings
+)
+
+// SetLeadingZeroPolicy selects what scalars such as 0123456789 and 0755
+// decode to in interface{} values.  Fields of other types parse them as
+// their type does.
+func (d *Decoder) SetLeadingZeroPolicy(p LeadingZeroPolicy) {
+	d.leadingZeros = p
+}
+
+// keepLeadingZeros returns v, resolved from the current scalar, as the
+// scalar's text if the policy keeps its leading zero.
+func (d *Decoder) keepLeadingZeros(v interface{}) interface{} {
+	e := &d.event
+	if d.leadingZeros == LeadingZerosResolve || !isNumber(v) || len(e.tag) > 0 ||
+		yaml_scalar_style_t(e.style) != yaml_PLAIN_SCALAR_STYLE {
+		return v
+	}
+
+	digits := e.value
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	if len(digits) < 2 || digits[0] != '0' {
+		return v
+	}
+
+	octal := true
+	for _, c := range digits {
+		if !is_digit(c) {
+			return v
+		}
+		if c > '7' {
+			octal = false
+		}
+	}
+	if octal && d.leadingZeros == LeadingZerosUnlessOctal {
+		return v
+	}
+	return string(e.value)
+}
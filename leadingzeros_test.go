@@ -0,0 +1,58 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Leading zeros", func() {
+	input := []byte("phone: 0123456789\nmode: 0755\nzip: 02134\nid: 089\nzero: 0\ncount: 12\nfrac: 0.5\nquoted: !!int 0755\nneg: -0755\npos: +089\n")
+	decode := func(p LeadingZeroPolicy) map[string]interface{} {
+		var v map[string]interface{}
+		Ω(Unmarshal(input, &v, DecodeOption(func(d *Decoder) {
+			d.SetLeadingZeroPolicy(p)
+		}))).Should(Succeed())
+		return v
+	}
+
+	It("resolves them by the schema by default", func() {
+		v := decode(LeadingZerosResolve)
+		Ω(v["phone"]).Should(Equal(float64(123456789)))
+		Ω(v["mode"]).Should(Equal(int64(493)))
+		Ω(v["id"]).Should(Equal(float64(89)))
+	})
+
+	It("keeps those that are not octal as strings", func() {
+		v := decode(LeadingZerosUnlessOctal)
+		Ω(v["phone"]).Should(Equal("0123456789"))
+		Ω(v["id"]).Should(Equal("089"))
+		Ω(v["mode"]).Should(Equal(int64(493)))
+		Ω(v["zip"]).Should(Equal(int64(1116)))
+		Ω(v["neg"]).Should(Equal(int64(-493)))
+		Ω(v["pos"]).Should(Equal("+089"))
+	})
+
+	It("keeps all of them as strings", func() {
+		v := decode(LeadingZerosAsStrings)
+		Ω(v).Should(Equal(map[string]interface{}{
+			"phone":  "0123456789",
+			"mode":   "0755",
+			"zip":    "02134",
+			"id":     "089",
+			"zero":   int64(0),
+			"count":  int64(12),
+			"frac":   0.5,
+			"quoted": int64(493),
+			"neg":    "-0755",
+			"pos":    "+089",
+		}))
+	})
+
+	It("applies inside nested interface{} values", func() {
+		var v interface{}
+		Ω(Unmarshal([]byte("ids: [007, 42]\n"), &v, DecodeOption(func(d *Decoder) {
+			d.SetLeadingZeroPolicy(LeadingZerosAsStrings)
+		}))).Should(Succeed())
+		Ω(v).Should(Equal(map[interface{}]interface{}{"ids": []interface{}{"007", int64(42)}}))
+	})
+})
@@ -0,0 +1,247 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// A Defaulter fills in the fields a document left out.  After a Decoder
+// decodes a document, it calls the Default method of each value reachable
+// from the one decoded into, outermost first, whether or not the document
+// mentioned it, so that a section missing from a configuration still gets
+// its defaults.  Nil pointers are left nil:
+//
+//	func (s *Server) Default() {
+//		if s.Port == 0 {
+//			s.Port = 8080
+//		}
+//	}
+type Defaulter interface {
+	Default()
+}
+
+// A Validator checks a decoded value.  Once the defaults are in, a Decoder
+// calls the Validate method of each value reachable from the one decoded
+// into, innermost first, and returns the first error as a ValidationError.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	defaulterType = reflect.TypeOf((*Defaulter)(nil)).Elem()
+	validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+)
+
+// ValidationError is returned when the Validate method of the value of
+// Type at Path returned Err.  Path is empty for the value decoded into, and
+// otherwise names the fields, map keys and slice indexes leading to the
+// value, as FieldSet paths do.
+type ValidationError struct {
+	Path string
+	Type reflect.Type
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("yaml: invalid %s: %v", e.Type, e.Err)
+	}
+	return fmt.Sprintf("yaml: invalid %s at %s: %v", e.Type, e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// SetPostprocess makes the Decoder pass the value each document was decoded
+// into to fn, once Default and Validate methods have been called, for checks
+// that span the whole value.  If fn returns an error, Decode returns it.  A
+// nil fn, the default, leaves values as they were decoded.
+func (d *Decoder) SetPostprocess(fn func(v interface{}) error) {
+	d.postprocess = fn
+}
+
+// finish runs the steps that follow decoding a document into v.
+func (d *Decoder) finish(v interface{}) error {
+	if rv := reflect.ValueOf(v).Elem(); mayHoldMethods(rv.Type()) {
+		walkValues(rv, "", make(map[walked]bool), func(v reflect.Value, path string) error {
+			if m, ok := method(v, defaulterType); ok {
+				m.(Defaulter).Default()
+			}
+			return nil
+		}, nil)
+		err := walkValues(rv, "", make(map[walked]bool), nil, func(v reflect.Value, path string) error {
+			if m, ok := method(v, validatorType); ok {
+				if err := m.(Validator).Validate(); err != nil {
+					return &ValidationError{Path: path, Type: v.Type(), Err: err}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if d.postprocess != nil {
+		return d.postprocess(v)
+	}
+	return nil
+}
+
+// method returns v, or its address, as a value of the interface type t, if
+// either implements it.
+func method(v reflect.Value, t reflect.Type) (interface{}, bool) {
+	if v.CanAddr() && v.Addr().Type().Implements(t) {
+		return v.Addr().Interface(), true
+	}
+	if v.Type().Implements(t) && v.CanInterface() {
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+// walked identifies a pointer, map or slice already walked, so that values
+// reached more than once, or through a cycle, are visited once.
+type walked struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// walkValues calls pre and post for v and each value reachable from it
+// through exported fields, pointers, slices, arrays and maps, before and
+// after the values inside it.  Interfaces are only followed to pointers,
+// since the maps, slices and scalars a Decoder puts in them have no
+// methods.  Embedded structs are walked
+// without calling pre and post for them, since their methods are promoted
+// to the struct embedding them, or replaced by its own.  Map values are
+// copied, walked and put back, so that Default methods can change them.
+func walkValues(v reflect.Value, path string, seen map[walked]bool, pre, post func(v reflect.Value, path string) error) error {
+	return walk(v, path, true, seen, pre, post)
+}
+
+func walk(v reflect.Value, path string, visit bool, seen map[walked]bool, pre, post func(v reflect.Value, path string) error) error {
+	join := func(elem string) string {
+		if path == "" {
+			return elem
+		}
+		return path + "." + elem
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		w := walked{v.Pointer(), v.Type()}
+		if seen[w] {
+			return nil
+		}
+		seen[w] = true
+	case reflect.Interface:
+		if v.IsNil() || v.Elem().Kind() != reflect.Ptr {
+			return nil
+		}
+		return walk(v.Elem(), path, visit, seen, pre, post)
+	}
+	if v.Kind() == reflect.Ptr {
+		return walk(v.Elem(), path, visit, seen, pre, post)
+	}
+
+	if visit && pre != nil {
+		if err := pre(v, path); err != nil {
+			return err
+		}
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" || !mayHoldMethods(sf.Type) {
+				continue
+			}
+			var err error
+			if sf.Anonymous {
+				err = walk(v.Field(i), path, false, seen, pre, post)
+			} else {
+				err = walkValues(v.Field(i), join(sf.Name), seen, pre, post)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if mayHoldMethods(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				if err := walkValues(v.Index(i), join(strconv.Itoa(i)), seen, pre, post); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		if mayHoldMethods(v.Type().Elem()) {
+			copied := v.Type().Elem().Kind() == reflect.Struct || v.Type().Elem().Kind() == reflect.Array
+			for _, key := range v.MapKeys() {
+				elem := v.MapIndex(key)
+				if copied {
+					elem = reflect.New(v.Type().Elem()).Elem()
+					elem.Set(v.MapIndex(key))
+				}
+				if err := walkValues(elem, join(fmt.Sprint(key.Interface())), seen, pre, post); err != nil {
+					return err
+				}
+				if copied {
+					v.SetMapIndex(key, elem)
+				}
+			}
+		}
+	}
+	if visit && post != nil {
+		return post(v, path)
+	}
+	return nil
+}
+
+// lifecycleTypes caches mayHoldMethods by type.
+var lifecycleTypes sync.Map
+
+// mayHoldMethods reports whether values of type t may have Default or
+// Validate methods or hold values that do.
+func mayHoldMethods(t reflect.Type) bool {
+	if b, ok := lifecycleTypes.Load(t); ok {
+		return b.(bool)
+	}
+	b := holdsMethods(t, make(map[reflect.Type]bool))
+	lifecycleTypes.Store(t, b)
+	return b
+}
+
+func holdsMethods(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t == nodeType {
+		return false
+	}
+	pt := reflect.PtrTo(t)
+	if pt.Implements(defaulterType) || pt.Implements(validatorType) {
+		return true
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsMethods(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if sf := t.Field(i); sf.PkgPath == "" && holdsMethods(sf.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
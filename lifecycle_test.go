@@ -0,0 +1,162 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var lifecycleCalls []string
+
+type lifecycleServer struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+func (s *lifecycleServer) Default() {
+	lifecycleCalls = append(lifecycleCalls, "default server "+s.Host)
+	if s.Port == 0 {
+		s.Port = 8080
+	}
+}
+
+func (s lifecycleServer) Validate() error {
+	lifecycleCalls = append(lifecycleCalls, "validate server "+s.Host)
+	if s.Port > 65535 {
+		return fmt.Errorf("port %d is out of range", s.Port)
+	}
+	return nil
+}
+
+type lifecycleBase struct {
+	Region string `yaml:"region"`
+}
+
+func (b *lifecycleBase) Default() {
+	lifecycleCalls = append(lifecycleCalls, "default base")
+	if b.Region == "" {
+		b.Region = "eu"
+	}
+}
+
+type lifecycleConfig struct {
+	lifecycleBase
+	Name    string                     `yaml:"name"`
+	Primary lifecycleServer            `yaml:"primary"`
+	Backup  *lifecycleServer           `yaml:"backup"`
+	Servers []lifecycleServer          `yaml:"servers"`
+	Named   map[string]lifecycleServer `yaml:"named"`
+	Extra   map[string]interface{}     `yaml:"extra"`
+}
+
+func (c *lifecycleConfig) Validate() error {
+	lifecycleCalls = append(lifecycleCalls, "validate config")
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+var _ = Describe("Default and Validate methods", func() {
+	BeforeEach(func() {
+		lifecycleCalls = nil
+	})
+
+	It("fills in defaults, then validates, innermost first", func() {
+		var c lifecycleConfig
+		err := Unmarshal([]byte(`
+name: app
+primary: {host: a}
+servers:
+- {host: b, port: 9000}
+named:
+  c: {host: c}
+extra: {x: 1}
+`), &c)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(c.Region).Should(Equal("eu"))
+		Ω(c.Primary.Port).Should(Equal(8080))
+		Ω(c.Backup).Should(BeNil())
+		Ω(c.Servers[0].Port).Should(Equal(9000))
+		Ω(c.Named["c"].Port).Should(Equal(8080))
+		Ω(lifecycleCalls).Should(Equal([]string{
+			"default base",
+			"default server a",
+			"default server b",
+			"default server c",
+			"validate server a",
+			"validate server b",
+			"validate server c",
+			"validate config",
+		}))
+	})
+
+	It("fills in sections the document left out", func() {
+		var c lifecycleConfig
+		Ω(Unmarshal([]byte("name: app\n"), &c)).Should(Succeed())
+		Ω(c.Primary.Port).Should(Equal(8080))
+	})
+
+	It("returns the first error as a ValidationError", func() {
+		var c lifecycleConfig
+		err := Unmarshal([]byte("name: app\nservers: [{host: a}, {host: b, port: 70000}]\n"), &c)
+		var ve *ValidationError
+		Ω(errors.As(err, &ve)).Should(BeTrue())
+		Ω(ve.Path).Should(Equal("Servers.1"))
+		Ω(err).Should(MatchError("yaml: invalid candiedyaml.lifecycleServer at Servers.1: port 70000 is out of range"))
+
+		var unnamed lifecycleConfig
+		err = Unmarshal([]byte("backup: {host: b}\n"), &unnamed)
+		Ω(err).Should(MatchError("yaml: invalid candiedyaml.lifecycleConfig: name is required"))
+	})
+
+	It("walks values reached through pointers and interfaces", func() {
+		backup := &lifecycleServer{}
+		v := struct{ Backup interface{} }{backup}
+		Ω(Unmarshal([]byte("backup: {host: b}\n"), &v)).Should(Succeed())
+		Ω(backup.Host).Should(Equal("b"))
+		Ω(backup.Port).Should(Equal(8080))
+
+		var servers []*lifecycleServer
+		Ω(Unmarshal([]byte("- {host: a, port: 70000}\n"), &servers)).Should(MatchError(HaveSuffix("at 0: port 70000 is out of range")))
+	})
+})
+
+var _ = Describe("SetPostprocess", func() {
+	It("passes each decoded value to the function after validation", func() {
+		lifecycleCalls = nil
+		d := NewDecoder(strings.NewReader("name: a\n---\nname: b\n"))
+		var names []string
+		d.SetPostprocess(func(v interface{}) error {
+			c := v.(*lifecycleConfig)
+			lifecycleCalls = append(lifecycleCalls, "postprocess "+c.Name)
+			if c.Name == "b" {
+				return errors.New("b is reserved")
+			}
+			names = append(names, c.Name)
+			return nil
+		})
+
+		var c lifecycleConfig
+		Ω(d.Decode(&c)).Should(Succeed())
+		Ω(lifecycleCalls[len(lifecycleCalls)-2:]).Should(Equal([]string{"validate config", "postprocess a"}))
+		Ω(d.Decode(&c)).Should(MatchError("b is reserved"))
+		Ω(names).Should(Equal([]string{"a"}))
+	})
+
+	It("is passed Nodes too", func() {
+		var kinds []NodeKind
+		var doc Node
+		err := Unmarshal([]byte("a: 1\n"), &doc, func(d *Decoder) {
+			d.SetPostprocess(func(v interface{}) error {
+				kinds = append(kinds, v.(*Node).Kind)
+				return nil
+			})
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(kinds).Should(Equal([]NodeKind{DocumentNode}))
+	})
+})
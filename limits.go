@@ -0,0 +1,108 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"io"
+)
+
+// LimitError is returned when a Decoder exceeds one of the limits set with
+// SetMaxDepth, SetMaxAliases or SetMaxInputSize, or when merge keys replay
+// too many events.  At is where the limit was exceeded; it is not set for
+// the input size limit.
+type LimitError struct {
+	Limit string
+	Max   int64
+	At    YAML_mark_t
+}
+
+func (e *LimitError) Error() string {
+	if e.Limit == "input size" {
+		return fmt.Sprintf("yaml: input exceeds %d bytes", e.Max)
+	}
+	return fmt.Sprintf("yaml: exceeded the %s limit of %d at line %d, column %d", e.Limit, e.Max, e.At.Line(), e.At.Column())
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// NewSafeDecoder returns a Decoder for untrusted input.  It allows at most
+// 100 levels of nesting, 100 aliases per document and 10MB of input, rejects
+// duplicate keys and aliases to anchors in earlier documents, and decodes
+// timestamps into interface{} values as strings.
+func NewSafeDecoder(r io.Reader) *Decoder {
+	d := NewDecoder(r)
+	d.AllowCrossDocumentAnchors(false)
+	d.SetMaxDepth(100)
+	d.SetMaxAliases(100)
+	d.SetMaxInputSize(10 << 20)
+	d.RejectDuplicateKeys(true)
+	d.ResolveTimestamps(false)
+	return d
+}
+
+// SetMaxDepth limits how deeply collections may be nested.  Zero, the
+// default, means no limit.
+func (d *Decoder) SetMaxDepth(n int) {
+	d.maxDepth = n
+}
+
+// SetMaxAliases limits how many aliases each document may use.  Zero, the
+// default, means no limit.
+func (d *Decoder) SetMaxAliases(n int) {
+	d.maxAliases = n
+}
+
+// SetMaxInputSize limits how many bytes the Decoder reads.  Zero, the
+// default, means no limit.
+func (d *Decoder) SetMaxInputSize(n int64) {
+	if lr, ok := d.parser.input_reader.(*limitedReader); ok {
+		lr.max = n
+		return
+	}
+	d.parser.input_reader = &limitedReader{r: d.parser.input_reader, max: n}
+}
+
+// ResolveTimestamps selects whether plain scalars that look like timestamps
+// are decoded into interface{} values as time.Time, the default, or left as
+// strings.  Fields of type time.Time are not affected.
+func (d *Decoder) ResolveTimestamps(resolve bool) {
+	d.noTimestamps = !resolve
+}
+
+func (d *Decoder) enter() {
+	d.depth++
+	if d.depth > d.deepest {
+		d.deepest = d.depth
+	}
+	if d.maxDepth > 0 && d.depth > d.maxDepth {
+		d.error(&LimitError{Limit: "depth", Max: int64(d.maxDepth), At: d.event.start_mark})
+	}
+}
+
+func (d *Decoder) leave() {
+	d.depth--
+}
+
+func (d *Decoder) countAlias() {
+	d.aliases++
+	if d.maxAliases > 0 && d.aliases > d.maxAliases {
+		d.error(&LimitError{Limit: "alias", Max: int64(d.maxAliases), At: d.event.start_mark})
+	}
+}
+
+// limitedReader fails once more than max bytes have been read.
+type limitedReader struct {
+	r    io.Reader
+	read int64
+	max  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.max > 0 && l.read > l.max {
+		return 0, &LimitError{Limit: "input size", Max: l.max}
+	}
+	return n, err
+}
@@ -0,0 +1,104 @@
+package candiedyaml
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Limits", func() {
+	It("limits nesting depth", func() {
+		d := NewDecoder(strings.NewReader("a: [[1]]\n"))
+		d.SetMaxDepth(2)
+		var v interface{}
+		err := d.Decode(&v)
+		Ω(errors.Is(err, ErrLimitExceeded)).Should(BeTrue())
+		Ω(err.Error()).Should(Equal("yaml: exceeded the depth limit of 2 at line 1, column 5"))
+		var lerr *LimitError
+		Ω(errors.As(err, &lerr)).Should(BeTrue())
+		Ω([]int{lerr.At.Line(), lerr.At.Column()}).Should(Equal([]int{1, 5}))
+
+		d = NewDecoder(strings.NewReader("a: [[1]]\n"))
+		d.SetMaxDepth(3)
+		var s struct{ A [][]int }
+		Ω(d.Decode(&s)).Should(Succeed())
+	})
+
+	It("limits nesting depth of nodes", func() {
+		d := NewDecoder(strings.NewReader("[[[]]]"))
+		d.SetMaxDepth(2)
+		var n Node
+		Ω(errors.Is(d.Decode(&n), ErrLimitExceeded)).Should(BeTrue())
+	})
+
+	It("limits aliases per document", func() {
+		data := "a: &a 1\nb: *a\nc: *a\n---\nd: &d 1\ne: *d\n"
+		d := NewDecoder(strings.NewReader(data))
+		d.SetMaxAliases(1)
+
+		var v map[string]int
+		err := d.Decode(&v)
+		Ω(err).Should(BeAssignableToTypeOf(&LimitError{}))
+		Ω(err.(*LimitError).Limit).Should(Equal("alias"))
+
+		d = NewDecoder(strings.NewReader(data))
+		d.SetMaxAliases(2)
+		var all []map[string]int
+		Ω(d.DecodeAll(&all)).Should(Succeed())
+		Ω(all).Should(HaveLen(2))
+	})
+
+	It("limits the input size", func() {
+		d := NewDecoder(strings.NewReader("a: " + strings.Repeat("b", 100)))
+		d.SetMaxInputSize(50)
+		var v interface{}
+		err := d.Decode(&v)
+		Ω(errors.Is(err, ErrLimitExceeded)).Should(BeTrue())
+		Ω(err.Error()).Should(ContainSubstring("input exceeds 50 bytes"))
+	})
+
+	It("can leave timestamps as strings", func() {
+		data := "a: 2001-12-14\nb: 2001-12-14\n"
+		var v struct {
+			A interface{}
+			B time.Time
+		}
+		Ω(Unmarshal([]byte(data), &v)).Should(Succeed())
+		Ω(v.A).Should(BeAssignableToTypeOf(time.Time{}))
+
+		d := NewDecoder(strings.NewReader(data))
+		d.ResolveTimestamps(false)
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(v.A).Should(Equal("2001-12-14"))
+		Ω(v.B).Should(Equal(time.Date(2001, 12, 14, 0, 0, 0, 0, time.UTC)))
+
+		var m interface{}
+		d = NewDecoder(strings.NewReader(data))
+		d.ResolveTimestamps(false)
+		Ω(d.Decode(&m)).Should(Succeed())
+		Ω(m).Should(Equal(map[interface{}]interface{}{"a": "2001-12-14", "b": "2001-12-14"}))
+	})
+
+	Context("NewSafeDecoder", func() {
+		It("decodes ordinary documents", func() {
+			var v interface{}
+			Ω(NewSafeDecoder(strings.NewReader("a: [1, {b: 2001-12-14}]\n")).Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal(map[interface{}]interface{}{
+				"a": []interface{}{int64(1), map[interface{}]interface{}{"b": "2001-12-14"}},
+			}))
+		})
+
+		It("rejects hostile documents", func() {
+			for _, data := range []string{
+				strings.Repeat("[", 101) + strings.Repeat("]", 101),
+				"a: 1\na: 2\n",
+				"a: &a [1]\nb: [" + strings.Repeat("*a, ", 101) + "]\n",
+			} {
+				var v map[string]interface{}
+				Ω(NewSafeDecoder(strings.NewReader(data)).Decode(&v)).ShouldNot(Succeed())
+			}
+		})
+	})
+})
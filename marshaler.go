@@ -0,0 +1,89 @@
+package candiedyaml
+
+import (
+	"reflect"
+)
+
+// EncoderSettings are the settings of the Encoder writing a value, as given
+// to a MarshalerWithOptions.
+type EncoderSettings struct {
+	// Indent is the number of spaces nested block collections are
+	// indented by, and Width the width long scalars are folded at, or -1
+	// for no limit.
+	Indent int
+	Width  int
+
+	// Schema is the schema set with SetSchema, or nil if strings are
+	// double-quoted.
+	Schema *Schema
+
+	// SortKeys is set when struct fields and MapSlice entries are written
+	// in key order, for output that must not depend on declaration order.
+	SortKeys bool
+
+	// Unicode is set when characters outside ASCII are written as they
+	// are, and Compact is the width set with SetCompact.
+	Unicode bool
+	Compact int
+}
+
+// A MarshalerWithOptions returns the value to encode in its place, chosen
+// with the settings of the Encoder writing it, so that it can follow them
+// rather than hard-code a style: a type can return its entries as a
+// MapSlice in key order when SortKeys is set, or a Node with a literal
+// scalar when lines are not folded.  The value returned is encoded as any
+// other, with its own MarshalYAMLWithOptions method if it has one, so it
+// must not return itself.
+type MarshalerWithOptions interface {
+	MarshalYAMLWithOptions(s EncoderSettings) (interface{}, error)
+}
+
+var marshalerWithOptionsType = reflect.TypeOf((*MarshalerWithOptions)(nil)).Elem()
+
+// settings returns the settings of e.
+func (e *Encoder) settings() EncoderSettings {
+	width := e.emitter.best_width
+	if width == 1<<31-1 {
+		width = -1
+	}
+	return EncoderSettings{
+		Indent:   e.emitter.best_indent,
+		Width:    width,
+		Schema:   e.schema,
+		SortKeys: e.sortKeys,
+		Unicode:  e.emitter.unicode,
+		Compact:  e.compact,
+	}
+}
+
+// marshalWithOptions encodes v with its MarshalYAMLWithOptions method, if it
+// has one.
+func (e *Encoder) marshalWithOptions(tag string, v reflect.Value) bool {
+	if !v.IsValid() || !v.CanInterface() {
+		return false
+	}
+	if !v.Type().Implements(marshalerWithOptionsType) {
+		if !v.CanAddr() || !v.Addr().Type().Implements(marshalerWithOptionsType) {
+			return false
+		}
+		v = v.Addr()
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return false
+	}
+
+	var out interface{}
+	var err error
+	e.call(func() {
+		out, err = v.Interface().(MarshalerWithOptions).MarshalYAMLWithOptions(e.settings())
+	})
+	if err != nil {
+		panic(err)
+	}
+	if out == nil {
+		e.emitNil()
+	} else {
+		e.marshal(tag, reflect.ValueOf(out))
+	}
+	return true
+}
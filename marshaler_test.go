@@ -0,0 +1,104 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// labels writes its entries in key order only when the Encoder sorts keys.
+type labels []string
+
+func (l labels) MarshalYAMLWithOptions(s EncoderSettings) (interface{}, error) {
+	out := append([]string(nil), l...)
+	if s.SortKeys {
+		sort.Strings(out)
+	}
+	return out, nil
+}
+
+// banner writes its text as a literal block when lines are not folded.
+type banner struct {
+	text string
+}
+
+func (b *banner) MarshalYAMLWithOptions(s EncoderSettings) (interface{}, error) {
+	if b.text == "" {
+		return nil, errors.New("empty banner")
+	}
+	if s.Width < 0 {
+		return &Node{Kind: ScalarNode, Style: LiteralStyle, Value: b.text}, nil
+	}
+	return strings.Replace(b.text, "\n", " ", -1), nil
+}
+
+// settingsProbe records the settings it was written with.
+type settingsProbe struct {
+	got *EncoderSettings
+}
+
+func (p settingsProbe) MarshalYAMLWithOptions(s EncoderSettings) (interface{}, error) {
+	*p.got = s
+	return "probe", nil
+}
+
+var _ = Describe("MarshalerWithOptions", func() {
+	encode := func(v interface{}, set func(e *Encoder)) string {
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(YAML11Schema)
+		if set != nil {
+			set(e)
+		}
+		Ω(e.Encode(v)).Should(Succeed())
+		return buf.String()
+	}
+
+	It("gives marshalers the settings of the Encoder", func() {
+		var got EncoderSettings
+		encode(settingsProbe{&got}, func(e *Encoder) {
+			e.SetIndent(4)
+			e.SetWidth(-1)
+			e.SortKeys(true)
+			e.SetUnicode(true)
+			e.SetCompact(40)
+		})
+		Ω(got).Should(Equal(EncoderSettings{
+			Indent:   4,
+			Width:    -1,
+			Schema:   YAML11Schema,
+			SortKeys: true,
+			Unicode:  true,
+			Compact:  40,
+		}))
+
+		encode(settingsProbe{&got}, nil)
+		Ω(got.Indent).Should(Equal(2))
+		Ω(got.Width).Should(Equal(80))
+		Ω(got.SortKeys).Should(BeFalse())
+	})
+
+	It("encodes the value returned in place of the marshaler", func() {
+		l := labels{"web", "app", "db"}
+		Ω(encode(l, nil)).Should(Equal("- web\n- app\n- db\n"))
+		Ω(encode(l, func(e *Encoder) { e.SortKeys(true) })).Should(Equal("- app\n- db\n- web\n"))
+	})
+
+	It("finds methods on pointers to addressable values", func() {
+		type page struct {
+			Banner banner `yaml:"banner"`
+		}
+		p := &page{banner{"line one\nline two"}}
+		Ω(encode(p, nil)).Should(Equal("banner: line one line two\n"))
+		Ω(encode(p, func(e *Encoder) { e.SetWidth(-1) })).Should(Equal("banner: |-\n  line one\n  line two\n"))
+	})
+
+	It("returns the errors of marshalers", func() {
+		err := NewEncoder(&bytes.Buffer{}).Encode(&banner{})
+		Ω(err).Should(MatchError("empty banner"))
+	})
+})
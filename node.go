@@ -0,0 +1,640 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var nodeType = reflect.TypeOf(Node{})
+
+// The standard tags, in the expanded form used by Node.Tag.
+const (
+	NullTag      = yaml_NULL_TAG
+	BoolTag      = yaml_BOOL_TAG
+	StrTag       = yaml_STR_TAG
+	IntTag       = yaml_INT_TAG
+	FloatTag     = yaml_FLOAT_TAG
+	TimestampTag = yaml_TIMESTAMP_TAG
+	BinaryTag    = "tag:yaml.org,2002:binary"
+	MergeTag     = "tag:yaml.org,2002:merge"
+	SeqTag       = yaml_SEQ_TAG
+	MapTag       = yaml_MAP_TAG
+)
+
+const standardTagPrefix = "tag:yaml.org,2002:"
+
+// IsScalarTag reports whether tag, in either form, is one of the standard
+// scalar tags.
+func IsScalarTag(tag string) bool {
+	switch LongTag(tag) {
+	case NullTag, BoolTag, StrTag, IntTag, FloatTag, TimestampTag, BinaryTag, MergeTag:
+		return true
+	}
+	return false
+}
+
+// ShortTag abbreviates a standard tag to its !! shorthand, e.g. "!!str" for
+// StrTag.  Other tags are returned unchanged.
+func ShortTag(tag string) string {
+	if strings.HasPrefix(tag, standardTagPrefix) {
+		return "!!" + tag[len(standardTagPrefix):]
+	}
+	return tag
+}
+
+// LongTag expands a !! shorthand, e.g. "!!str" to StrTag.  Other tags are
+// returned unchanged.
+func LongTag(tag string) string {
+	if strings.HasPrefix(tag, "!!") {
+		return standardTagPrefix + tag[2:]
+	}
+	return tag
+}
+
+// NodeKind identifies what a Node represents.
+type NodeKind int
+
+const (
+	DocumentNode NodeKind = iota + 1
+	SequenceNode
+	MappingNode
+	ScalarNode
+	AliasNode
+)
+
+var nodeKindNames = []string{"", "document", "sequence", "mapping", "scalar", "alias"}
+
+func (k NodeKind) String() string {
+	if k > 0 && int(k) < len(nodeKindNames) {
+		return nodeKindNames[k]
+	}
+	return fmt.Sprintf("NodeKind(%d)", int(k))
+}
+
+// NodeStyle records how a Node was written.  The zero value is a plain
+// scalar or a block collection.
+type NodeStyle int
+
+const (
+	DoubleQuotedStyle NodeStyle = iota + 1
+	SingleQuotedStyle
+	LiteralStyle
+	FoldedStyle
+	FlowStyle
+)
+
+var nodeStyleNames = []string{"plain", "double-quoted", "single-quoted", "literal", "folded", "flow"}
+
+func (s NodeStyle) String() string {
+	if s >= 0 && int(s) < len(nodeStyleNames) {
+		return nodeStyleNames[s]
+	}
+	return fmt.Sprintf("NodeStyle(%d)", int(s))
+}
+
+// Chomping is how a literal or folded scalar treats the line breaks at its
+// end.
+type Chomping int
+
+const (
+	// ClipChomping keeps a single final line break.  It has no indicator.
+	ClipChomping Chomping = iota
+	// StripChomping, written '-', removes all final line breaks.
+	StripChomping
+	// KeepChomping, written '+', keeps all final line breaks.
+	KeepChomping
+)
+
+// A Node is a YAML document, collection, scalar or alias together with the
+// details of how it was written, so that it can be encoded again without
+// rewriting the parts that did not change.
+//
+// Decoding into a Node produces a DocumentNode holding the root node.
+// Decoding into a Node field of a struct, or an element of a map or slice,
+// produces the node for that value.  Likewise Nodes and pointers to them may
+// be encoded on their own or as part of any other value; a Node with no Kind
+// is encoded as null and counts as empty for omitempty.
+type Node struct {
+	Kind  NodeKind
+	Style NodeStyle
+
+	// Tag is the explicit tag, if any, with shorthands expanded, e.g.
+	// StrTag for "!!str".
+	Tag string
+
+	// Value is the text of a scalar or the anchor named by an alias.
+	Value string
+
+	Anchor string
+
+	// Alias is the node an alias refers to.
+	Alias *Node
+
+	// Content holds the root of a document, the items of a sequence, or the
+	// keys and values of a mapping in turn.
+	Content []*Node
+
+	// Line and Column give the position of the node in the source, counting
+	// from 1.
+	Line   int
+	Column int
+
+	// BlankLines is the number of blank lines before the node in block
+	// context.  Only the outermost node starting on a line records them.
+	BlankLines int
+
+	// Chomping and Indent are the indicators of a literal or folded scalar,
+	// e.g. StripChomping and 2 for "|-2".  Indent is 0 unless it was given
+	// explicitly.  Encoding uses them where they fit Value and keeps the
+	// chomping Value requires otherwise.
+	Chomping Chomping
+	Indent   int
+
+	// HeadComment holds the comment lines before the node, each starting
+	// with '#', with blank lines among them kept as empty lines.
+	// LineComment is the comment at the end of the line the node is on.
+	// FootComment holds the comment lines at the end of a document.
+	HeadComment string
+	LineComment string
+	FootComment string
+}
+
+// Anchors returns the nodes under n, n included, that define an anchor, by
+// the name of the anchor.  Where a name is defined more than once, as YAML
+// allows, the map holds the last definition.  Aliases are not followed.
+func (n *Node) Anchors() map[string]*Node {
+	anchors := make(map[string]*Node)
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.Anchor != "" {
+			anchors[n.Anchor] = n
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(n)
+	return anchors
+}
+
+// Clone returns a deep copy of n, so that a document can be changed in
+// several ways without decoding it again.  Aliases to nodes under n refer to
+// their copies in the clone; those to nodes elsewhere are kept.  Strings are
+// immutable, so the copies share the text of scalars and comments with n.
+// Cloning a DocumentNode clones the whole document.
+func (n *Node) Clone() *Node {
+	return n.clone(make(map[*Node]*Node))
+}
+
+// clone is Clone, recording the copy of each node in clones.
+func (n *Node) clone(clones map[*Node]*Node) *Node {
+	var clone func(n *Node) *Node
+	clone = func(n *Node) *Node {
+		c := *n
+		clones[n] = &c
+		if n.Content != nil {
+			c.Content = make([]*Node, len(n.Content))
+			for i, child := range n.Content {
+				c.Content[i] = clone(child)
+			}
+		}
+		return &c
+	}
+	root := clone(n)
+
+	for _, c := range clones {
+		if a, ok := clones[c.Alias]; ok {
+			c.Alias = a
+		}
+	}
+	return root
+}
+
+// Decode decodes the value n holds into v, as Unmarshal would decode the
+// YAML that n encodes to.  It binds part of a document decoded into Nodes to
+// a Go value without encoding it first.  Errors give the positions recorded
+// in n.  Aliases to anchors outside n decode as the nodes they refer to.
+func (n *Node) Decode(v interface{}) (err error) {
+	defer recoverError(&err)
+
+	d := NewDecoder(strings.NewReader(""))
+	d.replay = []yaml_event_t{
+		{event_type: yaml_STREAM_START_EVENT, encoding: yaml_UTF8_ENCODING},
+		{event_type: yaml_DOCUMENT_START_EVENT, implicit: true},
+	}
+	d.replay = n.events(d.replay, make(map[string]bool))
+	d.replay = append(d.replay,
+		yaml_event_t{event_type: yaml_DOCUMENT_END_EVENT, implicit: true},
+		yaml_event_t{event_type: yaml_STREAM_END_EVENT})
+	return d.Decode(v)
+}
+
+// events appends the events a parser would produce for n to events.
+// defined holds the anchors defined so far.
+func (n *Node) events(events []yaml_event_t, defined map[string]bool) []yaml_event_t {
+	mark := YAML_mark_t{line: n.Line - 1, column: n.Column - 1}
+	e := yaml_event_t{
+		anchor:     []byte(n.Anchor),
+		tag:        []byte(LongTag(n.Tag)),
+		implicit:   n.Tag == "",
+		start_mark: mark,
+		end_mark:   mark,
+	}
+	if n.Anchor != "" {
+		defined[n.Anchor] = true
+	}
+
+	switch n.Kind {
+	case 0:
+		e.event_type = yaml_SCALAR_EVENT
+		e.value = []byte("null")
+		e.plain_implicit = true
+		e.style = yaml_style_t(yaml_PLAIN_SCALAR_STYLE)
+		return append(events, e)
+	case DocumentNode:
+		if len(n.Content) == 0 {
+			return (&Node{}).events(events, defined)
+		}
+		return n.Content[0].events(events, defined)
+	case ScalarNode:
+		e.event_type = yaml_SCALAR_EVENT
+		e.value = []byte(n.Value)
+		e.plain_implicit = n.Tag == ""
+		e.quoted_implicit = n.Tag == ""
+		switch n.Style {
+		case DoubleQuotedStyle:
+			e.style = yaml_style_t(yaml_DOUBLE_QUOTED_SCALAR_STYLE)
+		case SingleQuotedStyle:
+			e.style = yaml_style_t(yaml_SINGLE_QUOTED_SCALAR_STYLE)
+		case LiteralStyle:
+			e.style = yaml_style_t(yaml_LITERAL_SCALAR_STYLE)
+		case FoldedStyle:
+			e.style = yaml_style_t(yaml_FOLDED_SCALAR_STYLE)
+		default:
+			e.style = yaml_style_t(yaml_PLAIN_SCALAR_STYLE)
+		}
+		// As from the parser, only plain scalars are resolved by value.
+		e.implicit = e.plain_implicit && yaml_scalar_style_t(e.style) == yaml_PLAIN_SCALAR_STYLE
+		return append(events, e)
+	case AliasNode:
+		name := n.Value
+		if name == "" && n.Alias != nil {
+			name = n.Alias.Anchor
+		}
+		if !defined[name] && n.Alias != nil {
+			return n.Alias.events(events, defined)
+		}
+		e.event_type = yaml_ALIAS_EVENT
+		e.anchor = []byte(name)
+		return append(events, e)
+	case SequenceNode, MappingNode:
+		e.event_type, e.style = yaml_SEQUENCE_START_EVENT, yaml_style_t(yaml_BLOCK_SEQUENCE_STYLE)
+		end := yaml_SEQUENCE_END_EVENT
+		if n.Kind == MappingNode {
+			e.event_type, e.style = yaml_MAPPING_START_EVENT, yaml_style_t(yaml_BLOCK_MAPPING_STYLE)
+			end = yaml_MAPPING_END_EVENT
+		}
+		if n.Style == FlowStyle {
+			e.style = yaml_style_t(yaml_FLOW_SEQUENCE_STYLE)
+		}
+		events = append(events, e)
+		for _, c := range n.Content {
+			events = c.events(events, defined)
+		}
+		return append(events, yaml_event_t{event_type: end, start_mark: mark, end_mark: mark})
+	}
+	panic(errors.New("yaml: cannot decode node of unknown kind"))
+}
+
+// NodeFrom returns the node for v, as Marshal would encode it, ready to be
+// put into a document decoded into Nodes.  Strings are plain unless they
+// need quoting.
+func NodeFrom(v interface{}) (*Node, error) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.SetSchema(YAML11Schema)
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+
+	var doc Node
+	if err := NewDecoder(buf).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc.Content[0], nil
+}
+
+// nodeTypes caches holdsNodes by type.
+var nodeTypes sync.Map
+
+// holdsNodes reports whether decoding into values of type t may decode
+// Nodes, through fields, NodeUnmarshalers or adapters, so that the Decoder
+// has to keep comments.
+func holdsNodes(t reflect.Type) bool {
+	if b, ok := nodeTypes.Load(t); ok {
+		return b.(bool)
+	}
+	b := containsNode(t, make(map[reflect.Type]bool))
+	nodeTypes.Store(t, b)
+	return b
+}
+
+func containsNode(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t == nodeType || reflect.PtrTo(t).Implements(nodeUnmarshalerType) {
+		return true
+	}
+	if a, ok := adapterFor(t); ok {
+		return a.nodes
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsNode(t.Elem(), visiting)
+	case reflect.Map:
+		return containsNode(t.Key(), visiting) || containsNode(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if sf := t.Field(i); sf.PkgPath == "" && containsNode(sf.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *Decoder) documentNode(n *Node) {
+	if d.event.event_type != yaml_DOCUMENT_START_EVENT {
+		d.error(fmt.Errorf("Expected document start - found %d", d.event.event_type))
+	}
+
+	start := d.event.start_mark.offset
+	*n = Node{
+		Kind:        DocumentNode,
+		Line:        d.event.start_mark.line + 1,
+		Column:      d.event.start_mark.column + 1,
+		BlankLines:  d.blankLines(),
+		HeadComment: d.headComment(),
+	}
+
+	d.nextEvent()
+	if d.event.event_type != yaml_DOCUMENT_END_EVENT {
+		n.Content = []*Node{d.node(make(map[string]*Node))}
+		d.lineComments(n.Content[0])
+	}
+
+	if d.event.event_type != yaml_DOCUMENT_END_EVENT {
+		d.error(fmt.Errorf("Expected document end - found %d", d.event.event_type))
+	}
+
+	// Comments before a '---' belong to the document it starts, and those
+	// at the end of the stream to the last document.
+	if !d.event.implicit {
+		n.FootComment = d.headComment()
+	}
+	if token := peek_token(&d.parser); token != nil && token.token_type == yaml_STREAM_END_TOKEN {
+		line := token.start_mark.line
+		c := d.parser.head_comments[line]
+		delete(d.parser.head_comments, line)
+		n.FootComment = string(join_comments([]byte(n.FootComment), c, '\n'))
+	}
+
+	d.span(n, start)
+	d.nextEvent()
+}
+
+func (d *Decoder) node(anchors map[string]*Node) *Node {
+	start := d.event.start_mark.offset
+	n := &Node{
+		Tag:         string(d.event.tag),
+		Anchor:      string(d.event.anchor),
+		Line:        d.event.start_mark.line + 1,
+		Column:      d.event.start_mark.column + 1,
+		BlankLines:  d.blankLines(),
+		HeadComment: d.headComment(),
+	}
+
+	switch d.event.event_type {
+	case yaml_SCALAR_EVENT:
+		n.Kind = ScalarNode
+		n.Value = string(d.event.value)
+		switch yaml_scalar_style_t(d.event.style) {
+		case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+			n.Style = DoubleQuotedStyle
+		case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+			n.Style = SingleQuotedStyle
+		case yaml_LITERAL_SCALAR_STYLE:
+			n.Style = LiteralStyle
+		case yaml_FOLDED_SCALAR_STYLE:
+			n.Style = FoldedStyle
+		}
+		switch d.event.chomping {
+		case -1:
+			n.Chomping = StripChomping
+		case 1:
+			n.Chomping = KeepChomping
+		}
+		n.Indent = d.event.indent
+	case yaml_ALIAS_EVENT:
+		d.countAlias()
+		n.Kind = AliasNode
+		n.Value = n.Anchor
+		n.Anchor = ""
+		n.Alias = anchors[n.Value]
+		if n.Alias == nil {
+			d.error(&UnknownAnchorError{Anchor: n.Value, At: d.event.start_mark})
+		}
+	case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+		end := yaml_SEQUENCE_END_EVENT
+		n.Kind = SequenceNode
+		if d.event.event_type == yaml_MAPPING_START_EVENT {
+			end = yaml_MAPPING_END_EVENT
+			n.Kind = MappingNode
+		}
+		if d.event.style == yaml_style_t(yaml_FLOW_SEQUENCE_STYLE) {
+			n.Style = FlowStyle
+		}
+		if n.Anchor != "" {
+			anchors[n.Anchor] = n
+		}
+
+		d.enter()
+		d.nextEvent()
+		for d.event.event_type != end {
+			n.Content = append(n.Content, d.node(anchors))
+		}
+		d.leave()
+	default:
+		d.error(&UnexpectedEventError{
+			Value:     string(d.event.value),
+			EventType: d.event.event_type,
+			At:        d.event.start_mark,
+		})
+	}
+
+	if n.Kind == ScalarNode && n.Anchor != "" {
+		anchors[n.Anchor] = n
+	}
+
+	d.span(n, start)
+	d.nextEvent()
+	return n
+}
+
+// blankLines returns the blank lines before the current event, unless a node
+// starting on the same line has already claimed them.
+func (d *Decoder) blankLines() int {
+	line := d.event.start_mark.line
+	n := d.parser.blank_lines[line]
+	delete(d.parser.blank_lines, line)
+	return n
+}
+
+// headComment returns the comment lines before the current event, unless a
+// node starting on the same line has already claimed them.
+func (d *Decoder) headComment() string {
+	line := d.event.start_mark.line
+	c := d.parser.head_comments[line]
+	delete(d.parser.head_comments, line)
+	return string(c)
+}
+
+// lineComments gives each comment at the end of a line to the last node
+// starting on that line, other than the entries of a flow collection
+// starting on it.
+func (d *Decoder) lineComments(root *Node) {
+	if len(d.parser.line_comments) == 0 {
+		return
+	}
+
+	last := make(map[int]*Node)
+	var walk func(n *Node, flowLine int)
+	walk = func(n *Node, flowLine int) {
+		if n.Line != flowLine {
+			last[n.Line-1] = n
+			if n.Style == FlowStyle {
+				flowLine = n.Line
+			}
+		}
+		for _, c := range n.Content {
+			walk(c, flowLine)
+		}
+	}
+	walk(root, 0)
+
+	for line, n := range last {
+		if c, ok := d.parser.line_comments[line]; ok {
+			n.LineComment = string(c)
+			delete(d.parser.line_comments, line)
+		}
+	}
+}
+
+// commentLines returns text as comment lines, starting those that are not
+// blank or comments already with "# ".
+func commentLines(text string) []byte {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines[i] = "# " + line
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// lineComment returns text as a comment for the end of a line.
+func lineComment(text string) []byte {
+	return commentLines(strings.Replace(text, "\n", " ", -1))
+}
+
+func (e *Encoder) emitNode(n *Node) {
+	anchor := []byte(n.Anchor)
+	tag := []byte(n.Tag)
+	implicit := n.Tag == ""
+
+	switch n.Kind {
+	case 0:
+		e.emitNil()
+	case DocumentNode:
+		for i, c := range n.Content {
+			if i == 0 && n.BlankLines > 0 {
+				root := *c
+				root.BlankLines += n.BlankLines
+				c = &root
+			}
+			e.emitNode(c)
+		}
+	case SequenceNode:
+		style := yaml_BLOCK_SEQUENCE_STYLE
+		if n.Style == FlowStyle {
+			style = yaml_FLOW_SEQUENCE_STYLE
+		}
+		yaml_sequence_start_event_initialize(&e.event, anchor, tag, implicit, style)
+		e.nodeComments(n)
+		e.emit()
+		for _, c := range n.Content {
+			e.emitNode(c)
+		}
+		yaml_sequence_end_event_initialize(&e.event)
+		e.emit()
+	case MappingNode:
+		style := yaml_BLOCK_MAPPING_STYLE
+		if n.Style == FlowStyle {
+			style = yaml_FLOW_MAPPING_STYLE
+		}
+		yaml_mapping_start_event_initialize(&e.event, anchor, tag, implicit, style)
+		e.nodeComments(n)
+		e.emit()
+		for _, c := range n.Content {
+			e.emitNode(c)
+		}
+		yaml_mapping_end_event_initialize(&e.event)
+		e.emit()
+	case ScalarNode:
+		style := yaml_PLAIN_SCALAR_STYLE
+		switch n.Style {
+		case DoubleQuotedStyle:
+			style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+		case SingleQuotedStyle:
+			style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+		case LiteralStyle:
+			style = yaml_LITERAL_SCALAR_STYLE
+		case FoldedStyle:
+			style = yaml_FOLDED_SCALAR_STYLE
+		}
+		yaml_scalar_event_initialize(&e.event, anchor, tag, []byte(n.Value), implicit, implicit, style)
+		e.nodeComments(n)
+		e.event.indent = n.Indent
+		if n.Chomping == KeepChomping {
+			e.event.chomping = 1
+		}
+		e.emit()
+	case AliasNode:
+		name := n.Value
+		if name == "" && n.Alias != nil {
+			name = n.Alias.Anchor
+		}
+		yaml_alias_event_initialize(&e.event, []byte(name))
+		e.nodeComments(n)
+		e.emit()
+	default:
+		panic(errors.New("yaml: cannot encode node of unknown kind"))
+	}
+}
+
+// nodeComments adds the blank lines and comments of n to the current event.
+func (e *Encoder) nodeComments(n *Node) {
+	e.event.blank_lines = n.BlankLines
+	e.event.head_comment = commentLines(n.HeadComment)
+	e.event.line_comment = lineComment(n.LineComment)
+}
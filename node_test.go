@@ -0,0 +1,365 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node", func() {
+	roundTrip := func(data string) string {
+		var n Node
+		Ω(Unmarshal([]byte(data), &n)).Should(Succeed())
+
+		buf := &bytes.Buffer{}
+		Ω(NewEncoder(buf).Encode(&n)).Should(Succeed())
+		return buf.String()
+	}
+
+	It("decodes a document", func() {
+		var n Node
+		Ω(Unmarshal([]byte("a: [b, 'c']\n"), &n)).Should(Succeed())
+
+		Ω(n.Kind).Should(Equal(DocumentNode))
+		Ω(n.Content).Should(HaveLen(1))
+
+		m := n.Content[0]
+		Ω(m.Kind).Should(Equal(MappingNode))
+		Ω(m.Content).Should(HaveLen(2))
+		Ω(m.Content[0].Value).Should(Equal("a"))
+
+		s := m.Content[1]
+		Ω(s.Kind).Should(Equal(SequenceNode))
+		Ω(s.Style).Should(Equal(FlowStyle))
+		Ω(s.Line).Should(Equal(1))
+		Ω(s.Column).Should(Equal(4))
+		Ω(s.Content[1].Value).Should(Equal("c"))
+		Ω(s.Content[1].Style).Should(Equal(SingleQuotedStyle))
+	})
+
+	It("preserves scalar styles", func() {
+		data := `plain: value
+single: 'it''s'
+double: "tab\there"
+literal: |
+  line one
+  line two
+folded: >
+  folded text
+flow: [a, 'b', "c"]
+tagged: !!str 12
+`
+		Ω(roundTrip(data)).Should(Equal(data))
+	})
+
+	It("preserves block scalar indicators", func() {
+		data := `keep: |+
+  one line
+strip: >-
+  folded
+indented: |2
+    starts with spaces
+  then not
+nested:
+- |1-
+  one space
+top: |4+
+      deep
+
+`
+		Ω(roundTrip(data)).Should(Equal(data))
+
+		var n Node
+		Ω(Unmarshal([]byte(data), &n)).Should(Succeed())
+		root := n.Content[0]
+		Ω(root.Content[1].Chomping).Should(Equal(KeepChomping))
+		Ω(root.Content[3].Chomping).Should(Equal(StripChomping))
+		Ω(root.Content[5].Chomping).Should(Equal(ClipChomping))
+		Ω(root.Content[5].Indent).Should(Equal(2))
+		Ω(root.Content[5].Value).Should(Equal("  starts with spaces\nthen not\n"))
+	})
+
+	It("keeps the chomping a scalar needs", func() {
+		data, err := Marshal(&Node{Kind: ScalarNode, Style: LiteralStyle, Value: "a\n\n", Chomping: StripChomping, Indent: 3})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(data)).Should(Equal("|3+\n   a\n\n"))
+	})
+
+	It("preserves blank lines between block entries", func() {
+		data := `
+name: app
+
+# the comment is kept
+ports:
+  - 80
+
+  - 443
+script: |
+  run
+
+
+env:
+  a: [b,
+
+    c]
+  d: e
+`
+		Ω(roundTrip(data)).Should(Equal(`
+name: app
+
+# the comment is kept
+ports:
+- 80
+
+- 443
+script: |
+  run
+
+
+env:
+  a: [b, c]
+  d: e
+`))
+
+		var n Node
+		Ω(Unmarshal([]byte(data), &n)).Should(Succeed())
+		Ω(n.BlankLines).Should(Equal(1))
+		Ω(n.Content[0].BlankLines).Should(BeZero())
+		Ω(n.Content[0].Content[2].BlankLines).Should(Equal(1))
+		Ω(n.Content[0].Content[6].BlankLines).Should(Equal(2))
+	})
+
+	It("preserves comments", func() {
+		data := `# about the file
+
+# about a
+a: 1 # one
+b:
+  # about c
+  c: |- # literal
+    text
+  d: [x, y] # flow
+# the end
+`
+		Ω(roundTrip(data)).Should(Equal(data))
+
+		var n Node
+		Ω(Unmarshal([]byte(data), &n)).Should(Succeed())
+		Ω(n.HeadComment).Should(Equal("# about the file\n\n# about a"))
+		Ω(n.FootComment).Should(Equal("# the end"))
+
+		m := n.Content[0]
+		Ω(m.Content[1].LineComment).Should(Equal("# one"))
+		b := m.Content[3]
+		Ω(b.HeadComment).Should(Equal("# about c"))
+		Ω(b.Content[1].LineComment).Should(Equal("# literal"))
+		Ω(b.Content[3].LineComment).Should(Equal("# flow"))
+	})
+
+	It("keeps the comments of Nodes inside other values", func() {
+		var v struct{ B Node }
+		Ω(Unmarshal([]byte("b:\n  # about c\n  c: 1\n"), &v)).Should(Succeed())
+		Ω(v.B.HeadComment).Should(Equal("# about c"))
+
+		var raw struct{ B RawMessage }
+		Ω(Unmarshal([]byte("b:\n  # about c\n  c: 1\n"), &raw)).Should(Succeed())
+		Ω(string(raw.B)).Should(Equal("# about c\nc: 1\n"))
+	})
+
+	It("writes comments set on nodes", func() {
+		n := &Node{Kind: MappingNode, Content: []*Node{
+			{Kind: ScalarNode, Value: "a", HeadComment: "first\nsecond"},
+			{Kind: ScalarNode, Value: "1", LineComment: "one\nline"},
+		}}
+
+		buf := &bytes.Buffer{}
+		Ω(NewEncoder(buf).Encode(n)).Should(Succeed())
+		Ω(buf.String()).Should(Equal("# first\n# second\na: 1 # one line\n"))
+	})
+
+	It("preserves anchors and aliases", func() {
+		data := "a: &x {b: c}\nd: *x\n"
+		Ω(roundTrip(data)).Should(Equal(data))
+
+		var n Node
+		Ω(Unmarshal([]byte(data), &n)).Should(Succeed())
+		m := n.Content[0]
+		Ω(m.Content[3].Kind).Should(Equal(AliasNode))
+		Ω(m.Content[3].Alias).Should(BeIdenticalTo(m.Content[1]))
+	})
+
+	It("lists the anchors of a document", func() {
+		var n Node
+		Ω(Unmarshal([]byte("a: &x {b: &y c}\nd: *x\ne: &x [f]\ng: *y\n"), &n)).Should(Succeed())
+		m := n.Content[0]
+
+		anchors := n.Anchors()
+		Ω(anchors).Should(HaveLen(2))
+		Ω(anchors["x"]).Should(BeIdenticalTo(m.Content[5]))
+		Ω(anchors["y"]).Should(BeIdenticalTo(m.Content[1].Content[1]))
+		Ω(m.Content[3].Alias).Should(BeIdenticalTo(m.Content[1]))
+
+		Ω(m.Content[1].Anchors()).Should(HaveKey("y"))
+		Ω((&Node{}).Anchors()).Should(BeEmpty())
+	})
+
+	It("decodes nodes inside other values", func() {
+		var v struct {
+			Name  string
+			Extra Node
+		}
+		Ω(Unmarshal([]byte("name: n\nextra: \"x\"\n"), &v)).Should(Succeed())
+		Ω(v.Name).Should(Equal("n"))
+		Ω(v.Extra.Kind).Should(Equal(ScalarNode))
+		Ω(v.Extra.Style).Should(Equal(DoubleQuotedStyle))
+	})
+
+	It("encodes nodes inside other values", func() {
+		var n Node
+		Ω(Unmarshal([]byte("'0755' # mode\n"), &n)).Should(Succeed())
+
+		buf := &bytes.Buffer{}
+		Ω(NewEncoder(buf).Encode(map[string]interface{}{
+			"mode":  n.Content[0],
+			"list":  []interface{}{&Node{Kind: ScalarNode, Value: "x", Style: LiteralStyle}},
+			"empty": Node{},
+		})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("\"empty\": null\n\"list\":\n- |-\n  x\n\"mode\": '0755' # mode\n"))
+	})
+
+	It("honours the flow and omitempty options for node fields", func() {
+		var v struct {
+			A Node `yaml:"a,flow"`
+			B Node `yaml:"b,omitempty"`
+			C []int
+		}
+		Ω(Unmarshal([]byte("a:\n  - 1\n  - 2\nc: [3]\n"), &v)).Should(Succeed())
+		Ω(v.A.Style).Should(BeZero())
+
+		buf := &bytes.Buffer{}
+		Ω(NewEncoder(buf).Encode(v)).Should(Succeed())
+		Ω(buf.String()).Should(Equal("\"a\": [1, 2]\n\"C\":\n- 3\n"))
+	})
+
+	It("names the standard tags", func() {
+		var n Node
+		Ω(Unmarshal([]byte("!!map {a: !!binary aGk=, b: !custom c}"), &n)).Should(Succeed())
+
+		m := n.Content[0]
+		Ω(m.Tag).Should(Equal(MapTag))
+		Ω(m.Content[1].Tag).Should(Equal(BinaryTag))
+		Ω(IsScalarTag(m.Content[1].Tag)).Should(BeTrue())
+		Ω(IsScalarTag("!!int")).Should(BeTrue())
+		Ω(IsScalarTag(m.Tag)).Should(BeFalse())
+		Ω(IsScalarTag(m.Content[3].Tag)).Should(BeFalse())
+
+		Ω(ShortTag(StrTag)).Should(Equal("!!str"))
+		Ω(ShortTag("!custom")).Should(Equal("!custom"))
+		Ω(LongTag("!!seq")).Should(Equal(SeqTag))
+		Ω(LongTag("!custom")).Should(Equal("!custom"))
+	})
+
+	It("clones documents", func() {
+		var doc Node
+		Ω(Unmarshal([]byte("# head\nbase: &b {image: web}\nspec: *b\n"), &doc)).Should(Succeed())
+
+		c := doc.Clone()
+		Ω(c).Should(Equal(&doc))
+		Ω(c.Content[0]).ShouldNot(BeIdenticalTo(doc.Content[0]))
+		Ω(c.Content[0].Content[3].Alias).Should(BeIdenticalTo(c.Content[0].Content[1]))
+
+		c.Content[0].Content[1].Content[1].Value = "api"
+		Ω(doc.Content[0].Content[1].Content[1].Value).Should(Equal("web"))
+
+		spec := doc.Content[0].Content[3].Clone()
+		Ω(spec.Alias).Should(BeIdenticalTo(doc.Content[0].Content[1]))
+	})
+
+	Context("binding subtrees", func() {
+		type spec struct {
+			Replicas int
+			Image    string
+		}
+
+		It("decodes a node into a value and puts a value back as a node", func() {
+			var doc Node
+			Ω(Unmarshal([]byte("kind: app # keep\nspec:\n  replicas: 1\n  image: web\n"), &doc)).Should(Succeed())
+			root := doc.Content[0]
+
+			var s spec
+			Ω(root.Content[3].Decode(&s)).Should(Succeed())
+			Ω(s).Should(Equal(spec{Replicas: 1, Image: "web"}))
+
+			s.Replicas = 3
+			n, err := NodeFrom(map[string]interface{}{"replicas": s.Replicas, "image": s.Image})
+			Ω(err).ShouldNot(HaveOccurred())
+			root.Content[3] = n
+
+			buf := &bytes.Buffer{}
+			Ω(NewEncoder(buf).Encode(&doc)).Should(Succeed())
+			Ω(buf.String()).Should(Equal("kind: app # keep\nspec:\n  image: web\n  replicas: 3\n"))
+		})
+
+		It("decodes quoted scalars as strings", func() {
+			var doc Node
+			Ω(Unmarshal([]byte("[\"yes\", '12', yes, 12]\n"), &doc)).Should(Succeed())
+
+			var v []interface{}
+			Ω(doc.Content[0].Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal([]interface{}{"yes", "12", true, int64(12)}))
+		})
+
+		It("quotes strings that need it", func() {
+			n, err := NodeFrom([]string{"yes", "a: b", "plain"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(n.Kind).Should(Equal(SequenceNode))
+			Ω(n.Content[0].Style).ShouldNot(Equal(NodeStyle(0)))
+			Ω(n.Content[1].Style).ShouldNot(Equal(NodeStyle(0)))
+			Ω(n.Content[2].Style).Should(Equal(NodeStyle(0)))
+		})
+
+		It("follows aliases to anchors outside the node", func() {
+			var doc Node
+			Ω(Unmarshal([]byte("base: &b {image: web}\nspec: {replicas: 2, image: *b}\n"), &doc)).Should(Succeed())
+
+			var v map[string]interface{}
+			Ω(doc.Content[0].Content[3].Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal(map[string]interface{}{
+				"replicas": int64(2),
+				"image":    map[interface{}]interface{}{"image": "web"},
+			}))
+		})
+
+		It("honours short tags set on nodes", func() {
+			n := &Node{Kind: SequenceNode, Content: []*Node{
+				{Kind: ScalarNode, Tag: "!!str", Value: "123"},
+				{Kind: ScalarNode, Tag: "!!int", Value: "0x10"},
+			}}
+			var v []interface{}
+			Ω(n.Decode(&v)).Should(Succeed())
+			Ω(v).Should(Equal([]interface{}{"123", int64(16)}))
+		})
+
+		It("reports errors at the node's position", func() {
+			n := &Node{Kind: SequenceNode, Line: 2, Column: 3, Content: []*Node{
+				{Kind: AliasNode, Value: "missing", Line: 3, Column: 5},
+			}}
+			var v []string
+			err := n.Decode(&v)
+
+			var aerr *UnknownAnchorError
+			Ω(errors.As(err, &aerr)).Should(BeTrue())
+			Ω(aerr.At.line).Should(Equal(2))
+			Ω(aerr.At.column).Should(Equal(4))
+		})
+	})
+
+	It("names kinds and styles", func() {
+		Ω(MappingNode.String()).Should(Equal("mapping"))
+		Ω(NodeKind(0).String()).Should(Equal("NodeKind(0)"))
+		Ω(NodeStyle(0).String()).Should(Equal("plain"))
+		Ω(FoldedStyle.String()).Should(Equal("folded"))
+	})
+})
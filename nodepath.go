@@ -0,0 +1,240 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GetPath returns the node found by following path from n, or nil if there
+// is none.  A string in path selects the value of that key in a mapping and
+// an int the item at that index in a sequence, e.g.
+//
+//	image := doc.GetPath("spec", "containers", 0, "image")
+//
+// A DocumentNode stands for its root, and aliases stand for the nodes they
+// refer to.
+func (n *Node) GetPath(path ...interface{}) *Node {
+	for _, elem := range path {
+		n = n.resolve()
+		if n == nil {
+			return nil
+		}
+		switch elem := elem.(type) {
+		case string:
+			i := n.keyIndex(elem)
+			if i < 0 {
+				return nil
+			}
+			n = n.Content[i+1]
+		case int:
+			if n.Kind != SequenceNode || elem < 0 || elem >= len(n.Content) {
+				return nil
+			}
+			n = n.Content[elem]
+		default:
+			return nil
+		}
+	}
+	return n
+}
+
+// SetPath puts value at the end of path under n, replacing the node there
+// or adding it as a new entry at the end of its mapping, or item at the end
+// of its sequence if the index is the length of the sequence.  value may be
+// a *Node, put in as it is, or any value NodeFrom accepts, whose node is
+// copied over the node there, keeping its anchor and comments, so that
+// aliases to it and a Source it was read into see the change.  Mappings and
+// sequences missing along the way are created, in place of null nodes too,
+// so that
+//
+//	err := doc.SetPath("debug", "logging", "level")
+//
+// works whether or not doc has a logging section yet.  Setting through an
+// alias changes the node it refers to.
+func (n *Node) SetPath(value interface{}, path ...interface{}) error {
+	v, ok := value.(*Node)
+	if !ok {
+		var err error
+		if v, err = NodeFrom(value); err != nil {
+			return err
+		}
+	}
+	if len(path) == 0 {
+		return errors.New("yaml: SetPath needs a path")
+	}
+
+	for i, elem := range path {
+		last := i == len(path)-1
+		n = n.resolveFor(elem)
+		if n == nil {
+			return setPathError(path, i, "is not a mapping or sequence")
+		}
+
+		var slot **Node
+		switch elem := elem.(type) {
+		case string:
+			k := n.keyIndex(elem)
+			if k < 0 {
+				if n.Kind != MappingNode {
+					return setPathError(path, i, "is not a mapping")
+				}
+				key, err := NodeFrom(elem)
+				if err != nil {
+					return err
+				}
+				n.Content = append(n.Content, key, &Node{})
+				k = len(n.Content) - 2
+			}
+			slot = &n.Content[k+1]
+		case int:
+			switch {
+			case n.Kind != SequenceNode:
+				return setPathError(path, i, "is not a sequence")
+			case elem < 0 || elem > len(n.Content):
+				return setPathError(path, i, fmt.Sprintf("has no index %d", elem))
+			case elem == len(n.Content):
+				n.Content = append(n.Content, &Node{})
+			}
+			slot = &n.Content[elem]
+		default:
+			return fmt.Errorf("yaml: cannot set '%s': %T is not a key or index",
+				pathName(path), elem)
+		}
+
+		if last && ok {
+			*slot = v
+		} else if last {
+			old := *slot
+			v.Anchor, v.Line, v.Column = old.Anchor, old.Line, old.Column
+			v.BlankLines, v.HeadComment, v.LineComment = old.BlankLines, old.HeadComment, old.LineComment
+			*old = *v
+		}
+		n = *slot
+	}
+	return nil
+}
+
+// setPathError returns the error for SetPath failing at path[i].
+func setPathError(path []interface{}, i int, problem string) error {
+	at := "the node"
+	if i > 0 {
+		at = "'" + pathName(path[:i]) + "'"
+	}
+	return fmt.Errorf("yaml: cannot set '%s': %s %s", pathName(path), at, problem)
+}
+
+// DeletePath removes the node at the end of path from its mapping, with its
+// key, or from its sequence, and reports whether there was one.
+func (n *Node) DeletePath(path ...interface{}) bool {
+	if len(path) == 0 {
+		return false
+	}
+	parent := n.GetPath(path[:len(path)-1]...).resolve()
+	if parent == nil {
+		return false
+	}
+
+	switch elem := path[len(path)-1].(type) {
+	case string:
+		if i := parent.keyIndex(elem); i >= 0 {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	case int:
+		if parent.Kind == SequenceNode && elem >= 0 && elem < len(parent.Content) {
+			parent.Content = append(parent.Content[:elem], parent.Content[elem+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the node n stands for: the root of a document or the
+// node an alias refers to.
+func (n *Node) resolve() *Node {
+	for n != nil {
+		switch {
+		case n.Kind == DocumentNode && len(n.Content) > 0:
+			n = n.Content[0]
+		case n.Kind == AliasNode:
+			n = n.Alias
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// resolveFor returns the collection n stands for, to be followed by the path
+// element elem, turning null nodes and empty documents into a mapping for a
+// string or a sequence for an int.  It returns nil if n is another kind of
+// node.
+func (n *Node) resolveFor(elem interface{}) *Node {
+	if n.Kind == DocumentNode && len(n.Content) == 0 {
+		n.Content = []*Node{{}}
+	}
+	n = n.resolve()
+	if n == nil {
+		return nil
+	}
+	if isNull(n) {
+		kind := MappingNode
+		if _, ok := elem.(int); ok {
+			kind = SequenceNode
+		}
+		*n = Node{
+			Kind:        kind,
+			Anchor:      n.Anchor,
+			Line:        n.Line,
+			Column:      n.Column,
+			BlankLines:  n.BlankLines,
+			HeadComment: n.HeadComment,
+			LineComment: n.LineComment,
+		}
+	}
+	if n.Kind != MappingNode && n.Kind != SequenceNode {
+		return nil
+	}
+	return n
+}
+
+// keyIndex returns the index in the Content of the mapping n of the key
+// written as key, or -1.
+func (n *Node) keyIndex(key string) int {
+	if n.Kind != MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k := n.Content[i].resolve()
+		if k != nil && k.Kind == ScalarNode && k.Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// isNull reports whether n is a node with no kind or an untagged null
+// scalar.
+func isNull(n *Node) bool {
+	if n.Kind == 0 {
+		return true
+	}
+	if n.Kind != ScalarNode || n.Style != 0 || (n.Tag != "" && n.Tag != NullTag) {
+		return false
+	}
+	switch n.Value {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// pathName writes path as the keys and indexes in it separated by dots.
+func pathName(path []interface{}) string {
+	names := make([]string, len(path))
+	for i, elem := range path {
+		names[i] = fmt.Sprint(elem)
+	}
+	return strings.Join(names, ".")
+}
@@ -0,0 +1,115 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Node paths", func() {
+	var doc Node
+
+	BeforeEach(func() {
+		doc = Node{}
+		Ω(NewDecoder(strings.NewReader(`spec:
+  replicas: 2 # scaled by hand
+  containers:
+  - name: app
+    image: app:1.0
+  logging:
+defaults: &defaults
+  retries: 3
+service: *defaults
+`)).Decode(&doc)).Should(Succeed())
+	})
+
+	encode := func(n *Node) string {
+		out, err := Marshal(n)
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(out)
+	}
+
+	It("gets nodes by key and index", func() {
+		Ω(doc.GetPath("spec", "containers", 0, "image").Value).Should(Equal("app:1.0"))
+		Ω(doc.GetPath("spec", "replicas").Value).Should(Equal("2"))
+		Ω(doc.GetPath().Kind).Should(Equal(DocumentNode))
+	})
+
+	It("follows aliases", func() {
+		Ω(doc.GetPath("service", "retries").Value).Should(Equal("3"))
+	})
+
+	It("returns nil for missing nodes", func() {
+		Ω(doc.GetPath("spec", "missing")).Should(BeNil())
+		Ω(doc.GetPath("spec", "containers", 1)).Should(BeNil())
+		Ω(doc.GetPath("spec", "containers", -1)).Should(BeNil())
+		Ω(doc.GetPath("spec", 0)).Should(BeNil())
+		Ω(doc.GetPath("spec", "replicas", "x")).Should(BeNil())
+		Ω(doc.GetPath(1.5)).Should(BeNil())
+	})
+
+	It("sets values in place, keeping comments", func() {
+		Ω(doc.SetPath(3, "spec", "replicas")).Should(Succeed())
+		Ω(doc.SetPath("app:1.1", "spec", "containers", 0, "image")).Should(Succeed())
+		Ω(encode(doc.GetPath("spec"))).Should(HavePrefix("replicas: 3 # scaled by hand\ncontainers:\n- name: app\n  image: app:1.1\n"))
+	})
+
+	It("creates missing mappings and sequences", func() {
+		Ω(doc.SetPath("debug", "spec", "logging", "level")).Should(Succeed())
+		Ω(doc.SetPath("8080", "spec", "containers", 0, "ports", 0)).Should(Succeed())
+		Ω(doc.SetPath("sidecar", "spec", "containers", 1, "name")).Should(Succeed())
+		Ω(encode(doc.GetPath("spec"))).Should(Equal(`replicas: 2 # scaled by hand
+containers:
+- name: app
+  image: app:1.0
+  ports:
+  - "8080"
+- name: sidecar
+logging:
+  level: debug
+`))
+	})
+
+	It("builds documents from nothing", func() {
+		var n Node
+		Ω(n.SetPath(true, "feature", "enabled")).Should(Succeed())
+		Ω(encode(&n)).Should(Equal("feature:\n  enabled: true\n"))
+	})
+
+	It("puts nodes in as they are", func() {
+		n := &Node{Kind: ScalarNode, Value: "x", Style: DoubleQuotedStyle}
+		Ω(doc.SetPath(n, "spec", "replicas")).Should(Succeed())
+		Ω(doc.GetPath("spec", "replicas")).Should(BeIdenticalTo(n))
+	})
+
+	It("changes the nodes aliases refer to", func() {
+		Ω(doc.SetPath(5, "service", "retries")).Should(Succeed())
+		Ω(doc.GetPath("defaults", "retries").Value).Should(Equal("5"))
+	})
+
+	It("fails on paths through scalars and out of range", func() {
+		err := doc.SetPath(1, "spec", "replicas", "x")
+		Ω(err).Should(MatchError("yaml: cannot set 'spec.replicas.x': 'spec.replicas' is not a mapping or sequence"))
+		err = doc.SetPath(1, "spec", "containers", 3)
+		Ω(err).Should(MatchError("yaml: cannot set 'spec.containers.3': 'spec.containers' has no index 3"))
+		err = doc.SetPath(1, 0)
+		Ω(err).Should(MatchError("yaml: cannot set '0': the node is not a sequence"))
+	})
+
+	It("deletes entries and items", func() {
+		Ω(doc.DeletePath("spec", "containers", 0)).Should(BeTrue())
+		Ω(doc.DeletePath("spec", "logging")).Should(BeTrue())
+		Ω(doc.DeletePath("spec", "logging")).Should(BeFalse())
+		Ω(doc.DeletePath("nothing", "here")).Should(BeFalse())
+		Ω(encode(doc.GetPath("spec"))).Should(Equal("replicas: 2 # scaled by hand\ncontainers: []\n"))
+	})
+
+	It("edits a Source in place", func() {
+		s, err := ParseSource([]byte("spec:\n  replicas: 2   # scaled by hand\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(s.Documents[0].SetPath(4, "spec", "replicas")).Should(Succeed())
+		out, err := s.Bytes()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("spec:\n  replicas: 4   # scaled by hand\n"))
+	})
+})
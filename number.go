@@ -0,0 +1,111 @@
+package candiedyaml
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+var numberType = reflect.TypeOf(Number(""))
+
+// A Number holds an integer or float exactly as it was written, e.g.
+// "0x1F", "1_000" or "1.50", so that it can be encoded again unchanged.
+// Decoding a scalar that does not resolve to a number into a Number is an
+// error, as is encoding a Number that does not hold one.  The empty Number
+// is encoded as null, which decodes back into it.
+type Number string
+
+// String returns the number as written.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 returns the number as it would be decoded into an int64.
+func (n Number) Int64() (int64, error) {
+	var i int64
+	err := resolve_int(string(n), reflect.ValueOf(&i).Elem())
+	return i, err
+}
+
+// Float64 returns the number as it would be decoded into a float64.
+func (n Number) Float64() (float64, error) {
+	var f float64
+	err := resolve_float(string(n), reflect.ValueOf(&f).Elem())
+	return f, err
+}
+
+// isNumber reports whether v is an integer or float.
+func isNumber(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// PreferLosslessScalars causes untagged plain scalars decoded into
+// interface{} values to be left as strings if they resolve to a number that
+// would be written differently, so that version numbers such as 1.20 do not
+// turn into 1.2, nor 0x1F into 31, 1_000 into 1000 or 1e3 into 1000.  Only
+// numbers written the way Go formats them, such as 42, -7 or 0.25, are
+// decoded as numbers.
+func (d *Decoder) PreferLosslessScalars(prefer bool) {
+	d.preferLossless = prefer
+}
+
+// losslessScalar returns v, resolved from the current scalar, as the scalar's
+// text if v is a number that would not be written the same way.
+func (d *Decoder) losslessScalar(v interface{}) interface{} {
+	e := &d.event
+	if !isNumber(v) || len(e.tag) > 0 || yaml_scalar_style_t(e.style) != yaml_PLAIN_SCALAR_STYLE {
+		return v
+	}
+
+	var s string
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(rv.Uint(), 10)
+	default:
+		s = strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits())
+	}
+	if s != string(e.value) {
+		return string(e.value)
+	}
+	return v
+}
+
+// resolveNumber sets the Number v to the scalar of event, which must be a
+// number.
+func resolveNumber(event yaml_event_t, v reflect.Value, schema *Schema) error {
+	i, err := resolveInterface(event, schema)
+	if err != nil {
+		return err
+	}
+	if !isNumber(i) {
+		return errors.New("Number: " + string(event.value))
+	}
+	v.SetString(string(event.value))
+	return nil
+}
+
+func (e *Encoder) emitNumber(tag string, v reflect.Value) {
+	s := v.String()
+	if s == "" {
+		e.emitNil()
+		return
+	}
+
+	schema := e.schema
+	if schema == nil {
+		schema = YAML11Schema
+	}
+	if !isNumber(schema.Resolve(s)) {
+		panic(errors.New("yaml: invalid Number " + s))
+	}
+	e.emitScalar(s, "", tag, yaml_PLAIN_SCALAR_STYLE)
+}
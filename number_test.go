@@ -0,0 +1,96 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Number", func() {
+	type prices struct {
+		Count Number
+		Price Number
+		Mask  Number
+		Big   Number
+		None  Number
+	}
+
+	It("keeps numbers as they are written", func() {
+		data := "count: 1_000\nprice: 1.50\nmask: 0x1F\nbig: 123456789012345678901234567890\nnone: ~\n"
+
+		var p prices
+		Ω(Unmarshal([]byte(data), &p)).Should(Succeed())
+		Ω(p).Should(Equal(prices{Count: "1_000", Price: "1.50", Mask: "0x1F", Big: "123456789012345678901234567890"}))
+
+		buf := &bytes.Buffer{}
+		Ω(NewEncoder(buf).Encode(MapSlice{{"count", p.Count}, {"price", p.Price}, {"mask", p.Mask}, {"big", p.Big}, {"none", p.None}})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("\"count\": 1_000\n\"price\": 1.50\n\"mask\": 0x1F\n\"big\": 123456789012345678901234567890\n\"none\": null\n"))
+
+		var q prices
+		Ω(Unmarshal(buf.Bytes(), &q)).Should(Succeed())
+		Ω(q).Should(Equal(p))
+	})
+
+	It("round-trips with UseStringer", func() {
+		var p prices
+		Ω(Unmarshal([]byte("count: 1_000\nprice: 1.50\n"), &p)).Should(Succeed())
+
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf)
+		enc.UseStringer(true)
+		Ω(enc.Encode(MapSlice{{"count", p.Count}, {"price", &p.Price}})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("\"count\": 1_000\n\"price\": 1.50\n"))
+
+		var q prices
+		Ω(Unmarshal(buf.Bytes(), &q)).Should(Succeed())
+		Ω(q).Should(Equal(p))
+	})
+
+	It("converts to integers and floats", func() {
+		i, err := Number("0x1F").Int64()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(i).Should(Equal(int64(31)))
+
+		f, err := Number("1_000.5").Float64()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(f).Should(Equal(1000.5))
+
+		_, err = Number("1.5").Int64()
+		Ω(err).Should(HaveOccurred())
+		Ω(Number("12").String()).Should(Equal("12"))
+	})
+
+	It("accepts tagged numbers", func() {
+		var n Number
+		Ω(Unmarshal([]byte("!!int '017'"), &n)).Should(Succeed())
+		Ω(n).Should(Equal(Number("017")))
+		Ω(Unmarshal([]byte("!!float 1e3"), &n)).Should(Succeed())
+		Ω(n).Should(Equal(Number("1e3")))
+	})
+
+	It("rejects scalars that are not numbers", func() {
+		var n Number
+		Ω(Unmarshal([]byte("abc"), &n)).Should(MatchError("Number: abc"))
+		Ω(Unmarshal([]byte("'12'"), &n)).Should(MatchError("Number: 12"))
+		Ω(Unmarshal([]byte("true"), &n)).Should(MatchError("Number: true"))
+
+		_, err := Marshal(Number("12 apples"))
+		Ω(err).Should(MatchError("yaml: invalid Number 12 apples"))
+	})
+
+	It("leaves numbers that would be written differently as strings with PreferLosslessScalars", func() {
+		var v interface{}
+		d := NewDecoder(strings.NewReader("[1.20, 1.5, 0x1F, 42, -7, 1_000, 1e3, 08, 017, .inf, '5', !!float 2.50, yes, ~]"))
+		d.PreferLosslessScalars(true)
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(v).Should(Equal([]interface{}{"1.20", 1.5, "0x1F", int64(42), int64(-7), "1_000", "1e3", "08", "017",
+			".inf", "5", 2.5, true, nil}))
+
+		var m map[string]interface{}
+		d = NewDecoder(strings.NewReader("go: 1.20\nreplicas: 3\n"))
+		d.PreferLosslessScalars(true)
+		Ω(d.Decode(&m)).Should(Succeed())
+		Ω(m).Should(Equal(map[string]interface{}{"go": "1.20", "replicas": int64(3)}))
+	})
+})
@@ -0,0 +1,47 @@
+package candiedyaml
+
+// An EncodeOption configures the Encoder that Marshal uses, so that one-off
+// calls can be set up like an Encoder:
+//
+//	out, err := candiedyaml.Marshal(v, candiedyaml.Indent(4), candiedyaml.SortKeys())
+//
+// Options for other Encoder settings are written as functions, e.g.
+// EncodeOption(func(e *Encoder) { e.SetAlign(true) }).
+type EncodeOption func(*Encoder)
+
+// A DecodeOption configures the Decoder that Unmarshal uses:
+//
+//	err := candiedyaml.Unmarshal(data, &cfg, candiedyaml.Strict())
+type DecodeOption func(*Decoder)
+
+// Indent sets the indentation, as Encoder.SetIndent does.
+func Indent(n int) EncodeOption {
+	return func(e *Encoder) { e.SetIndent(n) }
+}
+
+// Width sets the line width, as Encoder.SetWidth does.
+func Width(n int) EncodeOption {
+	return func(e *Encoder) { e.SetWidth(n) }
+}
+
+// Compact writes collections that fit in width characters in flow style, as
+// Encoder.SetCompact does.
+func Compact(width int) EncodeOption {
+	return func(e *Encoder) { e.SetCompact(width) }
+}
+
+// SortKeys writes struct fields and MapSlice entries in key order, as
+// Encoder.SortKeys does.
+func SortKeys() EncodeOption {
+	return func(e *Encoder) { e.SortKeys(true) }
+}
+
+// Strict rejects duplicate keys, keys matching no struct field and keys
+// matching ambiguous fields, instead of skipping them with a warning.
+func Strict() DecodeOption {
+	return func(d *Decoder) {
+		d.RejectDuplicateKeys(true)
+		d.RejectUnknownFields(true)
+		d.RejectAmbiguousFields(true)
+	}
+}
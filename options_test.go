@@ -0,0 +1,44 @@
+package candiedyaml
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Options", func() {
+	type server struct {
+		Port  int               `yaml:"port"`
+		Host  string            `yaml:"host"`
+		Attrs map[string]string `yaml:"attrs"`
+	}
+	s := server{Port: 80, Host: "web", Attrs: map[string]string{"a": "b"}}
+
+	It("sets up the Encoder Marshal uses", func() {
+		out, err := Marshal(s, Indent(4), SortKeys())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("\"attrs\":\n    \"a\": \"b\"\n\"host\": \"web\"\n\"port\": 80\n"))
+
+		out, err = Marshal(s, Compact(20), EncodeOption(func(e *Encoder) { e.SetSchema(CoreSchema) }))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("port: 80\nhost: web\nattrs: {a: b}\n"))
+	})
+
+	It("sorts MapSlice entries with SortKeys", func() {
+		out, err := Marshal(MapSlice{{"b", 1}, {"a", 2}, {1, 3}}, SortKeys())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("1: 3\n\"a\": 2\n\"b\": 1\n"))
+	})
+
+	It("sets up the Decoder Unmarshal uses", func() {
+		var v server
+		Ω(Unmarshal([]byte("port: 80\nhots: web\n"), &v)).Should(Succeed())
+
+		err := Unmarshal([]byte("port: 80\nhots: web\n"), &v, Strict())
+		Ω(errors.Is(err, ErrUnknownField)).Should(BeTrue())
+		Ω(err.Error()).Should(HavePrefix("yaml: unknown field 'hots' in candiedyaml.server at line 2, column 1"))
+
+		err = Unmarshal([]byte("port: 80\nport: 81\n"), &v, Strict())
+		Ω(errors.Is(err, ErrDuplicateKey)).Should(BeTrue())
+	})
+})
@@ -0,0 +1,101 @@
+package candiedyaml
+
+import (
+	"reflect"
+	"sort"
+)
+
+// A MapItem is a key and value of a mapping.
+type MapItem struct {
+	Key, Value interface{}
+}
+
+// A MapSlice is a mapping that keeps its keys in document order.  Mappings
+// decode into a MapSlice target, or into interface{} values when OrderedMaps
+// is on, and MapSlices encode as mappings in the same order.
+type MapSlice []MapItem
+
+var mapSliceType = reflect.TypeOf(MapSlice{})
+
+// OrderedMaps causes mappings decoded into interface{} values to become
+// MapSlices rather than map[interface{}]interface{}, so that they can be
+// traversed in the order of the document.
+func (d *Decoder) OrderedMaps(ordered bool) {
+	d.orderedMaps = ordered
+}
+
+// mapSliceInterface is like mappingInterface but keeps the keys in order.  A
+// repeated key keeps its first position and its last value.
+func (d *Decoder) mapSliceInterface() MapSlice {
+	m := MapSlice{}
+
+	d.nextEvent()
+
+	seen := make(map[interface{}]bool)
+	index := make(map[interface{}]int)
+	var keys mergedKeys
+	if d.mergeKeys {
+		keys = make(mergedKeys)
+	}
+	entry := func(merged bool) {
+		mark := d.event.start_mark
+		d.key = true
+		key := d.valueInterface()
+		d.key = false
+		d.checkKey(key)
+		if !keys.add(key) && merged {
+			d.valueInterface()
+			return
+		}
+		if !merged {
+			d.checkDuplicate(seen, key, mark)
+		}
+
+		comparable := key == nil || reflect.TypeOf(key).Comparable()
+		i, ok := 0, false
+		if comparable {
+			i, ok = index[key]
+		}
+		if !ok {
+			i = len(m)
+			m = append(m, MapItem{Key: key})
+			if comparable {
+				index[key] = i
+			}
+		}
+
+		value := d.valueInterface()
+		if d.resolveLater(value, func(a interface{}) { m[i].Value = a }) {
+			value = nil
+		}
+		m[i].Value = value
+	}
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		if d.isMergeKey() {
+			d.merge(mapSliceType, entry)
+			continue
+		}
+		entry(false)
+	}
+
+	d.nextEvent()
+	return m
+}
+
+func (e *Encoder) emitMapSlice(tag string, v reflect.Value) {
+	items := v.Interface().(MapSlice)
+	if e.sortKeys {
+		items = append(MapSlice(nil), items...)
+		sort.SliceStable(items, func(i, j int) bool {
+			return stringValues{reflect.ValueOf(items[i].Key), reflect.ValueOf(items[j].Key)}.Less(0, 1)
+		})
+	}
+	e.mapping(tag, func() {
+		for _, item := range items {
+			e.marshalKey(reflect.ValueOf(item.Key))
+			e.enterPath(item.Key)
+			e.marshal("", reflect.ValueOf(item.Value))
+			e.leavePath()
+		}
+	})
+}
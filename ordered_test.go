@@ -0,0 +1,35 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ordered maps", func() {
+	It("decodes mappings into interface{} in document order", func() {
+		var v interface{}
+		d := NewDecoder(bytes.NewBufferString("z: 1\na: [2, {c: 3, b: 4}]\nm: 5\nz: 6\n"))
+		d.OrderedMaps(true)
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(v).Should(Equal(MapSlice{
+			{"z", int64(6)},
+			{"a", []interface{}{int64(2), MapSlice{{"c", int64(3)}, {"b", int64(4)}}}},
+			{"m", int64(5)},
+		}))
+	})
+
+	It("decodes into a MapSlice without OrderedMaps", func() {
+		var v struct {
+			Env MapSlice
+		}
+		Ω(Unmarshal([]byte("env: {PATH: /bin, HOME: /root}\n"), &v)).Should(Succeed())
+		Ω(v.Env).Should(Equal(MapSlice{{"PATH", "/bin"}, {"HOME", "/root"}}))
+	})
+
+	It("encodes a MapSlice in order", func() {
+		data, err := Marshal(MapSlice{{"z", 1}, {"a", MapSlice{{"c", 2}, {"b", 3}}}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(data)).Should(Equal("\"z\": 1\n\"a\":\n  \"c\": 2\n  \"b\": 3\n"))
+	})
+})
@@ -0,0 +1,90 @@
+package candiedyaml
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// An OverflowPolicy decides what a Decoder does with a number that does not
+// fit the integer or float it is decoded into.
+type OverflowPolicy int
+
+const (
+	// OverflowError fails the decode.  It is the default.
+	OverflowError OverflowPolicy = iota
+
+	// OverflowSaturate stores the closest value the type can hold: its
+	// minimum or maximum, or zero for negative numbers in unsigned types.
+	OverflowSaturate
+
+	// OverflowTruncate stores what a Go conversion would: the low-order
+	// bits of integers, and infinity for floats.
+	OverflowTruncate
+)
+
+// SetOverflowPolicy selects what happens to numbers that are out of range
+// for the field they are decoded into.
+func (d *Decoder) SetOverflowPolicy(p OverflowPolicy) {
+	d.overflow = p
+}
+
+// overflowError is returned by the resolvers for a number that is valid but
+// out of range, with the number so that an OverflowPolicy can be applied.
+type overflowError struct {
+	msg string
+	i   *big.Int
+	f   float64
+}
+
+func (e *overflowError) Error() string {
+	return e.msg
+}
+
+var uint64Mask = new(big.Int).SetUint64(math.MaxUint64)
+
+// apply stores the number in v according to p, and reports whether it did.
+func (e *overflowError) apply(v reflect.Value, p OverflowPolicy) bool {
+	if p == OverflowError {
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if p == OverflowTruncate {
+			v.SetInt(int64(new(big.Int).And(e.i, uint64Mask).Uint64()))
+			break
+		}
+		bits := uint(v.Type().Bits())
+		if e.i.Sign() < 0 {
+			v.SetInt(-1 << (bits - 1))
+		} else {
+			v.SetInt(1<<(bits-1) - 1)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if p == OverflowTruncate {
+			v.SetUint(new(big.Int).And(e.i, uint64Mask).Uint64())
+			break
+		}
+		if e.i.Sign() < 0 {
+			v.SetUint(0)
+		} else {
+			v.SetUint(math.MaxUint64 >> (64 - uint(v.Type().Bits())))
+		}
+	case reflect.Float32, reflect.Float64:
+		f := math.Inf(1)
+		if p == OverflowSaturate {
+			f = math.MaxFloat64
+			if v.Kind() == reflect.Float32 {
+				f = math.MaxFloat32
+			}
+		}
+		if e.f < 0 {
+			f = -f
+		}
+		v.SetFloat(f)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math"
+	"math/big"
+	"strings"
+)
+
+var _ = Describe("Overflow", func() {
+	type sample struct {
+		I8  int8
+		I64 int64
+		U8  uint8
+		U16 uint16
+		F32 float32
+	}
+
+	data := "i8: 300\ni64: -9223372036854775809\nu8: -1\nu16: 0x12345\nf32: 1e40\n"
+
+	decode := func(p OverflowPolicy) (sample, error) {
+		var s sample
+		d := NewDecoder(bytes.NewBufferString(data))
+		d.SetOverflowPolicy(p)
+		err := d.Decode(&s)
+		return s, err
+	}
+
+	It("fails by default", func() {
+		var s sample
+		Ω(Unmarshal([]byte("i8: 300\n"), &s)).Should(MatchError("Integer: 300"))
+
+		_, err := decode(OverflowError)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("saturates", func() {
+		s, err := decode(OverflowSaturate)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(s).Should(Equal(sample{
+			I8:  math.MaxInt8,
+			I64: math.MinInt64,
+			U8:  0,
+			U16: math.MaxUint16,
+			F32: math.MaxFloat32,
+		}))
+	})
+
+	It("truncates", func() {
+		s, err := decode(OverflowTruncate)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(s).Should(Equal(sample{
+			I8:  44,
+			I64: math.MaxInt64,
+			U8:  math.MaxUint8,
+			U16: 0x2345,
+			F32: float32(math.Inf(1)),
+		}))
+	})
+
+	It("applies the policy to integers of any length", func() {
+		digits := strings.Repeat("9876543210", 5000)
+		n, _ := new(big.Int).SetString(digits, 10)
+		low := func(i *big.Int) uint64 {
+			return new(big.Int).And(i, uint64Mask).Uint64()
+		}
+
+		var s sample
+		d := NewDecoder(strings.NewReader("i64: -" + digits + "\nu16: 1:" + digits + "\n"))
+		d.SetOverflowPolicy(OverflowTruncate)
+		Ω(d.Decode(&s)).Should(Succeed())
+		Ω(s.I64).Should(Equal(int64(low(new(big.Int).Neg(n)))))
+		Ω(s.U16).Should(Equal(uint16(low(new(big.Int).Add(n, big.NewInt(60))))))
+
+		d = NewDecoder(strings.NewReader("i64: -" + digits + "\n"))
+		d.SetOverflowPolicy(OverflowSaturate)
+		Ω(d.Decode(&s)).Should(Succeed())
+		Ω(s.I64).Should(Equal(int64(math.MinInt64)))
+	})
+
+	It("decodes the extremes of each type", func() {
+		var s sample
+		Ω(Unmarshal([]byte("i8: -128\ni64: -9223372036854775808\nu8: 255\n"), &s)).Should(Succeed())
+		Ω(s).Should(Equal(sample{I8: math.MinInt8, I64: math.MinInt64, U8: math.MaxUint8}))
+	})
+})
@@ -0,0 +1,73 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// MarshalPanicError is returned by an Encoder when a method it called on a
+// value, such as String, Error, YAMLComment or the marshal function of an
+// adapter, panicked.  Path is where the value is in the value encoded: the
+// keys and slice indexes down to it separated by dots, e.g.
+// "servers.0.status", or empty for the value itself.  Value is what the
+// method panicked with.
+type MarshalPanicError struct {
+	Path  string
+	Value interface{}
+}
+
+func (e *MarshalPanicError) Error() string {
+	at := "the value"
+	if e.Path != "" {
+		at = "'" + e.Path + "'"
+	}
+	return fmt.Sprintf("yaml: panic encoding %s: %v", at, e.Value)
+}
+
+// Unwrap returns the value panicked with, if it is an error.
+func (e *MarshalPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// call runs f, which calls a method of the value being encoded, turning a
+// panic in it into a MarshalPanicError.
+func (e *Encoder) call(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			panic(&MarshalPanicError{Path: e.pathString(), Value: r})
+		}
+	}()
+	f()
+}
+
+// enterPath adds the struct field, map key or slice index name to the path
+// of the value being encoded.
+func (e *Encoder) enterPath(name interface{}) {
+	e.path = append(e.path, name)
+}
+
+func (e *Encoder) leavePath() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+func (e *Encoder) pathString() string {
+	names := make([]string, len(e.path))
+	for i, name := range e.path {
+		names[i] = fmt.Sprint(name)
+	}
+	return strings.Join(names, ".")
+}
+
+// emitError writes the error held by v, an interface of a type that
+// implements error, as its message.
+func (e *Encoder) emitError(tag string, v reflect.Value) {
+	var msg string
+	e.call(func() {
+		msg = v.Interface().(error).Error()
+	})
+	e.emitString(tag, reflect.ValueOf(msg))
+}
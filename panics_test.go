@@ -0,0 +1,67 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type panickyStatus struct{}
+
+func (panickyStatus) String() string {
+	panic("status unavailable")
+}
+
+type panickyComments struct {
+	Name string
+}
+
+func (panickyComments) YAMLComment(field string) string {
+	var m map[string]string
+	m[field] = "boom"
+	return ""
+}
+
+var _ = Describe("Panics while encoding", func() {
+	It("writes errors as their messages", func() {
+		v := map[string]interface{}{
+			"result": struct {
+				Err   error
+				Other error
+			}{Err: errors.New("connection refused")},
+		}
+		out, err := Marshal(v)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("\"result\":\n  \"Err\": \"connection refused\"\n  \"Other\": null\n"))
+	})
+
+	It("returns the path to a value whose method panicked", func() {
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf)
+		enc.UseStringer(true)
+		err := enc.Encode(map[string][]interface{}{"servers": {"a", panickyStatus{}}})
+
+		var perr *MarshalPanicError
+		Ω(errors.As(err, &perr)).Should(BeTrue())
+		Ω(perr.Path).Should(Equal("servers.1"))
+		Ω(perr.Value).Should(Equal("status unavailable"))
+		Ω(err.Error()).Should(Equal("yaml: panic encoding 'servers.1': status unavailable"))
+	})
+
+	It("recovers runtime errors in the value's methods", func() {
+		_, err := Marshal([]panickyComments{{Name: "a"}})
+
+		var perr *MarshalPanicError
+		Ω(errors.As(err, &perr)).Should(BeTrue())
+		Ω(perr.Path).Should(Equal("0.Name"))
+		Ω(err.Error()).Should(ContainSubstring("assignment to entry in nil map"))
+	})
+
+	It("leaves the path empty for the value itself", func() {
+		enc := NewEncoder(&bytes.Buffer{})
+		enc.UseStringer(true)
+		err := enc.Encode(panickyStatus{})
+		Ω(err).Should(MatchError("yaml: panic encoding the value: status unavailable"))
+	})
+})
@@ -235,16 +235,36 @@ func yaml_parser_parse_document_start(parser *yaml_parser_t, event *yaml_event_t
 
 	/* Parse extra document end indicators. */
 
-	if !implicit {
-		for token.token_type == yaml_DOCUMENT_END_TOKEN {
-			skip_token(parser)
-			token = peek_token(parser)
-			if token == nil {
-				return false
-			}
+	for token.token_type == yaml_DOCUMENT_END_TOKEN {
+		skip_token(parser)
+		token = peek_token(parser)
+		if token == nil {
+			return false
 		}
 	}
 
+	/* Forget the comments of earlier documents. */
+
+	for line := range parser.head_comments {
+		if line < token.start_mark.line {
+			delete(parser.head_comments, line)
+		}
+	}
+	for line := range parser.line_comments {
+		if line < token.start_mark.line {
+			delete(parser.line_comments, line)
+		}
+	}
+
+	/* The spec requires '...' between a document and the next directives. */
+
+	if !implicit && parser.conformance == YAML12Conformance &&
+		(token.token_type == yaml_VERSION_DIRECTIVE_TOKEN ||
+			token.token_type == yaml_TAG_DIRECTIVE_TOKEN) {
+		return yaml_parser_set_parser_error(parser,
+			"found directive without a preceding document end marker", token.start_mark)
+	}
+
 	/* Parse an implicit document. */
 
 	if implicit && token.token_type != yaml_VERSION_DIRECTIVE_TOKEN &&
@@ -360,11 +380,37 @@ func yaml_parser_parse_document_end(parser *yaml_parser_t, event *yaml_event_t)
 		end_mark = token.end_mark
 		skip_token(parser)
 		implicit = false
+
+		/* The spec allows nothing but a comment to follow '...'. */
+
+		if parser.conformance == YAML12Conformance {
+			token = peek_token(parser)
+			if token == nil {
+				return false
+			}
+			if token.token_type != yaml_STREAM_END_TOKEN &&
+				token.start_mark.line == end_mark.line {
+				return yaml_parser_set_parser_error(parser,
+					"found content after the document end marker", token.start_mark)
+			}
+		}
 	}
 
 	parser.tag_directives = parser.tag_directives[:0]
 
-	parser.state = yaml_PARSE_DOCUMENT_START_STATE
+	for line := range parser.blank_lines {
+		if line < start_mark.line {
+			delete(parser.blank_lines, line)
+		}
+	}
+
+	/* A bare document may follow an explicit document end marker. */
+	if implicit {
+		parser.state = yaml_PARSE_DOCUMENT_START_STATE
+	} else {
+		parser.state = yaml_PARSE_IMPLICIT_DOCUMENT_START_STATE
+	}
+	arena_release(parser)
 	*event = yaml_event_t{
 		event_type: yaml_DOCUMENT_END_EVENT,
 		start_mark: start_mark,
@@ -482,7 +528,8 @@ func yaml_parser_parse_node(parser *yaml_parser_t, event *yaml_event_t,
 				for i := range parser.tag_directives {
 					tag_directive := &parser.tag_directives[i]
 					if bytes.Equal(tag_directive.handle, tag_handle) {
-						tag = append([]byte(nil), tag_directive.prefix...)
+						tag = arena_alloc(parser, len(tag_directive.prefix)+len(tag_suffix))
+						tag = append(tag, tag_directive.prefix...)
 						tag = append(tag, tag_suffix...)
 						tag_handle = nil
 						tag_suffix = nil
@@ -539,6 +586,8 @@ func yaml_parser_parse_node(parser *yaml_parser_t, event *yaml_event_t,
 					implicit:        plain_implicit,
 					quoted_implicit: quoted_implicit,
 					style:           yaml_style_t(token.style),
+					chomping:        token.chomping,
+					indent:          token.indent,
 				}
 
 				skip_token(parser)
@@ -774,6 +823,10 @@ func yaml_parser_parse_block_mapping_key(parser *yaml_parser_t,
 			parser.state = yaml_PARSE_BLOCK_MAPPING_VALUE_STATE
 			return yaml_parser_process_empty_scalar(parser, event, mark)
 		}
+	} else if token.token_type == yaml_VALUE_TOKEN {
+		/* An entry with an empty implicit key. */
+		parser.state = yaml_PARSE_BLOCK_MAPPING_VALUE_STATE
+		return yaml_parser_process_empty_scalar(parser, event, token.start_mark)
 	} else if token.token_type == yaml_BLOCK_END_TOKEN {
 		parser.state = parser.states[len(parser.states)-1]
 		parser.states = parser.states[:len(parser.states)-1]
@@ -893,6 +946,17 @@ func yaml_parser_parse_flow_sequence_entry(parser *yaml_parser_t,
 
 			skip_token(parser)
 			return true
+		} else if token.token_type == yaml_VALUE_TOKEN {
+			/* A single pair with an empty implicit key. */
+			parser.state = yaml_PARSE_FLOW_SEQUENCE_ENTRY_MAPPING_KEY_STATE
+			*event = yaml_event_t{
+				event_type: yaml_MAPPING_START_EVENT,
+				start_mark: token.start_mark,
+				end_mark:   token.start_mark,
+				implicit:   true,
+				style:      yaml_style_t(yaml_FLOW_MAPPING_STYLE),
+			}
+			return true
 		} else if token.token_type != yaml_FLOW_SEQUENCE_END_TOKEN {
 			parser.states = append(parser.states, yaml_PARSE_FLOW_SEQUENCE_ENTRY_STATE)
 			return yaml_parser_parse_node(parser, event, false, false)
@@ -932,8 +996,7 @@ func yaml_parser_parse_flow_sequence_entry_mapping_key(parser *yaml_parser_t,
 		parser.states = append(parser.states, yaml_PARSE_FLOW_SEQUENCE_ENTRY_MAPPING_VALUE_STATE)
 		return yaml_parser_parse_node(parser, event, false, false)
 	} else {
-		mark := token.end_mark
-		skip_token(parser)
+		mark := token.start_mark
 		parser.state = yaml_PARSE_FLOW_SEQUENCE_ENTRY_MAPPING_VALUE_STATE
 		return yaml_parser_process_empty_scalar(parser, event, mark)
 	}
@@ -1052,6 +1115,11 @@ func yaml_parser_parse_flow_mapping_key(parser *yaml_parser_t,
 				return yaml_parser_process_empty_scalar(parser, event,
 					token.start_mark)
 			}
+		} else if token.token_type == yaml_VALUE_TOKEN {
+			/* An entry with an empty implicit key. */
+			parser.state = yaml_PARSE_FLOW_MAPPING_VALUE_STATE
+			return yaml_parser_process_empty_scalar(parser, event,
+				token.start_mark)
 		} else if token.token_type != yaml_FLOW_MAPPING_END_TOKEN {
 			parser.states = append(parser.states, yaml_PARSE_FLOW_MAPPING_EMPTY_VALUE_STATE)
 			return yaml_parser_parse_node(parser, event, false, false)
@@ -1150,7 +1218,8 @@ func yaml_parser_process_directives(parser *yaml_parser_t,
 				return false
 			}
 			if token.major != 1 ||
-				token.minor != 1 {
+				(token.minor != 1 && token.minor != 2 &&
+					parser.conformance != YAML12Conformance) {
 				yaml_parser_set_parser_error(parser,
 					"found incompatible YAML document", token.start_mark)
 				return false
@@ -1173,7 +1242,7 @@ func yaml_parser_process_directives(parser *yaml_parser_t,
 		}
 
 		skip_token(parser)
-		token := peek_token(parser)
+		token = peek_token(parser)
 		if token == nil {
 			return false
 		}
@@ -0,0 +1,39 @@
+package candiedyaml
+
+import "reflect"
+
+// Positioned is a scalar decoded into an interface{} together with where it
+// was in the document, for validators working on generic values that need
+// to point at a line.  See Decoder.KeepPositions.  Line and Column count
+// from 1.  A Positioned is encoded as its Value.
+type Positioned struct {
+	Value  interface{}
+	Line   int
+	Column int
+}
+
+// KeepPositions causes scalars decoded into interface{} values, such as the
+// values of a map[string]interface{} or the leaves of nested maps and
+// slices, to be decoded as Positioned values holding the scalar and its
+// position.  Mapping keys are left as they are.
+func (d *Decoder) KeepPositions(keep bool) {
+	d.positions = keep
+}
+
+// positioned returns v, decoded from the current scalar, as a Positioned
+// unless it is a mapping key.
+func (d *Decoder) positioned(v interface{}) interface{} {
+	if d.key {
+		return v
+	}
+	mark := d.event.start_mark
+	return Positioned{Value: v, Line: mark.line + 1, Column: mark.column + 1}
+}
+
+func init() {
+	registerAdapter(reflect.TypeOf(Positioned{}), adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			return v.(Positioned).Value, nil
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("KeepPositions", func() {
+	It("wraps the scalars of generic values in Positioned", func() {
+		var v map[string]interface{}
+		d := NewDecoder(strings.NewReader("name: web\nports:\n  - 80\n  - 443\nlimits: {cpu: ~}\n"))
+		d.KeepPositions(true)
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(v).Should(Equal(map[string]interface{}{
+			"name":  Positioned{Value: "web", Line: 1, Column: 7},
+			"ports": []interface{}{Positioned{int64(80), 3, 5}, Positioned{int64(443), 4, 5}},
+			"limits": map[interface{}]interface{}{
+				"cpu": Positioned{Value: nil, Line: 5, Column: 15},
+			},
+		}))
+	})
+
+	It("leaves typed fields alone", func() {
+		var v struct {
+			Name  string
+			Extra interface{}
+		}
+		d := NewDecoder(strings.NewReader("name: web\nextra: 1\n"))
+		d.KeepPositions(true)
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(v.Name).Should(Equal("web"))
+		Ω(v.Extra).Should(Equal(Positioned{Value: int64(1), Line: 2, Column: 8}))
+	})
+
+	It("encodes Positioned values as their value", func() {
+		out, err := Marshal(map[string]interface{}{"a": Positioned{Value: 1, Line: 3}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("\"a\": 1\n"))
+	})
+})
@@ -0,0 +1,52 @@
+package candiedyaml
+
+// SetPreprocess makes the Decoder compose each document into a Node and
+// pass it to fn before decoding it, so that normalization such as renaming
+// legacy keys or converting units is done in one place rather than by every
+// caller:
+//
+//	d.SetPreprocess(func(doc *candiedyaml.Node) error {
+//		if timeout := doc.GetPath("timeout_ms"); timeout != nil {
+//			doc.DeletePath("timeout_ms")
+//			return doc.SetPath(timeout.Value+"ms", "timeout")
+//		}
+//		return nil
+//	})
+//
+// fn may change the Node in place.  If it returns an error, Decode returns
+// that error and the document is skipped.  Line and column numbers in
+// errors are those of the Nodes, so values fn adds are reported at the
+// position it gives them.  A nil fn, the default, decodes documents
+// straight from the parser.
+func (d *Decoder) SetPreprocess(fn func(doc *Node) error) {
+	d.preprocess = fn
+}
+
+// preprocessDocument composes the document at the current event, passes it
+// to the preprocess function and puts its events back to be decoded in its
+// place.
+func (d *Decoder) preprocessDocument() {
+	var doc Node
+	d.documentNode(&doc)
+	if err := d.preprocess(&doc); err != nil {
+		d.error(err)
+	}
+
+	mark := YAML_mark_t{line: doc.Line - 1, column: doc.Column - 1}
+	events := []yaml_event_t{{event_type: yaml_DOCUMENT_START_EVENT, implicit: true, start_mark: mark, end_mark: mark}}
+	events = doc.events(events, make(map[string]bool))
+	events = append(events, yaml_event_t{event_type: yaml_DOCUMENT_END_EVENT, implicit: true})
+
+	// Merge keys replay the mappings as fn left them.
+	if d.mergeKeys {
+		next := d.event
+		for _, e := range events {
+			d.event = e
+			d.record()
+		}
+		d.event = next
+	}
+
+	d.replay = append(append(events[1:], d.event), d.replay...)
+	d.event = events[0]
+}
@@ -0,0 +1,98 @@
+package candiedyaml
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SetPreprocess", func() {
+	type config struct {
+		Name    string `yaml:"name"`
+		Timeout string `yaml:"timeout"`
+		Workers int    `yaml:"workers,max=8"`
+	}
+
+	renameLegacy := func(doc *Node) error {
+		if timeout := doc.GetPath("timeout_ms"); timeout != nil {
+			doc.DeletePath("timeout_ms")
+			return doc.SetPath(timeout.Value+"ms", "timeout")
+		}
+		return nil
+	}
+
+	It("decodes each document as the function leaves it", func() {
+		d := NewDecoder(strings.NewReader("name: a\ntimeout_ms: 250\n---\nname: b\ntimeout: 1s\n"))
+		d.SetPreprocess(renameLegacy)
+
+		var a, b config
+		Ω(d.Decode(&a)).Should(Succeed())
+		Ω(a).Should(Equal(config{Name: "a", Timeout: "250ms"}))
+		Ω(d.Decode(&b)).Should(Succeed())
+		Ω(b).Should(Equal(config{Name: "b", Timeout: "1s"}))
+		Ω(d.Decode(&b)).Should(Equal(io.EOF))
+	})
+
+	It("returns the function's error and skips the document", func() {
+		errLegacy := errors.New("legacy document")
+		d := NewDecoder(strings.NewReader("version: 1\n---\nversion: 2\nname: b\n"))
+		d.SetPreprocess(func(doc *Node) error {
+			if doc.GetPath("version").Value == "1" {
+				return errLegacy
+			}
+			return nil
+		})
+
+		var c config
+		Ω(d.Decode(&c)).Should(Equal(errLegacy))
+		Ω(d.Decode(&c)).Should(Succeed())
+		Ω(c.Name).Should(Equal("b"))
+	})
+
+	It("passes documents decoded into Nodes through the function", func() {
+		var doc Node
+		err := Unmarshal([]byte("timeout_ms: 5 # legacy\n"), &doc, func(d *Decoder) { d.SetPreprocess(renameLegacy) })
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(doc.GetPath("timeout").Value).Should(Equal("5ms"))
+		Ω(doc.GetPath("timeout_ms")).Should(BeNil())
+	})
+
+	It("decodes short tags the function sets", func() {
+		var v map[string]interface{}
+		err := Unmarshal([]byte("version: 1.10\n"), &v, func(d *Decoder) {
+			d.SetPreprocess(func(doc *Node) error {
+				doc.GetPath("version").Tag = "!!str"
+				return nil
+			})
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(v).Should(Equal(map[string]interface{}{"version": "1.10"}))
+	})
+
+	It("reports errors at the positions of the nodes", func() {
+		var c config
+		err := Unmarshal([]byte("name: a\n\nworkers: 16\n"), &c, func(d *Decoder) { d.SetPreprocess(renameLegacy) })
+		Ω(err).Should(MatchError(HaveSuffix("at line 3, column 10")))
+	})
+
+	It("merges mappings as the function left them", func() {
+		var c config
+		err := Unmarshal([]byte(`
+defaults: &defaults
+  timeout_ms: 100
+overrides:
+  <<: *defaults
+`), &struct{ Overrides *config }{&c}, func(d *Decoder) {
+			d.SetCompatibility(GoYAMLv2)
+			d.SetPreprocess(func(doc *Node) error {
+				defaults := doc.GetPath("defaults")
+				return defaults.SetPath(defaults.GetPath("timeout_ms").Value+"ms", "timeout")
+			})
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(c.Timeout).Should(Equal("100ms"))
+	})
+})
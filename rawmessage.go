@@ -0,0 +1,139 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// RawMessage is the YAML text of a single value.  An Encoder writes it in
+// place of a value, as part of the document around it, rather than as
+// binary, so that YAML built elsewhere can be embedded in a map or struct.
+// Decoding into a RawMessage keeps the value's YAML, with its comments,
+// tags and anchors, to be decoded later.  An empty RawMessage is written as
+// null.
+//
+// json.RawMessage values are written the same way, converted from JSON
+// with the order of their keys kept, and decoded into JSON, which has no
+// comments, tags or anchors to keep.
+type RawMessage []byte
+
+func init() {
+	registerAdapter(reflect.TypeOf(RawMessage(nil)), adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			raw := v.(RawMessage)
+			if len(bytes.TrimSpace(raw)) == 0 {
+				return nil, nil
+			}
+			var doc Node
+			if err := Unmarshal(raw, &doc); err != nil {
+				return nil, err
+			}
+			if len(doc.Content) == 0 {
+				return nil, nil
+			}
+			return doc.Content[0], nil
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var n Node
+			if err := unmarshal(&n); err != nil {
+				return nil, err
+			}
+			raw, err := Marshal(&n)
+			return RawMessage(raw), err
+		},
+		nodes: true,
+	})
+
+	registerAdapter(reflect.TypeOf(json.RawMessage(nil)), adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			raw := v.(json.RawMessage)
+			if len(bytes.TrimSpace(raw)) == 0 {
+				return nil, nil
+			}
+			if !json.Valid(raw) {
+				return nil, errors.New("yaml: json.RawMessage holds invalid JSON")
+			}
+			var out interface{}
+			err := Unmarshal(raw, &out, func(d *Decoder) {
+				d.OrderedMaps(true)
+				d.SetSchema(JSONSchema)
+			})
+			return out, err
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var n Node
+			if err := unmarshal(&n); err != nil {
+				return nil, err
+			}
+			var buf bytes.Buffer
+			if err := writeJSON(&buf, &n); err != nil {
+				return nil, err
+			}
+			return json.RawMessage(buf.Bytes()), nil
+		},
+		nodes: true,
+	})
+}
+
+// writeJSON writes the value of n as JSON, with mapping keys in the order
+// they were read.  Keys have to be scalars, and are written as strings.
+func writeJSON(buf *bytes.Buffer, n *Node) error {
+	switch n.Kind {
+	case AliasNode:
+		return writeJSON(buf, n.Alias)
+	case DocumentNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, n.Content[0])
+	case SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Kind == AliasNode {
+				key = key.Alias
+			}
+			if key.Kind != ScalarNode {
+				return fmt.Errorf("yaml: line %d, column %d: a JSON key must be a scalar", key.Line, key.Column)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			k, _ := json.Marshal(key.Value)
+			buf.Write(k)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("yaml: line %d, column %d: %v", n.Line, n.Column, err)
+	}
+	buf.Write(b)
+	return nil
+}
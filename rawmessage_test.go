@@ -0,0 +1,63 @@
+package candiedyaml
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RawMessage", func() {
+	yaml11 := EncodeOption(func(e *Encoder) { e.SetSchema(YAML11Schema) })
+
+	It("writes YAML text in place of a value", func() {
+		out, err := Marshal(map[string]interface{}{
+			"spec":  RawMessage("replicas: 3\nports: [80, 443]\n"),
+			"empty": RawMessage(nil),
+		}, yaml11)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("empty: null\nspec:\n  replicas: 3\n  ports: [80, 443]\n"))
+
+		_, err = Marshal(struct{ Spec RawMessage }{RawMessage("a: [\n")})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("writes json.RawMessage values as YAML, keeping the order of keys", func() {
+		type wrapper struct {
+			Meta  json.RawMessage `yaml:"meta"`
+			Other json.RawMessage `yaml:"other"`
+		}
+		out, err := Marshal(wrapper{
+			Meta:  json.RawMessage(`{"z": 1, "a": [true, null, "yes", 1.5]}`),
+			Other: json.RawMessage(`"text"`),
+		}, yaml11)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("meta:\n  z: 1\n  a:\n  - true\n  - null\n  - \"yes\"\n  - 1.5\nother: text\n"))
+
+		var back wrapper
+		Ω(Unmarshal(out, &back)).Should(Succeed())
+		Ω(string(back.Meta)).Should(Equal(`{"z":1,"a":[true,null,"yes",1.5]}`))
+		Ω(string(back.Other)).Should(Equal(`"text"`))
+
+		_, err = Marshal(wrapper{Meta: json.RawMessage(`{"a": `)})
+		Ω(err).Should(MatchError(ContainSubstring("invalid JSON")))
+	})
+
+	It("keeps the YAML of a value decoded into it", func() {
+		var v struct {
+			Spec RawMessage `yaml:"spec"`
+			None RawMessage `yaml:"none"`
+		}
+		Ω(Unmarshal([]byte("spec:\n  # replicas\n  replicas: &n 3\n  max: *n\nnone: ~\n"), &v)).Should(Succeed())
+		Ω(string(v.Spec)).Should(Equal("# replicas\nreplicas: &n 3\nmax: *n\n"))
+		Ω(v.None).Should(BeNil())
+
+		var commented struct{ R RawMessage }
+		Ω(Unmarshal([]byte("r: &x {a: 1} # hi\n"), &commented)).Should(Succeed())
+		Ω(string(commented.R)).Should(Equal("&x {a: 1} # hi\n"))
+
+		var spec map[string]int
+		Ω(Unmarshal(v.Spec, &spec)).Should(Succeed())
+		Ω(spec).Should(Equal(map[string]int{"replicas": 3, "max": 3}))
+	})
+})
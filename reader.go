@@ -1,6 +1,7 @@
 package candiedyaml
 
 import (
+	"bytes"
 	"io"
 )
 
@@ -63,6 +64,7 @@ func yaml_parser_determine_encoding(parser *yaml_parser_t) bool {
 	} else {
 		parser.encoding = yaml_UTF8_ENCODING
 	}
+	parser.mark.offset = parser.offset
 
 	return true
 }
@@ -100,6 +102,7 @@ func yaml_parser_update_raw_buffer(parser *yaml_parser_t) bool {
 	if err == io.EOF {
 		parser.eof = true
 	} else if err != nil {
+		parser.read_error = err
 		return yaml_parser_set_reader_error(parser, "input error: "+err.Error(),
 			parser.offset, -1)
 	}
@@ -114,6 +117,30 @@ func yaml_parser_update_raw_buffer(parser *yaml_parser_t) bool {
  * The length is supposed to be significantly less that the buffer size.
  */
 
+/*
+ * Keep the most recently consumed lines around so that errors can show the
+ * offending line.
+ */
+
+func yaml_parser_retain_history(parser *yaml_parser_t, consumed []byte) {
+	parser.history = append(parser.history, consumed...)
+
+	breaks := bytes.Count(parser.history, []byte{'\n'})
+	for breaks > INPUT_HISTORY_LINES {
+		i := bytes.IndexByte(parser.history, '\n')
+		parser.history = parser.history[i+1:]
+		parser.history_line++
+		parser.history_partial = false
+		breaks--
+	}
+
+	if cut := len(parser.history) - INPUT_HISTORY_SIZE; cut > 0 {
+		parser.history_line += bytes.Count(parser.history[:cut], []byte{'\n'})
+		parser.history_partial = parser.history[cut-1] != '\n'
+		parser.history = append(parser.history[:0], parser.history[cut:]...)
+	}
+}
+
 func yaml_parser_update_buffer(parser *yaml_parser_t, length int) bool {
 	/* Read handler must be set. */
 	if parser.read_handler == nil {
@@ -142,6 +169,9 @@ func yaml_parser_update_buffer(parser *yaml_parser_t, length int) bool {
 
 	/* Move the unread characters to the beginning of the buffer. */
 	buffer_end := len(parser.buffer)
+	if parser.buffer_pos > 0 {
+		yaml_parser_retain_history(parser, parser.buffer[:parser.buffer_pos])
+	}
 	if 0 < parser.buffer_pos &&
 		parser.buffer_pos < buffer_end {
 		copy(parser.buffer, parser.buffer[parser.buffer_pos:])
@@ -283,7 +313,7 @@ func yaml_parser_update_buffer(parser *yaml_parser_t, length int) bool {
 				if parser.encoding == yaml_UTF16LE_ENCODING {
 					low, high = 0, 1
 				} else {
-					high, low = 1, 0
+					high, low = 0, 1
 				}
 
 				/*
@@ -413,24 +443,27 @@ func yaml_parser_update_buffer(parser *yaml_parser_t, length int) bool {
 			/* 0000 0000-0000 007F . 0xxxxxxx */
 			if value <= 0x7F {
 				parser.buffer[buffer_end] = byte(value)
+				buffer_end += 1
 			} else if value <= 0x7FF {
 				/* 0000 0080-0000 07FF . 110xxxxx 10xxxxxx */
 				parser.buffer[buffer_end] = byte(0xC0 + (value >> 6))
 				parser.buffer[buffer_end+1] = byte(0x80 + (value & 0x3F))
+				buffer_end += 2
 			} else if value <= 0xFFFF {
 				/* 0000 0800-0000 FFFF . 1110xxxx 10xxxxxx 10xxxxxx */
 				parser.buffer[buffer_end] = byte(0xE0 + (value >> 12))
 				parser.buffer[buffer_end+1] = byte(0x80 + ((value >> 6) & 0x3F))
 				parser.buffer[buffer_end+2] = byte(0x80 + (value & 0x3F))
+				buffer_end += 3
 			} else {
 				/* 0001 0000-0010 FFFF . 11110xxx 10xxxxxx 10xxxxxx 10xxxxxx */
 				parser.buffer[buffer_end] = byte(0xF0 + (value >> 18))
 				parser.buffer[buffer_end+1] = byte(0x80 + ((value >> 12) & 0x3F))
 				parser.buffer[buffer_end+2] = byte(0x80 + ((value >> 6) & 0x3F))
 				parser.buffer[buffer_end+3] = byte(0x80 + (value & 0x3F))
+				buffer_end += 4
 			}
 
-			buffer_end += w
 			parser.unread++
 		}
 
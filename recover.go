@@ -0,0 +1,126 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+)
+
+// ParseRecovering decodes every document in data into Nodes like ParseSource,
+// but does not stop at the first error.  When a document has a syntax error
+// or an alias to no anchor, the lines from the top-level entry the error is
+// in, or from the start of the flow collection or quoted scalar it is in, up
+// to the next line starting at the first column are left out, and the stream
+// is parsed again.  It returns the documents that could be read, with their
+// nodes at the lines and columns they are at in data, and every error found
+// on the way, in the order found.  Editors and linters can show all the
+// problems in a file at once and still work with the rest of it.
+//
+// Errors that are not about a place in the input, such as invalid UTF-8,
+// cannot be recovered from: only the documents read before them are
+// returned.
+func ParseRecovering(data []byte) ([]*Node, []error) {
+	text := append([]byte(nil), toUTF8(data)...)
+	var errs []error
+	for {
+		docs, err := parseNodes(text)
+		if err == nil {
+			return docs, errs
+		}
+		errs = append(errs, err)
+
+		line, ok := errorLine(err)
+		if !ok || !blankEntry(text, line) {
+			return docs, errs
+		}
+	}
+}
+
+// parseNodes decodes the documents of text into Nodes until the first
+// error, returning those before it.
+func parseNodes(text []byte) ([]*Node, error) {
+	var docs []*Node
+	d := NewDecoder(bytes.NewReader(text))
+	for {
+		doc := &Node{}
+		if err := d.Decode(doc); err == io.EOF {
+			return docs, nil
+		} else if err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// errorLine returns the first line, counting from 0, of what err is about,
+// or false if err is not about a place in the input.
+func errorLine(err error) (int, bool) {
+	switch err := err.(type) {
+	case *ParserError:
+		if err.ErrorType == yaml_READER_ERROR {
+			return 0, false
+		}
+		line := err.ProblemMark.line
+		if err.Context != "" && err.ContextMark.line < line {
+			line = err.ContextMark.line
+		}
+		return line, true
+	case *UnknownAnchorError:
+		return err.At.line, true
+	}
+	return 0, false
+}
+
+// blankEntry replaces with spaces the lines of text from the last one at or
+// before line that starts at the first column up to the next one after it
+// that does, keeping the document markers on them, and reports whether that
+// changed text.
+func blankEntry(text []byte, line int) bool {
+	var starts []int
+	for i := 0; i < len(text); {
+		starts = append(starts, i)
+		if n := bytes.IndexByte(text[i:], '\n'); n >= 0 {
+			i += n + 1
+		} else {
+			i = len(text)
+		}
+	}
+	if len(starts) == 0 {
+		return false
+	}
+	if line >= len(starts) {
+		line = len(starts) - 1
+	}
+	topLevel := func(l int) bool {
+		return bytes.IndexByte([]byte(" \t\r\n#"), text[starts[l]]) < 0
+	}
+
+	first, last := line, line+1
+	for first > 0 && !topLevel(first) {
+		first--
+	}
+	for last < len(starts) && !topLevel(last) {
+		last++
+	}
+	end := len(text)
+	if last < len(starts) {
+		end = starts[last]
+	}
+
+	start := starts[first]
+	marker := text[start:end]
+	if n := bytes.IndexByte(marker, '\n'); n >= 0 {
+		marker = marker[:n]
+	}
+	if isMarker(marker, "---") || isMarker(marker, "...") {
+		start += 3
+	}
+
+	changed := false
+	for i := start; i < end; i++ {
+		if c := text[i]; c != ' ' && c != '\r' && c != '\n' {
+			text[i] = ' '
+			changed = true
+		}
+	}
+	return changed
+}
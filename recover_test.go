@@ -0,0 +1,80 @@
+package candiedyaml
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseRecovering", func() {
+	keys := func(doc *Node) []string {
+		var s []string
+		root := doc.Content[0]
+		for i := 0; i < len(root.Content); i += 2 {
+			s = append(s, root.Content[i].Value)
+		}
+		return s
+	}
+
+	It("reads a well-formed stream like ParseSource", func() {
+		docs, errs := ParseRecovering([]byte("a: 1\n---\n- b\n"))
+		Ω(errs).Should(BeEmpty())
+		Ω(docs).Should(HaveLen(2))
+		Ω(keys(docs[0])).Should(Equal([]string{"a"}))
+		Ω(docs[1].Content[0].Content[0].Value).Should(Equal("b"))
+	})
+
+	It("leaves out the entries with errors and keeps the rest", func() {
+		docs, errs := ParseRecovering([]byte(`a: 1
+b: "unclosed
+c: 3
+d: [1, 2
+e: 5
+f:
+  g: 6
+   h: 7
+i: 9
+`))
+		Ω(errs).Should(HaveLen(3))
+		Ω(docs).Should(HaveLen(1))
+		Ω(keys(docs[0])).Should(Equal([]string{"a", "c", "e", "i"}))
+
+		root := docs[0].Content[0]
+		Ω(root.Content[5].Value).Should(Equal("5"))
+		Ω(root.Content[5].Line).Should(Equal(5))
+		Ω(root.Content[7].Line).Should(Equal(9))
+	})
+
+	It("reports the errors at their place in the input", func() {
+		_, errs := ParseRecovering([]byte("a: 1\nb: [x\nc: 3\n"))
+		Ω(errs).Should(HaveLen(1))
+
+		var perr *ParserError
+		Ω(errors.As(errs[0], &perr)).Should(BeTrue())
+		Ω(perr.ContextMark.line + 1).Should(Equal(2))
+	})
+
+	It("goes on to the documents after one with errors", func() {
+		docs, errs := ParseRecovering([]byte("a: 1\n---\nb: *nowhere\nc: 2\n---\nd: 4\n"))
+		Ω(errs).Should(HaveLen(1))
+		Ω(errors.Is(errs[0], ErrUnknownAnchor)).Should(BeTrue())
+		Ω(docs).Should(HaveLen(3))
+		Ω(keys(docs[1])).Should(Equal([]string{"c"}))
+		Ω(keys(docs[2])).Should(Equal([]string{"d"}))
+	})
+
+	It("keeps document markers on the lines it leaves out", func() {
+		docs, errs := ParseRecovering([]byte("a: 1\n--- [x\nb: 2\n"))
+		Ω(errs).Should(HaveLen(1))
+		Ω(docs).Should(HaveLen(2))
+		Ω(keys(docs[0])).Should(Equal([]string{"a"}))
+		Ω(keys(docs[1])).Should(Equal([]string{"b"}))
+	})
+
+	It("stops at errors it cannot recover from", func() {
+		_, errs := ParseRecovering([]byte("a: 1\n---\nb: \xff\n"))
+		Ω(errs).Should(HaveLen(1))
+		Ω(errors.Is(errs[0], ErrInvalidEncoding)).Should(BeTrue())
+	})
+})
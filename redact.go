@@ -0,0 +1,141 @@
+package candiedyaml
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Redact copies the YAML stream read from r to w with the values at paths
+// replaced by the scalar replacement, keeping everything else, comments
+// included, as it is.  A whole collection at a path is replaced by the one
+// scalar.
+//
+// A path names mapping keys and sequence indexes from the root of each
+// document, separated by dots, e.g. "db.password" or "users.0.token".  The
+// wildcard "*" matches any one key or index, so "users.*.token" matches the
+// token of every user.  Values that are keys themselves are never replaced.
+func Redact(r io.Reader, w io.Writer, paths []string, replacement string) error {
+	rd := &redactor{replacement: replacement}
+	for _, p := range paths {
+		rd.paths = append(rd.paths, strings.Split(p, "."))
+	}
+	return Transform(r, w, rd.event)
+}
+
+type redactor struct {
+	paths       [][]string
+	replacement string
+
+	// frames holds the collections the current event is inside, and path
+	// the key or index each of them has reached.
+	frames []redactFrame
+	path   []string
+
+	// skip counts the collections open inside a value being replaced.
+	skip int
+}
+
+type redactFrame struct {
+	mapping bool
+	index   int
+
+	// key reports that the next node of a mapping is a key.
+	key bool
+}
+
+func (rd *redactor) event(ev Event) ([]Event, error) {
+	if rd.skip > 0 {
+		switch ev.Kind {
+		case SequenceStartEvent, MappingStartEvent:
+			rd.skip++
+		case SequenceEndEvent, MappingEndEvent:
+			rd.skip--
+			if rd.skip == 0 {
+				rd.next()
+			}
+		}
+		return nil, nil
+	}
+
+	switch ev.Kind {
+	case DocumentStartEvent:
+		rd.frames, rd.path = rd.frames[:0], rd.path[:0]
+	case SequenceEndEvent, MappingEndEvent:
+		rd.frames = rd.frames[:len(rd.frames)-1]
+		rd.path = rd.path[:len(rd.path)-1]
+		rd.next()
+	case ScalarEvent, AliasEvent, SequenceStartEvent, MappingStartEvent:
+		isKey := len(rd.frames) > 0 && rd.frames[len(rd.frames)-1].key
+		if len(rd.frames) > 0 && !isKey && rd.matches() {
+			out := Event{
+				Kind:        ScalarEvent,
+				Value:       rd.replacement,
+				Anchor:      ev.Anchor,
+				BlankLines:  ev.BlankLines,
+				HeadComment: ev.HeadComment,
+				LineComment: ev.LineComment,
+			}
+			switch {
+			case ev.Kind == ScalarEvent || ev.Kind == AliasEvent:
+				rd.next()
+			case ev.Style == FlowStyle:
+				rd.skip = 1
+			default:
+				// The comments of a block collection are on its
+				// first entry, which goes with it.
+				rd.skip = 1
+				out.BlankLines, out.HeadComment, out.LineComment = 0, "", ""
+			}
+			return []Event{out}, nil
+		}
+
+		switch ev.Kind {
+		case ScalarEvent, AliasEvent:
+			if isKey {
+				rd.path[len(rd.path)-1] = ev.Value
+			}
+			rd.next()
+		default:
+			if isKey {
+				rd.path[len(rd.path)-1] = ""
+			}
+			mapping := ev.Kind == MappingStartEvent
+			rd.frames = append(rd.frames, redactFrame{mapping: mapping, key: mapping})
+			rd.path = append(rd.path, "0")
+		}
+	}
+	return []Event{ev}, nil
+}
+
+// next moves past the node that just ended to the next key, value or
+// index of the collection it is in.
+func (rd *redactor) next() {
+	if len(rd.frames) == 0 {
+		return
+	}
+	f := &rd.frames[len(rd.frames)-1]
+	if f.mapping {
+		f.key = !f.key
+		return
+	}
+	f.index++
+	rd.path[len(rd.path)-1] = strconv.Itoa(f.index)
+}
+
+// matches reports whether the current value is at one of the paths.
+func (rd *redactor) matches() bool {
+	for _, p := range rd.paths {
+		if len(p) != len(rd.path) {
+			continue
+		}
+		i := 0
+		for i < len(p) && (p[i] == "*" || p[i] == rd.path[i]) {
+			i++
+		}
+		if i == len(p) {
+			return true
+		}
+	}
+	return false
+}
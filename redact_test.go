@@ -0,0 +1,89 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Redact", func() {
+	redact := func(data string, paths ...string) string {
+		var buf bytes.Buffer
+		err := Redact(strings.NewReader(data), &buf, paths, "REDACTED")
+		Ω(err).ShouldNot(HaveOccurred())
+		return buf.String()
+	}
+
+	It("replaces the values at paths and keeps comments", func() {
+		data := `# database
+db:
+  user: app
+  password: hunter2 # rotate monthly
+
+  hosts: [a, b]
+`
+		Ω(redact(data, "db.password")).Should(Equal(`# database
+db:
+  user: app
+  password: REDACTED # rotate monthly
+
+  hosts: [a, b]
+`))
+	})
+
+	It("replaces whole collections", func() {
+		data := `keys:
+  # deploy keys
+  - a
+  - b
+tokens: {a: 1, b: [2, 3]}
+other: 1
+`
+		Ω(redact(data, "keys", "tokens")).Should(Equal(`keys: REDACTED
+tokens: REDACTED
+other: 1
+`))
+	})
+
+	It("matches indexes and wildcards", func() {
+		data := `users:
+- name: a
+  token: x
+- name: b
+  token: y
+`
+		Ω(redact(data, "users.1.token")).Should(Equal(`users:
+- name: a
+  token: x
+- name: b
+  token: REDACTED
+`))
+		Ω(redact(data, "users.*.token")).Should(Equal(`users:
+- name: a
+  token: REDACTED
+- name: b
+  token: REDACTED
+`))
+		Ω(redact(data, "*.*.*")).Should(Equal(`users:
+- name: REDACTED
+  token: REDACTED
+- name: REDACTED
+  token: REDACTED
+`))
+	})
+
+	It("leaves keys, other paths and other documents alone", func() {
+		data := "password: 1\nnested: {password: 2}\n--- {password: 3}\n"
+		Ω(redact(data, "password")).Should(Equal("password: REDACTED\nnested: {password: 2}\n--- {password: REDACTED}\n"))
+		Ω(redact(data, "password.x", "nested")).Should(Equal("password: 1\nnested: REDACTED\n--- {password: 3}\n"))
+		Ω(redact("[a, b]\n", "*")).Should(Equal("[REDACTED, REDACTED]\n"))
+	})
+
+	It("quotes the replacement where needed", func() {
+		var buf bytes.Buffer
+		err := Redact(strings.NewReader("a: !!int 1\n"), &buf, []string{"a"}, "- none")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(buf.String()).Should(Equal("a: '- none'\n"))
+	})
+})
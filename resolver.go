@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"math"
+	"math/big"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -12,7 +13,10 @@ import (
 	"time"
 )
 
-var byteSliceType = reflect.TypeOf([]byte(nil))
+// isByteSlice reports whether t is []byte or a named type based on it.
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
 
 var bool_values map[string]bool
 var null_values map[string]bool
@@ -24,6 +28,15 @@ var bools = []byte{'t', 'T', 'f', 'F', 'y', 'Y', 'n', 'N', 'o', 'O'}
 var timestamp_regexp *regexp.Regexp
 var ymd_regexp *regexp.Regexp
 
+// float_regexp matches the floats of YAML 1.1, such as .5, 5., 1_000.5 and
+// 190:20:30.15, along with integers and exponents without a fraction, which
+// float fields accept too.
+var float_regexp = regexp.MustCompile(`^[-+]?(` +
+	`([0-9][0-9_]*\.[0-9_]*|\.[0-9][0-9_]*|[0-9][0-9_]*)([eE][-+]?[0-9]+)?|` +
+	`[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?|` +
+	`\.(inf|Inf|INF))$|` +
+	`^\.(nan|NaN|NAN)$`)
+
 func init() {
 	bool_values = make(map[string]bool)
 	bool_values["y"] = true
@@ -46,14 +59,19 @@ func init() {
 	ymd_regexp = regexp.MustCompile("^([0-9][0-9][0-9][0-9])-([0-9][0-9]?)-([0-9][0-9]?)$")
 }
 
-func resolve(event yaml_event_t, v reflect.Value) error {
+func resolve(event yaml_event_t, v reflect.Value, schema *Schema) error {
 	val := string(event.value)
 
-	if null_values[val] {
+	// Interfaces are left to the schema, which decides what is null.
+	if null_values[val] && v.Kind() != reflect.Interface {
 		v.Set(reflect.Zero(v.Type()))
 		return nil
 	}
 
+	if v.Type() == numberType {
+		return resolveNumber(event, v, schema)
+	}
+
 	switch v.Kind() {
 	case reflect.String:
 		v.SetString(val)
@@ -61,16 +79,24 @@ func resolve(event yaml_event_t, v reflect.Value) error {
 		return resolve_bool(val, v)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return resolve_int(val, v)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		return resolve_uint(val, v)
 	case reflect.Float32, reflect.Float64:
 		return resolve_float(val, v)
 	case reflect.Interface:
-		v.Set(reflect.ValueOf(resolveInterface(event)))
+		i, err := resolveInterface(event, schema)
+		if err != nil {
+			return err
+		}
+		if i == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(i))
+		}
 	case reflect.Struct:
 		return resolve_time(val, v)
 	case reflect.Slice:
-		if v.Type() != byteSliceType {
+		if !isByteSlice(v.Type()) {
 			return errors.New("Cannot resolve into " + v.Type().String())
 		}
 		b := make([]byte, base64.StdEncoding.DecodedLen(len(event.value)))
@@ -79,7 +105,7 @@ func resolve(event yaml_event_t, v reflect.Value) error {
 			return err
 		}
 
-		v.Set(reflect.ValueOf(b[0:n]))
+		v.SetBytes(b[0:n])
 	default:
 		return errors.New("Resolve failed for " + v.Kind().String())
 	}
@@ -99,9 +125,12 @@ func resolve_bool(val string, v reflect.Value) error {
 
 func resolve_int(val string, v reflect.Value) error {
 	val = strings.Replace(val, "_", "", -1)
-	var value int64
 
-	sign := int64(1)
+	if val == "" || val == "-" || val == "+" {
+		return errors.New("Integer: " + val)
+	}
+
+	sign := 1
 	if val[0] == '-' {
 		sign = -1
 		val = val[1:]
@@ -125,42 +154,35 @@ func resolve_int(val string, v reflect.Value) error {
 		base = 8
 		val = val[1:]
 	} else if strings.Contains(val, ":") {
-		digits := strings.Split(val, ":")
-		bes := int64(1)
-		for j := len(digits) - 1; j >= 0; j-- {
-			n, err := strconv.ParseInt(digits[j], 10, 64)
-			n *= bes
-			if err != nil || v.OverflowInt(n) {
-				return errors.New("Integer: " + val)
-			}
-			value += n
-			bes *= 60
-		}
-
-		value *= sign
-		v.SetInt(value)
-		return nil
+		base = 60
 	}
 
-	value, err := strconv.ParseInt(val, base, 64)
-	value *= sign
-	if err != nil || v.OverflowInt(value) {
+	u, value, ok := parseMagnitude(val, base)
+	if !ok {
 		return errors.New("Integer: " + val)
 	}
-
-	v.SetInt(value)
-	return nil
+	if value == nil && (u < 1<<63 || sign < 0 && u == 1<<63) {
+		i := int64(u)
+		if sign < 0 {
+			i = -i
+		}
+		if !v.OverflowInt(i) {
+			v.SetInt(i)
+			return nil
+		}
+	}
+	return &overflowError{"Integer: " + val, bigMagnitude(u, value, sign < 0), 0}
 }
 
 func resolve_uint(val string, v reflect.Value) error {
 	val = strings.Replace(val, "_", "", -1)
-	var value uint64
 
-	if val[0] == '-' {
-		return errors.New("Unsigned int with negative value: " + val)
+	if val == "" || val == "-" || val == "+" {
+		return errors.New("Unsigned Integer: " + val)
 	}
 
-	if val[0] == '+' {
+	negative := val[0] == '-'
+	if negative || val[0] == '+' {
 		val = val[1:]
 	}
 
@@ -180,32 +202,132 @@ func resolve_uint(val string, v reflect.Value) error {
 		base = 8
 		val = val[1:]
 	} else if strings.Contains(val, ":") {
-		digits := strings.Split(val, ":")
-		bes := uint64(1)
-		for j := len(digits) - 1; j >= 0; j-- {
-			n, err := strconv.ParseUint(digits[j], 10, 64)
-			n *= bes
-			if err != nil || v.OverflowUint(n) {
-				return errors.New("Unsigned Integer: " + val)
-			}
-			value += n
-			bes *= 60
-		}
+		base = 60
+	}
 
-		v.SetUint(value)
+	u, value, ok := parseMagnitude(val, base)
+	if !ok {
+		return errors.New("Unsigned Integer: " + val)
+	}
+	if negative && (u != 0 || value != nil) {
+		return &overflowError{"Unsigned int with negative value: -" + val, bigMagnitude(u, value, true), 0}
+	}
+	if value == nil && !v.OverflowUint(u) {
+		v.SetUint(u)
 		return nil
 	}
+	return &overflowError{"Unsigned Integer: " + val, bigMagnitude(u, value, false), 0}
+}
 
-	value, err := strconv.ParseUint(val, base, 64)
-	if err != nil || v.OverflowUint(value) {
-		return errors.New("Unsigned Integer: " + val)
+// maxIntegerDigits is the length beyond which integers are not converted
+// exactly, which takes time quadratic in their length.  They are far out of
+// range, and an OverflowPolicy needs no more than their low 64 bits.
+const maxIntegerDigits = 1000
+
+// parseMagnitude returns the unsigned integer val stands for in base, or in
+// base 60 with colons between the digits.  It is returned as a uint64, or,
+// if it does not fit one, as a big.Int.  ok is false if val is not an
+// integer in base.
+func parseMagnitude(val string, base int) (u uint64, value *big.Int, ok bool) {
+	if base != 60 {
+		u, err := strconv.ParseUint(val, base, 64)
+		if err == nil {
+			return u, nil, true
+		}
+		if err.(*strconv.NumError).Err != strconv.ErrRange {
+			return 0, nil, false
+		}
 	}
 
-	v.SetUint(value)
-	return nil
+	if len(val) > maxIntegerDigits {
+		return lowBits(val, base)
+	}
+
+	value = new(big.Int)
+	if base != 60 {
+		_, ok = value.SetString(val, base)
+		return 0, value, ok
+	}
+	digits := strings.Split(val, ":")
+	bes := big.NewInt(1)
+	for j := len(digits) - 1; j >= 0; j-- {
+		n, ok := new(big.Int).SetString(digits[j], 10)
+		if !ok || n.Sign() < 0 {
+			return 0, nil, false
+		}
+		value.Add(value, n.Mul(n, bes))
+		bes.Mul(bes, big.NewInt(60))
+	}
+	if value.IsUint64() {
+		return value.Uint64(), nil, true
+	}
+	return 0, value, true
+}
+
+// lowBits is parseMagnitude for integers too long to convert.  value holds
+// 2^64 plus their low 64 bits, which is out of range for every integer type
+// and truncates like them.
+func lowBits(val string, base int) (u uint64, value *big.Int, ok bool) {
+	// In base 60, the digits between colons are decimal.
+	digitBase, component := base, uint64(0)
+	if base == 60 {
+		digitBase = 10
+	}
+	empty := true
+	for _, c := range val {
+		var digit int
+		switch {
+		case c == ':' && base == 60:
+			if empty {
+				return 0, nil, false
+			}
+			u = u*60 + component
+			component, empty = 0, true
+			continue
+		case '0' <= c && c <= '9':
+			digit = int(c - '0')
+		case 'a' <= c && c <= 'f':
+			digit = int(c-'a') + 10
+		case 'A' <= c && c <= 'F':
+			digit = int(c-'A') + 10
+		default:
+			return 0, nil, false
+		}
+		if digit >= digitBase {
+			return 0, nil, false
+		}
+		component = component*uint64(digitBase) + uint64(digit)
+		empty = false
+	}
+	if empty {
+		return 0, nil, false
+	}
+	if base == 60 {
+		u = u*60 + component
+	} else {
+		u = component
+	}
+
+	value = new(big.Int).Lsh(big.NewInt(1), 64)
+	return 0, value.Add(value, new(big.Int).SetUint64(u)), true
+}
+
+// bigMagnitude returns the integer of magnitude u, or value if it is set,
+// negated if negative.
+func bigMagnitude(u uint64, value *big.Int, negative bool) *big.Int {
+	if value == nil {
+		value = new(big.Int).SetUint64(u)
+	}
+	if negative {
+		value.Neg(value)
+	}
+	return value
 }
 
 func resolve_float(val string, v reflect.Value) error {
+	if !float_regexp.MatchString(val) {
+		return errors.New("Float: " + val)
+	}
 	val = strings.Replace(val, "_", "", -1)
 	var value float64
 
@@ -226,28 +348,46 @@ func resolve_float(val string, v reflect.Value) error {
 		digits := strings.Split(val, ":")
 		bes := float64(1)
 		for j := len(digits) - 1; j >= 0; j-- {
-			n, err := strconv.ParseFloat(digits[j], v.Type().Bits())
-			n *= bes
-			if err != nil || v.OverflowFloat(n) {
+			n, err := strconv.ParseFloat(digits[j], 64)
+			if err != nil {
 				return errors.New("Float: " + val)
 			}
-			value += n
+			value += n * bes
 			bes *= 60
 		}
 		value *= float64(sign)
 	} else {
 		var err error
 		value, err = strconv.ParseFloat(val, v.Type().Bits())
-		value *= float64(sign)
-		if err != nil || v.OverflowFloat(value) {
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
 			return errors.New("Float: " + val)
 		}
+		value *= float64(sign)
+	}
+
+	if math.IsInf(value, 0) && valLower != ".inf" || v.OverflowFloat(value) {
+		return &overflowError{"Float: " + val, nil, value}
 	}
 
 	v.SetFloat(value)
 	return nil
 }
 
+// isTruncatedFloat reports whether f, resolved from val into a float of the
+// given size, does not hold all the digits of val.
+func isTruncatedFloat(val string, f float64, bits int) bool {
+	if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return f >= math.MaxInt64 || int64(f) != i
+	}
+
+	exact, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsNaN(exact) {
+		return false
+	}
+	shortest, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, bits), 64)
+	return shortest != exact
+}
+
 func resolve_time(val string, v reflect.Value) error {
 	var parsedTime time.Time
 	matches := ymd_regexp.FindStringSubmatch(val)
@@ -270,9 +410,13 @@ func resolve_time(val string, v reflect.Value) error {
 		sec, _ := strconv.Atoi(matches[6])
 
 		nsec := 0
-		if matches[7] != "" {
-			millis, _ := strconv.Atoi(matches[7])
-			nsec = int(time.Duration(millis) * time.Millisecond)
+		if frac := matches[7]; frac != "" {
+			// The fraction is of a second: .1 is 100ms.  Digits past
+			// nanoseconds are dropped.
+			if len(frac) > 9 {
+				frac = frac[:9]
+			}
+			nsec, _ = strconv.Atoi(frac + strings.Repeat("0", 9-len(frac)))
 		}
 
 		loc := time.UTC
@@ -294,18 +438,67 @@ func resolve_time(val string, v reflect.Value) error {
 		parsedTime = time.Date(year, time.Month(month), day, hour, min, sec, nsec, loc)
 	}
 
-	v.Set(reflect.ValueOf(parsedTime))
+	// Types defined as time.Time, such as a Date type, take it as well.
+	t := reflect.ValueOf(parsedTime)
+	if !t.Type().ConvertibleTo(v.Type()) {
+		return errors.New("Cannot resolve into " + v.Type().String())
+	}
+	v.Set(t.Convert(v.Type()))
 	return nil
 }
 
-func resolveInterface(event yaml_event_t) interface{} {
-	if len(event.value) == 0 {
-		return nil
-	}
-
+func resolveInterface(event yaml_event_t, schema *Schema) (interface{}, error) {
 	val := string(event.value)
 	if len(event.tag) == 0 && !event.implicit {
-		return val
+		return val, nil
+	}
+
+	if v, ok, err := resolveTagged(string(event.tag), val, schema); ok {
+		return v, err
+	}
+	return schema.Resolve(val), nil
+}
+
+// resolveTagged returns the value of a scalar with one of the standard tags,
+// which decides its type whatever the schema resolves.  It returns false
+// for other tags.
+func resolveTagged(tag, val string, schema *Schema) (interface{}, bool, error) {
+	var v reflect.Value
+	switch tag {
+	case yaml_STR_TAG:
+		return val, true, nil
+	case yaml_NULL_TAG:
+		if !null_values[val] {
+			return nil, true, errors.New("Invalid null: " + val)
+		}
+		return nil, true, nil
+	case yaml_BOOL_TAG:
+		v = reflect.New(reflect.TypeOf(false)).Elem()
+	case yaml_INT_TAG:
+		v = reflect.New(reflect.TypeOf(int64(0))).Elem()
+	case yaml_FLOAT_TAG:
+		v = reflect.New(reflect.TypeOf(float64(0))).Elem()
+	case yaml_TIMESTAMP_TAG:
+		if schema.noTimestamps {
+			return val, true, nil
+		}
+		v = reflect.New(timeTimeType).Elem()
+	case BinaryTag:
+		v = reflect.New(reflect.TypeOf([]byte(nil))).Elem()
+	default:
+		return nil, false, nil
+	}
+
+	err := resolve(yaml_event_t{value: []byte(val)}, v, schema)
+	if err != nil {
+		return nil, true, err
+	}
+	return v.Interface(), true, nil
+}
+
+func resolveYAML11(val string) (interface{}, bool) {
+	if len(val) == 0 {
+		return nil, true
 	}
 
 	sign := false
@@ -317,38 +510,38 @@ func resolveInterface(event yaml_event_t) interface{} {
 	case c >= '0' && c <= '9':
 		i := int64(0)
 		if resolve_int(val, reflect.ValueOf(&i).Elem()) == nil {
-			return i
+			return i, true
 		}
 		f := float64(0)
 		if resolve_float(val, reflect.ValueOf(&f).Elem()) == nil {
-			return f
+			return f, true
 		}
 
 		if !sign {
 			t := time.Time{}
 			if resolve_time(val, reflect.ValueOf(&t).Elem()) == nil {
-				return t
+				return t, true
 			}
 		}
 	case bytes.IndexByte(nulls, c) != -1:
 		if null_values[val] {
-			return nil
+			return nil, true
 		}
 		b := false
 		if resolve_bool(val, reflect.ValueOf(&b).Elem()) == nil {
-			return b
+			return b, true
 		}
 	case c == '.':
 		f := float64(0)
 		if resolve_float(val, reflect.ValueOf(&f).Elem()) == nil {
-			return f
+			return f, true
 		}
 	case bytes.IndexByte(bools, c) != -1:
 		b := false
 		if resolve_bool(val, reflect.ValueOf(&b).Elem()) == nil {
-			return b
+			return b, true
 		}
 	}
 
-	return string(event.value)
+	return nil, false
 }
@@ -36,7 +36,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&aString)
 				event.value = []byte("abc")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(aString).To(Equal("abc"))
 			})
@@ -46,7 +46,7 @@ var _ = Describe("Resolver", func() {
 					aString := "abc"
 					v := reflect.ValueOf(&aString)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(aString).To(Equal(""))
 				})
@@ -58,7 +58,7 @@ var _ = Describe("Resolver", func() {
 					pString := &aString
 					v := reflect.ValueOf(&pString)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(pString).To(BeNil())
 				})
@@ -73,7 +73,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&b)
 				event.value = []byte(val)
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(b).To(Equal(expected))
 			}
@@ -113,7 +113,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&b)
 				event.value = []byte("fail")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -122,7 +122,7 @@ var _ = Describe("Resolver", func() {
 					b := true
 					v := reflect.ValueOf(&b)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(b).To(BeFalse())
 				})
@@ -134,7 +134,7 @@ var _ = Describe("Resolver", func() {
 					pb := &b
 					v := reflect.ValueOf(&pb)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(pb).To(BeNil())
 				})
@@ -147,7 +147,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("1234")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(1234))
 			})
@@ -157,7 +157,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("+678")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(int16(678)))
 			})
@@ -167,7 +167,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("-2345")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(int32(-2345)))
 			})
@@ -177,7 +177,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("0b11")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(3))
 			})
@@ -187,7 +187,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("012")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(10))
 			})
@@ -197,7 +197,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("0xff")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(255))
 			})
@@ -207,7 +207,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("1:30:00")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(5400))
 			})
@@ -217,7 +217,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("2345")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -226,7 +226,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("234f")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -235,7 +235,7 @@ var _ = Describe("Resolver", func() {
 					i := 1
 					v := reflect.ValueOf(&i)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(i).To(Equal(0))
 				})
@@ -247,7 +247,7 @@ var _ = Describe("Resolver", func() {
 					pi := &i
 					v := reflect.ValueOf(&pi)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(pi).To(BeNil())
 				})
@@ -260,7 +260,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("1234")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(uint(1234)))
 			})
@@ -270,7 +270,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("+678")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(uint16(678)))
 			})
@@ -280,7 +280,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("0b11")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(uint(3)))
 			})
@@ -290,7 +290,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("012")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(uint(10)))
 			})
@@ -300,7 +300,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("0xff")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(uint(255)))
 			})
@@ -310,7 +310,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("1:30:01")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(i).To(Equal(uint(5401)))
 			})
@@ -320,7 +320,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("-2345")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -329,7 +329,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("2345")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -338,7 +338,7 @@ var _ = Describe("Resolver", func() {
 					i := uint(1)
 					v := reflect.ValueOf(&i)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(i).To(Equal(uint(0)))
 				})
@@ -350,7 +350,7 @@ var _ = Describe("Resolver", func() {
 					pi := &i
 					v := reflect.ValueOf(&pi)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(pi).To(BeNil())
 				})
@@ -364,7 +364,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&f)
 				event.value = []byte("2345.01")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(f).To(Equal(float32(2345.01)))
 			})
@@ -374,7 +374,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&f)
 				event.value = []byte("-456456.01")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(f).To(Equal(float64(-456456.01)))
 			})
@@ -384,7 +384,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&f)
 				event.value = []byte("+.inf")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(f).To(Equal(math.Inf(1)))
 			})
@@ -394,7 +394,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&f)
 				event.value = []byte("-.inf")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(f).To(Equal(float32(math.Inf(-1))))
 			})
@@ -404,7 +404,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&f)
 				event.value = []byte(".NaN")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(math.IsNaN(f)).To(BeTrue())
 			})
@@ -414,17 +414,61 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&f)
 				event.value = []byte("1:30:02")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(f).To(Equal(float64(5402)))
 			})
 
+			It("follows the YAML 1.1 float syntax", func() {
+				floats := []struct {
+					value string
+					f     float64
+				}{
+					// The examples of the YAML 1.1 float type.
+					{"6.8523015e+5", 685230.15},
+					{"685.230_15e+03", 685230.15},
+					{"685_230.15", 685230.15},
+					{"190:20:30.15", 685230.15},
+					{"-.inf", math.Inf(-1)},
+
+					{".5", 0.5},
+					{"+.5", 0.5},
+					{"-.5", -0.5},
+					{"5.", 5},
+					{"-5.", -5},
+					{"5.e+2", 500},
+					{".5E-1", 0.05},
+					{"+.inf", math.Inf(1)},
+					{".Inf", math.Inf(1)},
+					{"+.INF", math.Inf(1)},
+				}
+				for _, c := range floats {
+					f := float64(0)
+					event.value = []byte(c.value)
+					Ω(resolve(event, reflect.ValueOf(&f).Elem(), YAML11Schema)).Should(Succeed(), c.value)
+					Ω(f).Should(Or(Equal(c.f), BeNumerically("~", c.f)), c.value)
+
+					v, ok := resolveYAML11(c.value)
+					Ω(ok).Should(BeTrue(), c.value)
+					Ω(v).Should(Or(Equal(c.f), BeNumerically("~", c.f)), c.value)
+				}
+
+				for _, value := range []string{".", "+.", "._5", "inf", "+inf", "-infinity", "nan", "-.nan", ".iNf", "0x1p-2", "1.2.3"} {
+					f := float64(0)
+					event.value = []byte(value)
+					Ω(resolve(event, reflect.ValueOf(&f).Elem(), YAML11Schema)).ShouldNot(Succeed(), value)
+
+					_, ok := resolveYAML11(value)
+					Ω(ok).Should(BeFalse(), value)
+				}
+			})
+
 			It("fails on overflow", func() {
 				i := float32(0)
 				v := reflect.ValueOf(&i)
 				event.value = []byte("123e10000")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -433,7 +477,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&i)
 				event.value = []byte("123e1a")
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).Should(HaveOccurred())
 			})
 
@@ -442,7 +486,7 @@ var _ = Describe("Resolver", func() {
 					f := float64(1)
 					v := reflect.ValueOf(&f)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(f).To(Equal(0.0))
 				})
@@ -454,7 +498,7 @@ var _ = Describe("Resolver", func() {
 					pf := &f
 					v := reflect.ValueOf(&pf)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(pf).To(BeNil())
 				})
@@ -467,7 +511,7 @@ var _ = Describe("Resolver", func() {
 				v := reflect.ValueOf(&d)
 				event.value = []byte(val)
 
-				err := resolve(event, v.Elem())
+				err := resolve(event, v.Elem(), YAML11Schema)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(d).To(Equal(date))
 			}
@@ -477,19 +521,19 @@ var _ = Describe("Resolver", func() {
 			})
 
 			It("canonical", func() {
-				parse_date("2001-12-15T02:59:43.1Z", time.Date(2001, time.December, 15, 2, 59, 43, int(1*time.Millisecond), time.UTC))
+				parse_date("2001-12-15T02:59:43.1Z", time.Date(2001, time.December, 15, 2, 59, 43, int(100*time.Millisecond), time.UTC))
 			})
 
 			It("iso8601", func() {
-				parse_date("2001-12-14t21:59:43.10-05:00", time.Date(2001, time.December, 14, 21, 59, 43, int(10*time.Millisecond), time.FixedZone("", -5*3600)))
+				parse_date("2001-12-14t21:59:43.10-05:00", time.Date(2001, time.December, 14, 21, 59, 43, int(100*time.Millisecond), time.FixedZone("", -5*3600)))
 			})
 
 			It("space separated", func() {
-				parse_date("2001-12-14 21:59:43.10 -5", time.Date(2001, time.December, 14, 21, 59, 43, int(10*time.Millisecond), time.FixedZone("", -5*3600)))
+				parse_date("2001-12-14 21:59:43.10 -5", time.Date(2001, time.December, 14, 21, 59, 43, int(100*time.Millisecond), time.FixedZone("", -5*3600)))
 			})
 
 			It("no time zone", func() {
-				parse_date("2001-12-15 2:59:43.10", time.Date(2001, time.December, 15, 2, 59, 43, int(10*time.Millisecond), time.UTC))
+				parse_date("2001-12-15 2:59:43.10", time.Date(2001, time.December, 15, 2, 59, 43, int(100*time.Millisecond), time.UTC))
 			})
 
 			It("resolves null", func() {
@@ -497,7 +541,7 @@ var _ = Describe("Resolver", func() {
 					d := time.Now()
 					v := reflect.ValueOf(&d)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(d).To(Equal(time.Time{}))
 				})
@@ -509,7 +553,7 @@ var _ = Describe("Resolver", func() {
 					pd := &d
 					v := reflect.ValueOf(&pd)
 
-					err := resolve(event, v.Elem())
+					err := resolve(event, v.Elem(), YAML11Schema)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(pd).To(BeNil())
 				})
@@ -522,7 +566,7 @@ var _ = Describe("Resolver", func() {
 			v := reflect.ValueOf(&pString)
 			event.value = []byte("abc")
 
-			err := resolve(event, v.Elem())
+			err := resolve(event, v.Elem(), YAML11Schema)
 			Ω(err).Should(HaveOccurred())
 		})
 
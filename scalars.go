@@ -0,0 +1,70 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ParseInt returns the integer the plain scalar s stands for, read as a
+// Decoder reads int64 fields: in decimal, in binary after 0b, in hex after
+// 0x, in octal after a leading 0, or in base 60 with colons, as in 1:30:00,
+// with an optional sign and underscores anywhere between digits.
+//
+// ParseInt, ParseFloat, ParseBool and ParseTimestamp let tools that handle
+// scalars outside of documents, such as expression evaluators, read them as
+// this package does.  Errors are *strconv.NumError values, with ErrRange
+// for integers an int64 cannot hold and ErrSyntax for anything else.
+func ParseInt(s string) (int64, error) {
+	var i int64
+	if err := resolve_int(s, reflect.ValueOf(&i).Elem()); err != nil {
+		var oe *overflowError
+		if errors.As(err, &oe) {
+			return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: strconv.ErrRange}
+		}
+		return 0, &strconv.NumError{Func: "ParseInt", Num: s, Err: strconv.ErrSyntax}
+	}
+	return i, nil
+}
+
+// ParseFloat returns the float the plain scalar s stands for, read as a
+// Decoder reads float64 fields: decimals and integers with underscores, an
+// exponent, base 60 with colons, .inf, -.inf and .nan.  Errors are
+// *strconv.NumError values; for numbers too large for a float64 it returns
+// an infinity and ErrRange, as strconv.ParseFloat does.
+func ParseFloat(s string) (float64, error) {
+	var f float64
+	if err := resolve_float(s, reflect.ValueOf(&f).Elem()); err != nil {
+		var oe *overflowError
+		if errors.As(err, &oe) {
+			return oe.f, &strconv.NumError{Func: "ParseFloat", Num: s, Err: strconv.ErrRange}
+		}
+		return 0, &strconv.NumError{Func: "ParseFloat", Num: s, Err: strconv.ErrSyntax}
+	}
+	return f, nil
+}
+
+// ParseBool returns the boolean the plain scalar s stands for in YAML 1.1:
+// true for true, yes, y and on, and false for false, no, n and off, in any
+// case.  Errors are *strconv.NumError values, as strconv.ParseBool returns.
+func ParseBool(s string) (bool, error) {
+	var b bool
+	if err := resolve_bool(s, reflect.ValueOf(&b).Elem()); err != nil {
+		return false, &strconv.NumError{Func: "ParseBool", Num: s, Err: strconv.ErrSyntax}
+	}
+	return b, nil
+}
+
+// ParseTimestamp returns the time the plain scalar s stands for, read as a
+// Decoder reads time.Time fields: a date such as 2001-12-14, taken as
+// midnight UTC, or a date and time such as 2001-12-14t21:59:43.10-05:00 or
+// 2001-12-14 21:59:43.10 -5, in UTC if it gives no zone.
+func ParseTimestamp(s string) (time.Time, error) {
+	var t time.Time
+	if err := resolve_time(s, reflect.ValueOf(&t).Elem()); err != nil {
+		return time.Time{}, fmt.Errorf("yaml: ParseTimestamp: invalid timestamp '%s'", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,124 @@
+package candiedyaml
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scalar parsing helpers", func() {
+	It("parses integers in every notation", func() {
+		for s, want := range map[string]int64{
+			"685230":                     685230,
+			"+685_230":                   685230,
+			"-0":                         0,
+			"02472256":                   685230,
+			"0x_0A_74_AE":                685230,
+			"0b1010_0111_0100_1010_1110": 685230,
+			"190:20:30":                  685230,
+			"-190:20:30":                 -685230,
+		} {
+			i, err := ParseInt(s)
+			Ω(err).ShouldNot(HaveOccurred(), s)
+			Ω(i).Should(Equal(want), s)
+		}
+	})
+
+	It("rejects what is not an integer", func() {
+		for _, s := range []string{"", "+", "_", "1.5", "0x", "09", "abc", "1:60x"} {
+			_, err := ParseInt(s)
+			Ω(errors.Is(err, strconv.ErrSyntax)).Should(BeTrue(), s)
+		}
+
+		var i int
+		Ω(Unmarshal([]byte("+\n"), &i)).Should(MatchError("Integer: +"))
+
+		_, err := ParseInt("9223372036854775808")
+		Ω(errors.Is(err, strconv.ErrRange)).Should(BeTrue())
+		Ω(err).Should(MatchError(`strconv.ParseInt: parsing "9223372036854775808": value out of range`))
+	})
+
+	It("parses floats", func() {
+		for s, want := range map[string]float64{
+			"6.8523015e+5": 685230.15,
+			"685_230.15":   685230.15,
+			"190:20:30.15": 685230.15,
+			"-.5":          -0.5,
+			"12":           12,
+		} {
+			f, err := ParseFloat(s)
+			Ω(err).ShouldNot(HaveOccurred(), s)
+			Ω(f).Should(BeNumerically("~", want, 1e-6), s)
+		}
+
+		f, err := ParseFloat("-.inf")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(math.IsInf(f, -1)).Should(BeTrue())
+		f, err = ParseFloat(".NaN")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(math.IsNaN(f)).Should(BeTrue())
+
+		f, err = ParseFloat("1e400")
+		Ω(errors.Is(err, strconv.ErrRange)).Should(BeTrue())
+		Ω(math.IsInf(f, 1)).Should(BeTrue())
+
+		_, err = ParseFloat("1.2.3")
+		Ω(errors.Is(err, strconv.ErrSyntax)).Should(BeTrue())
+	})
+
+	It("parses YAML 1.1 booleans", func() {
+		for s, want := range map[string]bool{"yes": true, "Y": true, "On": true, "TRUE": true, "no": false, "off": false, "n": false} {
+			b, err := ParseBool(s)
+			Ω(err).ShouldNot(HaveOccurred(), s)
+			Ω(b).Should(Equal(want), s)
+		}
+
+		_, err := ParseBool("1")
+		Ω(err).Should(MatchError(`strconv.ParseBool: parsing "1": invalid syntax`))
+	})
+
+	It("parses timestamps", func() {
+		t, err := ParseTimestamp("2002-12-14")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(t).Should(Equal(time.Date(2002, time.December, 14, 0, 0, 0, 0, time.UTC)))
+
+		t, err = ParseTimestamp("2001-12-14 21:59:43 -5")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(t.Equal(time.Date(2001, time.December, 15, 2, 59, 43, 0, time.UTC))).Should(BeTrue())
+
+		for val, nsec := range map[string]int{
+			"2001-12-14t21:59:43.10-05:00":    100000000,
+			"2001-12-14t21:59:43.1Z":          100000000,
+			"2001-12-14t21:59:43.5Z":          500000000,
+			"2001-12-14t21:59:43.1234567Z":    123456700,
+			"2001-12-14t21:59:43.0000000001Z": 0,
+		} {
+			t, err = ParseTimestamp(val)
+			Ω(err).ShouldNot(HaveOccurred(), val)
+			Ω(t.Second()).Should(Equal(43), val)
+			Ω(t.Nanosecond()).Should(Equal(nsec), val)
+		}
+
+		_, err = ParseTimestamp("14/12/2001")
+		Ω(err).Should(MatchError("yaml: ParseTimestamp: invalid timestamp '14/12/2001'"))
+	})
+
+	It("reads scalars as Decoders do", func() {
+		var v struct {
+			I int64
+			F float64
+			B bool
+		}
+		Ω(Unmarshal([]byte("i: 0b1_01\nf: 1:30.5\nb: off\n"), &v)).Should(Succeed())
+		i, _ := ParseInt("0b1_01")
+		f, _ := ParseFloat("1:30.5")
+		b, _ := ParseBool("off")
+		Ω(i).Should(Equal(v.I))
+		Ω(f).Should(Equal(v.F))
+		Ω(b).Should(Equal(v.B))
+	})
+})
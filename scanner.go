@@ -496,10 +496,12 @@ func cache(parser *yaml_parser_t, length int) bool {
  * Advance the buffer pointer.
  */
 func skip(parser *yaml_parser_t) {
+	w := width(parser.buffer[parser.buffer_pos])
 	parser.mark.index++
 	parser.mark.column++
+	parser.mark.offset += input_width(parser, w)
 	parser.unread--
-	parser.buffer_pos += width(parser.buffer[parser.buffer_pos])
+	parser.buffer_pos += w
 }
 
 func skip_line(parser *yaml_parser_t) {
@@ -507,15 +509,83 @@ func skip_line(parser *yaml_parser_t) {
 		parser.mark.index += 2
 		parser.mark.column = 0
 		parser.mark.line++
+		parser.mark.offset += 2 * input_width(parser, 1)
 		parser.unread -= 2
 		parser.buffer_pos += 2
 	} else if is_break_at(parser.buffer, parser.buffer_pos) {
+		w := width(parser.buffer[parser.buffer_pos])
 		parser.mark.index++
 		parser.mark.column = 0
 		parser.mark.line++
+		parser.mark.offset += input_width(parser, w)
 		parser.unread--
-		parser.buffer_pos += width(parser.buffer[parser.buffer_pos])
+		parser.buffer_pos += w
+	}
+}
+
+/*
+ * Return the number of input bytes taken by a character of the given UTF-8
+ * width.
+ */
+func input_width(parser *yaml_parser_t, w int) int {
+	switch {
+	case parser.encoding == yaml_UTF8_ENCODING:
+		return w
+	case w == 4:
+		return 4
+	}
+	return 2
+}
+
+/*
+ * Advance the buffer pointer past n characters of a single byte each.
+ */
+
+func skip_bytes(parser *yaml_parser_t, n int) {
+	parser.mark.index += n
+	parser.mark.column += n
+	parser.mark.offset += n * input_width(parser, 1)
+	parser.unread -= n
+	parser.buffer_pos += n
+}
+
+/*
+ * Skip the run of spaces at the buffer pointer, at most max of them unless
+ * max is negative, as far as the buffer is filled.  Returns the number of
+ * spaces skipped.
+ */
+
+func skip_spaces(parser *yaml_parser_t, max int) int {
+	run := parser.buffer[parser.buffer_pos : parser.buffer_pos+parser.unread]
+	if max >= 0 && max < len(run) {
+		run = run[:max]
+	}
+	n := len(run) - len(bytes.TrimLeft(run, " "))
+	skip_bytes(parser, n)
+	return n
+}
+
+/*
+ * Copy the run of printable ASCII characters and tabs at the buffer pointer
+ * to a string buffer, as far as the buffer is filled, stopping before a line
+ * break, NUL or any character of more than one byte.
+ */
+
+func read_ascii(parser *yaml_parser_t, s []byte) []byte {
+	run := parser.buffer[parser.buffer_pos : parser.buffer_pos+parser.unread]
+	n := 0
+	for n < len(run) && (run[n] >= 0x20 && run[n] < 0x7F || run[n] == '\t') {
+		n++
+	}
+	if n == 0 {
+		return s
+	}
+	if len(s) == 0 {
+		s = arena_alloc(parser, n)
 	}
+	s = append(s, run[:n]...)
+	skip_bytes(parser, n)
+	return s
 }
 
 /*
@@ -528,7 +598,7 @@ func read(parser *yaml_parser_t, s []byte) []byte {
 		panic("invalid character sequence")
 	}
 	if len(s) == 0 {
-		s = make([]byte, 0, 32)
+		s = arena_alloc(parser, 32)
 	}
 	if w == 1 && len(s)+w <= cap(s) {
 		s = s[:len(s)+1]
@@ -540,6 +610,7 @@ func read(parser *yaml_parser_t, s []byte) []byte {
 	}
 	parser.mark.index++
 	parser.mark.column++
+	parser.mark.offset += input_width(parser, w)
 	parser.unread--
 	return s
 }
@@ -555,7 +626,9 @@ func read_line(parser *yaml_parser_t, s []byte) []byte {
 		s = append(s, '\n')
 		parser.buffer_pos += 2
 		parser.mark.index++
+		parser.mark.offset += input_width(parser, 1)
 		parser.unread--
+		pos++ // the LF is counted below
 	} else if buf[pos] == '\r' || buf[pos] == '\n' {
 		/* CR|LF . LF */
 		s = append(s, '\n')
@@ -576,6 +649,7 @@ func read_line(parser *yaml_parser_t, s []byte) []byte {
 	parser.mark.index++
 	parser.mark.column = 0
 	parser.mark.line++
+	parser.mark.offset += input_width(parser, parser.buffer_pos-pos)
 	parser.unread--
 	return s
 }
@@ -632,11 +706,11 @@ func yaml_parser_set_scanner_error(parser *yaml_parser_t, context string,
 }
 
 func yaml_parser_set_scanner_tag_error(parser *yaml_parser_t, directive bool, context_mark YAML_mark_t, problem string) bool {
-	context := "while parsing a %TAG directive"
+	context := "while parsing a tag"
 	if directive {
-		context = "while parsing a tag"
+		context = "while parsing a %TAG directive"
 	}
-	return yaml_parser_set_scanner_error(parser, context, context_mark, "did not find URI escaped octet")
+	return yaml_parser_set_scanner_error(parser, context, context_mark, problem)
 }
 
 /*
@@ -883,6 +957,13 @@ func yaml_parser_fetch_next_token(parser *yaml_parser_t) bool {
 	 */
 
 	b := buf[pos]
+	if parser.conformance == YAML12Conformance && parser.flow_level > 0 &&
+		b == '-' && bytes.IndexByte([]byte(",[]{}"), buf[pos+1]) >= 0 {
+		return yaml_parser_set_scanner_error(parser,
+			"while scanning for the next token", parser.mark,
+			"found '-' followed by a flow indicator")
+	}
+
 	if !(is_blankz_at(buf, pos) || b == '-' ||
 		b == '?' || b == ':' ||
 		b == ',' || b == '[' ||
@@ -895,7 +976,7 @@ func yaml_parser_fetch_next_token(parser *yaml_parser_t) bool {
 		b == '@' || b == '`') ||
 		(b == '-' && !is_blank(buf[pos+1])) ||
 		(parser.flow_level == 0 &&
-			(buf[pos] == '?' || buf[pos+1] == ':') &&
+			(buf[pos] == '?' || buf[pos] == ':') &&
 			!is_blank(buf[pos+1])) {
 		return yaml_parser_fetch_plain_scalar(parser)
 	}
@@ -1255,8 +1336,10 @@ func yaml_parser_fetch_directive(parser *yaml_parser_t) bool {
 		return false
 	}
 
-	/* Append the token to the queue. */
-	insert_token(parser, -1, &token)
+	/* Append the token to the queue, unless the directive was ignored. */
+	if token.token_type != yaml_NO_TOKEN {
+		insert_token(parser, -1, &token)
+	}
 
 	return true
 }
@@ -1755,11 +1838,115 @@ func yaml_parser_fetch_plain_scalar(parser *yaml_parser_t) bool {
 	return true
 }
 
+/*
+ * Check if the blanks at the current position are followed by something that
+ * cannot start a block collection, in which case tabs separate tokens rather
+ * than indent them.  Scalars, comments, flow collections and line breaks may
+ * follow; block entries, explicit keys, values and implicit keys may not.
+ */
+
+func yaml_parser_check_separating_tabs(parser *yaml_parser_t) bool {
+	k := 0
+	for {
+		if k >= MAX_LOOKAHEAD || !cache(parser, k+2) {
+			return false
+		}
+		if !is_blank(parser.buffer[parser.buffer_pos+k]) {
+			break
+		}
+		k++
+	}
+
+	b := parser.buffer[parser.buffer_pos+k]
+	if b == '#' || b == '[' || b == '{' || is_breakz_at(parser.buffer, parser.buffer_pos+k) {
+		return true
+	}
+	if (b == '-' || b == '?' || b == ':') && is_blankz_at(parser.buffer, parser.buffer_pos+k+1) {
+		return false
+	}
+
+	/* Look for an implicit key on the rest of the line. */
+
+	var quote byte
+	if b == '\'' || b == '"' {
+		quote = b
+		k++
+	}
+	for ; k < MAX_LOOKAHEAD; k++ {
+		if !cache(parser, k+2) {
+			return false
+		}
+		c := parser.buffer[parser.buffer_pos+k]
+		switch {
+		case is_breakz_at(parser.buffer, parser.buffer_pos+k):
+			return true
+		case quote == '"' && c == '\\':
+			k++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == ':' && is_blankz_at(parser.buffer, parser.buffer_pos+k+1):
+			return false
+		case c == '#' && is_blank(parser.buffer[parser.buffer_pos+k-1]):
+			return true
+		}
+	}
+
+	return true
+}
+
+/*
+ * Check if the rest of the current line is blank.
+ */
+
+func yaml_parser_check_blank_line(parser *yaml_parser_t) bool {
+	for k := 0; k < MAX_LOOKAHEAD; k++ {
+		if !cache(parser, k+1) {
+			return false
+		}
+		if !is_blank(parser.buffer[parser.buffer_pos+k]) {
+			return is_breakz_at(parser.buffer, parser.buffer_pos+k)
+		}
+	}
+	return false
+}
+
+/*
+ * Check if the character at the current position is separated from the
+ * previous token by whitespace, as a comment must be.
+ */
+
+func yaml_parser_check_comment_separated(parser *yaml_parser_t) bool {
+	if parser.mark.column == 0 || len(parser.tokens) == 0 {
+		return true
+	}
+	return parser.tokens[len(parser.tokens)-1].end_mark.index != parser.mark.index
+}
+
+/*
+ * Check that a continuation line of a flow collection or a quoted scalar is
+ * indented past the enclosing block collection.
+ */
+
+func yaml_parser_check_flow_indent(parser *yaml_parser_t, spaces int,
+	context string, context_mark YAML_mark_t) bool {
+	if parser.conformance != YAML12Conformance || spaces > parser.indent {
+		return true
+	}
+	return yaml_parser_set_scanner_error(parser, context, context_mark,
+		"found continuation line not indented past the enclosing block")
+}
+
 /*
  * Eat whitespaces and comments until the next token is found.
  */
 
 func yaml_parser_scan_to_next_token(parser *yaml_parser_t) bool {
+	/* The number of spaces indenting the line, once a line break is eaten. */
+
+	new_line, indentation, spaces := false, false, 0
+
 	/* Until the next token is not found. */
 
 	for {
@@ -1769,6 +1956,8 @@ func yaml_parser_scan_to_next_token(parser *yaml_parser_t) bool {
 			return false
 		}
 
+		blank := parser.mark.column == 0
+
 		if parser.mark.column == 0 && is_bom_at(parser.buffer, parser.buffer_pos) {
 			skip(parser)
 		}
@@ -1780,7 +1969,9 @@ func yaml_parser_scan_to_next_token(parser *yaml_parser_t) bool {
 		 *
 		 *  - in the flow context;
 		 *  - in the block context, but not at the beginning of the line or
-		 *  after '-', '?', or ':' (complex value).
+		 *  after '-', '?', or ':' (complex value), unless they are followed
+		 *  by a comment, a line break or the start of a flow collection and
+		 *  so cannot be mistaken for indentation.
 		 */
 
 		if !cache(parser, 1) {
@@ -1788,9 +1979,23 @@ func yaml_parser_scan_to_next_token(parser *yaml_parser_t) bool {
 		}
 
 		for parser.buffer[parser.buffer_pos] == ' ' ||
-			((parser.flow_level > 0 || !parser.simple_key_allowed) &&
-				parser.buffer[parser.buffer_pos] == '\t') {
-			skip(parser)
+			(parser.buffer[parser.buffer_pos] == '\t' &&
+				(parser.flow_level > 0 || !parser.simple_key_allowed ||
+					(blank && parser.tab_width > 0) ||
+					yaml_parser_check_separating_tabs(parser))) {
+			if parser.buffer[parser.buffer_pos] == ' ' {
+				n := skip_spaces(parser, -1)
+				if indentation {
+					spaces += n
+				}
+			} else {
+				indentation = false
+				if blank && parser.flow_level == 0 && parser.tab_width > 0 {
+					yaml_parser_skip_indentation_tab(parser)
+				} else {
+					skip(parser)
+				}
+			}
 			if !cache(parser, 1) {
 				return false
 			}
@@ -1799,12 +2004,24 @@ func yaml_parser_scan_to_next_token(parser *yaml_parser_t) bool {
 		/* Eat a comment until a line break. */
 
 		if parser.buffer[parser.buffer_pos] == '#' {
+			if parser.conformance == YAML12Conformance &&
+				!yaml_parser_check_comment_separated(parser) {
+				return yaml_parser_set_scanner_error(parser,
+					"while scanning a comment", parser.mark,
+					"found comment without preceding whitespace")
+			}
+			var comment []byte
 			for !is_breakz_at(parser.buffer, parser.buffer_pos) {
-				skip(parser)
+				comment = read_ascii(parser, comment)
+				if parser.unread > 0 && !is_breakz_at(parser.buffer, parser.buffer_pos) {
+					comment = read(parser, comment)
+				}
 				if !cache(parser, 1) {
 					return false
 				}
 			}
+			yaml_parser_save_comment(parser, bytes.TrimRight(comment, " \t"), blank)
+			blank = false
 		}
 
 		/* If it is a line break, eat it. */
@@ -1820,16 +2037,103 @@ func yaml_parser_scan_to_next_token(parser *yaml_parser_t) bool {
 			if parser.flow_level == 0 {
 				parser.simple_key_allowed = true
 			}
+
+			/* Blank lines after a comment are part of it. */
+
+			if blank && len(parser.pending_comment) > 0 {
+				parser.pending_comment = append(parser.pending_comment, '\n')
+			} else if blank && parser.flow_level == 0 {
+				parser.pending_blank_lines++
+			}
+
+			new_line, indentation, spaces = true, true, 0
 		} else {
 			/* We have found a token. */
 
+			if new_line && parser.flow_level > 0 &&
+				!yaml_parser_check_flow_indent(parser, spaces,
+					"while scanning a flow collection", parser.mark) {
+				return false
+			}
 			break
 		}
 	}
 
+	yaml_parser_record_blank_lines(parser)
+
 	return true
 }
 
+/*
+ * Skip a tab indenting a line as if it was written as spaces up to the next
+ * tab stop, warning about the first one on each line.
+ */
+
+func yaml_parser_skip_indentation_tab(parser *yaml_parser_t) {
+	n := len(parser.warnings)
+	if n == 0 || parser.warnings[n-1].At.line != parser.mark.line {
+		parser.warnings = append(parser.warnings, Warning{
+			Message: "found a tab character used for indentation",
+			At:      parser.mark,
+		})
+	}
+
+	parser.mark.index++
+	parser.mark.column += parser.tab_width - parser.mark.column%parser.tab_width
+	parser.mark.offset += input_width(parser, 1)
+	parser.unread--
+	parser.buffer_pos++
+}
+
+/*
+ * Remember how many blank lines precede the line the next token starts on,
+ * so that they can be reproduced when the document is written back, if
+ * comments and blank lines are kept.
+ */
+
+func yaml_parser_record_blank_lines(parser *yaml_parser_t) {
+	if len(parser.pending_comment) > 0 {
+		if parser.head_comments == nil {
+			parser.head_comments = make(map[int][]byte)
+		}
+		parser.head_comments[parser.mark.line] = parser.pending_comment
+		parser.pending_comment = nil
+	}
+
+	if parser.pending_blank_lines == 0 || !parser.keep_comments {
+		parser.pending_blank_lines = 0
+		return
+	}
+	if parser.blank_lines == nil {
+		parser.blank_lines = make(map[int]int)
+	}
+	parser.blank_lines[parser.mark.line] += parser.pending_blank_lines
+	parser.pending_blank_lines = 0
+}
+
+/*
+ * Save a comment, including its '#', as one of the lines before the next
+ * token if it is on a line of its own, or as the comment at the end of the
+ * current line, if comments are kept.
+ */
+
+func yaml_parser_save_comment(parser *yaml_parser_t, comment []byte, own_line bool) {
+	if !parser.keep_comments {
+		return
+	}
+	if own_line {
+		if len(parser.pending_comment) > 0 {
+			parser.pending_comment = append(parser.pending_comment, '\n')
+		}
+		parser.pending_comment = append(parser.pending_comment, comment...)
+		return
+	}
+	if parser.line_comments == nil {
+		parser.line_comments = make(map[int][]byte)
+	}
+	parser.line_comments[parser.mark.line] = comment
+}
+
 /*
  * Scan a YAML-DIRECTIVE or TAG-DIRECTIVE token.
  *
@@ -1894,6 +2198,24 @@ func yaml_parser_scan_directive(parser *yaml_parser_t, token *yaml_token_t) bool
 			value:      handle,
 			prefix:     prefix,
 		}
+	} else if parser.conformance == YAML12Conformance {
+		/* Ignore a reserved directive and its parameters. */
+
+		if !cache(parser, 1) {
+			return false
+		}
+
+		blank := false
+		for !is_breakz_at(parser.buffer, parser.buffer_pos) &&
+			!(blank && parser.buffer[parser.buffer_pos] == '#') {
+			blank = is_blank(parser.buffer[parser.buffer_pos])
+			skip(parser)
+			if !cache(parser, 1) {
+				return false
+			}
+		}
+
+		*token = yaml_token_t{token_type: yaml_NO_TOKEN}
 	} else {
 		/* Unknown directive. */
 		yaml_parser_set_scanner_error(parser, "while scanning a directive",
@@ -1907,7 +2229,9 @@ func yaml_parser_scan_directive(parser *yaml_parser_t, token *yaml_token_t) bool
 		return false
 	}
 
+	separated := false
 	for is_blank(parser.buffer[parser.buffer_pos]) {
+		separated = true
 		skip(parser)
 		if !cache(parser, 1) {
 			return false
@@ -1915,12 +2239,20 @@ func yaml_parser_scan_directive(parser *yaml_parser_t, token *yaml_token_t) bool
 	}
 
 	if parser.buffer[parser.buffer_pos] == '#' {
+		if parser.conformance == YAML12Conformance && !separated &&
+			token.token_type != yaml_NO_TOKEN {
+			yaml_parser_set_scanner_error(parser, "while scanning a directive",
+				start_mark, "found comment without preceding whitespace")
+			return false
+		}
+		var comment []byte
 		for !is_breakz_at(parser.buffer, parser.buffer_pos) {
-			skip(parser)
+			comment = read(parser, comment)
 			if !cache(parser, 1) {
 				return false
 			}
 		}
+		yaml_parser_save_comment(parser, bytes.TrimRight(comment, " \t"), false)
 	}
 
 	/* Check if we are at the end of the line. */
@@ -2144,7 +2476,7 @@ func yaml_parser_scan_tag_directive_value(parser *yaml_parser_t,
 
 	/* Scan a prefix. */
 	var prefix_value []byte
-	if !yaml_parser_scan_tag_uri(parser, true, nil, start_mark, &prefix_value) {
+	if !yaml_parser_scan_tag_uri(parser, true, true, nil, start_mark, &prefix_value) {
 		return false
 	}
 
@@ -2181,8 +2513,13 @@ func yaml_parser_scan_anchor(parser *yaml_parser_t, token *yaml_token_t,
 		return false
 	}
 
+	/* The spec allows any character but whitespace and flow indicators. */
+
+	spec := parser.conformance == YAML12Conformance
 	var s []byte
-	for is_alpha(parser.buffer[parser.buffer_pos]) {
+	for is_alpha(parser.buffer[parser.buffer_pos]) ||
+		(spec && !is_blankz_at(parser.buffer, parser.buffer_pos) &&
+			bytes.IndexByte([]byte(",[]{}"), parser.buffer[parser.buffer_pos]) < 0) {
 		s = read(parser, s)
 		if !cache(parser, 1) {
 			return false
@@ -2199,7 +2536,7 @@ func yaml_parser_scan_anchor(parser *yaml_parser_t, token *yaml_token_t,
 	 */
 
 	b := parser.buffer[parser.buffer_pos]
-	if len(s) == 0 || !(is_blankz_at(parser.buffer, parser.buffer_pos) || b == '?' ||
+	if len(s) == 0 || !(spec || is_blankz_at(parser.buffer, parser.buffer_pos) || b == '?' ||
 		b == ':' || b == ',' ||
 		b == ']' || b == '}' ||
 		b == '%' || b == '@' ||
@@ -2242,6 +2579,8 @@ func yaml_parser_scan_tag(parser *yaml_parser_t, token *yaml_token_t) bool {
 	if parser.buffer[parser.buffer_pos+1] == '<' {
 		/* Set the handle to '' */
 
+		handle = []byte{}
+
 		/* Eat '!<' */
 
 		skip(parser)
@@ -2249,7 +2588,7 @@ func yaml_parser_scan_tag(parser *yaml_parser_t, token *yaml_token_t) bool {
 
 		/* Consume the tag value. */
 
-		if !yaml_parser_scan_tag_uri(parser, false, nil, start_mark, &suffix) {
+		if !yaml_parser_scan_tag_uri(parser, false, true, nil, start_mark, &suffix) {
 			return false
 		}
 
@@ -2271,18 +2610,22 @@ func yaml_parser_scan_tag(parser *yaml_parser_t, token *yaml_token_t) bool {
 			return false
 		}
 
+		/* The spec does not allow flow indicators in a tag shorthand. */
+
+		flow_indicators := parser.conformance != YAML12Conformance
+
 		/* Check if it is, indeed, handle. */
 
-		if handle[0] == '!' && len(handle) > 0 && handle[len(handle)-1] == '!' {
+		if len(handle) > 1 && handle[0] == '!' && handle[len(handle)-1] == '!' {
 			/* Scan the suffix now. */
 
-			if !yaml_parser_scan_tag_uri(parser, false, nil, start_mark, &suffix) {
+			if !yaml_parser_scan_tag_uri(parser, false, flow_indicators, nil, start_mark, &suffix) {
 				return false
 			}
 		} else {
 			/* It wasn't a handle after all.  Scan the rest of the tag. */
 
-			if !yaml_parser_scan_tag_uri(parser, false, handle, start_mark, &suffix) {
+			if !yaml_parser_scan_tag_uri(parser, false, flow_indicators, handle, start_mark, &suffix) {
 				return false
 			}
 
@@ -2296,7 +2639,7 @@ func yaml_parser_scan_tag(parser *yaml_parser_t, token *yaml_token_t) bool {
 			 */
 
 			if len(suffix) == 0 {
-				handle, suffix = suffix, handle
+				handle, suffix = []byte{}, handle
 			}
 
 		}
@@ -2308,7 +2651,9 @@ func yaml_parser_scan_tag(parser *yaml_parser_t, token *yaml_token_t) bool {
 		return false
 	}
 
-	if !is_blankz_at(parser.buffer, parser.buffer_pos) {
+	if !is_blankz_at(parser.buffer, parser.buffer_pos) &&
+		!(parser.conformance == YAML12Conformance && parser.flow_level > 0 &&
+			bytes.IndexByte([]byte(",[]{}"), parser.buffer[parser.buffer_pos]) >= 0) {
 		yaml_parser_set_scanner_error(parser, "while scanning a tag",
 			start_mark, "did not find expected whitespace or line break")
 		return false
@@ -2393,7 +2738,7 @@ func yaml_parser_scan_tag_handle(parser *yaml_parser_t, directive bool,
  */
 
 func yaml_parser_scan_tag_uri(parser *yaml_parser_t, directive bool,
-	head []byte, start_mark YAML_mark_t, uri *[]byte) bool {
+	flow_indicators bool, head []byte, start_mark YAML_mark_t, uri *[]byte) bool {
 
 	var s []byte
 	/*
@@ -2416,6 +2761,9 @@ func yaml_parser_scan_tag_uri(parser *yaml_parser_t, directive bool,
 	 *      '0'-'9', 'A'-'Z', 'a'-'z', '_', '-', ';', '/', '?', ':', '@', '&',
 	 *      '=', '+', '$', ',', '.', '!', '~', '*', '\'', '(', ')', '[', ']',
 	 *      '%'.
+	 *
+	 * The flow indicators ',', '[' and ']' are left out of tag shorthands
+	 * when following the spec.
 	 */
 
 	b := parser.buffer[parser.buffer_pos]
@@ -2424,12 +2772,12 @@ func yaml_parser_scan_tag_uri(parser *yaml_parser_t, directive bool,
 		b == ':' || b == '@' ||
 		b == '&' || b == '=' ||
 		b == '+' || b == '$' ||
-		b == ',' || b == '.' ||
+		b == '.' ||
 		b == '!' || b == '~' ||
 		b == '*' || b == '\'' ||
 		b == '(' || b == ')' ||
-		b == '[' || b == ']' ||
-		b == '%' {
+		b == '%' ||
+		(flow_indicators && (b == ',' || b == '[' || b == ']')) {
 		/* Check if it is a URI-escape sequence. */
 
 		if b == '%' {
@@ -2447,9 +2795,9 @@ func yaml_parser_scan_tag_uri(parser *yaml_parser_t, directive bool,
 		b = parser.buffer[parser.buffer_pos]
 	}
 
-	/* Check if the tag is non-empty. */
+	/* Check if the tag is non-empty.  A lone '!' head counts. */
 
-	if len(s) == 0 {
+	if len(s) == 0 && len(head) == 0 {
 		yaml_parser_set_scanner_tag_error(parser, directive,
 			start_mark, "did not find expected tag URI")
 		return false
@@ -2605,7 +2953,9 @@ func yaml_parser_scan_block_scalar(parser *yaml_parser_t, token *yaml_token_t,
 		return false
 	}
 
+	separated := false
 	for is_blank(parser.buffer[parser.buffer_pos]) {
+		separated = true
 		skip(parser)
 		if !cache(parser, 1) {
 			return false
@@ -2613,12 +2963,19 @@ func yaml_parser_scan_block_scalar(parser *yaml_parser_t, token *yaml_token_t,
 	}
 
 	if parser.buffer[parser.buffer_pos] == '#' {
+		if parser.conformance == YAML12Conformance && !separated {
+			yaml_parser_set_scanner_error(parser, "while scanning a block scalar",
+				start_mark, "found comment without preceding whitespace")
+			return false
+		}
+		var comment []byte
 		for !is_breakz_at(parser.buffer, parser.buffer_pos) {
-			skip(parser)
+			comment = read(parser, comment)
 			if !cache(parser, 1) {
 				return false
 			}
 		}
+		yaml_parser_save_comment(parser, bytes.TrimRight(comment, " \t"), false)
 	}
 
 	/* Check if we are at the end of the line. */
@@ -2641,8 +2998,8 @@ func yaml_parser_scan_block_scalar(parser *yaml_parser_t, token *yaml_token_t,
 
 	end_mark := parser.mark
 
-	/* Set the intendation level if it was specified. */
-	indent := 0
+	/* Set the intendation level if it was specified (-1 means auto-detect). */
+	indent := -1
 	if increment > 0 {
 		if parser.indent >= 0 {
 			indent = parser.indent + increment
@@ -2670,6 +3027,17 @@ func yaml_parser_scan_block_scalar(parser *yaml_parser_t, token *yaml_token_t,
 	trailing_blank := false
 	for parser.mark.column == indent && !is_z(parser.buffer[parser.buffer_pos]) {
 
+		/* A zero-indented scalar ends at a document indicator. */
+
+		if indent == 0 {
+			if !cache(parser, 4) {
+				return false
+			}
+			if is_document_indicator(parser) {
+				break
+			}
+		}
+
 		/*
 		 * We are at the beginning of a non-empty line.
 		 */
@@ -2732,6 +3100,9 @@ func yaml_parser_scan_block_scalar(parser *yaml_parser_t, token *yaml_token_t,
 	}
 	if chomping == 1 {
 		s = append(s, trailing_breaks...)
+	} else if parser.flow_level == 0 {
+		/* The chomped lines separate the scalar from what follows. */
+		parser.pending_blank_lines += bytes.Count(trailing_breaks, []byte{'\n'})
 	}
 
 	/* Create a token. */
@@ -2742,6 +3113,8 @@ func yaml_parser_scan_block_scalar(parser *yaml_parser_t, token *yaml_token_t,
 		end_mark:   end_mark,
 		value:      s,
 		style:      yaml_LITERAL_SCALAR_STYLE,
+		chomping:   chomping,
+		indent:     increment,
 	}
 	if !literal {
 		token.style = yaml_FOLDED_SCALAR_STYLE
@@ -2762,7 +3135,7 @@ func yaml_parser_scan_block_scalar_breaks(parser *yaml_parser_t,
 	*end_mark = parser.mark
 
 	/* Eat the intendation spaces and line breaks. */
-	max_indent := 0
+	max_indent, blank_indent := 0, 0
 	for {
 		/* Eat the intendation spaces. */
 
@@ -2770,9 +3143,16 @@ func yaml_parser_scan_block_scalar_breaks(parser *yaml_parser_t,
 			return false
 		}
 
-		for (*indent == 0 || parser.mark.column < *indent) &&
-			is_space(parser.buffer[parser.buffer_pos]) {
-			skip(parser)
+		for (*indent < 0 || parser.mark.column < *indent) &&
+			(is_space(parser.buffer[parser.buffer_pos]) ||
+				(parser.tab_width > 0 && is_tab(parser.buffer[parser.buffer_pos]))) {
+			if is_tab(parser.buffer[parser.buffer_pos]) {
+				yaml_parser_skip_indentation_tab(parser)
+			} else if *indent < 0 {
+				skip_spaces(parser, -1)
+			} else {
+				skip_spaces(parser, *indent-parser.mark.column)
+			}
 			if !cache(parser, 1) {
 				return false
 			}
@@ -2781,10 +3161,15 @@ func yaml_parser_scan_block_scalar_breaks(parser *yaml_parser_t,
 			max_indent = parser.mark.column
 		}
 
-		/* Check for a tab character messing the intendation. */
+		/*
+		 * Check for a tab character messing the intendation.  While the
+		 * indentation is still being detected, a tab following enough
+		 * spaces is content.
+		 */
 
-		if (*indent == 0 || parser.mark.column < *indent) &&
-			is_tab(parser.buffer[parser.buffer_pos]) {
+		if is_tab(parser.buffer[parser.buffer_pos]) &&
+			((*indent >= 0 && parser.mark.column < *indent) ||
+				(*indent < 0 && parser.mark.column < parser.indent+1)) {
 			return yaml_parser_set_scanner_error(parser, "while scanning a block scalar",
 				start_mark, "found a tab character where an intendation space is expected")
 		}
@@ -2792,8 +3177,21 @@ func yaml_parser_scan_block_scalar_breaks(parser *yaml_parser_t,
 		/* Have we found a non-empty line? */
 
 		if !is_break_at(parser.buffer, parser.buffer_pos) {
+			/*
+			 * The spec does not allow the leading empty lines to be
+			 * indented more than the first content line.
+			 */
+
+			if parser.conformance == YAML12Conformance && *indent < 0 &&
+				parser.mark.column > parser.indent &&
+				parser.mark.column < blank_indent &&
+				!is_z(parser.buffer[parser.buffer_pos]) {
+				return yaml_parser_set_scanner_error(parser, "while scanning a block scalar",
+					start_mark, "found a leading empty line indented more than the first non-empty line")
+			}
 			break
 		}
+		blank_indent = max_indent
 
 		/* Consume the line break. */
 
@@ -2807,14 +3205,11 @@ func yaml_parser_scan_block_scalar_breaks(parser *yaml_parser_t,
 
 	/* Determine the indentation level if needed. */
 
-	if *indent == 0 {
+	if *indent < 0 {
 		*indent = max_indent
 		if *indent < parser.indent+1 {
 			*indent = parser.indent + 1
 		}
-		if *indent < 1 {
-			*indent = 1
-		}
 	}
 
 	return true
@@ -2932,7 +3327,14 @@ func yaml_parser_scan_flow_scalar(parser *yaml_parser_t, token *yaml_token_t,
 					s = append(s, '\x20')
 				case '"':
 					s = append(s, '"')
+				case '/':
+					s = append(s, '/')
 				case '\'':
+					if parser.conformance == YAML12Conformance {
+						yaml_parser_set_scanner_error(parser, "while parsing a quoted scalar",
+							start_mark, "found unknown escape character")
+						return false
+					}
 					s = append(s, '\'')
 				case '\\':
 					s = append(s, '\\')
@@ -3042,8 +3444,15 @@ func yaml_parser_scan_flow_scalar(parser *yaml_parser_t, token *yaml_token_t,
 			return false
 		}
 
+		indentation, spaces := leading_blanks, 0
 		for is_blank(parser.buffer[parser.buffer_pos]) || is_break_at(parser.buffer, parser.buffer_pos) {
 			if is_blank(parser.buffer[parser.buffer_pos]) {
+				if parser.buffer[parser.buffer_pos] != ' ' {
+					indentation = false
+				} else if indentation {
+					spaces++
+				}
+
 				/* Consume a space or a tab character. */
 				if !leading_blanks {
 					whitespaces = read(parser, whitespaces)
@@ -3063,6 +3472,7 @@ func yaml_parser_scan_flow_scalar(parser *yaml_parser_t, token *yaml_token_t,
 				} else {
 					trailing_breaks = read_line(parser, trailing_breaks)
 				}
+				indentation, spaces = true, 0
 			}
 
 			if !cache(parser, 1) {
@@ -3070,6 +3480,11 @@ func yaml_parser_scan_flow_scalar(parser *yaml_parser_t, token *yaml_token_t,
 			}
 		}
 
+		if leading_blanks && !yaml_parser_check_flow_indent(parser, spaces,
+			"while scanning a quoted scalar", start_mark) {
+			return false
+		}
+
 		/* Join the whitespaces or fold line breaks. */
 
 		if leading_blanks {
@@ -3122,6 +3537,22 @@ func yaml_parser_scan_flow_scalar(parser *yaml_parser_t, token *yaml_token_t,
  * Scan a plain scalar.
  */
 
+/*
+ * Check if a '---' or '...' document indicator starts at the current
+ * position.  At least 4 characters must be cached.
+ */
+
+func is_document_indicator(parser *yaml_parser_t) bool {
+	return parser.mark.column == 0 &&
+		((parser.buffer[parser.buffer_pos] == '-' &&
+			parser.buffer[parser.buffer_pos+1] == '-' &&
+			parser.buffer[parser.buffer_pos+2] == '-') ||
+			(parser.buffer[parser.buffer_pos] == '.' &&
+				parser.buffer[parser.buffer_pos+1] == '.' &&
+				parser.buffer[parser.buffer_pos+2] == '.')) &&
+		is_blankz_at(parser.buffer, parser.buffer_pos+3)
+}
+
 func yaml_parser_scan_plain_scalar(parser *yaml_parser_t, token *yaml_token_t) bool {
 	var s []byte
 	var leading_break []byte
@@ -3142,14 +3573,7 @@ func yaml_parser_scan_plain_scalar(parser *yaml_parser_t, token *yaml_token_t) b
 			return false
 		}
 
-		if parser.mark.column == 0 &&
-			((parser.buffer[parser.buffer_pos] == '-' &&
-				parser.buffer[parser.buffer_pos+1] == '-' &&
-				parser.buffer[parser.buffer_pos+2] == '-') ||
-				(parser.buffer[parser.buffer_pos] == '.' &&
-					parser.buffer[parser.buffer_pos+1] == '.' &&
-					parser.buffer[parser.buffer_pos+2] == '.')) &&
-			is_blankz_at(parser.buffer, parser.buffer_pos+3) {
+		if is_document_indicator(parser) {
 			break
 		}
 
@@ -3162,22 +3586,18 @@ func yaml_parser_scan_plain_scalar(parser *yaml_parser_t, token *yaml_token_t) b
 		/* Consume non-blank characters. */
 
 		for !is_blankz_at(parser.buffer, parser.buffer_pos) {
-			/* Check for 'x:x' in the flow context. TODO: Fix the test "spec-08-13". */
-
-			if parser.flow_level > 0 &&
-				parser.buffer[parser.buffer_pos] == ':' &&
-				!is_blankz_at(parser.buffer, parser.buffer_pos+1) {
-				yaml_parser_set_scanner_error(parser, "while scanning a plain scalar",
-					start_mark, "found unexpected ':'")
-				return false
-			}
-
-			/* Check for indicators that may end a plain scalar. */
+			/*
+			 * Check for indicators that may end a plain scalar.  In the flow
+			 * context a ':' followed by a flow indicator ends it too, while a
+			 * ':' followed by anything else is part of the scalar, as in
+			 * '{url: http://example.com}'.
+			 */
 			b := parser.buffer[parser.buffer_pos]
 			if (b == ':' && is_blankz_at(parser.buffer, parser.buffer_pos+1)) ||
+				(parser.flow_level > 0 && b == ':' &&
+					bytes.IndexByte([]byte(",[]{}"), parser.buffer[parser.buffer_pos+1]) >= 0) ||
 				(parser.flow_level > 0 &&
-					(b == ',' || b == ':' ||
-						b == '?' || b == '[' ||
+					(b == ',' || b == '[' ||
 						b == ']' || b == '{' ||
 						b == '}')) {
 				break
@@ -3240,8 +3660,10 @@ func yaml_parser_scan_plain_scalar(parser *yaml_parser_t, token *yaml_token_t) b
 			if is_blank(parser.buffer[parser.buffer_pos]) {
 				/* Check for tab character that abuse intendation. */
 
-				if leading_blanks && parser.mark.column < indent &&
-					is_tab(parser.buffer[parser.buffer_pos]) {
+				tab_indent := leading_blanks && parser.mark.column < indent &&
+					is_tab(parser.buffer[parser.buffer_pos]) &&
+					!yaml_parser_check_blank_line(parser)
+				if tab_indent && parser.tab_width == 0 {
 					yaml_parser_set_scanner_error(parser, "while scanning a plain scalar",
 						start_mark, "found a tab character that violate intendation")
 					return false
@@ -3251,6 +3673,10 @@ func yaml_parser_scan_plain_scalar(parser *yaml_parser_t, token *yaml_token_t) b
 
 				if !leading_blanks {
 					whitespaces = read(parser, whitespaces)
+				} else if tab_indent {
+					yaml_parser_skip_indentation_tab(parser)
+				} else if is_space(parser.buffer[parser.buffer_pos]) {
+					skip_spaces(parser, -1)
 				} else {
 					skip(parser)
 				}
@@ -3295,6 +3721,9 @@ func yaml_parser_scan_plain_scalar(parser *yaml_parser_t, token *yaml_token_t) b
 
 	if leading_blanks {
 		parser.simple_key_allowed = true
+		if parser.flow_level == 0 {
+			parser.pending_blank_lines += bytes.Count(trailing_breaks, []byte{'\n'})
+		}
 	}
 
 	return true
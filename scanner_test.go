@@ -3,9 +3,12 @@ package candiedyaml
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"testing/iotest"
 )
 
 var scan = func(filename string) {
@@ -60,3 +63,116 @@ var _ = Describe("Scanner", func() {
 	scanYamls("fixtures/specification")
 	scanYamls("fixtures/specification/types")
 })
+
+type scannedToken struct {
+	token_type yaml_token_type_t
+	value      string
+	start_mark YAML_mark_t
+	end_mark   YAML_mark_t
+}
+
+func scanTokens(r io.Reader) ([]scannedToken, *yaml_parser_t) {
+	parser := &yaml_parser_t{}
+	yaml_parser_initialize(parser)
+	yaml_parser_set_input_reader(parser, r)
+	parser.keep_comments = true
+
+	var tokens []scannedToken
+	for {
+		token := yaml_token_t{}
+		Ω(yaml_parser_scan(parser, &token)).To(BeTrue(), parser.problem)
+		tokens = append(tokens, scannedToken{token.token_type, string(token.value),
+			token.start_mark, token.end_mark})
+		if token.token_type == yaml_STREAM_END_TOKEN {
+			return tokens, parser
+		}
+	}
+}
+
+var _ = Describe("Scanner runs of whitespace", func() {
+	// Reading a byte at a time leaves the runs split across refills of
+	// the buffer, which the bulk skipping must not notice.
+	sameEitherWay := func(input string) []scannedToken {
+		whole, _ := scanTokens(strings.NewReader(input))
+		split, _ := scanTokens(iotest.OneByteReader(strings.NewReader(input)))
+		Ω(split).Should(Equal(whole))
+		return whole
+	}
+
+	scalar := func(tokens []scannedToken, value string) scannedToken {
+		for _, token := range tokens {
+			if token.token_type == yaml_SCALAR_TOKEN && token.value == value {
+				return token
+			}
+		}
+		Fail("no scalar " + value)
+		return scannedToken{}
+	}
+
+	It("skips deep indentation", func() {
+		input := "a:\n" + strings.Repeat(" ", 3000) + "b: 1\n" +
+			strings.Repeat(" ", 3000) + "c: 2\n"
+		tokens := sameEitherWay(input)
+
+		b := scalar(tokens, "b")
+		Ω(b.start_mark.line).Should(Equal(1))
+		Ω(b.start_mark.column).Should(Equal(3000))
+		Ω(b.start_mark.index).Should(Equal(3003))
+		Ω(b.start_mark.offset).Should(Equal(3003))
+		Ω(scalar(tokens, "c").start_mark.column).Should(Equal(3000))
+	})
+
+	It("counts the columns of indentation after a tab", func() {
+		tokens := sameEitherWay("- [a,\n \t  b]\n")
+		Ω(scalar(tokens, "b").start_mark.column).Should(Equal(4))
+	})
+
+	It("reads comments mixing ASCII, tabs and wider characters", func() {
+		input := "# héllo\tworld ☃ 𝄞 end  \nkey: v   # trailing ünïcode\t\nnext: w\n"
+		sameEitherWay(input)
+
+		_, parser := scanTokens(iotest.OneByteReader(strings.NewReader(input)))
+		Ω(string(parser.head_comments[1])).Should(Equal("# héllo\tworld ☃ 𝄞 end"))
+		Ω(string(parser.line_comments[1])).Should(Equal("# trailing ünïcode"))
+
+		parser = &yaml_parser_t{}
+		yaml_parser_initialize(parser)
+		yaml_parser_set_input_string(parser, []byte(input+"\n\nlast: x\n"))
+		for token := (yaml_token_t{}); token.token_type != yaml_STREAM_END_TOKEN; {
+			Ω(yaml_parser_scan(parser, &token)).Should(BeTrue())
+		}
+		Ω(parser.head_comments).Should(BeEmpty())
+		Ω(parser.line_comments).Should(BeEmpty())
+		Ω(parser.blank_lines).Should(BeEmpty())
+
+		next := scalar(sameEitherWay(input), "next")
+		Ω(next.start_mark.offset).Should(Equal(len("# héllo\tworld ☃ 𝄞 end  \nkey: v   # trailing ünïcode\t\n")))
+	})
+
+	It("stops block scalar indentation at the indentation level", func() {
+		tokens := sameEitherWay("s: |2\n    more\n  less\n\n   x\nt: |\n  \n    deep\n")
+		Ω(scalar(tokens, "  more\nless\n\n x\n").token_type).Should(Equal(yaml_SCALAR_TOKEN))
+		Ω(scalar(tokens, "\ndeep\n").token_type).Should(Equal(yaml_SCALAR_TOKEN))
+	})
+
+	It("folds the indentation of plain scalar continuation lines", func() {
+		tokens := sameEitherWay("k: a\n        b\n  \t  c\n")
+		Ω(scalar(tokens, "a b c").token_type).Should(Equal(yaml_SCALAR_TOKEN))
+	})
+
+	It("counts offsets in UTF-16 input", func() {
+		input := "\xff\xfe" + string(utf16le("a:\n     b: 1\n"))
+		tokens := sameEitherWay(input)
+		b := scalar(tokens, "b")
+		Ω(b.start_mark.column).Should(Equal(5))
+		Ω(b.start_mark.offset).Should(Equal(2 + 2*8))
+	})
+})
+
+func utf16le(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		b = append(b, byte(r), byte(r>>8))
+	}
+	return b
+}
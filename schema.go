@@ -0,0 +1,188 @@
+package candiedyaml
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A ScalarResolver returns the value an untagged plain scalar stands for, or
+// false if it does not recognise the scalar.
+type ScalarResolver func(value string) (interface{}, bool)
+
+// A Schema decides which values untagged plain scalars resolve to when they
+// are decoded into an interface{}, and which strings an Encoder has to quote
+// so that they read back as strings.  Scalars no resolver recognises are
+// strings.
+//
+// Schemas only affect interface{} values; scalars decoded into typed fields
+// are parsed according to the type of the field.
+type Schema struct {
+	name         string
+	resolvers    []ScalarResolver
+	noTimestamps bool
+}
+
+var (
+	// FailsafeSchema resolves every scalar to a string.
+	FailsafeSchema = NewSchema("failsafe")
+
+	// JSONSchema resolves null, true, false and JSON numbers.
+	JSONSchema = FailsafeSchema.Extend("json",
+		resolveJSONNull, resolveJSONBool, resolveJSONNumber)
+
+	// CoreSchema is the YAML 1.2 default: it resolves null and ~, booleans
+	// in three cases, decimal, 0o octal and 0x hexadecimal integers, and
+	// floats including .inf and .nan.
+	CoreSchema = FailsafeSchema.Extend("core",
+		resolveCoreNull, resolveCoreBool, resolveCoreNumber)
+
+	// YAML11Schema additionally resolves yes/no/on/off booleans, 0b binary
+	// and 0-prefixed octal integers, sexagesimal numbers and timestamps.
+	// It is the default for Decoders.
+	YAML11Schema = FailsafeSchema.Extend("yaml 1.1", resolveYAML11)
+)
+
+// NewSchema returns a schema that tries each resolver in turn.  Schemas
+// cannot be changed once made, so one may be shared by Decoders and Encoders
+// in different goroutines.
+func NewSchema(name string, resolvers ...ScalarResolver) *Schema {
+	return &Schema{name: name, resolvers: append([]ScalarResolver(nil), resolvers...)}
+}
+
+// Extend returns a new schema that tries the resolvers of s before the
+// given resolvers.
+func (s *Schema) Extend(name string, resolvers ...ScalarResolver) *Schema {
+	all := make([]ScalarResolver, 0, len(s.resolvers)+len(resolvers))
+	all = append(all, s.resolvers...)
+	all = append(all, resolvers...)
+	e := NewSchema(name, all...)
+	e.noTimestamps = s.noTimestamps
+	return e
+}
+
+// WithoutTimestamps returns a copy of s that leaves scalars looking like
+// timestamps, including those tagged !!timestamp, as strings.  Fields of
+// type time.Time still decode them.
+func (s *Schema) WithoutTimestamps() *Schema {
+	c := *s
+	c.name += " without timestamps"
+	c.noTimestamps = true
+	return &c
+}
+
+func (s *Schema) String() string {
+	return s.name
+}
+
+// Resolve returns the value of an untagged plain scalar.
+func (s *Schema) Resolve(value string) interface{} {
+	for _, r := range s.resolvers {
+		if v, ok := r(value); ok {
+			if _, ok := v.(time.Time); ok && s.noTimestamps {
+				break
+			}
+			return v
+		}
+	}
+	return value
+}
+
+// needsQuotes reports whether value would not read back as itself if it was
+// written as a plain scalar.
+func (s *Schema) needsQuotes(value string) bool {
+	v, ok := s.Resolve(value).(string)
+	return !ok || v != value
+}
+
+var (
+	json_number_regexp  = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+	core_int_regexp     = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	core_float_regexp   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	core_special_floats = map[string]float64{
+		".inf": math.Inf(1), ".Inf": math.Inf(1), ".INF": math.Inf(1),
+		"+.inf": math.Inf(1), "+.Inf": math.Inf(1), "+.INF": math.Inf(1),
+		"-.inf": math.Inf(-1), "-.Inf": math.Inf(-1), "-.INF": math.Inf(-1),
+		".nan": math.NaN(), ".NaN": math.NaN(), ".NAN": math.NaN(),
+	}
+)
+
+func resolveJSONNull(val string) (interface{}, bool) {
+	return nil, val == "null"
+}
+
+func resolveJSONBool(val string) (interface{}, bool) {
+	switch val {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return nil, false
+}
+
+func resolveJSONNumber(val string) (interface{}, bool) {
+	if !json_number_regexp.MatchString(val) {
+		return nil, false
+	}
+	return parseNumber(val, 10)
+}
+
+func resolveCoreNull(val string) (interface{}, bool) {
+	switch val {
+	case "", "~", "null", "Null", "NULL":
+		return nil, true
+	}
+	return nil, false
+}
+
+func resolveCoreBool(val string) (interface{}, bool) {
+	switch val {
+	case "true", "True", "TRUE":
+		return true, true
+	case "false", "False", "FALSE":
+		return false, true
+	}
+	return nil, false
+}
+
+func resolveCoreNumber(val string) (interface{}, bool) {
+	if f, ok := core_special_floats[val]; ok {
+		return f, true
+	}
+
+	switch {
+	case strings.HasPrefix(val, "0o"):
+		return parseNumber(val[2:], 8)
+	case strings.HasPrefix(val, "0x"):
+		return parseNumber(val[2:], 16)
+	case core_int_regexp.MatchString(val), core_float_regexp.MatchString(val):
+		return parseNumber(val, 10)
+	}
+	return nil, false
+}
+
+// parseNumber returns val as an int64, or as a float64 if it has a fraction
+// or does not fit.  Only decimal numbers may carry a sign.
+func parseNumber(val string, base int) (interface{}, bool) {
+	if base != 10 {
+		u, err := strconv.ParseUint(val, base, 64)
+		if err != nil {
+			return nil, false
+		}
+		if u > math.MaxInt64 {
+			return float64(u), true
+		}
+		return int64(u), true
+	}
+
+	if i, err := strconv.ParseInt(val, base, 64); err == nil {
+		return i, true
+	}
+	if f, err := strconv.ParseFloat(val, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
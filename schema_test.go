@@ -0,0 +1,126 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math"
+	"time"
+)
+
+var _ = Describe("Schema", func() {
+	decode := func(schema *Schema, data string) interface{} {
+		var v interface{}
+		d := NewDecoder(bytes.NewBufferString(data))
+		d.SetSchema(schema)
+		Ω(d.Decode(&v)).Should(Succeed())
+		return v
+	}
+
+	encode := func(schema *Schema, v interface{}) string {
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(schema)
+		Ω(e.Encode(v)).Should(Succeed())
+		return buf.String()
+	}
+
+	Context("Decoding", func() {
+		It("resolves everything to strings with the failsafe schema", func() {
+			Ω(decode(FailsafeSchema, "[null, true, 1, 1.5, ~]")).Should(Equal(
+				[]interface{}{"null", "true", "1", "1.5", "~"}))
+		})
+
+		It("resolves JSON values with the JSON schema", func() {
+			Ω(decode(JSONSchema, "[null, true, false, -1, 1.5e3, True, ~, 0x1f, 01]")).Should(Equal(
+				[]interface{}{nil, true, false, int64(-1), float64(1500), "True", "~", "0x1f", "01"}))
+			Ω(decode(JSONSchema, "[1., 1.e3, -0.5]")).Should(Equal(
+				[]interface{}{"1.", "1.e3", float64(-0.5)}))
+		})
+
+		It("resolves YAML 1.2 values with the core schema", func() {
+			v := decode(CoreSchema, "[Null, ~, TRUE, False, +12, 0o17, 0xff, .5, -.INF, yes, on, 0b11, 1:20]")
+			Ω(v).Should(Equal([]interface{}{
+				nil, nil, true, false, int64(12), int64(15), int64(255), 0.5, math.Inf(-1),
+				"yes", "on", "0b11", "1:20",
+			}))
+
+			f := decode(CoreSchema, ".nan").(float64)
+			Ω(math.IsNaN(f)).Should(BeTrue())
+		})
+
+		It("resolves YAML 1.1 values by default", func() {
+			var v interface{}
+			Ω(Unmarshal([]byte("[yes, off, 0b11, 017, 1:20]"), &v)).Should(Succeed())
+			Ω(v).Should(Equal([]interface{}{true, false, int64(3), int64(15), int64(80)}))
+			Ω(decode(YAML11Schema, "[yes, off, 0b11, 017, 1:20]")).Should(Equal(v))
+		})
+
+		It("leaves quoted scalars alone", func() {
+			Ω(decode(CoreSchema, `["true", '1']`)).Should(Equal([]interface{}{"true", "1"}))
+		})
+
+		It("resolves tagged timestamps whatever the schema", func() {
+			Ω(decode(CoreSchema, "[2001-12-14, !!timestamp 2001-12-14]")).Should(Equal(
+				[]interface{}{"2001-12-14", time.Date(2001, time.December, 14, 0, 0, 0, 0, time.UTC)}))
+		})
+
+		It("leaves timestamps as strings without timestamps", func() {
+			schema := YAML11Schema.WithoutTimestamps()
+			Ω(schema.String()).Should(Equal("yaml 1.1 without timestamps"))
+			Ω(decode(schema, "[2001-12-14, !!timestamp 2001-12-14, 12]")).Should(Equal(
+				[]interface{}{"2001-12-14", "2001-12-14", int64(12)}))
+
+			var v struct{ T time.Time }
+			d := NewDecoder(bytes.NewBufferString("t: 2001-12-14\n"))
+			d.SetSchema(schema)
+			Ω(d.Decode(&v)).Should(Succeed())
+			Ω(v.T).Should(Equal(time.Date(2001, time.December, 14, 0, 0, 0, 0, time.UTC)))
+
+			Ω(encode(schema, []string{"2001-12-14"})).Should(Equal("- 2001-12-14\n"))
+			Ω(encode(YAML11Schema, []string{"2001-12-14"})).Should(Equal("- \"2001-12-14\"\n"))
+		})
+
+		It("composes schemas", func() {
+			onOff := func(val string) (interface{}, bool) {
+				switch val {
+				case "on":
+					return true, true
+				case "off":
+					return false, true
+				}
+				return nil, false
+			}
+			schema := JSONSchema.Extend("json+on/off", onOff)
+			Ω(schema.String()).Should(Equal("json+on/off"))
+			Ω(decode(schema, "[true, 12, on, off, yes]")).Should(Equal(
+				[]interface{}{true, int64(12), true, false, "yes"}))
+		})
+	})
+
+	Context("Encoding", func() {
+		It("quotes every string without a schema", func() {
+			buf := &bytes.Buffer{}
+			Ω(NewEncoder(buf).Encode("abc")).Should(Succeed())
+			Ω(buf.String()).Should(Equal("\"abc\"\n"))
+		})
+
+		It("quotes only the strings the schema would not read back", func() {
+			v := []string{"abc", "yes", "true", "12", "0o17", "null", ""}
+
+			Ω(encode(FailsafeSchema, v[:6])).Should(Equal(
+				"- abc\n- yes\n- true\n- 12\n- 0o17\n- null\n"))
+			Ω(encode(JSONSchema, v)).Should(Equal(
+				"- abc\n- yes\n- \"true\"\n- \"12\"\n- 0o17\n- \"null\"\n- \n"))
+			Ω(encode(CoreSchema, v)).Should(Equal(
+				"- abc\n- yes\n- \"true\"\n- \"12\"\n- \"0o17\"\n- \"null\"\n- \"\"\n"))
+			Ω(encode(YAML11Schema, v)).Should(Equal(
+				"- abc\n- \"yes\"\n- \"true\"\n- \"12\"\n- 0o17\n- \"null\"\n- \"\"\n"))
+		})
+
+		It("falls back to quotes where plain scalars are not allowed", func() {
+			Ω(encode(CoreSchema, []string{"a: b", "- c", "d\ne"})).Should(Equal(
+				"- 'a: b'\n- '- c'\n- 'd\n\n  e'\n"))
+		})
+	})
+})
@@ -0,0 +1,162 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const setTag = "tag:yaml.org,2002:set"
+
+// isSet reports whether t is a map used as a set, e.g. map[string]struct{}.
+func isSet(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0
+}
+
+// UseSetTag causes sets, maps with values of type struct{}, to be written as
+// !!set mappings whose values are all null instead of as sequences of their
+// members.  Either form decodes into a set.
+func (e *Encoder) UseSetTag(use bool) {
+	e.setTag = use
+}
+
+func (e *Encoder) emitSet(tag string, v reflect.Value) {
+	var keys stringValues = v.MapKeys()
+	sort.Sort(keys)
+
+	if e.setTag {
+		if tag == "" {
+			tag = setTag
+		}
+		e.checkCompact(v)
+		e.mapping(tag, func() {
+			for _, k := range keys {
+				e.marshalKey(k)
+				e.emitNil()
+			}
+		})
+		return
+	}
+
+	e.checkCompact(v)
+	e.sequence(tag, func() {
+		for _, k := range keys {
+			e.marshal("", k)
+		}
+	})
+}
+
+// sequenceSet decodes a sequence into the set v, adding each item to it.
+func (d *Decoder) sequenceSet(v reflect.Value) {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	member := reflect.Zero(v.Type().Elem())
+
+	d.nextEvent()
+	for d.event.event_type != yaml_SEQUENCE_END_EVENT {
+		key := reflect.New(v.Type().Key()).Elem()
+		d.parse(key)
+		v.SetMapIndex(key, member)
+	}
+	d.nextEvent()
+}
+
+// RegisterFlags makes values of the integer type t, whose bits are flags,
+// encode as the sequence of the names of the flags they have set, and decode
+// from such a sequence or from an integer.  names gives the value of each
+// flag.  A name may stand for several bits, in which case it is used only
+// for values that have all of them set and that no names of smaller values
+// cover.  Encoding a value with bits that no name covers is an error.
+//
+//	RegisterFlags(reflect.TypeOf(Perm(0)), map[string]uint64{
+//		"read": 1, "write": 2, "exec": 4,
+//	})
+//
+// RegisterFlags is meant to be called from init functions.  It copies names,
+// which may be changed afterwards without affecting t.
+func RegisterFlags(t reflect.Type, names map[string]uint64) {
+	names = copyFlags(names)
+	type flag struct {
+		name  string
+		value uint64
+	}
+	var flags []flag
+	for name, value := range names {
+		flags = append(flags, flag{name, value})
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].value != flags[j].value {
+			return flags[i].value < flags[j].value
+		}
+		return flags[i].name < flags[j].name
+	})
+
+	bits := func(v reflect.Value) uint64 {
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return uint64(v.Int())
+		}
+		return v.Uint()
+	}
+
+	registerAdapter(t, adapter{
+		marshal: func(v interface{}) (interface{}, error) {
+			b := bits(reflect.ValueOf(v))
+			set := []string{}
+			var covered uint64
+			for _, f := range flags {
+				if f.value != 0 && b&f.value == f.value && f.value&^covered != 0 {
+					set = append(set, f.name)
+					covered |= f.value
+				}
+			}
+			if covered != b {
+				return nil, fmt.Errorf("yaml: %s has unnamed flags %#x", t, b&^covered)
+			}
+			return set, nil
+		},
+		unmarshal: func(unmarshal func(interface{}) error) (interface{}, error) {
+			var i interface{}
+			if err := unmarshal(&i); err != nil {
+				return nil, err
+			}
+
+			out := reflect.New(t).Elem()
+			switch i := i.(type) {
+			case []interface{}:
+				var b uint64
+				for _, name := range i {
+					s, ok := name.(string)
+					value, known := names[s]
+					if !ok || !known {
+						return nil, fmt.Errorf("unknown %s flag '%v'", t, name)
+					}
+					b |= value
+				}
+				if out.Kind() >= reflect.Uint {
+					out.SetUint(b)
+				} else {
+					out.SetInt(int64(b))
+				}
+			default:
+				resolve := resolve_int
+				if out.Kind() >= reflect.Uint {
+					resolve = resolve_uint
+				}
+				if err := resolve(fmt.Sprint(i), out); err != nil {
+					return nil, fmt.Errorf("cannot decode %v into %s", i, t)
+				}
+			}
+			return out.Interface(), nil
+		},
+	})
+}
+
+func copyFlags(names map[string]uint64) map[string]uint64 {
+	c := make(map[string]uint64, len(names))
+	for name, value := range names {
+		c[name] = value
+	}
+	return c
+}
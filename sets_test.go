@@ -0,0 +1,60 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"reflect"
+)
+
+type testPerm uint8
+
+func init() {
+	RegisterFlags(reflect.TypeOf(testPerm(0)), map[string]uint64{
+		"read": 1, "write": 2, "exec": 4, "rw": 3,
+	})
+}
+
+var _ = Describe("Sets", func() {
+	set := map[string]struct{}{"b": {}, "a": {}, "c": {}}
+
+	It("encodes sets as sorted sequences", func() {
+		out, err := Marshal(map[string]interface{}{"tags": set})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("\"tags\":\n- \"a\"\n- \"b\"\n- \"c\"\n"))
+	})
+
+	It("encodes sets as !!set mappings with UseSetTag", func() {
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf)
+		enc.UseSetTag(true)
+		Ω(enc.Encode(map[int]struct{}{2: {}, 1: {}})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("!!set\n1: null\n2: null\n"))
+	})
+
+	It("decodes sequences and mappings into sets", func() {
+		var v struct{ A, B map[string]struct{} }
+		Ω(Unmarshal([]byte("a: [x, y, x]\nb: !!set {x, z}\n"), &v)).Should(Succeed())
+		Ω(v.A).Should(Equal(map[string]struct{}{"x": {}, "y": {}}))
+		Ω(v.B).Should(Equal(map[string]struct{}{"x": {}, "z": {}}))
+	})
+})
+
+var _ = Describe("RegisterFlags", func() {
+	It("encodes flags as a sequence of names", func() {
+		out, err := Marshal(map[string]testPerm{"a": 0, "b": 5, "c": 7})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(out)).Should(Equal("\"a\": []\n\"b\":\n- \"read\"\n- \"exec\"\n\"c\":\n- \"read\"\n- \"write\"\n- \"exec\"\n"))
+
+		_, err = Marshal(testPerm(9))
+		Ω(err).Should(MatchError("yaml: candiedyaml.testPerm has unnamed flags 0x8"))
+	})
+
+	It("decodes names or integers", func() {
+		var v []testPerm
+		Ω(Unmarshal([]byte("- [read, exec]\n- [rw]\n- 6\n- []\n"), &v)).Should(Succeed())
+		Ω(v).Should(Equal([]testPerm{5, 3, 6, 0}))
+
+		Ω(Unmarshal([]byte("- [read, delete]\n"), &v)).Should(MatchError(ContainSubstring("unknown candiedyaml.testPerm flag 'delete'")))
+	})
+})
@@ -0,0 +1,145 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ShardHeadDepth is how many levels below the root of a document the head
+// given to the selector of Shard holds.
+const ShardHeadDepth = 2
+
+// Shard copies each document of the stream read from r, as it was written,
+// to the writer in writers at the index selector returns for its head, so
+// that manifests can be routed by their kind or namespace without decoding
+// them:
+//
+//	err := candiedyaml.Shard(r, func(head candiedyaml.Node) int {
+//		if ns := head.GetPath("metadata", "namespace"); ns != nil && ns.Value == "prod" {
+//			return 0
+//		}
+//		return 1
+//	}, []io.Writer{prod, other})
+//
+// The head is the root node of the document down to ShardHeadDepth levels:
+// the keys and values of a root mapping and the entries of the collections
+// among them, with collections below those left empty.  Aliases to anchors
+// in the parts left out have no Alias.  Documents for which selector
+// returns a negative index are dropped.  Each writer gets a stream of its
+// own, with the markers JoinDocuments would write between its documents.
+// Documents are written as they are read, so those before an error have
+// been written when Shard returns it.
+func Shard(r io.Reader, selector func(docHead Node) int, writers []io.Writer) (err error) {
+	d := NewDecoder(r)
+	defer recoverError(&err)
+
+	written := make([]bool, len(writers))
+	ended := make([]bool, len(writers))
+	for i := range ended {
+		ended[i] = true
+	}
+	var buf bytes.Buffer
+	for {
+		if err := d.startDocument(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		start := d.offset
+		at := d.event.start_mark
+		d.nextEvent()
+		head := d.headNode(ShardHeadDepth, make(map[string]*Node))
+		if d.event.event_type != yaml_DOCUMENT_END_EVENT {
+			d.error(fmt.Errorf("Expected document end - found %d", d.event.event_type))
+		}
+		raw := d.raw.slice(start, d.event.end_mark.offset)
+		d.nextEvent()
+
+		i := selector(*head)
+		if i < 0 {
+			continue
+		}
+		if i >= len(writers) {
+			return fmt.Errorf("yaml: Shard: no writer %d for the document at line %d", i, at.line+1)
+		}
+		buf.Reset()
+		ended[i] = joinDocument(&buf, raw, !written[i], ended[i])
+		written[i] = true
+		if _, err := writers[i].Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
+
+// headNode returns the node at the current event, as node does, with the
+// collections more than depth levels below it left empty.
+func (d *Decoder) headNode(depth int, anchors map[string]*Node) *Node {
+	e := &d.event
+	if e.event_type != yaml_SEQUENCE_START_EVENT && e.event_type != yaml_MAPPING_START_EVENT {
+		if e.event_type != yaml_ALIAS_EVENT {
+			return d.node(anchors)
+		}
+		d.countAlias()
+		n := &Node{
+			Kind:   AliasNode,
+			Value:  string(e.anchor),
+			Alias:  anchors[string(e.anchor)],
+			Line:   e.start_mark.line + 1,
+			Column: e.start_mark.column + 1,
+		}
+		d.nextEvent()
+		return n
+	}
+
+	n := &Node{
+		Kind:   SequenceNode,
+		Tag:    string(e.tag),
+		Anchor: string(e.anchor),
+		Line:   e.start_mark.line + 1,
+		Column: e.start_mark.column + 1,
+	}
+	end := yaml_SEQUENCE_END_EVENT
+	if e.event_type == yaml_MAPPING_START_EVENT {
+		n.Kind = MappingNode
+		end = yaml_MAPPING_END_EVENT
+	}
+	if e.style == yaml_style_t(yaml_FLOW_SEQUENCE_STYLE) {
+		n.Style = FlowStyle
+	}
+	if n.Anchor != "" {
+		anchors[n.Anchor] = n
+	}
+
+	d.enter()
+	d.nextEvent()
+	for d.event.event_type != end {
+		if depth > 0 {
+			n.Content = append(n.Content, d.headNode(depth-1, anchors))
+		} else {
+			d.skipNode()
+		}
+	}
+	d.leave()
+	d.nextEvent()
+	return n
+}
+
+// skipNode moves past the node at the current event.
+func (d *Decoder) skipNode() {
+	for level := 0; ; {
+		switch d.event.event_type {
+		case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+			level++
+		case yaml_SEQUENCE_END_EVENT, yaml_MAPPING_END_EVENT:
+			level--
+		case yaml_ALIAS_EVENT:
+			d.countAlias()
+		}
+		d.nextEvent()
+		if level == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shard", func() {
+	const stream = `# web
+kind: Deployment
+metadata: {name: web, namespace: prod}
+spec:
+  containers:
+  - {name: web, image: nginx}
+---
+kind: Service
+metadata:
+  name: web
+  namespace: dev
+---
+kind: ConfigMap
+metadata: {name: cfg, namespace: prod}
+`
+
+	byNamespace := func(head Node) int {
+		switch ns := head.GetPath("metadata", "namespace"); {
+		case ns == nil:
+			return -1
+		case ns.Value == "prod":
+			return 0
+		}
+		return 1
+	}
+
+	It("copies each document to the writer its head selects", func() {
+		var prod, dev bytes.Buffer
+		Ω(Shard(strings.NewReader(stream), byNamespace, []io.Writer{&prod, &dev})).Should(Succeed())
+		Ω(prod.String()).Should(Equal(`# web
+kind: Deployment
+metadata: {name: web, namespace: prod}
+spec:
+  containers:
+  - {name: web, image: nginx}
+---
+kind: ConfigMap
+metadata: {name: cfg, namespace: prod}
+`))
+		Ω(dev.String()).Should(Equal("---\nkind: Service\nmetadata:\n  name: web\n  namespace: dev\n"))
+
+		var docs []Node
+		Ω(UnmarshalAll(prod.Bytes(), &docs)).Should(Succeed())
+		Ω(docs).Should(HaveLen(2))
+	})
+
+	It("gives the selector only the head of each document", func() {
+		var heads []Node
+		Ω(Shard(strings.NewReader(stream), func(head Node) int {
+			heads = append(heads, head)
+			return -1
+		}, nil)).Should(Succeed())
+		Ω(heads).Should(HaveLen(3))
+
+		containers := heads[0].GetPath("spec", "containers")
+		Ω(containers.Kind).Should(Equal(SequenceNode))
+		Ω(containers.Content).Should(BeEmpty())
+		Ω(heads[0].GetPath("metadata", "namespace").Value).Should(Equal("prod"))
+		Ω(heads[1].GetPath("kind").Value).Should(Equal("Service"))
+		Ω(heads[1].GetPath("metadata", "name").Line).Should(Equal(10))
+	})
+
+	It("keeps aliases whose anchors are in the head", func() {
+		var head Node
+		Ω(Shard(strings.NewReader("a: &x 1\nb: *x\nc: {d: [&y 2]}\ne: *y\n"), func(h Node) int {
+			head = h
+			return -1
+		}, nil)).Should(Succeed())
+		Ω(head.GetPath("b").Alias.Value).Should(Equal("1"))
+		Ω(head.GetPath("e").Kind).Should(Equal(AliasNode))
+		Ω(head.GetPath("e").Alias).Should(BeNil())
+	})
+
+	It("reports selections with no writer and parse errors", func() {
+		var w bytes.Buffer
+		err := Shard(strings.NewReader(stream), func(Node) int { return 1 }, []io.Writer{&w})
+		Ω(err).Should(MatchError(ContainSubstring("no writer 1 for the document at line 2")))
+
+		err = Shard(strings.NewReader("a: 1\n---\nb: [\n"), func(Node) int { return 0 }, []io.Writer{&w})
+		Ω(err).Should(HaveOccurred())
+		Ω(w.String()).Should(Equal("a: 1\n"))
+	})
+
+	It("returns the errors of the writers", func() {
+		err := Shard(strings.NewReader("a: 1\n"), func(Node) int { return 0 }, []io.Writer{failingWriter{}})
+		Ω(errors.Is(err, io.ErrShortWrite)).Should(BeTrue())
+	})
+})
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, io.ErrShortWrite }
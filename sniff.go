@@ -0,0 +1,68 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+)
+
+// SniffHead returns the keys of the root mapping of the first document in
+// data whose values are scalars, such as apiVersion and kind, with the
+// values as written, parsing no more than the first maxBytes bytes of data
+// so that routing decisions about huge files stay cheap.  A maxBytes of 0 or
+// less parses the whole document.
+//
+// Only the whole lines within the budget are parsed, and a value is only
+// returned once the key after it, or the end of the mapping, has been read
+// from them, so values the budget may have cut short, such as plain
+// scalars that could go on in the next line, are left out rather than
+// returned truncated.  Documents whose root is not a mapping give
+// an empty map.  Syntax errors are returned only when all of data fits in
+// the budget, since the budget may cut off what would make the rest valid.
+func SniffHead(data []byte, maxBytes int) (head map[string]string, err error) {
+	truncated := maxBytes > 0 && maxBytes < len(data)
+	if truncated {
+		// Cut at a line break, so that tokens on one line are whole.
+		data = data[:bytes.LastIndexByte(data[:maxBytes], '\n')+1]
+	}
+	text := toUTF8(data)
+
+	head = make(map[string]string)
+	defer func() {
+		if err != nil && truncated {
+			err = nil
+		}
+		if err != nil {
+			head = nil
+		}
+	}()
+	defer recoverError(&err)
+
+	d := NewDecoder(bytes.NewReader(text))
+	if err := d.startDocument(); err != nil {
+		if err == io.EOF {
+			return head, nil
+		}
+		return nil, err
+	}
+	d.nextEvent()
+	if d.event.event_type != yaml_MAPPING_START_EVENT {
+		return head, nil
+	}
+
+	d.nextEvent()
+	for d.event.event_type != yaml_MAPPING_END_EVENT {
+		key, scalarKey := string(d.event.value), d.event.event_type == yaml_SCALAR_EVENT
+		d.skipNode()
+		value, scalarValue := string(d.event.value), d.event.event_type == yaml_SCALAR_EVENT
+		d.skipNode()
+
+		// The value is complete once the next token has been read whole.
+		if truncated && d.event.start_mark.offset >= len(text) {
+			break
+		}
+		if scalarKey && scalarValue {
+			head[key] = value
+		}
+	}
+	return head, nil
+}
@@ -0,0 +1,58 @@
+package candiedyaml
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SniffHead", func() {
+	manifest := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nnote: \"héllo\"\nspec:\n  replicas: 3\n" +
+		strings.Repeat("# padding\n", 1000)
+
+	It("returns the scalars of the root mapping", func() {
+		head, err := SniffHead([]byte(manifest), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(head).Should(Equal(map[string]string{"apiVersion": "apps/v1", "kind": "Deployment", "note": "héllo"}))
+	})
+
+	It("stops at the budget without returning values it cut short", func() {
+		for budget, want := range map[int]map[string]string{
+			10: {},
+			27: {},
+			42: {"apiVersion": "apps/v1"},
+			60: {"apiVersion": "apps/v1", "kind": "Deployment"},
+			80: {"apiVersion": "apps/v1", "kind": "Deployment", "note": "héllo"},
+		} {
+			head, err := SniffHead([]byte(manifest), budget)
+			Ω(err).ShouldNot(HaveOccurred(), "budget %d", budget)
+			Ω(head).Should(Equal(want), "budget %d", budget)
+		}
+	})
+
+	It("leaves out values of plain scalars that continue past the budget", func() {
+		head, err := SniffHead([]byte("a: one\nb: two\n  three\nc: x\n"), 20)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(head).Should(Equal(map[string]string{"a": "one"}))
+	})
+
+	It("only looks at the root mapping of the first document", func() {
+		head, err := SniffHead([]byte("- a: 1\n"), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(head).Should(BeEmpty())
+
+		head, err = SniffHead([]byte("a: 1\n---\nb: 2\n"), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(head).Should(Equal(map[string]string{"a": "1"}))
+
+		head, err = SniffHead(nil, 0)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(head).Should(BeEmpty())
+	})
+
+	It("returns syntax errors in what it parsed whole", func() {
+		_, err := SniffHead([]byte("a: [\n"), 0)
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,218 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+)
+
+// A Source is a YAML stream decoded into Nodes that keeps the text it was
+// read from, so that writing it back with Bytes reproduces that text byte
+// for byte: indentation, quoting, comments, blank lines and line endings
+// included.  Tools that edit files change the Nodes in Documents, and only
+// the text of the nodes they changed is written anew.
+type Source struct {
+	// Documents holds a DocumentNode for each document of the stream.
+	Documents []*Node
+
+	text []byte
+	utf8 bool
+
+	// docs holds the documents read, spans where each node read was in
+	// text, and orig a copy of each node as it was read.
+	docs  []*Node
+	spans map[*Node]span
+	orig  map[*Node]*Node
+}
+
+// span is where in the input a node was, as byte offsets.
+type span struct {
+	start, end int
+}
+
+// span records that the node n, read from start on, ends with the current
+// event, when the Decoder keeps spans.
+func (d *Decoder) span(n *Node, start int) {
+	if d.spans != nil {
+		d.spans[n] = span{start, d.event.end_mark.offset}
+	}
+}
+
+// ParseSource decodes every document in data into Nodes and keeps data for
+// Bytes to write back.
+func ParseSource(data []byte) (*Source, error) {
+	s := &Source{
+		text:  data,
+		spans: make(map[*Node]span),
+		orig:  make(map[*Node]*Node),
+	}
+	d := NewDecoder(bytes.NewReader(data))
+	d.spans = s.spans
+	for {
+		doc := &Node{}
+		if err := d.Decode(doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		s.Documents = append(s.Documents, doc)
+		doc.clone(s.orig)
+	}
+	s.utf8 = d.parser.encoding == yaml_UTF8_ENCODING
+	s.docs = append([]*Node(nil), s.Documents...)
+	return s, nil
+}
+
+// Bytes returns the stream as YAML.  Unchanged, that is the text it was read
+// from.  Scalars, aliases and flow collections that changed are written in
+// place of their old text, on the line they were on, and everything around
+// them is kept.  Changes that cannot be written on the line, such as adding
+// an entry to a block mapping, changing a comment or giving a value several
+// lines, re-encode the whole stream as encoding the Documents would.
+func (s *Source) Bytes() ([]byte, error) {
+	if out, ok := s.splice(); ok {
+		return out, nil
+	}
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.SetUnicode(true)
+	for _, doc := range s.Documents {
+		if err := e.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// splice writes the stream from its text with the nodes that changed
+// written anew, or returns false if some change cannot be written in place.
+func (s *Source) splice() ([]byte, bool) {
+	if !s.utf8 || len(s.Documents) != len(s.docs) {
+		return nil, false
+	}
+	buf := &bytes.Buffer{}
+	pos := 0
+	for i, doc := range s.Documents {
+		if doc != s.docs[i] {
+			return nil, false
+		}
+		sp := s.spans[doc]
+		buf.Write(s.text[pos:sp.start])
+		if !s.spliceNode(buf, doc, false) {
+			return nil, false
+		}
+		pos = sp.end
+	}
+	buf.Write(s.text[pos:])
+	return buf.Bytes(), true
+}
+
+// spliceNode writes n, in a flow collection if flow is set, to buf.
+func (s *Source) spliceNode(buf *bytes.Buffer, n *Node, flow bool) bool {
+	sp, ok := s.spans[n]
+	if !ok {
+		return false
+	}
+	o := s.orig[n]
+
+	if s.unchanged(n, o) {
+		pos := sp.start
+		for _, c := range n.Content {
+			cs := s.spans[c]
+			buf.Write(s.text[pos:cs.start])
+			if !s.spliceNode(buf, c, flow || n.Style == FlowStyle) {
+				return false
+			}
+			pos = cs.end
+		}
+		buf.Write(s.text[pos:sp.end])
+		return true
+	}
+
+	// Only what was written on one line can be replaced with one line.
+	switch {
+	case o.Kind == DocumentNode,
+		o.Kind == ScalarNode && (o.Style == LiteralStyle || o.Style == FoldedStyle),
+		(o.Kind == SequenceNode || o.Kind == MappingNode) && o.Style != FlowStyle:
+		return false
+	}
+	// Its own comments and blank lines are in the text around it.
+	if n.HeadComment != o.HeadComment || n.LineComment != o.LineComment ||
+		n.FootComment != o.FootComment || n.BlankLines != o.BlankLines {
+		return false
+	}
+	bare := *n
+	bare.HeadComment, bare.LineComment, bare.FootComment, bare.BlankLines = "", "", "", 0
+	for _, c := range append(bare.Content, o.Content...) {
+		if !uncommented(c) {
+			return false
+		}
+	}
+	text, ok := inline(&bare, flow)
+	if !ok {
+		return false
+	}
+
+	// An empty node is where its key or indicator ends.
+	if sp.start == sp.end && sp.start > 0 && bytes.IndexByte([]byte(" \t\r\n[{,"), s.text[sp.start-1]) < 0 {
+		buf.WriteByte(' ')
+	}
+	buf.Write(text)
+	return true
+}
+
+// unchanged reports whether n is as it was read, o, apart from changes
+// inside its children.
+func (s *Source) unchanged(n, o *Node) bool {
+	if o == nil || n.Kind != o.Kind || n.Style != o.Style || n.Tag != o.Tag ||
+		n.Value != o.Value || n.Anchor != o.Anchor || s.orig[n.Alias] != o.Alias ||
+		n.BlankLines != o.BlankLines || n.Chomping != o.Chomping || n.Indent != o.Indent ||
+		n.HeadComment != o.HeadComment || n.LineComment != o.LineComment ||
+		n.FootComment != o.FootComment || len(n.Content) != len(o.Content) {
+		return false
+	}
+	for i, c := range n.Content {
+		if s.orig[c] != o.Content[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// uncommented reports whether neither n nor any node under it has comments
+// or blank lines, which a node written on one line could not keep.
+func uncommented(n *Node) bool {
+	if n.HeadComment != "" || n.LineComment != "" || n.FootComment != "" || n.BlankLines > 0 {
+		return false
+	}
+	for _, c := range n.Content {
+		if !uncommented(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// inline returns n encoded on one line, as it would be written inside a
+// flow collection if flow is set, or false if it takes more than one.
+func inline(n *Node, flow bool) ([]byte, bool) {
+	if flow {
+		n = &Node{Kind: SequenceNode, Style: FlowStyle, Content: []*Node{n}}
+	}
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.SetUnicode(true)
+	e.SetWidth(-1)
+	if err := e.Encode(n); err != nil {
+		return nil, false
+	}
+
+	text := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	if flow {
+		text = bytes.TrimSuffix(bytes.TrimPrefix(text, []byte("[")), []byte("]"))
+	}
+	if len(text) == 0 || bytes.IndexByte(text, '\n') >= 0 || bytes.HasPrefix(text, []byte("---")) {
+		return nil, false
+	}
+	return text, true
+}
@@ -0,0 +1,74 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"path/filepath"
+)
+
+var _ = Describe("Source", func() {
+	parse := func(text string) *Source {
+		s, err := ParseSource([]byte(text))
+		Ω(err).ShouldNot(HaveOccurred())
+		return s
+	}
+
+	bytesOf := func(s *Source) string {
+		out, err := s.Bytes()
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(out)
+	}
+
+	It("writes the specification examples back byte for byte", func() {
+		files, err := filepath.Glob("fixtures/specification/*.yaml")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(files).ShouldNot(BeEmpty())
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			Ω(err).ShouldNot(HaveOccurred())
+			s, err := ParseSource(data)
+			Ω(err).ShouldNot(HaveOccurred(), file)
+			out, err := s.Bytes()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(out)).Should(Equal(string(data)), file)
+		}
+	})
+
+	It("keeps whitespace, quoting and line endings", func() {
+		text := "# settings\r\nname:   'app'    # the name\r\n\r\nports: [ 80,443 ]\r\n---\r\n- \"x\"\r\n...\r\n"
+		Ω(bytesOf(parse(text))).Should(Equal(text))
+	})
+
+	It("writes changed scalars in place", func() {
+		s := parse("# settings\r\nname:   'app'    # the name\r\nimage: app:1.0\r\nreplicas: 2\r\n")
+		root := s.Documents[0].Content[0]
+		root.Content[1].Value = "web"
+		root.Content[3].Value = "app:1.1"
+		root.Content[5].Value = "3"
+		Ω(bytesOf(s)).Should(Equal("# settings\r\nname:   'web'    # the name\r\nimage: app:1.1\r\nreplicas: 3\r\n"))
+	})
+
+	It("quotes changed scalars as their place requires", func() {
+		s := parse("a: x\nb: [y, z]\nc:\n")
+		root := s.Documents[0].Content[0]
+		root.Content[1].Value = "- not a sequence"
+		root.Content[3].Content[0].Value = "1, 2"
+		root.Content[5].Value = "set"
+		Ω(bytesOf(s)).Should(Equal("a: '- not a sequence'\nb: ['1, 2', z]\nc: set\n"))
+	})
+
+	It("writes changed flow collections in place", func() {
+		s := parse("a:   {x: 1}  # flow\nb: 2\n")
+		flow := s.Documents[0].Content[0].Content[1]
+		flow.Content = append(flow.Content, &Node{Kind: ScalarNode, Value: "y"}, &Node{Kind: ScalarNode, Value: "2"})
+		Ω(bytesOf(s)).Should(Equal("a:   {x: 1, y: 2}  # flow\nb: 2\n"))
+	})
+
+	It("re-encodes the stream for changes that cannot be made in place", func() {
+		s := parse("a:   1 # one\nb: |\n  text\n")
+		root := s.Documents[0].Content[0]
+		root.Content = append(root.Content, &Node{Kind: ScalarNode, Value: "c"}, &Node{Kind: ScalarNode, Value: "3"})
+		Ω(bytesOf(s)).Should(Equal("a: 1 # one\nb: |\n  text\nc: 3\n"))
+	})
+})
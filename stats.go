@@ -0,0 +1,92 @@
+package candiedyaml
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// DecodeStats describe how a Decoder decoded one document.
+type DecodeStats struct {
+	// Bytes is the input the document took up, from the end of the
+	// document before it.
+	Bytes int64
+
+	// MaxDepth is the deepest nesting of collections in the document, and
+	// Aliases the number of aliases it expanded.
+	MaxDepth int
+	Aliases  int
+
+	// Duration is the time Decode took, and Err the error it returned.
+	Duration time.Duration
+	Err      error
+}
+
+// A StatsCollector is told about each document a Decoder decodes, so that
+// services can monitor their YAML input without wrapping its reader.
+// DocumentDecoded is called by Decode, and by the functions decoding
+// through it, once for each document, including those that fail.
+type StatsCollector interface {
+	DocumentDecoded(s DecodeStats)
+}
+
+// SetStatsCollector makes the Decoder report the statistics of each
+// document it decodes to c.  A nil StatsCollector, the default, turns
+// reporting off.
+func (d *Decoder) SetStatsCollector(c StatsCollector) {
+	d.stats = c
+}
+
+// reportStats reports the document decoded since start, which began at
+// offset, with the error err.
+func (d *Decoder) reportStats(start time.Time, offset int, err error) {
+	d.stats.DocumentDecoded(DecodeStats{
+		Bytes:    int64(d.offset - offset),
+		MaxDepth: d.deepest,
+		Aliases:  d.aliases,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+}
+
+// ExpvarStats is a StatsCollector that adds up the statistics of documents
+// in an expvar.Map, under the keys documents, errors, bytes, aliases and
+// nanoseconds, with max_depth holding the deepest nesting seen.  One
+// ExpvarStats may be shared by any number of Decoders:
+//
+//	stats := candiedyaml.NewExpvarStats(expvar.NewMap("yaml"))
+//	...
+//	d.SetStatsCollector(stats)
+type ExpvarStats struct {
+	m *expvar.Map
+
+	mu       sync.Mutex
+	maxDepth *expvar.Int
+}
+
+// NewExpvarStats returns an ExpvarStats adding up statistics in m.
+func NewExpvarStats(m *expvar.Map) *ExpvarStats {
+	s := &ExpvarStats{m: m, maxDepth: new(expvar.Int)}
+	for _, key := range []string{"documents", "errors", "bytes", "aliases", "nanoseconds"} {
+		m.Add(key, 0)
+	}
+	m.Set("max_depth", s.maxDepth)
+	return s
+}
+
+// DocumentDecoded adds the statistics of a document to the map.
+func (s *ExpvarStats) DocumentDecoded(st DecodeStats) {
+	s.m.Add("documents", 1)
+	if st.Err != nil {
+		s.m.Add("errors", 1)
+	}
+	s.m.Add("bytes", st.Bytes)
+	s.m.Add("aliases", int64(st.Aliases))
+	s.m.Add("nanoseconds", int64(st.Duration))
+
+	s.mu.Lock()
+	if int64(st.MaxDepth) > s.maxDepth.Value() {
+		s.maxDepth.Set(int64(st.MaxDepth))
+	}
+	s.mu.Unlock()
+}
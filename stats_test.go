@@ -0,0 +1,66 @@
+package candiedyaml
+
+import (
+	"expvar"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type statsRecorder []DecodeStats
+
+func (r *statsRecorder) DocumentDecoded(s DecodeStats) {
+	*r = append(*r, s)
+}
+
+var _ = Describe("Decode statistics", func() {
+	const stream = "a: &x {b: [1, 2]}\nc: *x\n---\nd: [[[1]]]\n---\ne: [\n"
+
+	It("reports each document decoded", func() {
+		var stats statsRecorder
+		d := NewDecoder(strings.NewReader(stream))
+		d.SetStatsCollector(&stats)
+
+		var v interface{}
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(d.Decode(&v)).ShouldNot(Succeed())
+
+		Ω(stats).Should(HaveLen(3))
+		Ω(stats[0].MaxDepth).Should(Equal(3))
+		Ω(stats[0].Aliases).Should(Equal(1))
+		Ω(stats[0].Err).ShouldNot(HaveOccurred())
+		Ω(stats[1].MaxDepth).Should(Equal(4))
+		Ω(stats[1].Aliases).Should(BeZero())
+		Ω(stats[2].Err).Should(HaveOccurred())
+		Ω(stats[0].Bytes + stats[1].Bytes).Should(Equal(d.InputOffset()))
+		Ω(stats[0].Duration).Should(BeNumerically(">", 0))
+	})
+
+	It("does not report the end of the stream", func() {
+		var stats statsRecorder
+		d := NewDecoder(strings.NewReader("a: 1\n"))
+		d.SetStatsCollector(&stats)
+		var v interface{}
+		Ω(d.Decode(&v)).Should(Succeed())
+		Ω(d.Decode(&v)).ShouldNot(Succeed())
+		Ω(stats).Should(HaveLen(1))
+		Ω(stats[0].Bytes).Should(Equal(int64(5)))
+	})
+
+	It("adds statistics up in an expvar.Map", func() {
+		m := new(expvar.Map).Init()
+		stats := NewExpvarStats(m)
+		for i := 0; i < 2; i++ {
+			d := NewDecoder(strings.NewReader(stream))
+			d.SetStatsCollector(stats)
+			var vs []interface{}
+			d.DecodeAll(&vs)
+		}
+		Ω(m.Get("documents").String()).Should(Equal("6"))
+		Ω(m.Get("errors").String()).Should(Equal("2"))
+		Ω(m.Get("aliases").String()).Should(Equal("2"))
+		Ω(m.Get("max_depth").String()).Should(Equal("4"))
+	})
+})
@@ -0,0 +1,52 @@
+package candiedyaml
+
+import (
+	"context"
+	"io"
+)
+
+// EncodeStream writes each value received from ch to w as a document of its
+// own, by an Encoder set up with opts, until ch is closed or ctx is done.
+// Each document is flushed before the next value is received, through the
+// Flush method of w if it has one, as bufio.Writer and http.ResponseWriter
+// do, so that readers see documents as they are produced and a slow reader
+// holds up the sender rather than filling memory.
+//
+// It returns nil once ch is closed, ctx.Err() if ctx is done first, and
+// otherwise the first error of encoding or writing a document.
+func EncodeStream(ctx context.Context, ch <-chan interface{}, w io.Writer, opts ...EncodeOption) error {
+	e := NewEncoder(w)
+	for _, opt := range opts {
+		opt(e)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case v, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := e.Encode(v); err != nil {
+				return err
+			}
+			if err := flushWriter(w); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// flushWriter flushes w if it buffers what is written to it.
+func flushWriter(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
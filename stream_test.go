@@ -0,0 +1,74 @@
+package candiedyaml
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EncodeStream", func() {
+	It("writes each value as a document as it arrives", func() {
+		ch := make(chan interface{})
+		r, w := io.Pipe()
+		done := make(chan error, 1)
+		go func() {
+			done <- EncodeStream(context.Background(), ch, w, SortKeys())
+			w.Close()
+		}()
+
+		lines := bufio.NewReader(r)
+		ch <- map[string]int{"b": 2, "a": 1}
+		Ω(lines.ReadString('\n')).Should(Equal("\"a\": 1\n"))
+		Ω(lines.ReadString('\n')).Should(Equal("\"b\": 2\n"))
+		ch <- "next"
+		Ω(lines.ReadString('\n')).Should(Equal("--- \"next\"\n"))
+
+		close(ch)
+		Ω(<-done).Should(Succeed())
+	})
+
+	It("flushes writers that buffer after each document", func() {
+		var out bytes.Buffer
+		w := bufio.NewWriterSize(&out, 4096)
+		ch := make(chan interface{}, 2)
+		ch <- 1
+		ch <- 2
+		close(ch)
+		Ω(EncodeStream(context.Background(), ch, w)).Should(Succeed())
+		Ω(out.String()).Should(Equal("1\n--- 2\n"))
+	})
+
+	It("stops when the context is done", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := make(chan interface{})
+		done := make(chan error, 1)
+		go func() {
+			done <- EncodeStream(ctx, ch, ioutil.Discard)
+		}()
+		ch <- 1
+		cancel()
+		Ω(errors.Is(<-done, context.Canceled)).Should(BeTrue())
+	})
+
+	It("returns the first error of encoding a value", func() {
+		ch := make(chan interface{}, 2)
+		ch <- failingValue{}
+		ch <- 1
+		close(ch)
+		var out bytes.Buffer
+		Ω(EncodeStream(context.Background(), ch, &out)).Should(MatchError("no value"))
+		Ω(ch).Should(HaveLen(1))
+	})
+})
+
+type failingValue struct{}
+
+func (failingValue) MarshalYAMLWithOptions(EncoderSettings) (interface{}, error) {
+	return nil, errors.New("no value")
+}
@@ -1,6 +1,7 @@
 package candiedyaml
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
@@ -16,6 +17,34 @@ type field struct {
 	typ       reflect.Type
 	omitEmpty bool
 	flow      bool
+	ints      intFormat
+	comment   string
+
+	// constraints are the values the field may be decoded from, or nil.
+	constraints *constraints
+}
+
+// intFormat describes how the integers of a field are written.
+type intFormat struct {
+	// base is 2, 8 or 16 for fields tagged binary, octal or hex.  Zero
+	// means decimal.
+	base int
+
+	// separated groups the digits with underscores.
+	separated bool
+}
+
+func parseIntFormat(opts tagOptions) intFormat {
+	f := intFormat{separated: opts.Contains("separated")}
+	switch {
+	case opts.Contains("binary"):
+		f.base = 2
+	case opts.Contains("octal"):
+		f.base = 8
+	case opts.Contains("hex"):
+		f.base = 16
+	}
+	return f
 }
 
 // byName sorts field by name, breaking ties with depth,
@@ -59,10 +88,18 @@ func (x byIndex) Less(i, j int) bool {
 	return len(x[i].index) < len(x[j].index)
 }
 
+// structFields holds the fields of a struct type, along with the names
+// that several embedded fields at the same depth would be promoted to.  Like
+// Go itself, the codec leaves such names out instead of picking one.
+type structFields struct {
+	list      []field
+	ambiguous map[string]bool
+}
+
 // typeFields returns a list of fields that JSON should recognize for the given type.
 // The algorithm is breadth-first search over the set of structs to include - the top struct
 // and then any reachable anonymous structs.
-func typeFields(t reflect.Type) []field {
+func typeFields(t reflect.Type) structFields {
 	// Anonymous fields to explore at the current level and the next.
 	current := []field{}
 	next := []field{{typ: t}}
@@ -118,7 +155,8 @@ func typeFields(t reflect.Type) []field {
 						name = sf.Name
 					}
 					fields = append(fields, field{name, tagged, index, ft,
-						opts.Contains("omitempty"), opts.Contains("flow")})
+						opts.Contains("omitempty"), opts.Contains("flow"), parseIntFormat(opts),
+						sf.Tag.Get("yamlcomment"), parseConstraints(opts)})
 					if count[f.typ] > 1 {
 						// If there were multiple instances, add a second,
 						// so that the annihilation code will see a duplicate.
@@ -147,6 +185,7 @@ func typeFields(t reflect.Type) []field {
 	// of field index length. Loop over names; for each name, delete
 	// hidden fields by choosing the one dominant field that survives.
 	out := fields[:0]
+	ambiguous := map[string]bool{}
 	for advance, i := 0, 0; i < len(fields); i += advance {
 		// One iteration per name.
 		// Find the sequence of fields with the name of this first field.
@@ -165,13 +204,15 @@ func typeFields(t reflect.Type) []field {
 		dominant, ok := dominantField(fields[i : i+advance])
 		if ok {
 			out = append(out, dominant)
+		} else {
+			ambiguous[name] = true
 		}
 	}
 
 	fields = out
 	sort.Sort(byIndex(fields))
 
-	return fields
+	return structFields{fields, ambiguous}
 }
 
 // dominantField looks through the fields, all of which are known to
@@ -214,28 +255,25 @@ func dominantField(fields []field) (field, bool) {
 
 var fieldCache struct {
 	sync.RWMutex
-	m map[reflect.Type][]field
+	m map[reflect.Type]structFields
 }
 
 // cachedTypeFields is like typeFields but uses a cache to avoid repeated work.
-func cachedTypeFields(t reflect.Type) []field {
+func cachedTypeFields(t reflect.Type) structFields {
 	fieldCache.RLock()
-	f := fieldCache.m[t]
+	f, ok := fieldCache.m[t]
 	fieldCache.RUnlock()
-	if f != nil {
+	if ok {
 		return f
 	}
 
 	// Compute fields without lock.
 	// Might duplicate effort but won't hold other computations back.
 	f = typeFields(t)
-	if f == nil {
-		f = []field{}
-	}
 
 	fieldCache.Lock()
 	if fieldCache.m == nil {
-		fieldCache.m = map[reflect.Type][]field{}
+		fieldCache.m = map[reflect.Type]structFields{}
 	}
 	fieldCache.m[t] = f
 	fieldCache.Unlock()
@@ -288,14 +326,41 @@ func typeByIndex(t reflect.Type, index []int) reflect.Type {
 	return t
 }
 
-// stringValues is a slice of reflect.Value holding *reflect.StringValue.
-// It implements the methods to sort by string.
+// stringValues is a slice of map keys.  It implements the methods to sort
+// them by kind, then numbers by value and everything else by its text.
 type stringValues []reflect.Value
 
-func (sv stringValues) Len() int           { return len(sv) }
-func (sv stringValues) Swap(i, j int)      { sv[i], sv[j] = sv[j], sv[i] }
-func (sv stringValues) Less(i, j int) bool { return sv.get(i) < sv.get(j) }
-func (sv stringValues) get(i int) string   { return sv[i].String() }
+func (sv stringValues) Len() int      { return len(sv) }
+func (sv stringValues) Swap(i, j int) { sv[i], sv[j] = sv[j], sv[i] }
+
+func (sv stringValues) Less(i, j int) bool {
+	a, b := sv[i], sv[j]
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+	if a.Kind() != b.Kind() {
+		return a.Kind() < b.Kind()
+	}
+
+	switch a.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
 
 // parseTag splits a struct field's json tag into its name and
 // comma-separated options.
@@ -0,0 +1,60 @@
+package candiedyaml
+
+import "strings"
+
+// TemplateFuncs returns functions for the FuncMap of a text/template or
+// html/template, under the names Helm uses:
+//
+//	toYaml      encodes a value, or returns "" if it cannot be encoded
+//	mustToYaml  encodes a value, or stops the template with the error
+//	fromYaml    decodes a mapping, or returns one whose "Error" key holds
+//	            the error
+//	indent      indents every line of a string by a number of spaces
+//	nindent     is indent with a line break first
+//
+// Encoded values have no final line break, so they can be piped into
+// nindent to nest them under a key:
+//
+//	spec:{{ .Spec | toYaml | nindent 2 }}
+func TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"toYaml":     toYaml,
+		"mustToYaml": mustToYaml,
+		"fromYaml":   fromYaml,
+		"indent":     indent,
+		"nindent":    nindent,
+	}
+}
+
+func toYaml(v interface{}) string {
+	s, err := mustToYaml(v)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+func mustToYaml(v interface{}) (string, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+func fromYaml(s string) map[string]interface{} {
+	m := make(map[string]interface{})
+	if err := Unmarshal([]byte(s), &m); err != nil {
+		return map[string]interface{}{"Error": err.Error()}
+	}
+	return m
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+func nindent(spaces int, s string) string {
+	return "\n" + indent(spaces, s)
+}
@@ -0,0 +1,58 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"text/template"
+)
+
+var _ = Describe("TemplateFuncs", func() {
+	execute := func(text string, data interface{}) (string, error) {
+		t, err := template.New("t").Funcs(TemplateFuncs()).Parse(text)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		err = t.Execute(&buf, data)
+		return buf.String(), err
+	}
+
+	It("nests encoded values with nindent", func() {
+		out, err := execute("spec:{{ .Spec | toYaml | nindent 2 }}\nname: x\n", map[string]interface{}{
+			"Spec": MapSlice{{"replicas", 2}, {"ports", []int{80, 443}}},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal(`spec:
+  "replicas": 2
+  "ports":
+  - 80
+  - 443
+name: x
+`))
+	})
+
+	It("indents without a line break", func() {
+		out, err := execute(`{{ "a: 1\nb: 2" | indent 4 }}`, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("    a: 1\n    b: 2"))
+	})
+
+	It("reports values that cannot be encoded", func() {
+		out, err := execute(`[{{ toYaml . }}]`, make(chan int))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("[]"))
+
+		_, err = execute(`{{ mustToYaml . }}`, make(chan int))
+		Ω(err).Should(MatchError(ContainSubstring("Can't marshal type yet: chan int")))
+	})
+
+	It("decodes mappings", func() {
+		out, err := execute(`{{ $m := fromYaml "a: {b: c}" }}{{ $m.a.b }}`, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("c"))
+
+		out, err = execute(`{{ (fromYaml "a: [b").Error }}`, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(ContainSubstring("did not find expected ',' or ']'"))
+	})
+})
@@ -0,0 +1,114 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Tracer follows a Decoder through a decode, to help find out why a value
+// did not end up where it was expected.  Lines and columns count from 1.
+type Tracer interface {
+	// ParserState is called each time the parser takes a step, with the
+	// states of its state machine before and after, e.g.
+	// "BLOCK_MAPPING_KEY" and "BLOCK_MAPPING_VALUE".
+	ParserState(from, to string)
+
+	// Event is called for each event the parser produces, e.g. "SCALAR" or
+	// "MAPPING_START", with the value of a scalar or the anchor named by
+	// an alias.
+	Event(event, value string, line, column int)
+
+	// Target is called when the Decoder picks the Go value the node at line
+	// and column is decoded into.  Field is the struct field, e.g.
+	// "Config.Port", for values of struct fields, and t is nil for values
+	// that are skipped, such as those of keys that match no field.
+	Target(field string, t reflect.Type, line, column int)
+}
+
+// SetTracer makes the Decoder report what it does to t.  A nil Tracer, the
+// default, turns tracing off.
+func (d *Decoder) SetTracer(t Tracer) {
+	d.tracer = t
+}
+
+var parserStateNames = []string{
+	"STREAM_START",
+	"IMPLICIT_DOCUMENT_START",
+	"DOCUMENT_START",
+	"DOCUMENT_CONTENT",
+	"DOCUMENT_END",
+	"BLOCK_NODE",
+	"BLOCK_NODE_OR_INDENTLESS_SEQUENCE",
+	"FLOW_NODE",
+	"BLOCK_SEQUENCE_FIRST_ENTRY",
+	"BLOCK_SEQUENCE_ENTRY",
+	"INDENTLESS_SEQUENCE_ENTRY",
+	"BLOCK_MAPPING_FIRST_KEY",
+	"BLOCK_MAPPING_KEY",
+	"BLOCK_MAPPING_VALUE",
+	"FLOW_SEQUENCE_FIRST_ENTRY",
+	"FLOW_SEQUENCE_ENTRY",
+	"FLOW_SEQUENCE_ENTRY_MAPPING_KEY",
+	"FLOW_SEQUENCE_ENTRY_MAPPING_VALUE",
+	"FLOW_SEQUENCE_ENTRY_MAPPING_END",
+	"FLOW_MAPPING_FIRST_KEY",
+	"FLOW_MAPPING_KEY",
+	"FLOW_MAPPING_VALUE",
+	"FLOW_MAPPING_EMPTY_VALUE",
+	"END",
+}
+
+func (s yaml_parser_state_t) String() string {
+	if s >= 0 && int(s) < len(parserStateNames) {
+		return parserStateNames[s]
+	}
+	return fmt.Sprintf("yaml_parser_state_t(%d)", int(s))
+}
+
+var eventTypeNames = []string{
+	"NO_EVENT",
+	"STREAM_START",
+	"STREAM_END",
+	"DOCUMENT_START",
+	"DOCUMENT_END",
+	"ALIAS",
+	"SCALAR",
+	"SEQUENCE_START",
+	"SEQUENCE_END",
+	"MAPPING_START",
+	"MAPPING_END",
+}
+
+func (t yaml_event_type_t) String() string {
+	if t >= 0 && int(t) < len(eventTypeNames) {
+		return eventTypeNames[t]
+	}
+	return fmt.Sprintf("yaml_event_type_t(%d)", int(t))
+}
+
+// traceEvent reports the step the parser took from state to produce the
+// current event.
+func (d *Decoder) traceEvent(state yaml_parser_state_t) {
+	d.tracer.ParserState(state.String(), d.parser.state.String())
+
+	var value string
+	switch d.event.event_type {
+	case yaml_SCALAR_EVENT:
+		value = string(d.event.value)
+	case yaml_ALIAS_EVENT:
+		value = string(d.event.anchor)
+	}
+	mark := d.event.start_mark
+	d.tracer.Event(d.event.event_type.String(), value, mark.line+1, mark.column+1)
+}
+
+// traceTarget reports that the current node is decoded into rv.
+func (d *Decoder) traceTarget(rv reflect.Value) {
+	var t reflect.Type
+	if rv.IsValid() {
+		t = rv.Type()
+	}
+	mark := d.event.start_mark
+	d.tracer.Target(d.field, t, mark.line+1, mark.column+1)
+	d.field = ""
+}
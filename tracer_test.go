@@ -0,0 +1,76 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"reflect"
+)
+
+type recordingTracer struct {
+	states  []string
+	events  []string
+	targets []string
+}
+
+func (r *recordingTracer) ParserState(from, to string) {
+	r.states = append(r.states, from+" -> "+to)
+}
+
+func (r *recordingTracer) Event(event, value string, line, column int) {
+	r.events = append(r.events, fmt.Sprintf("%s %q %d:%d", event, value, line, column))
+}
+
+func (r *recordingTracer) Target(field string, t reflect.Type, line, column int) {
+	r.targets = append(r.targets, fmt.Sprintf("%s %v %d:%d", field, t, line, column))
+}
+
+var _ = Describe("Tracer", func() {
+	type server struct {
+		Port int
+	}
+
+	It("reports parser steps, events and targets", func() {
+		r := &recordingTracer{}
+		d := NewDecoder(bytes.NewBufferString("port: 80\nhost: x\n"))
+		d.SetTracer(r)
+
+		var s server
+		Ω(d.Decode(&s)).Should(Succeed())
+		Ω(s.Port).Should(Equal(80))
+
+		Ω(r.states[:4]).Should(Equal([]string{
+			"STREAM_START -> IMPLICIT_DOCUMENT_START",
+			"IMPLICIT_DOCUMENT_START -> BLOCK_NODE",
+			"BLOCK_NODE -> BLOCK_MAPPING_FIRST_KEY",
+			"BLOCK_MAPPING_FIRST_KEY -> BLOCK_MAPPING_VALUE",
+		}))
+		Ω(r.events).Should(Equal([]string{
+			`STREAM_START "" 1:1`,
+			`DOCUMENT_START "" 1:1`,
+			`MAPPING_START "" 1:1`,
+			`SCALAR "port" 1:1`,
+			`SCALAR "80" 1:7`,
+			`SCALAR "host" 2:1`,
+			`SCALAR "x" 2:7`,
+			`MAPPING_END "" 3:1`,
+			`DOCUMENT_END "" 3:1`,
+			`STREAM_END "" 3:1`,
+		}))
+		Ω(r.targets).Should(Equal([]string{
+			" *candiedyaml.server 1:1",
+			" *string 1:1",
+			"server.Port int 1:7",
+			" *string 2:1",
+			" <nil> 2:7",
+		}))
+	})
+
+	It("is off by default", func() {
+		var v interface{}
+		d := NewDecoder(bytes.NewBufferString("a"))
+		d.SetTracer(nil)
+		Ω(d.Decode(&v)).Should(Succeed())
+	})
+})
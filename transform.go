@@ -0,0 +1,334 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EventKind identifies what an Event represents.
+type EventKind int
+
+const (
+	StreamStartEvent EventKind = iota + 1
+	StreamEndEvent
+	DocumentStartEvent
+	DocumentEndEvent
+	AliasEvent
+	ScalarEvent
+	SequenceStartEvent
+	SequenceEndEvent
+	MappingStartEvent
+	MappingEndEvent
+)
+
+var eventKindNames = []string{"", "stream start", "stream end", "document start", "document end",
+	"alias", "scalar", "sequence start", "sequence end", "mapping start", "mapping end"}
+
+func (k EventKind) String() string {
+	if k > 0 && int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// An Event is one step of a YAML stream as the parser reports it: the start
+// or end of the stream, a document or a collection, a scalar or an alias.
+// Its fields mean the same as those of a Node.
+type Event struct {
+	Kind  EventKind
+	Style NodeStyle
+
+	// Tag is the explicit tag, if any, with shorthands expanded.
+	Tag string
+
+	// Value is the text of a scalar or the anchor named by an alias.
+	Value string
+
+	Anchor string
+
+	// Implicit reports that a document start or end has no '---' or '...'
+	// marker.
+	Implicit bool
+
+	// Line and Column give the position of the event in the source,
+	// counting from 1.  They are ignored when the event is written.
+	Line   int
+	Column int
+
+	BlankLines int
+
+	Chomping Chomping
+	Indent   int
+
+	// HeadComment holds the comment lines before the event.  For a
+	// document end it holds those at the end of the document.
+	// LineComment is the comment at the end of the line the event is on.
+	HeadComment string
+	LineComment string
+}
+
+// Transform copies the YAML stream read from r to w one event at a time.
+// Each event is passed to fn and the events it returns are written in its
+// place, so returning nil drops the event and returning more than one
+// inserts events.  The events written must still make up a valid stream.
+//
+// Only a few events are held in memory at once, so Transform can rewrite
+// streams of any size, e.g. to change tags, rename keys or remove secrets.
+// The first error from reading, fn or writing stops the transform and is
+// returned.
+func Transform(r io.Reader, w io.Writer, fn func(Event) ([]Event, error)) (err error) {
+	defer recoverError(&err)
+
+	t := &transformer{d: NewDecoder(r), fn: fn}
+	t.d.raw.streaming = true
+	t.d.parser.keep_comments = true
+	yaml_emitter_initialize(&t.emitter)
+	yaml_emitter_set_output_writer(&t.emitter, w)
+
+	for {
+		t.d.nextEvent()
+		ev := t.event()
+
+		if ev.Kind == StreamEndEvent {
+			t.footComment()
+			t.pending = append(t.pending, ev)
+			t.flush(-1)
+			return nil
+		}
+		t.flush(ev.Line)
+		t.pending = append(t.pending, ev)
+	}
+}
+
+type transformer struct {
+	d       *Decoder
+	fn      func(Event) ([]Event, error)
+	emitter yaml_emitter_t
+
+	// pending holds the events whose line comment may not have been read
+	// yet.
+	pending []Event
+
+	// flowLines holds, for each open collection, the line of the flow
+	// collection starting on the line its entries are on, if any.
+	flowLines []int
+}
+
+// event returns the current event of the parser together with the blank
+// lines and comments before it.
+func (t *transformer) event() Event {
+	ev := t.d.currentEvent()
+	switch t.d.event.event_type {
+	case yaml_DOCUMENT_START_EVENT, yaml_ALIAS_EVENT, yaml_SCALAR_EVENT,
+		yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+		ev.BlankLines = t.d.blankLines()
+		ev.HeadComment = t.d.headComment()
+	case yaml_DOCUMENT_END_EVENT:
+		if !ev.Implicit {
+			ev.HeadComment = t.d.headComment()
+		}
+	}
+	return ev
+}
+
+// currentEvent returns the current event of the parser.
+func (d *Decoder) currentEvent() Event {
+	e := &d.event
+	ev := Event{
+		Kind:     EventKind(e.event_type),
+		Tag:      string(e.tag),
+		Anchor:   string(e.anchor),
+		Implicit: e.implicit,
+		Line:     e.start_mark.line + 1,
+		Column:   e.start_mark.column + 1,
+	}
+
+	switch e.event_type {
+	case yaml_SCALAR_EVENT:
+		ev.Value = string(e.value)
+		switch yaml_scalar_style_t(e.style) {
+		case yaml_DOUBLE_QUOTED_SCALAR_STYLE:
+			ev.Style = DoubleQuotedStyle
+		case yaml_SINGLE_QUOTED_SCALAR_STYLE:
+			ev.Style = SingleQuotedStyle
+		case yaml_LITERAL_SCALAR_STYLE:
+			ev.Style = LiteralStyle
+		case yaml_FOLDED_SCALAR_STYLE:
+			ev.Style = FoldedStyle
+		}
+		switch e.chomping {
+		case -1:
+			ev.Chomping = StripChomping
+		case 1:
+			ev.Chomping = KeepChomping
+		}
+		ev.Indent = e.indent
+	case yaml_ALIAS_EVENT:
+		ev.Value = ev.Anchor
+		ev.Anchor = ""
+	case yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+		if e.style == yaml_style_t(yaml_FLOW_SEQUENCE_STYLE) {
+			ev.Style = FlowStyle
+		}
+	}
+	return ev
+}
+
+// footComment gives the comments at the end of the stream to the document
+// that ends it.
+func (t *transformer) footComment() {
+	line := t.d.event.start_mark.line
+	c := t.d.parser.head_comments[line]
+	delete(t.d.parser.head_comments, line)
+	if c == nil {
+		return
+	}
+	for i := len(t.pending) - 1; i >= 0; i-- {
+		if t.pending[i].Kind == DocumentEndEvent {
+			ev := &t.pending[i]
+			ev.HeadComment = string(join_comments([]byte(ev.HeadComment), c, '\n'))
+			return
+		}
+	}
+}
+
+// flush writes the pending events starting before line, all of them for a
+// negative line.  Their line comments have been read by then, and go to the
+// last event starting on their line, other than the entries of a flow
+// collection starting on it, as for Nodes.  The last document end is held
+// back for the comments at the end of the stream.
+func (t *transformer) flush(line int) {
+	n := 0
+	for n < len(t.pending) && (line < 0 || t.pending[n].Line < line) {
+		if line >= 0 && n == len(t.pending)-1 && t.pending[n].Kind == DocumentEndEvent {
+			break
+		}
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	owner := -1
+	for i := 0; i < n; i++ {
+		if t.ownsLineComment(t.pending[i]) {
+			owner = i
+		}
+		if i == n-1 || t.pending[i+1].Line != t.pending[i].Line {
+			if owner >= 0 {
+				ev := &t.pending[owner]
+				if c, ok := t.d.parser.line_comments[ev.Line-1]; ok {
+					ev.LineComment = string(c)
+					delete(t.d.parser.line_comments, ev.Line-1)
+				}
+			}
+			owner = -1
+		}
+	}
+
+	for _, ev := range t.pending[:n] {
+		t.write(ev)
+	}
+	t.pending = append(t.pending[:0], t.pending[n:]...)
+}
+
+// ownsLineComment reports whether ev may take the comment at the end of its
+// line, keeping track of the flow collections it is inside.
+func (t *transformer) ownsLineComment(ev Event) bool {
+	flowLine := 0
+	if len(t.flowLines) > 0 {
+		flowLine = t.flowLines[len(t.flowLines)-1]
+	}
+
+	switch ev.Kind {
+	case SequenceEndEvent, MappingEndEvent:
+		t.flowLines = t.flowLines[:len(t.flowLines)-1]
+		return false
+	case StreamStartEvent, StreamEndEvent, DocumentEndEvent:
+		return false
+	}
+
+	owns := ev.Line != flowLine
+	if ev.Kind == SequenceStartEvent || ev.Kind == MappingStartEvent {
+		if owns && ev.Style == FlowStyle {
+			flowLine = ev.Line
+		}
+		t.flowLines = append(t.flowLines, flowLine)
+	}
+	return owns
+}
+
+// write passes ev through fn and emits the events it returns.
+func (t *transformer) write(ev Event) {
+	out, err := t.fn(ev)
+	if err != nil {
+		panic(err)
+	}
+	for _, ev := range out {
+		t.emit(ev)
+	}
+}
+
+func (t *transformer) emit(ev Event) {
+	var event yaml_event_t
+	anchor := []byte(ev.Anchor)
+	tag := []byte(ev.Tag)
+	implicit := ev.Tag == ""
+
+	switch ev.Kind {
+	case StreamStartEvent:
+		yaml_stream_start_event_initialize(&event, yaml_UTF8_ENCODING)
+	case StreamEndEvent:
+		yaml_stream_end_event_initialize(&event)
+	case DocumentStartEvent:
+		yaml_document_start_event_initialize(&event, nil, nil, ev.Implicit)
+	case DocumentEndEvent:
+		yaml_document_end_event_initialize(&event, ev.Implicit)
+	case AliasEvent:
+		yaml_alias_event_initialize(&event, []byte(ev.Value))
+	case ScalarEvent:
+		style := yaml_PLAIN_SCALAR_STYLE
+		switch ev.Style {
+		case DoubleQuotedStyle:
+			style = yaml_DOUBLE_QUOTED_SCALAR_STYLE
+		case SingleQuotedStyle:
+			style = yaml_SINGLE_QUOTED_SCALAR_STYLE
+		case LiteralStyle:
+			style = yaml_LITERAL_SCALAR_STYLE
+		case FoldedStyle:
+			style = yaml_FOLDED_SCALAR_STYLE
+		}
+		yaml_scalar_event_initialize(&event, anchor, tag, []byte(ev.Value), implicit, implicit, style)
+		event.indent = ev.Indent
+		if ev.Chomping == KeepChomping {
+			event.chomping = 1
+		}
+	case SequenceStartEvent:
+		style := yaml_BLOCK_SEQUENCE_STYLE
+		if ev.Style == FlowStyle {
+			style = yaml_FLOW_SEQUENCE_STYLE
+		}
+		yaml_sequence_start_event_initialize(&event, anchor, tag, implicit, style)
+	case SequenceEndEvent:
+		yaml_sequence_end_event_initialize(&event)
+	case MappingStartEvent:
+		style := yaml_BLOCK_MAPPING_STYLE
+		if ev.Style == FlowStyle {
+			style = yaml_FLOW_MAPPING_STYLE
+		}
+		yaml_mapping_start_event_initialize(&event, anchor, tag, implicit, style)
+	case MappingEndEvent:
+		yaml_mapping_end_event_initialize(&event)
+	default:
+		panic(errors.New("yaml: cannot write event of unknown kind"))
+	}
+
+	event.blank_lines = ev.BlankLines
+	event.head_comment = commentLines(ev.HeadComment)
+	event.line_comment = lineComment(ev.LineComment)
+
+	if !yaml_emitter_emit(&t.emitter, &event) {
+		panic(errors.New("yaml: " + t.emitter.problem))
+	}
+}
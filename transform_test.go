@@ -0,0 +1,132 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Transform", func() {
+	transform := func(data string, fn func(Event) ([]Event, error)) (string, error) {
+		var buf bytes.Buffer
+		err := Transform(strings.NewReader(data), &buf, fn)
+		return buf.String(), err
+	}
+
+	same := func(ev Event) ([]Event, error) {
+		return []Event{ev}, nil
+	}
+
+	It("copies a stream with its comments", func() {
+		data := `# settings
+server:
+  host: example.com # the host
+  ports: [80, 443] # open
+
+  key: &k |- # literal
+    text
+  again: *k
+--- !!str x
+# end
+`
+		out, err := transform(data, same)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal(`# settings
+server:
+  host: example.com # the host
+  ports: [80, 443] # open
+
+  key: &k |- # literal
+    text
+  again: *k
+--- !!str x
+# end
+`))
+	})
+
+	It("reports each event in order", func() {
+		var kinds []EventKind
+		var values []string
+		_, err := transform("a: [b, 'c']\n", func(ev Event) ([]Event, error) {
+			kinds = append(kinds, ev.Kind)
+			values = append(values, ev.Value)
+			if ev.Kind == ScalarEvent && ev.Value == "c" {
+				Ω(ev.Style).Should(Equal(SingleQuotedStyle))
+				Ω(ev.Line).Should(Equal(1))
+				Ω(ev.Column).Should(Equal(8))
+			}
+			return []Event{ev}, nil
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(kinds).Should(Equal([]EventKind{
+			StreamStartEvent, DocumentStartEvent, MappingStartEvent, ScalarEvent,
+			SequenceStartEvent, ScalarEvent, ScalarEvent, SequenceEndEvent,
+			MappingEndEvent, DocumentEndEvent, StreamEndEvent,
+		}))
+		Ω(values[3]).Should(Equal("a"))
+		Ω(MappingStartEvent.String()).Should(Equal("mapping start"))
+	})
+
+	It("rewrites keys, values and tags", func() {
+		secret := false
+		out, err := transform("user: bob\npassword: hunter2\nid: !legacy 7\n", func(ev Event) ([]Event, error) {
+			switch {
+			case secret:
+				ev.Value = "<redacted>"
+				ev.Style = DoubleQuotedStyle
+				secret = false
+			case ev.Kind == ScalarEvent && ev.Value == "password":
+				secret = true
+			case ev.Kind == ScalarEvent && ev.Value == "user":
+				ev.Value = "name"
+			case ev.Tag == "!legacy":
+				ev.Tag = IntTag
+			}
+			return []Event{ev}, nil
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("name: bob\npassword: \"<redacted>\"\nid: !!int 7\n"))
+	})
+
+	It("drops and inserts events", func() {
+		out, err := transform("[a, b, c]\n", func(ev Event) ([]Event, error) {
+			switch ev.Value {
+			case "a":
+				return nil, nil
+			case "c":
+				return []Event{ev, {Kind: ScalarEvent, Value: "d"}}, nil
+			}
+			return []Event{ev}, nil
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("[b, c, d]\n"))
+	})
+
+	It("stops at the first error", func() {
+		boom := errors.New("boom")
+		calls := 0
+		_, err := transform("[a, b, c]\n", func(ev Event) ([]Event, error) {
+			calls++
+			if ev.Value == "b" {
+				return nil, boom
+			}
+			return []Event{ev}, nil
+		})
+		Ω(err).Should(Equal(boom))
+		Ω(calls).Should(Equal(5))
+
+		_, err = transform("a: [b\n", same)
+		var perr *ParserError
+		Ω(errors.As(err, &perr)).Should(BeTrue())
+
+		_, err = transform("a: b\n", func(ev Event) ([]Event, error) {
+			if ev.Kind == MappingEndEvent {
+				return nil, nil
+			}
+			return []Event{ev}, nil
+		})
+		Ω(err).Should(MatchError("yaml: expected SCALAR, SEQUENCE-START, MAPPING-START, or ALIAS"))
+	})
+})
@@ -0,0 +1,75 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// truncationMarker ends the output of an Encoder cut short by
+// SetMaxOutputBytes.
+const truncationMarker = "# ... truncated\n"
+
+var errOutputLimit = errors.New("output limit reached")
+
+// SetMaxOutputBytes limits everything the Encoder writes to n bytes, for
+// dumps that end up in size-capped places such as log fields.  A document
+// that does not fit is cut after its last whole line that does and followed
+// by the comment "# ... truncated", which counts towards the limit.  Encode
+// returns nil for it and writes nothing more afterwards.  A limit of 0, the
+// default, means none.
+func (e *Encoder) SetMaxOutputBytes(n int) {
+	if n <= 0 {
+		e.limit = nil
+		e.emitter.output_writer = e.w
+		return
+	}
+	e.limit = &outputLimit{w: e.w, max: n}
+	e.emitter.output_writer = e.limit
+}
+
+// outputLimit holds back the output of a document until it is known to fit.
+type outputLimit struct {
+	w       io.Writer
+	max     int
+	written int
+	buf     bytes.Buffer
+
+	truncated bool
+}
+
+func (l *outputLimit) Write(p []byte) (int, error) {
+	if l.truncated {
+		return 0, errOutputLimit
+	}
+	l.buf.Write(p)
+	if l.written+l.buf.Len() > l.max {
+		l.truncated = true
+		return 0, errOutputLimit
+	}
+	return len(p), nil
+}
+
+// commit writes the document held back.
+func (l *outputLimit) commit() error {
+	n, err := l.w.Write(l.buf.Bytes())
+	l.written += n
+	l.buf.Reset()
+	return err
+}
+
+// truncate writes the lines of the document held back that fit together
+// with the truncation marker, if it fits at all.
+func (l *outputLimit) truncate() error {
+	defer l.buf.Reset()
+
+	room := l.max - l.written - len(truncationMarker)
+	if room < 0 {
+		return nil
+	}
+	out := l.buf.Bytes()[:room]
+	out = append(out[:bytes.LastIndexByte(out, '\n')+1], truncationMarker...)
+	n, err := l.w.Write(out)
+	l.written += n
+	return err
+}
@@ -0,0 +1,52 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("SetMaxOutputBytes", func() {
+	var buf *bytes.Buffer
+	var enc *Encoder
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+		enc = NewEncoder(buf)
+	})
+
+	It("writes documents that fit unchanged", func() {
+		enc.SetMaxOutputBytes(100)
+		Ω(enc.Encode([]int{1, 2, 3})).Should(Succeed())
+		Ω(buf.String()).Should(Equal("- 1\n- 2\n- 3\n"))
+	})
+
+	It("cuts a document that does not fit after a whole line", func() {
+		items := make([]string, 100)
+		for i := range items {
+			items[i] = strings.Repeat("x", 10)
+		}
+
+		enc.SetMaxOutputBytes(60)
+		Ω(enc.Encode(items)).Should(Succeed())
+		Ω(buf.String()).Should(Equal(`- "xxxxxxxxxx"
+- "xxxxxxxxxx"
+# ... truncated
+`))
+	})
+
+	It("counts every document towards the limit", func() {
+		enc.SetMaxOutputBytes(40)
+		Ω(enc.Encode("first")).Should(Succeed())
+		Ω(enc.Encode([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})).Should(Succeed())
+		Ω(enc.Encode("last")).Should(Succeed())
+		Ω(buf.String()).Should(Equal("\"first\"\n---\n- 1\n- 2\n- 3\n# ... truncated\n"))
+	})
+
+	It("writes nothing if the marker does not fit", func() {
+		enc.SetMaxOutputBytes(10)
+		Ω(enc.Encode([]int{1, 2, 3, 4, 5})).Should(Succeed())
+		Ω(buf.String()).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,53 @@
+package candiedyaml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A NodeUnmarshaler decodes itself from the Node of its YAML value, so that
+// it can look at tags, styles, anchors and positions, not only at the value
+// a Go type would hold.  Node.Decode decodes the node, or a part of it, into
+// other Go values:
+//
+//	func (p *Port) UnmarshalYAMLNode(n *candiedyaml.Node) error {
+//		if n.Tag == "!range" {
+//			return p.parseRange(n.Value)
+//		}
+//		return n.Decode(&p.Number)
+//	}
+//
+// Nulls and aliases are decoded by the usual rules instead, and adapters
+// registered for the type take precedence.  An alias inside the node to an
+// anchor outside it is an error.
+type NodeUnmarshaler interface {
+	UnmarshalYAMLNode(node *Node) error
+}
+
+var nodeUnmarshalerType = reflect.TypeOf((*NodeUnmarshaler)(nil)).Elem()
+
+// unmarshalNode decodes the current node into rv through its
+// UnmarshalYAMLNode method, if its type has one.
+func (d *Decoder) unmarshalNode(rv reflect.Value) bool {
+	t := rv.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || !reflect.PtrTo(t).Implements(nodeUnmarshalerType) ||
+		d.event.event_type == yaml_ALIAS_EVENT {
+		return false
+	}
+	if d.event.event_type == yaml_SCALAR_EVENT && d.event.implicit && null_values[string(d.event.value)] {
+		return false
+	}
+
+	mark := d.event.start_mark
+	anchor := string(d.event.anchor)
+	n := d.node(make(map[string]*Node))
+	v := d.indirect(rv)
+	if err := v.Addr().Interface().(NodeUnmarshaler).UnmarshalYAMLNode(n); err != nil {
+		d.error(fmt.Errorf("yaml: line %d, column %d: %v", mark.line+1, mark.column+1, err))
+	}
+	d.anchor(anchor, rv)
+	return true
+}
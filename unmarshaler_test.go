@@ -0,0 +1,98 @@
+package candiedyaml
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// portRange is a port, or a range of them tagged !range.
+type portRange struct {
+	from, to int
+	line     int
+}
+
+func (p *portRange) UnmarshalYAMLNode(n *Node) error {
+	p.line = n.Line
+	if n.Tag == "!range" {
+		if _, err := fmt.Sscanf(n.Value, "%d-%d", &p.from, &p.to); err != nil {
+			return fmt.Errorf("invalid range %q", n.Value)
+		}
+		return nil
+	}
+	if err := n.Decode(&p.from); err != nil {
+		return err
+	}
+	p.to = p.from
+	return nil
+}
+
+// quotedFlag records whether its value was quoted.
+type quotedFlag struct {
+	value  string
+	quoted bool
+}
+
+func (f *quotedFlag) UnmarshalYAMLNode(n *Node) error {
+	if n.Kind != ScalarNode {
+		return errors.New("flag must be a scalar")
+	}
+	f.value = n.Value
+	f.quoted = n.Style == SingleQuotedStyle || n.Style == DoubleQuotedStyle
+	return nil
+}
+
+var _ = Describe("NodeUnmarshaler", func() {
+	type service struct {
+		Ports []portRange  `yaml:"ports"`
+		Main  *portRange   `yaml:"main"`
+		Flag  quotedFlag   `yaml:"flag"`
+		Flags []quotedFlag `yaml:"flags"`
+	}
+
+	It("hands types the node of their value", func() {
+		var s service
+		Ω(Unmarshal([]byte(`ports:
+- 80
+- !range 8000-8080
+main: 443
+flag: "on"
+flags: [on, 'off']
+`), &s)).Should(Succeed())
+		Ω(s.Ports).Should(Equal([]portRange{{80, 80, 2}, {8000, 8080, 3}}))
+		Ω(s.Main).Should(Equal(&portRange{443, 443, 4}))
+		Ω(s.Flag).Should(Equal(quotedFlag{"on", true}))
+		Ω(s.Flags).Should(Equal([]quotedFlag{{"on", false}, {"off", true}}))
+	})
+
+	It("decodes the top-level value", func() {
+		var p portRange
+		Ω(Unmarshal([]byte("!range 1-2\n"), &p)).Should(Succeed())
+		Ω(p).Should(Equal(portRange{1, 2, 1}))
+	})
+
+	It("leaves nulls and aliases to the usual rules", func() {
+		var s service
+		Ω(Unmarshal([]byte("ports: [&p !range 1-2, *p]\nmain: ~\n"), &s)).Should(Succeed())
+		Ω(s.Ports).Should(Equal([]portRange{{1, 2, 1}, {1, 2, 1}}))
+		Ω(s.Main).Should(Equal(&portRange{}))
+	})
+
+	It("reports errors at the value", func() {
+		var s service
+		err := Unmarshal([]byte("ports:\n- !range x\n"), &s)
+		Ω(err).Should(MatchError(`yaml: line 2, column 3: invalid range "x"`))
+
+		err = Unmarshal([]byte("flag: [a]\n"), &s)
+		Ω(err).Should(MatchError("yaml: line 1, column 7: flag must be a scalar"))
+	})
+
+	It("is used by Node.Decode", func() {
+		var doc Node
+		Ω(Unmarshal([]byte("main: !range 5-6\n"), &doc)).Should(Succeed())
+		var s service
+		Ω(doc.Decode(&s)).Should(Succeed())
+		Ω(s.Main).Should(Equal(&portRange{5, 6, 1}))
+	})
+})
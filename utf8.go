@@ -0,0 +1,47 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// An InvalidUTF8Policy decides what an Encoder does with a string that is
+// not valid UTF-8, which YAML has no way to represent as it is.
+type InvalidUTF8Policy int
+
+const (
+	// InvalidUTF8Error fails the encode with an error matching
+	// ErrInvalidUTF8.  It is the default.
+	InvalidUTF8Error InvalidUTF8Policy = iota
+
+	// InvalidUTF8Replace writes each run of invalid bytes as U+FFFD.
+	InvalidUTF8Replace
+
+	// InvalidUTF8Escape writes the string double-quoted with each invalid
+	// byte as a \x escape.  The escapes read back as the characters U+0080
+	// to U+00FF rather than the original bytes.
+	InvalidUTF8Escape
+)
+
+// SetInvalidUTF8Policy selects what happens to strings that are not valid
+// UTF-8.  Control characters are valid and are always written as escapes.
+func (e *Encoder) SetInvalidUTF8Policy(p InvalidUTF8Policy) {
+	e.invalidUTF8 = p
+}
+
+// checkUTF8 applies the policy to the scalar event about to be emitted.
+func (e *Encoder) checkUTF8() {
+	if e.event.event_type != yaml_SCALAR_EVENT || utf8.Valid(e.event.value) {
+		return
+	}
+
+	switch e.invalidUTF8 {
+	case InvalidUTF8Replace:
+		e.event.value = bytes.ToValidUTF8(e.event.value, []byte("\uFFFD"))
+	case InvalidUTF8Escape:
+		e.event.style = yaml_style_t(yaml_DOUBLE_QUOTED_SCALAR_STYLE)
+	default:
+		panic(fmt.Errorf("%w: %q", ErrInvalidUTF8, e.event.value))
+	}
+}
@@ -0,0 +1,56 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Invalid UTF-8", func() {
+	encode := func(p InvalidUTF8Policy, v interface{}) (string, error) {
+		buf := &bytes.Buffer{}
+		e := NewEncoder(buf)
+		e.SetSchema(CoreSchema)
+		e.SetInvalidUTF8Policy(p)
+		err := e.Encode(v)
+		return buf.String(), err
+	}
+
+	It("is rejected by default", func() {
+		_, err := Marshal("a\xffb")
+		Ω(errors.Is(err, ErrInvalidUTF8)).Should(BeTrue())
+
+		_, err = encode(InvalidUTF8Error, map[string]int{"\xc0": 1})
+		Ω(errors.Is(err, ErrInvalidUTF8)).Should(BeTrue())
+	})
+
+	It("can be replaced", func() {
+		out, err := encode(InvalidUTF8Replace, []string{"a\xff\xfeb", "c\xe2\x82"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("- \"a\\uFFFDb\"\n- \"c\\uFFFD\"\n"))
+	})
+
+	It("can be escaped", func() {
+		out, err := encode(InvalidUTF8Escape, map[string]string{"\x80k": "v\xc2"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("\"\\x80k\": \"v\\xC2\"\n"))
+
+		var v map[string]string
+		Ω(Unmarshal([]byte(out), &v)).Should(Succeed())
+		Ω(v).Should(Equal(map[string]string{"\u0080k": "v\u00c2"}))
+	})
+
+	It("escapes nodes whatever their style", func() {
+		n := &Node{Kind: ScalarNode, Style: LiteralStyle, Value: "a\xff\n"}
+		out, err := encode(InvalidUTF8Escape, n)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("\"a\\xFF\\n\"\n"))
+	})
+
+	It("always escapes control characters", func() {
+		out, err := encode(InvalidUTF8Error, "a\x01\x1bb")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(out).Should(Equal("\"a\\x01\\eb\"\n"))
+	})
+})
@@ -0,0 +1,41 @@
+package candiedyaml
+
+import (
+	"bytes"
+	"io"
+)
+
+// Valid reports whether data is a well-formed YAML stream.
+func Valid(data []byte) bool {
+	return ValidStream(bytes.NewReader(data)) == nil
+}
+
+// ValidStream reads a YAML stream from r and returns the first thing wrong
+// with it: a syntax error, or an alias that does not refer to an anchor
+// earlier in the same document.  The stream is only parsed, not decoded, so
+// this takes about half the time of a throwaway Unmarshal and keeps nothing
+// but the anchor names of the current document.
+func ValidStream(r io.Reader) (err error) {
+	defer recoverError(&err)
+
+	d := NewDecoder(r)
+	anchors := map[string]bool{}
+	for d.nextEvent(); d.event.event_type != yaml_STREAM_END_EVENT; d.nextEvent() {
+		switch d.event.event_type {
+		case yaml_DOCUMENT_START_EVENT:
+			for a := range anchors {
+				delete(anchors, a)
+			}
+		case yaml_ALIAS_EVENT:
+			anchor := string(d.event.anchor)
+			if !anchors[anchor] {
+				return &UnknownAnchorError{Anchor: anchor, At: d.event.start_mark}
+			}
+		case yaml_SCALAR_EVENT, yaml_SEQUENCE_START_EVENT, yaml_MAPPING_START_EVENT:
+			if len(d.event.anchor) > 0 {
+				anchors[string(d.event.anchor)] = true
+			}
+		}
+	}
+	return nil
+}
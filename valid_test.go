@@ -0,0 +1,36 @@
+package candiedyaml
+
+import (
+	"errors"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Valid", func() {
+	It("accepts well-formed streams", func() {
+		for _, data := range []string{
+			"",
+			"a: [b, {c: d}]\n",
+			"- &a x\n- *a\n--- &b [*b]\n",
+			"--- |\n  text\n...\n",
+		} {
+			Ω(Valid([]byte(data))).Should(BeTrue(), data)
+			Ω(ValidStream(strings.NewReader(data))).Should(Succeed(), data)
+		}
+	})
+
+	It("rejects syntax errors", func() {
+		Ω(Valid([]byte("a: [b\n"))).Should(BeFalse())
+
+		err := ValidStream(strings.NewReader("a: b\n---\nc: d: e\n"))
+		Ω(errors.Is(err, ErrSyntax)).Should(BeTrue())
+		Ω(err.Error()).Should(ContainSubstring("line 3"))
+	})
+
+	It("rejects unknown anchors", func() {
+		err := ValidStream(strings.NewReader("- &a x\n---\n- *a\n"))
+		Ω(errors.Is(err, ErrUnknownAnchor)).Should(BeTrue())
+		Ω(err).Should(MatchError("yaml: unknown anchor 'a' referenced at line 3, column 3"))
+	})
+})
@@ -0,0 +1,35 @@
+package candiedyaml
+
+import (
+	"fmt"
+)
+
+// A Warning describes input the Decoder accepted even though it is probably
+// not what its author meant, found at the position At.
+type Warning struct {
+	Message string
+	At      YAML_mark_t
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("yaml: line %d, column %d: %s", w.At.Line(), w.At.Column(), w.Message)
+}
+
+// AllowTabIndentation makes the Decoder accept tabs in the indentation of
+// block content, each one moving to the next multiple of width columns, and
+// report a Warning for every line that uses them.  A width of zero, the
+// default, rejects them as the spec requires.
+func (d *Decoder) AllowTabIndentation(width int) {
+	if width < 0 {
+		width = 0
+	}
+	d.parser.tab_width = width
+}
+
+// Warnings returns the warnings reported so far: tabs used for indentation,
+// duplicate keys whose last value was kept, keys that match no struct field,
+// YAML 1.1 booleans such as yes and off, and floats that lose precision in the
+// type they were decoded into.  Decoding never fails because of a warning.
+func (d *Decoder) Warnings() []Warning {
+	return d.parser.warnings
+}
@@ -0,0 +1,121 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Warnings", func() {
+	Context("Tab indentation", func() {
+		decode := func(width int, data string) (interface{}, []Warning, error) {
+			var v interface{}
+			d := NewDecoder(bytes.NewBufferString(data))
+			d.AllowTabIndentation(width)
+			err := d.Decode(&v)
+			return v, d.Warnings(), err
+		}
+
+		It("is rejected by default", func() {
+			_, warnings, err := decode(0, "a:\n\tb: c\n")
+			Ω(err).Should(MatchError(ContainSubstring("found character that cannot start any token")))
+			Ω(warnings).Should(BeEmpty())
+		})
+
+		It("is accepted with a warning per line", func() {
+			v, warnings, err := decode(4, "a:\n\tb: c\n\td:\n\t\t- e\n\t    - f\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{
+				"a": map[interface{}]interface{}{
+					"b": "c",
+					"d": []interface{}{"e", "f"},
+				},
+			}))
+
+			Ω(warnings).Should(HaveLen(4))
+			Ω(warnings[0].String()).Should(Equal("yaml: line 2, column 1: found a tab character used for indentation"))
+			Ω(warnings[3].At.line).Should(Equal(4))
+			Ω([]int{warnings[3].At.Line(), warnings[3].At.Column()}).Should(Equal([]int{5, 1}))
+		})
+
+		It("converts tabs at the given width", func() {
+			v, _, err := decode(2, "a:\n\tb: c\n  d: e\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{
+				"a": map[interface{}]interface{}{"b": "c", "d": "e"},
+			}))
+
+			_, _, err = decode(4, "a:\n\tb: c\n  d: e\n")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("accepts tabs indenting scalar continuation lines", func() {
+			v, warnings, err := decode(2, "a: |\n\tone\n\ttwo\nb: c\n\td\n")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(v).Should(Equal(map[interface{}]interface{}{"a": "one\ntwo\n", "b": "c d"}))
+			Ω(warnings).Should(HaveLen(3))
+		})
+	})
+
+	Context("Decoding", func() {
+		decode := func(data string, v interface{}) []Warning {
+			d := NewDecoder(bytes.NewBufferString(data))
+			Ω(d.Decode(v)).Should(Succeed())
+			return d.Warnings()
+		}
+
+		messages := func(warnings []Warning) []string {
+			var m []string
+			for _, w := range warnings {
+				m = append(m, w.Message)
+			}
+			return m
+		}
+
+		It("reports nothing for ordinary documents", func() {
+			var v interface{}
+			Ω(decode("a: [1, 1.5, true, \"yes\"]\n", &v)).Should(BeEmpty())
+		})
+
+		It("reports duplicate keys", func() {
+			var v map[string]int
+			warnings := decode("a: 1\nb: 2\na: 3\n", &v)
+			Ω(v).Should(Equal(map[string]int{"a": 3, "b": 2}))
+			Ω(messages(warnings)).Should(Equal([]string{"duplicate key 'a', keeping the last value"}))
+			Ω(warnings[0].At.line).Should(Equal(2))
+		})
+
+		It("reports unknown fields", func() {
+			var v struct{ Name string }
+			warnings := decode("name: n\nnmae: m\n", &v)
+			Ω(messages(warnings)).Should(Equal([]string{"unknown field 'nmae' in struct { Name string }"}))
+		})
+
+		It("reports YAML 1.1 booleans", func() {
+			var v struct {
+				Enabled bool
+				Extra   interface{}
+			}
+			warnings := decode("enabled: yes\nextra: Off\n", &v)
+			Ω(v.Enabled).Should(BeTrue())
+			Ω(messages(warnings)).Should(Equal([]string{
+				"'yes' is a YAML 1.1 boolean, YAML 1.2 reads it as a string",
+				"'Off' is a YAML 1.1 boolean, YAML 1.2 reads it as a string",
+			}))
+		})
+
+		It("reports floats that lose precision", func() {
+			var v struct {
+				A float32
+				B float32
+				C float64
+				D float64
+			}
+			warnings := decode("a: 0.1\nb: 3.14159265358979\nc: 0.1\nd: 9007199254740993\n", &v)
+			Ω(messages(warnings)).Should(Equal([]string{
+				"'3.14159265358979' loses precision as float32",
+				"'9007199254740993' loses precision as float64",
+			}))
+		})
+	})
+})
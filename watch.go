@@ -0,0 +1,184 @@
+package candiedyaml
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// A Watcher keeps a value decoded from a file up to date with the file.
+type Watcher struct {
+	path     string
+	target   reflect.Value
+	onChange func(error)
+
+	mu       sync.RWMutex
+	interval time.Duration
+
+	stat    os.FileInfo
+	statErr error
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// calls guards calling and closed, so that Close knows whether it
+	// may be running inside onChange, where waiting for the watching
+	// goroutine to end would never return.
+	calls   sync.Mutex
+	calling bool
+	closed  bool
+}
+
+// Watch decodes the first document of the file at path into target, which
+// must be a non-nil pointer, and then checks the file for changes once a
+// second.  Whenever the file changes it is decoded into a new value, which
+// replaces the value target points to only if the whole file decodes.
+// onChange, if not nil, is called after each attempt with its error, so
+// that a broken edit can be reported while the last good value stays in
+// place.
+//
+// Watch returns the error of the first decode without starting to watch.
+// Code reading the value while the file may change has to hold the read
+// lock of the Watcher.
+func Watch(path string, target interface{}, onChange func(error)) (*Watcher, error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, errors.New("yaml: Watch needs a non-nil pointer")
+	}
+
+	w := &Watcher{
+		path:     path,
+		target:   rv.Elem(),
+		onChange: onChange,
+		interval: time.Second,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	w.stat, w.statErr = os.Stat(path)
+	if w.statErr != nil {
+		return nil, w.statErr
+	}
+	if err := w.load(); err != nil {
+		return nil, err
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// SetInterval sets how often the file is checked for changes.
+func (w *Watcher) SetInterval(d time.Duration) {
+	w.mu.Lock()
+	w.interval = d
+	w.mu.Unlock()
+}
+
+// RLock locks the value against being replaced while it is read.
+func (w *Watcher) RLock() {
+	w.mu.RLock()
+}
+
+// RUnlock undoes a single RLock call.
+func (w *Watcher) RUnlock() {
+	w.mu.RUnlock()
+}
+
+// Close stops watching the file.  Once Close returns, onChange is not
+// called again.  Close may be called from onChange, and then returns
+// without waiting for onChange to return; so does a Close called
+// elsewhere while onChange runs.
+func (w *Watcher) Close() error {
+	w.closeOnce.Do(func() { close(w.stop) })
+	w.calls.Lock()
+	w.closed = true
+	calling := w.calling
+	w.calls.Unlock()
+	if !calling {
+		<-w.done
+	}
+	return nil
+}
+
+func (w *Watcher) watch() {
+	defer close(w.done)
+	for {
+		w.mu.RLock()
+		interval := w.interval
+		w.mu.RUnlock()
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(interval):
+		}
+
+		if !w.changed() {
+			continue
+		}
+		err := w.statErr
+		if err == nil {
+			err = w.load()
+		}
+		if w.onChange != nil && !w.call(err) {
+			return
+		}
+	}
+}
+
+// call calls onChange with err, unless the Watcher was closed, and reports
+// whether it did.
+func (w *Watcher) call(err error) bool {
+	w.calls.Lock()
+	if w.closed {
+		w.calls.Unlock()
+		return false
+	}
+	w.calling = true
+	w.calls.Unlock()
+
+	w.onChange(err)
+
+	w.calls.Lock()
+	w.calling = false
+	w.calls.Unlock()
+	return true
+}
+
+// changed reports whether the file was changed, removed or restored since
+// it was last checked.
+func (w *Watcher) changed() bool {
+	stat, err := os.Stat(w.path)
+	prev, prevErr := w.stat, w.statErr
+	w.stat, w.statErr = stat, err
+
+	switch {
+	case err != nil:
+		return prevErr == nil
+	case prevErr != nil:
+		return true
+	}
+	return !stat.ModTime().Equal(prev.ModTime()) || stat.Size() != prev.Size()
+}
+
+// load decodes the file into a new value and swaps it in.
+func (w *Watcher) load() error {
+	f, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	v := reflect.New(w.target.Type())
+	if err := NewDecoder(f).Decode(v.Interface()); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.target.Set(v.Elem())
+	w.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,128 @@
+package candiedyaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Watch", func() {
+	type config struct {
+		Name  string
+		Ports []int
+	}
+
+	var (
+		dir     string
+		path    string
+		changes chan error
+		watcher *Watcher
+		cfg     config
+	)
+
+	// write changes the file and moves its modification time on, as the
+	// clock may not have ticked since the last write.
+	var stamp time.Time
+	write := func(data string) {
+		Ω(ioutil.WriteFile(path, []byte(data), 0644)).Should(Succeed())
+		stamp = stamp.Add(time.Second)
+		Ω(os.Chtimes(path, stamp, stamp)).Should(Succeed())
+	}
+
+	read := func() config {
+		watcher.RLock()
+		defer watcher.RUnlock()
+		return cfg
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "watch")
+		Ω(err).ShouldNot(HaveOccurred())
+		path = filepath.Join(dir, "config.yml")
+		stamp = time.Now()
+
+		cfg = config{}
+		changes = make(chan error, 10)
+		write("name: a\nports: [80]\n")
+
+		watcher, err = Watch(path, &cfg, func(err error) { changes <- err })
+		Ω(err).ShouldNot(HaveOccurred())
+		watcher.SetInterval(5 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+		os.RemoveAll(dir)
+	})
+
+	It("decodes the file straight away", func() {
+		Ω(read()).Should(Equal(config{Name: "a", Ports: []int{80}}))
+	})
+
+	It("decodes the file again when it changes", func() {
+		write("name: b\n")
+		Eventually(changes).Should(Receive(BeNil()))
+		Ω(read()).Should(Equal(config{Name: "b"}))
+	})
+
+	It("keeps the last good value when the file is broken", func() {
+		write("name: [b\n")
+		Eventually(changes).Should(Receive(HaveOccurred()))
+		Ω(read()).Should(Equal(config{Name: "a", Ports: []int{80}}))
+
+		write("name: c\n")
+		Eventually(changes).Should(Receive(BeNil()))
+		Ω(read().Name).Should(Equal("c"))
+	})
+
+	It("reports a removed file once", func() {
+		Ω(os.Remove(path)).Should(Succeed())
+		Eventually(changes).Should(Receive(HaveOccurred()))
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+
+		write("name: d\n")
+		Eventually(changes).Should(Receive(BeNil()))
+		Ω(read().Name).Should(Equal("d"))
+	})
+
+	It("stops calling back once closed", func() {
+		watcher.Close()
+		watcher = nil
+		write("name: e\n")
+		Consistently(changes, 50*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("can be closed from the callback", func() {
+		watcher.Close()
+		closed := make(chan error, 1)
+		var self *Watcher
+		self, err := Watch(path, &cfg, func(error) { closed <- self.Close() })
+		Ω(err).ShouldNot(HaveOccurred())
+		self.SetInterval(5 * time.Millisecond)
+
+		write("name: f\n")
+		Eventually(closed).Should(Receive(BeNil()))
+		Ω(self.Close()).Should(Succeed())
+		write("name: g\n")
+		Consistently(closed, 50*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("fails when the file cannot be decoded", func() {
+		write("[")
+		var v config
+		_, err := Watch(path, &v, nil)
+		Ω(err).Should(HaveOccurred())
+
+		_, err = Watch(filepath.Join(dir, "missing.yml"), &v, nil)
+		Ω(os.IsNotExist(err)).Should(BeTrue())
+
+		_, err = Watch(path, v, nil)
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,105 @@
+package candiedyaml
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges holds the East Asian Wide and Fullwidth characters and the
+// emoji presented as pictographs, which terminals draw two columns wide.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1}, // Hangul Jamo initials
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x23e9, 0x23ec, 1},
+		{0x23f0, 0x23f0, 1},
+		{0x23f3, 0x23f3, 1},
+		{0x25fd, 0x25fe, 1},
+		{0x2614, 0x2615, 1},
+		{0x2648, 0x2653, 1},
+		{0x267f, 0x267f, 1},
+		{0x2693, 0x2693, 1},
+		{0x26a1, 0x26a1, 1},
+		{0x26aa, 0x26ab, 1},
+		{0x26bd, 0x26be, 1},
+		{0x26c4, 0x26c5, 1},
+		{0x26ce, 0x26ce, 1},
+		{0x26d4, 0x26d4, 1},
+		{0x26ea, 0x26ea, 1},
+		{0x26f2, 0x26f3, 1},
+		{0x26f5, 0x26f5, 1},
+		{0x26fa, 0x26fa, 1},
+		{0x26fd, 0x26fd, 1},
+		{0x2705, 0x2705, 1},
+		{0x270a, 0x270b, 1},
+		{0x2728, 0x2728, 1},
+		{0x274c, 0x274c, 1},
+		{0x274e, 0x274e, 1},
+		{0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1},
+		{0x2795, 0x2797, 1},
+		{0x27b0, 0x27b0, 1},
+		{0x27bf, 0x27bf, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b50, 1},
+		{0x2b55, 0x2b55, 1},
+		{0x2e80, 0x303e, 1}, // CJK radicals, symbols and punctuation
+		{0x3041, 0x33ff, 1}, // kana, bopomofo, CJK compatibility
+		{0x3400, 0x4dbf, 1}, // CJK extension A
+		{0x4e00, 0x9fff, 1}, // CJK unified ideographs
+		{0xa000, 0xa4cf, 1}, // Yi
+		{0xa960, 0xa97f, 1},
+		{0xac00, 0xd7a3, 1}, // Hangul syllables
+		{0xf900, 0xfaff, 1}, // CJK compatibility ideographs
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe6f, 1},
+		{0xff00, 0xff60, 1}, // fullwidth forms
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x16fe0, 0x16fe4, 1},
+		{0x17000, 0x18cff, 1}, // Tangut
+		{0x1b000, 0x1b2ff, 1}, // kana supplement
+		{0x1f004, 0x1f004, 1},
+		{0x1f0cf, 0x1f0cf, 1},
+		{0x1f18e, 0x1f18e, 1},
+		{0x1f191, 0x1f19a, 1},
+		{0x1f200, 0x1f251, 1},
+		{0x1f300, 0x1f64f, 1}, // pictographs and emoticons
+		{0x1f680, 0x1f6ff, 1}, // transport and map symbols
+		{0x1f7e0, 0x1f7eb, 1},
+		{0x1f90c, 0x1f9ff, 1}, // supplemental symbols and pictographs
+		{0x1fa70, 0x1faff, 1},
+		{0x20000, 0x2fffd, 1}, // CJK extensions B to F
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// column_width returns the number of columns the character at b[i] takes
+// up on a terminal: none for combining marks and joiners, two for wide
+// characters and one for the rest.
+func column_width(b []byte, i int) int {
+	if b[i] < utf8.RuneSelf {
+		return 1
+	}
+	r, _ := utf8.DecodeRune(b[i:])
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRanges, r):
+		return 2
+	}
+	return 1
+}
+
+// string_width returns the number of columns s takes up on a terminal.
+func string_width(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		n += column_width([]byte(s[i:i+size]), 0)
+		i += size
+	}
+	return n
+}
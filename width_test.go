@@ -0,0 +1,44 @@
+package candiedyaml
+
+import (
+	"bytes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+)
+
+var _ = Describe("Display width", func() {
+	It("counts wide characters as two columns and combining marks as none", func() {
+		Ω(string_width("abc")).Should(Equal(3))
+		Ω(string_width("東京")).Should(Equal(4))
+		Ω(string_width("🎉")).Should(Equal(2))
+		Ω(string_width("é")).Should(Equal(1))
+		Ω(string_width("한국어")).Should(Equal(6))
+	})
+
+	Context("when the emitter writes UTF-8", func() {
+		encode := func(v interface{}) string {
+			buf := &bytes.Buffer{}
+			enc := NewEncoder(buf)
+			enc.SetWidth(20)
+			yaml_emitter_set_unicode(&enc.emitter, true)
+			Ω(enc.Encode(v)).Should(Succeed())
+			return buf.String()
+		}
+
+		It("breaks lines by display width", func() {
+			Ω(encode("東京 大阪 京都 名古屋 札幌 福岡 横浜 神戸")).Should(Equal(
+				"\"東京 大阪 京都 名古屋\n  札幌 福岡 横浜 神戸\"\n"))
+		})
+
+		It("does not break after combining marks", func() {
+			out := encode("ééé ééé ééé ééé ééé")
+			Ω(strings.Count(out, "\n")).Should(Equal(1))
+		})
+
+		It("writes emoji unescaped", func() {
+			Ω(encode("🎉🎉 🎉🎉 🎉🎉 🎉🎉 🎉🎉 🎉🎉")).Should(Equal(
+				"\"🎉🎉 🎉🎉 🎉🎉 🎉🎉 🎉🎉\n  🎉🎉\"\n"))
+		})
+	})
+})
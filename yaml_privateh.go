@@ -26,6 +26,21 @@ const (
 
 	INITIAL_STACK_SIZE = 16
 	INITIAL_QUEUE_SIZE = 16
+
+	/*
+	 * The number of consumed lines and bytes retained for error snippets.
+	 */
+
+	INPUT_HISTORY_LINES = 8
+	INPUT_HISTORY_SIZE  = 4096
+
+	/*
+	 * The number of characters the scanner may look ahead.  A single raw
+	 * buffer always decodes to at least this many characters, so the
+	 * input buffer never overflows.
+	 */
+
+	MAX_LOOKAHEAD = INPUT_RAW_BUFFER_SIZE / 8
 )
 
 func width(b byte) int {
@@ -204,7 +219,8 @@ func is_printable_at(b []byte, i int) bool {
 		(b[i] == 0xEE) ||
 		(b[i] == 0xEF && /* && . != #xFEFF */
 			!(b[i+1] == 0xBB && b[i+2] == 0xBF) &&
-			!(b[i+1] == 0xBF && (b[i+2] == 0xBE || b[i+2] == 0xBF))))
+			!(b[i+1] == 0xBF && (b[i+2] == 0xBE || b[i+2] == 0xBF))) ||
+		(b[i] >= 0xF0 && b[i] <= 0xF4)) /* #x10000 <= . <= #x10FFFF */
 }
 
 func insert_token(parser *yaml_parser_t, pos int, token *yaml_token_t) {
@@ -0,0 +1,148 @@
+//go:build yamltestsuite
+// +build yamltestsuite
+
+package candiedyaml
+
+// Runs the parser against the yaml-test-suite corpus
+// (https://github.com/yaml/yaml-test-suite).  Check out a data release and
+// point YAML_TEST_SUITE at its name/ directory:
+//
+//	YAML_TEST_SUITE=/path/to/yaml-test-suite/name go test -tags yamltestsuite -v
+//
+// Every case is parsed in YAML12Conformance mode and must be accepted or
+// rejected as its "error" file says, except for the known failures below.
+// A summary for both conformance modes is written to the Ginkgo output.
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Cases the parser gets wrong even when following the spec, keyed by their
+// path below the name/ directory.
+var yamlTestSuiteKnownFailures = map[string]string{
+	"colon-and-adjacent-value-after-comment-on-next-line": "implicit flow key spanning lines",
+	"colon-and-adjacent-value-on-next-line":               "implicit flow key spanning lines",
+	"flow-collections-over-many-lines/01":                 "implicit flow key spanning lines",
+	"flow-mapping-colon-on-line-after-key/00":             "implicit flow key spanning lines",
+	"flow-mapping-colon-on-line-after-key/01":             "implicit flow key spanning lines",
+	"flow-mapping-colon-on-line-after-key/02":             "implicit flow key spanning lines",
+	"multiline-double-quoted-flow-mapping-key":            "implicit flow key spanning lines",
+	"multiline-plain-flow-mapping-key":                    "implicit flow key spanning lines",
+	"spec-example-9-4-explicit-documents":                 "implicit flow key spanning lines",
+	"colon-at-the-beginning-of-adjacent-flow-scalar":      "plain scalar starting with ':' in flow",
+	"flow-mapping-edge-cases":                             "plain scalar starting with ':' in flow",
+	"spec-example-7-10-plain-characters":                  "plain scalar starting with ':' in flow",
+}
+
+type yamlTestSuiteCase struct {
+	name    string
+	input   []byte
+	invalid bool
+}
+
+// loadYamlTestSuite reads the cases below dir.  Entries may be symlinks,
+// as they are in the name/ directory, and cases with several variants keep
+// them in numbered subdirectories.
+func loadYamlTestSuite(dir string) []yamlTestSuiteCase {
+	var cases []yamlTestSuiteCase
+	var load func(name string)
+	load = func(name string) {
+		path := filepath.Join(dir, name)
+		input, err := ioutil.ReadFile(filepath.Join(path, "in.yaml"))
+		if err == nil {
+			_, err = os.Stat(filepath.Join(path, "error"))
+			cases = append(cases, yamlTestSuiteCase{
+				name:    filepath.ToSlash(name),
+				input:   input,
+				invalid: err == nil,
+			})
+			return
+		}
+
+		f, err := os.Open(path)
+		Ω(err).ShouldNot(HaveOccurred())
+		names, err := f.Readdirnames(-1)
+		f.Close()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		for _, n := range names {
+			if info, err := os.Stat(filepath.Join(path, n)); err == nil && info.IsDir() {
+				load(filepath.Join(name, n))
+			}
+		}
+	}
+	load("")
+
+	sort.Slice(cases, func(i, j int) bool { return cases[i].name < cases[j].name })
+	return cases
+}
+
+func parseYamlTestSuiteCase(c Conformance, input []byte) bool {
+	parser := yaml_parser_t{}
+	yaml_parser_initialize(&parser)
+	yaml_parser_set_input_reader(&parser, bytes.NewReader(input))
+	parser.conformance = c
+
+	event := yaml_event_t{}
+	for {
+		if !yaml_parser_parse(&parser, &event) {
+			return false
+		}
+		if event.event_type == yaml_STREAM_END_EVENT {
+			return true
+		}
+	}
+}
+
+var _ = Describe("yaml-test-suite", func() {
+	It("parses the corpus as the spec requires", func() {
+		dir := os.Getenv("YAML_TEST_SUITE")
+		if dir == "" {
+			Skip("YAML_TEST_SUITE is not set")
+		}
+
+		cases := loadYamlTestSuite(dir)
+		Ω(cases).ShouldNot(BeEmpty())
+
+		var regressions, fixed []string
+		for _, mode := range []struct {
+			name        string
+			conformance Conformance
+		}{
+			{"libyaml", LibyamlConformance},
+			{"YAML 1.2", YAML12Conformance},
+		} {
+			passed := 0
+			for _, tc := range cases {
+				ok := parseYamlTestSuiteCase(mode.conformance, tc.input) != tc.invalid
+				if ok {
+					passed++
+				}
+
+				if mode.conformance != YAML12Conformance {
+					continue
+				}
+
+				_, known := yamlTestSuiteKnownFailures[tc.name]
+				if !ok && !known {
+					regressions = append(regressions, tc.name)
+				} else if ok && known {
+					fixed = append(fixed, tc.name)
+				}
+			}
+			fmt.Fprintf(GinkgoWriter, "%s: %d of %d cases pass\n", mode.name, passed, len(cases))
+		}
+
+		for _, name := range fixed {
+			fmt.Fprintf(GinkgoWriter, "now passing, remove from known failures: %s\n", name)
+		}
+		Ω(regressions).Should(BeEmpty())
+	})
+})
@@ -75,6 +75,9 @@ type YAML_mark_t struct {
 
 	/** The position column. */
 	column int
+
+	/** The number of input bytes before the position. */
+	offset int
 }
 
 /** @} */
@@ -219,6 +222,10 @@ type yaml_token_t struct {
 	/** The scalar value (for @c yaml_SCALAR_TOKEN). */
 	/** The scalar style. */
 	style yaml_scalar_style_t
+	/** The chomping indicator of a block scalar: -1 for '-', +1 for '+'. */
+	chomping int
+	/** The indentation indicator of a block scalar, or 0. */
+	indent int
 
 	/** The version directive (for @c yaml_VERSION_DIRECTIVE_TOKEN). */
 	version_directive yaml_version_directive_t
@@ -310,6 +317,18 @@ type yaml_event_t struct {
 	/** The sequence style. */
 	/** The scalar style. */
 	style yaml_style_t
+	/** The chomping indicator of a block scalar: -1 for '-', +1 for '+'. */
+	chomping int
+	/** The indentation indicator of a block scalar, or 0. */
+	indent int
+
+	/** The number of blank lines to write before the event. */
+	blank_lines int
+
+	/** A comment to write on the lines before the event. */
+	head_comment []byte
+	/** A comment to write at the end of the line the event ends on. */
+	line_comment []byte
 
 	/** The beginning of the event. */
 	start_mark, end_mark YAML_mark_t
@@ -588,6 +607,9 @@ type yaml_parser_t struct {
 	input        []byte
 	input_pos    int
 
+	/** The error returned by the read handler, if any. */
+	read_error error
+
 	/** EOF flag */
 	eof bool
 
@@ -595,6 +617,13 @@ type yaml_parser_t struct {
 	buffer     []byte
 	buffer_pos int
 
+	/** The consumed input retained for error reporting. */
+	history []byte
+	/** The line of the first byte in the history. */
+	history_line int
+	/** Is the first line of the history incomplete? */
+	history_partial bool
+
 	/* The number of unread characters in the buffer. */
 	unread int
 
@@ -651,6 +680,27 @@ type yaml_parser_t struct {
 	/** The stack of simple keys. */
 	simple_keys []yaml_simple_key_t
 
+	/** Are comments and blank lines kept for the Nodes they belong to? */
+	keep_comments bool
+
+	/** The number of blank lines preceding each line a token starts on. */
+	blank_lines map[int]int
+
+	/** The number of blank lines since the last token. */
+	pending_blank_lines int
+
+	/** The comment lines preceding each line a token starts on. */
+	head_comments map[int][]byte
+
+	/** The comments at the end of each line. */
+	line_comments map[int][]byte
+
+	/** The comment lines since the last token. */
+	pending_comment []byte
+
+	/** The chunk the buffers for token values are carved from. */
+	arena []byte
+
 	/**
 	 * @}
 	 */
@@ -672,6 +722,15 @@ type yaml_parser_t struct {
 	/** The list of TAG directives. */
 	tag_directives []yaml_tag_directive_t
 
+	/** Which set of rules to follow where libyaml and the spec disagree. */
+	conformance Conformance
+
+	/** The width of a tab in indentation, or 0 if tabs may not indent. */
+	tab_width int
+
+	/** The warnings reported so far. */
+	warnings []Warning
+
 	/**
 	 * @}
 	 */
@@ -810,12 +869,18 @@ type yaml_emitter_t struct {
 
 	/** If the output is in the canonical style? */
 	canonical bool
+	/** If the output is as short as possible? */
+	minify bool
+	/** If the values of block mappings are lined up? */
+	align bool
 	/** The number of indentation spaces. */
 	best_indent int
 	/** The preferred width of the output lines. */
 	best_width int
 	/** Allow unescaped non-ASCII characters? */
 	unicode bool
+	/** The characters always written as escapes. */
+	escape map[rune]bool
 	/** The preferred line break. */
 	line_break yaml_break_t
 
@@ -832,6 +897,20 @@ type yaml_emitter_t struct {
 	/** The stack of indentation levels. */
 	indents []int
 
+	/** The stack of the widths of the keys of block mappings, or -1. */
+	key_widths []int
+
+	/**
+	 * The widths of the keys of the queued mappings, by the index of their
+	 * MAPPING-START event, and how far the events have been scanned for
+	 * the end of the mapping at the head: up to scan_pos, at scan_level,
+	 * for the head scan_head-1.
+	 */
+	measured   map[int]int
+	scan_head  int
+	scan_pos   int
+	scan_level int
+
 	/** The list of tag directives. */
 	tag_directives []yaml_tag_directive_t
 
@@ -860,6 +939,12 @@ type yaml_emitter_t struct {
 	indention bool
 	/** If an explicit document end is required? */
 	open_ended bool
+	/** The number of blank lines to write before the next line. */
+	blank_lines int
+	/** The comment to write before the next line. */
+	head_comment []byte
+	/** The comment to write at the end of the current line. */
+	line_comment []byte
 
 	/** Anchor analysis. */
 	anchor_data struct {
@@ -893,6 +978,8 @@ type yaml_emitter_t struct {
 		block_allowed bool
 		/** The output style. */
 		style yaml_scalar_style_t
+		/** The requested chomping and indentation indicators. */
+		chomping, indent int
 	}
 
 	/**